@@ -0,0 +1,36 @@
+// Command diagrams generates photos2map's architecture diagrams, by default into docs/diagrams. It is meant to be
+// run from the module's root, as make diagrams does.
+package main
+
+import (
+	"errors"
+	"flag"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/diagrams"
+	"github.com/toozej/photos2map/internal/output"
+)
+
+func main() {
+	out := flag.String("out", "docs/diagrams", "Directory to write the diagrams to")
+	format := flag.String("format", "dot", "Output format: dot, or png or svg rendered with Graphviz")
+	root := flag.String("root", ".", "Root directory of the module whose packages are shown in the components diagram")
+	flag.Parse()
+
+	components, err := diagrams.Components(*root)
+	if err != nil {
+		log.Fatalf("Error reading the module's packages: %v", err)
+	}
+	for _, g := range []diagrams.Graph{components, diagrams.DataFlow(output.Formats)} {
+		path, err := diagrams.Render(g, *out, *format)
+		if errors.Is(err, diagrams.ErrNoGraphviz) {
+			log.Warnf("Graphviz isn't installed, only wrote %s", path)
+			continue
+		}
+		if err != nil {
+			log.Fatalf("Error rendering the %s diagram: %v", g.Name, err)
+		}
+		log.Infof("Wrote %s", path)
+	}
+}
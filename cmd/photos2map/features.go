@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/toozej/photos2map/internal/features"
+)
+
+var featuresCmd = &cobra.Command{
+	Use:   "features",
+	Short: "List the optional features this binary supports",
+	Long: `Lists the optional features compiled into this binary and whether the external tools some of them
+need are installed, along with the build tags it was built with. Binaries built with -tags nonetwork
+contain no feature that connects to other machines.`,
+	Args: cobra.ExactArgs(0),
+	Run:  runFeatures,
+}
+
+// Print every feature with whether it's available and why
+func runFeatures(cmd *cobra.Command, args []string) {
+	tags := features.BuildTags()
+	if tags == "" {
+		tags = "none"
+	}
+	fmt.Printf("Built with %s for %s/%s, build tags: %s\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH, tags)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FEATURE\tAVAILABLE\tDETAIL")
+	for _, f := range features.List() {
+		available := "no"
+		if f.Enabled {
+			available = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, available, f.Detail)
+	}
+	w.Flush()
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/toozej/photos2map/internal/geotag"
+	"github.com/toozej/photos2map/internal/output"
+	"github.com/toozej/photos2map/internal/track"
+)
+
+var geotagCmd = &cobra.Command{
+	Use:   "geotag",
+	Short: "Tag photos without GPS data from GPX, FIT or NMEA tracks",
+	Long: `Interpolates the position of each photo without GPS coordinates from GPX, FIT or NMEA tracks by its
+capture time, and writes it to an XMP sidecar next to the photo. Tracks from several devices are merged into
+one timeline. Capture times are read in the local time zone; use --offset to correct for a camera clock set to
+a different zone or running fast or slow. How reliable each position is, or why a photo wasn't tagged, is
+written to geotag-report.csv in --out-dir.`,
+	Args: cobra.ExactArgs(0),
+	Run:  runGeotag,
+}
+
+func init() {
+	geotagCmd.Flags().StringSlice("track", nil, "GPX, FIT or NMEA track to take positions from; repeat to merge tracks from several devices, the most accurate first")
+	geotagCmd.Flags().StringSlice("gpx", nil, "GPX track to take positions from")
+	_ = geotagCmd.Flags().MarkDeprecated("gpx", "use --track instead")
+	geotagCmd.Flags().StringP("dir", "i", ".", "Directory to scan for images")
+	geotagCmd.Flags().Duration("offset", 0, "Duration added to photo capture times before matching, e.g. -1h30m")
+	geotagCmd.Flags().Bool("use-exiftool", false, "Read capture times with a locally installed exiftool, also covering RAW/HEIF/video files")
+	geotagCmd.Flags().Bool("overwrite", false, "Replace existing XMP sidecars")
+	geotagCmd.Flags().Duration("max-gap", 5*time.Minute, "Skip photos taken further than this from the nearest track point, 0 to tag all")
+	geotagCmd.MarkFlagsOneRequired("track", "gpx")
+}
+
+// Geotag photos from the merged tracks and report how reliable each position is, or why a photo couldn't be tagged
+func runGeotag(cmd *cobra.Command, args []string) {
+	var tracks []track.Track
+	for _, path := range append(viper.GetStringSlice("track"), viper.GetStringSlice("gpx")...) {
+		t, err := track.Read(path)
+		if err != nil {
+			log.Fatalf("Error reading track %s: %v", path, err)
+		}
+		if len(t) == 0 {
+			log.Warnf("Track %s has no timestamped points", path)
+		}
+		tracks = append(tracks, t)
+	}
+	trk := track.Merge(tracks...)
+	if len(trk) == 0 {
+		log.Fatalf("No timestamped track points to geotag from")
+	}
+
+	tagged, skipped := geotag.Apply(viper.GetString("dir"), trk, geotag.Options{
+		Offset:      viper.GetDuration("offset"),
+		UseExiftool: viper.GetBool("use-exiftool"),
+		Overwrite:   viper.GetBool("overwrite"),
+		MaxGap:      viper.GetDuration("max-gap"),
+	})
+	output.GenerateGeotagReport(tagged, skipped)
+	fmt.Printf("Geotagged %d photos.\n", len(tagged))
+}
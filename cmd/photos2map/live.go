@@ -0,0 +1,139 @@
+//go:build !nonetwork
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/internal/extract"
+	"github.com/toozej/photos2map/internal/geotag"
+	"github.com/toozej/photos2map/internal/gpsd"
+	"github.com/toozej/photos2map/internal/hotfolder"
+	"github.com/toozej/photos2map/internal/output"
+)
+
+var liveCmd = &cobra.Command{
+	Use:   "live",
+	Short: "Geotag photos as they land in a hot folder from a live gpsd position",
+	Long: `Records the position reported by gpsd and writes an XMP sidecar for each photo without GPS coordinates
+that is added to the hot folder, such as by tethering software, interpolated at its capture time. Photos
+already in the folder are left alone. Photos taken just before their position arrives are retried for up to
+--max-gap. Stop with Ctrl-C; how reliable each position is, or why a photo wasn't tagged, is then written to
+geotag-report.csv in --out-dir.`,
+	Args: cobra.ExactArgs(0),
+	Run:  runLive,
+}
+
+func init() {
+	liveCmd.Flags().String("gpsd", gpsd.DefaultAddr, "Address of the gpsd daemon")
+	liveCmd.Flags().StringP("dir", "i", ".", "Hot folder to watch for new photos")
+	liveCmd.Flags().Duration("interval", time.Second, "How often to check the hot folder for new photos")
+	liveCmd.Flags().Duration("offset", 0, "Duration added to photo capture times before matching, e.g. -1h30m")
+	liveCmd.Flags().Bool("use-exiftool", false, "Read capture times with a locally installed exiftool, also covering RAW/HEIF/video files")
+	liveCmd.Flags().Bool("overwrite", false, "Replace existing XMP sidecars")
+	liveCmd.Flags().Duration("max-gap", time.Minute, "Skip photos taken further than this from the nearest gpsd fix")
+
+	// registered here rather than with the other commands so nonetwork builds leave it out entirely
+	rootCmd.AddCommand(liveCmd)
+}
+
+// Tag photos landing in the hot folder with positions from gpsd until interrupted or gpsd goes away
+func runLive(cmd *cobra.Command, args []string) {
+	opts := geotag.Options{
+		Offset:      viper.GetDuration("offset"),
+		UseExiftool: viper.GetBool("use-exiftool"),
+		Overwrite:   viper.GetBool("overwrite"),
+		MaxGap:      viper.GetDuration("max-gap"),
+	}
+	if opts.UseExiftool && !exif.ExiftoolAvailable() {
+		log.Warn("exiftool was requested but is not installed, continuing with the native decoder only")
+		opts.UseExiftool = false
+	}
+
+	folder, err := hotfolder.New(viper.GetString("dir"))
+	if err != nil {
+		log.Fatalf("Error reading the hot folder: %v", err)
+	}
+	addr := viper.GetString("gpsd")
+	if viper.GetBool("no-network") && !gpsd.IsLocal(addr) {
+		log.Fatalf("gpsd at %s is on another machine, which --no-network doesn't allow", addr)
+	}
+	client, err := gpsd.Dial(addr)
+	if err != nil {
+		log.Fatalf("Error connecting to gpsd: %v", err)
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(viper.GetDuration("interval"))
+	defer ticker.Stop()
+	log.Infof("Watching %s for new photos, press Ctrl-C to stop", viper.GetString("dir"))
+
+	var tagged []geotag.Tagged
+	var skipped []extract.Skipped
+	// photos taken after the last fix so far, with the time they landed
+	waiting := map[string]time.Time{}
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-client.Done():
+			log.Errorf("Lost the connection to gpsd: %v", client.Err())
+			break loop
+		case <-ticker.C:
+		}
+
+		paths, err := folder.Poll()
+		if err != nil {
+			log.Warnf("Error reading the hot folder: %v", err)
+			continue
+		}
+		for path := range waiting {
+			paths = append(paths, path)
+		}
+
+		trk := client.Track()
+		for _, path := range paths {
+			m, err := geotag.Tag(path, trk, opts)
+			if errors.Is(err, geotag.ErrOutsideTrack) {
+				landed, ok := waiting[path]
+				if !ok {
+					landed = time.Now()
+					waiting[path] = landed
+				}
+				if time.Since(landed) <= opts.MaxGap {
+					continue
+				}
+			}
+			delete(waiting, path)
+
+			switch {
+			case errors.Is(err, extract.ErrUnsupported), errors.Is(err, geotag.ErrHasGPS):
+			case err != nil:
+				log.Warnf("Not geotagging %s: %v", path, err)
+				skipped = append(skipped, extract.Skipped{Path: path, Err: err})
+			default:
+				log.Infof("Geotagged %s at %f, %f with %s confidence", path, m.Lat, m.Lon, m.Confidence())
+				tagged = append(tagged, geotag.Tagged{Path: path, Match: m})
+			}
+		}
+	}
+
+	for path := range waiting {
+		skipped = append(skipped, extract.Skipped{Path: path, Err: errors.New("stopped before gpsd reported a fix around its capture time")})
+	}
+	output.GenerateGeotagReport(tagged, skipped)
+	fmt.Printf("Geotagged %d photos.\n", len(tagged))
+}
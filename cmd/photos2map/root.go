@@ -1,20 +1,50 @@
 package cmd
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/automaxprocs/maxprocs"
 
+	"github.com/toozej/photos2map/internal/backup"
+	"github.com/toozej/photos2map/internal/cache"
+	"github.com/toozej/photos2map/internal/dedupe"
 	"github.com/toozej/photos2map/internal/extract"
+	"github.com/toozej/photos2map/internal/features"
+	"github.com/toozej/photos2map/internal/hooks"
+	"github.com/toozej/photos2map/internal/logfile"
 	"github.com/toozej/photos2map/internal/output"
+	"github.com/toozej/photos2map/internal/progress"
+	"github.com/toozej/photos2map/internal/ratelimit"
+	"github.com/toozej/photos2map/internal/s3"
+	"github.com/toozej/photos2map/internal/transport"
+	"github.com/toozej/photos2map/internal/webdav"
+	"github.com/toozej/photos2map/pkg/geodata"
 	"github.com/toozej/photos2map/pkg/man"
 	"github.com/toozej/photos2map/pkg/version"
 )
 
+// exitInterrupted is the exit code after a scan was stopped with Ctrl-C, the shell convention of 128 + SIGINT
+const exitInterrupted = 130
+
+// stdoutFormats are the output formats that can be written to stdout with --out -
+var stdoutFormats = []string{"gpx", "geojson", "json", "mymaps-csv"}
+
 var rootCmd = &cobra.Command{
 	Use:              "photos2map",
 	Short:            "Generate a GPX file from photos EXIF data",
@@ -28,14 +58,25 @@ func rootCmdPreRun(cmd *cobra.Command, args []string) {
 	if err := viper.BindPFlags(cmd.Flags()); err != nil {
 		return
 	}
+	if err := readConfig(); err != nil {
+		log.Fatalf("Error reading the config file: %v", err)
+	}
 	if viper.GetBool("debug") {
 		log.SetLevel(log.DebugLevel)
 	}
+	output.Dir = viper.GetString("out-dir")
+	if path := viper.GetString("log-file"); path != "" {
+		w, err := logfile.Open(path, viper.GetInt64("log-max-size")*1024*1024, viper.GetInt("log-max-backups"))
+		if err != nil {
+			log.Fatalf("Error opening the log file: %v", err)
+		}
+		log.AddHook(logfile.NewHook(w))
+	}
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 }
@@ -47,14 +88,175 @@ func init() {
 	}
 
 	// create rootCmd-level flags
-	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Enable debug-level logging")
-	rootCmd.Flags().StringP("dir", "i", ".", "Directory to scan for images")
-	rootCmd.Flags().StringP("output", "o", "html", "Output format: html or gpx")
+	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Enable debug-level logging, including how long decoding took per file format")
+	rootCmd.PersistentFlags().String("config", "", "Read defaults for any flag from this YAML, TOML or JSON file, keyed by flag name (default: photos2map/config.yaml in the user's config directory, $XDG_CONFIG_HOME or ~/.config on Linux, if it exists)")
+	rootCmd.PersistentFlags().Bool("no-network", false, "Never connect to other machines, and make the HTML map load ECharts, Leaflet or MapLibre locally and its tiles from this machine; also set by PHOTOS2MAP_NO_NETWORK=true")
+	_ = viper.BindEnv("no-network", "PHOTOS2MAP_NO_NETWORK")
+	rootCmd.PersistentFlags().String("log-file", "", "Also write the log to this file, such as for the live command running as a service")
+	rootCmd.PersistentFlags().Int64("log-max-size", 10, "Size in MB at which --log-file is rotated, 0 to never rotate")
+	rootCmd.PersistentFlags().Int("log-max-backups", 3, "Number of rotated log files kept next to --log-file")
+	rootCmd.PersistentFlags().String("out-dir", "out", "Directory to write the output and reports to, created if it doesn't exist")
+	rootCmd.Flags().StringP("dir", "i", ".", "Directory, ZIP or tar(.gz) archive, or s3://, webdav:// or sftp:// URL to scan for images")
+	rootCmd.Flags().StringSliceP("output", "o", []string{"html"}, "Output formats, comma-separated or repeated to write several from one scan: html, gpx, geojson, json, shapefile, gpkg (GeoPackage), fit (Garmin course), pdf (printable contact sheet), or an import preset for another service: strava or komoot (activity GPX), umap, felt, mymaps (KML) or mymaps-csv")
+	rootCmd.Flags().String("out-name", "", "Base name, without extension, of the output files, e.g. trip for trip.html, trip.gpx and trip-umap.geojson (default: map for html, output for the others)")
+	rootCmd.Flags().Bool("use-exiftool", false, "Fall back to a locally installed exiftool for files the native decoder can't read, and scan RAW/HEIF/video files")
+	rootCmd.Flags().String("files", "", "Read the files listed one per line in this file, or - for stdin, instead of scanning --dir")
+	rootCmd.Flags().String("urls", "", "Read the http(s) URLs of photos listed one per line in this file, or - for stdin, fetching only the start of each")
+	rootCmd.Flags().Bool("camera", false, "Experimental: read the photos on a camera or phone connected over USB (PTP/MTP) with gphoto2 instead of --dir")
+	rootCmd.Flags().StringSlice("ext", nil, "Only scan files with these extensions, e.g. jpg,heic,mp4 (default: all supported formats)")
+	rootCmd.Flags().StringSlice("types", nil, "Same as --ext")
+	rootCmd.Flags().StringSlice("exclude-ext", nil, "Don't scan files with these extensions, e.g. png,mov, even if --ext lists them")
+	rootCmd.Flags().Bool("follow-symlinks", false, "Scan the directories symlinks point to, skipping any linked more than once")
+	rootCmd.Flags().Int("max-depth", 0, "Scan at most this many directory levels, counting the given directory (default: unlimited)")
+	rootCmd.Flags().StringSlice("skip-markers", extract.DefaultSkipMarkers, "Don't scan directories containing a file with one of these names, such as Android's .nomedia or PhotoPrism's .ppstorage; \"\" to scan them all")
+	rootCmd.Flags().Bool("scan-previews", false, "Also scan the folders photo managers keep generated previews in, such as Synology's @eaDir and Immich's thumbs and encoded-video, skipped by default as they would count the photos again")
+	rootCmd.Flags().Bool("no-recursive", false, "Only scan the given directory, not its subdirectories (same as --max-depth 1)")
+	rootCmd.Flags().String("cache-dir", "", "Directory to keep the extraction cache in (default: photos2map in the user's cache directory, $XDG_CACHE_HOME or ~/.cache on Linux)")
+	rootCmd.Flags().Bool("no-cache", false, "Decode every file and generate every thumbnail instead of reusing what was made from unchanged files in earlier runs")
+	rootCmd.Flags().Bool("no-progress", false, "Don't show a progress bar while scanning")
+	rootCmd.Flags().Bool("mmap", false, "Read local JPEG and PNG files through a memory map, which is faster on SSDs (64-bit Linux, macOS and BSD only)")
+	rootCmd.Flags().Int("retries", transport.DefaultRetries, "How many times a failed request to an http(s), s3:// or webdav:// source is retried, waiting longer each time")
+	rootCmd.Flags().Duration("timeout", transport.DefaultTimeout, "How long a request to an http(s), s3:// or webdav:// source waits for the server before it fails and may be retried, 0 for no limit")
+	rootCmd.Flags().String("ca-cert", "", "PEM file of CA certificates to trust, besides the system's, for https and s3:// or webdav:// sources with a certificate of a private CA")
+	rootCmd.Flags().Bool("insecure-skip-verify", false, "Don't verify the certificates of https and s3:// or webdav:// sources, for servers with a self-signed certificate; anyone on the way could read and change the traffic")
+	rootCmd.Flags().String("max-bandwidth", "", "Limit the remote sources to this many bytes per second, all reads together, such as 500K or 2M, to leave room on the connection (default: no limit)")
+	rootCmd.Flags().Int("workers", 0, "Number of files to decode in parallel (default: number of CPUs)")
+	rootCmd.Flags().String("dedupe", "none", "Map duplicate photos once: none, content (identical files), burst (taken in the same second at the same spot, such as burst shots and edited copies) or all")
+	rootCmd.Flags().Bool("filter-outliers", false, "Leave out photos with evidently wrong coordinates: at 0,0, or an isolated jump faster than --max-speed from the photos around it")
+	rootCmd.Flags().Float64("max-speed", 1000, "Speed in km/h above which --filter-outliers treats a jump between photos as impossible")
+	rootCmd.Flags().String("bbox", "", "Only map photos inside this bounding box, given as minLon,minLat,maxLon,maxLat")
+	rootCmd.Flags().String("near", "", "Only map photos within a radius of a place, given as lat,lon,radius with the radius in meters or km, e.g. 48.8566,2.3522,5km")
+	rootCmd.Flags().String("min-distance", "", "Leave out photos taken closer than this to the previous photo kept, in meters or with a km suffix, e.g. 50m")
+	rootCmd.Flags().Int("precision", 0, "Round the coordinates in the output to this many decimal places, e.g. 2 for about 1 km, to share approximate locations")
+	rootCmd.Flags().Float64("fuzz", 0, "Move each point in the output in a random direction by up to this many meters, to share approximate locations; the photos in an area about as wide move the same way, every run, see --fuzz-key-file")
+	rootCmd.Flags().String("fuzz-key-file", "", "File holding the secret key of at least 16 bytes the --fuzz offsets are derived from, created with a random key if missing; keep it, to keep the offsets from run to run, and private, since it undoes them (default: photos2map/fuzz.key in the user's config directory)")
+	rootCmd.Flags().String("link", "", "Link GPX waypoints to their photo: file for file:// URLs of the local files, or a URL template such as https://example.com/photos/{path}, with {path} (relative to --dir), {file}, {name} and {id} filled in")
+	rootCmd.Flags().String("out", "", "Write the output to stdout with -, for pipelines such as photos2map -o gpx --out - | gpsbabel ..., and the messages to stderr; supported for gpx, geojson, json and mymaps-csv")
+	rootCmd.Flags().Bool("stdout", false, "Same as --out -")
+	rootCmd.Flags().Bool("strict", false, "Exit with an error if any file couldn't be read")
+	rootCmd.Flags().String("pre-run", "", "Shell command to run before scanning, with the run described in PHOTOS2MAP_* environment variables; hooks.pre_run in the config file")
+	rootCmd.Flags().Bool("sidecar-per-folder", false, "Also write a photos2map.geojson into every folder of photos, listing the photos in that folder")
+	rootCmd.Flags().String("post-run", "", "Shell command to run once the output is written, such as \"rsync -a out/ server:/var/www/map\", with the run and its results in PHOTOS2MAP_* environment variables; hooks.post_run in the config file")
+	rootCmd.Flags().Bool("partial", false, "When the scan is stopped with Ctrl-C, still write the output for the photos scanned so far")
+	rootCmd.Flags().String("gpx-mode", output.GPXWaypoints, "What the gpx output holds: wpt for a waypoint per photo, trk for a track through the photos in capture order, both, or rte for a route through the photos in capture order")
+	rootCmd.Flags().Int("smooth", 0, "Smooth GPX tracks, strava and komoot activities and FIT courses with the median position of this many consecutive photos, such as 5, taking out the zig-zag of noisy phone GPS; 0 to keep the positions as taken")
+	rootCmd.Flags().Duration("track-gap", 6*time.Hour, "Split GPX tracks into segments and PDF contact sheets into trips wherever more than this passed between photos, 0 to never split")
+	rootCmd.Flags().Bool("path", false, "Connect photos in capture order on the HTML map and in the mymaps KML, styled by speed and stops")
+	rootCmd.Flags().Float64("stop-radius", 50, "Distance in meters within which consecutive photos count as a stop")
+	rootCmd.Flags().Bool("fullscreen", false, "Add a fullscreen toggle to the HTML map")
+	rootCmd.Flags().Bool("scale-bar", false, "Add a scale bar to the HTML map")
+	rootCmd.Flags().Bool("measure", false, "Add a distance measurement tool to the HTML map")
+	rootCmd.Flags().Bool("locate", false, "Add a button centering the HTML map on the viewer's location")
+	rootCmd.Flags().Bool("minimap", false, "Add an inset overview map to the HTML map")
+	rootCmd.Flags().Bool("search", false, "Add a search box filtering the markers on the HTML map")
+	rootCmd.Flags().Bool("permalink", false, "Keep the HTML map's view and search filter in the URL so it can be bookmarked")
+	rootCmd.Flags().Bool("inline", false, "Embed the points in the HTML map instead of writing a separate points.js")
+	rootCmd.Flags().Bool("pwa", false, "Make the HTML map an installable web app that works offline")
+	rootCmd.Flags().String("title", "", "Title of the HTML map and its link previews (default \"photos2map: GPS Image Map\")")
+	rootCmd.Flags().String("description", "", "Description shown in link previews of the HTML map (default: a summary of the photos)")
+	rootCmd.Flags().String("url", "", "URL the HTML map will be published at, so link previews can use absolute image URLs")
+	rootCmd.Flags().String("assets-host", "", "URL or path relative to the HTML map that ECharts, Leaflet or MapLibre is loaded from (default: the go-echarts CDN or unpkg, or assets/ with --no-network)")
+	rootCmd.Flags().Int("max-map-points", 5000, "Split the HTML map by region into maps of at most this many photos, linked from an index in map.html; 0 to never split")
+	rootCmd.Flags().Int("thin-above", 2000, "On HTML maps with more photos than this, show fewer markers while zoomed out, more the further in; 0 to always show all")
+	rootCmd.Flags().Bool("cluster", false, "Group nearby markers on the echarts map into markers counting them, which split up as the map is zoomed in or clicked, instead of thinning them out per --thin-above")
+	rootCmd.Flags().String("map-engine", output.EngineECharts, "Draw the HTML map with: echarts, leaflet for OpenStreetMap tiles that pan and zoom anywhere in the world down to street level, or maplibre for smooth vector tiles of --map-style; leaflet and maplibre support --path, --number-markers, --thumbnails and the page and link preview options")
+	rootCmd.Flags().String("region", output.RegionAuto, "Map the echarts map is drawn on: auto for the world zoomed in on the photos, world, china, or a Chinese province or city by its Chinese name")
+	rootCmd.Flags().String("map-style", "", "URL of the MapLibre style the map is drawn with when --map-engine is maplibre, such as one served by your own tile server (default: OpenFreeMap's Liberty style, or the --tiles given)")
+	rootCmd.Flags().String("map-lang", "", "Language of the place names on the maplibre map's vector tiles, such as en or de, or auto for the viewer's browser language; names missing in it stay local (default: the style's own)")
+	rootCmd.Flags().String("tiles", "", "Tiles the leaflet and maplibre maps are drawn on: osm, opentopo for topographic maps, carto, or the URL of a tile server with {z}, {x} and {y} (default: osm with leaflet, --map-style with maplibre)")
+	rootCmd.Flags().String("tiles-attribution", "", "HTML crediting the --tiles in the corner of the map, replacing the provider's own; needed by most tile servers given by URL")
+	rootCmd.Flags().String("renderer", output.RendererAuto, "Draw the HTML map's markers with: canvas, webgl for maps too large to pan smoothly otherwise, or auto to use webgl above 5000 photos")
+	rootCmd.Flags().String("units", output.UnitsMetric, "Units distances, altitudes and speeds are shown in on the map and in descriptions: metric or imperial")
+	rootCmd.Flags().String("backup-manifest", "", "Highlight the photos missing from a backup listed in this file, as checksums from md5sum, sha1sum, sha256sum or sha512sum (matched by content) or one path per line (matched by path relative to --dir); implies --legend backup")
+	rootCmd.Flags().String("legend", "", "Add a legend to the HTML map grouping the photos by folder, day, camera or, with --backup-manifest, whether they are backed up, with counts and toggles")
+	rootCmd.Flags().String("names", geodata.NamesBase, "How to name photos sharing a file name with another photo in every output: base to keep the name, path for the path relative to --dir, folder to prefix the folder, hash to add a short hash of the path")
+	rootCmd.Flags().String("name-template", "", "Go template composing the name of each photo in every output, e.g. \"{{.Date}} {{.Place}} ({{.Filename}})\", from .Name, .Filename, .Folder, .Path, .Date, .Time, .Place (the continent), .Camera, .Lens, .Caption, .Number, .Lat and .Lon (default: the file name without extension)")
+	rootCmd.Flags().Bool("number-markers", false, "Label HTML map markers 1 to N in capture order and number GPX waypoint names the same way")
+	rootCmd.Flags().Bool("spread-duplicates", false, "Move HTML map markers of photos taken at the exact same spot a few meters apart so each can be clicked")
+	rootCmd.Flags().Bool("thumbnails", false, "Write photo thumbnails next to the HTML map and show them when a marker is clicked; thumbnails of photos unchanged since an earlier run are kept")
 	_ = viper.BindPFlag("dir", rootCmd.Flags().Lookup("dir"))
 	_ = viper.BindPFlag("output", rootCmd.Flags().Lookup("output"))
+	_ = viper.BindPFlag("out-name", rootCmd.Flags().Lookup("out-name"))
+	_ = viper.BindPFlag("use-exiftool", rootCmd.Flags().Lookup("use-exiftool"))
+	_ = viper.BindPFlag("files", rootCmd.Flags().Lookup("files"))
+	_ = viper.BindPFlag("urls", rootCmd.Flags().Lookup("urls"))
+	_ = viper.BindPFlag("camera", rootCmd.Flags().Lookup("camera"))
+	_ = viper.BindPFlag("ext", rootCmd.Flags().Lookup("ext"))
+	_ = viper.BindPFlag("types", rootCmd.Flags().Lookup("types"))
+	_ = viper.BindPFlag("exclude-ext", rootCmd.Flags().Lookup("exclude-ext"))
+	_ = viper.BindPFlag("follow-symlinks", rootCmd.Flags().Lookup("follow-symlinks"))
+	_ = viper.BindPFlag("max-depth", rootCmd.Flags().Lookup("max-depth"))
+	_ = viper.BindPFlag("skip-markers", rootCmd.Flags().Lookup("skip-markers"))
+	_ = viper.BindPFlag("scan-previews", rootCmd.Flags().Lookup("scan-previews"))
+	_ = viper.BindPFlag("no-recursive", rootCmd.Flags().Lookup("no-recursive"))
+	_ = viper.BindPFlag("cache-dir", rootCmd.Flags().Lookup("cache-dir"))
+	_ = viper.BindPFlag("no-cache", rootCmd.Flags().Lookup("no-cache"))
+	_ = viper.BindPFlag("no-progress", rootCmd.Flags().Lookup("no-progress"))
+	_ = viper.BindPFlag("mmap", rootCmd.Flags().Lookup("mmap"))
+	_ = viper.BindPFlag("retries", rootCmd.Flags().Lookup("retries"))
+	_ = viper.BindPFlag("timeout", rootCmd.Flags().Lookup("timeout"))
+	_ = viper.BindPFlag("ca-cert", rootCmd.Flags().Lookup("ca-cert"))
+	_ = viper.BindPFlag("insecure-skip-verify", rootCmd.Flags().Lookup("insecure-skip-verify"))
+	_ = viper.BindPFlag("max-bandwidth", rootCmd.Flags().Lookup("max-bandwidth"))
+	_ = viper.BindPFlag("workers", rootCmd.Flags().Lookup("workers"))
+	_ = viper.BindPFlag("dedupe", rootCmd.Flags().Lookup("dedupe"))
+	_ = viper.BindPFlag("filter-outliers", rootCmd.Flags().Lookup("filter-outliers"))
+	_ = viper.BindPFlag("max-speed", rootCmd.Flags().Lookup("max-speed"))
+	_ = viper.BindPFlag("bbox", rootCmd.Flags().Lookup("bbox"))
+	_ = viper.BindPFlag("near", rootCmd.Flags().Lookup("near"))
+	_ = viper.BindPFlag("min-distance", rootCmd.Flags().Lookup("min-distance"))
+	_ = viper.BindPFlag("precision", rootCmd.Flags().Lookup("precision"))
+	_ = viper.BindPFlag("fuzz", rootCmd.Flags().Lookup("fuzz"))
+	_ = viper.BindPFlag("fuzz-key-file", rootCmd.Flags().Lookup("fuzz-key-file"))
+	_ = viper.BindPFlag("link", rootCmd.Flags().Lookup("link"))
+	_ = viper.BindPFlag("out", rootCmd.Flags().Lookup("out"))
+	_ = viper.BindPFlag("stdout", rootCmd.Flags().Lookup("stdout"))
+	_ = viper.BindPFlag("strict", rootCmd.Flags().Lookup("strict"))
+	_ = viper.BindPFlag("pre-run", rootCmd.Flags().Lookup("pre-run"))
+	_ = viper.BindPFlag("sidecar-per-folder", rootCmd.Flags().Lookup("sidecar-per-folder"))
+	_ = viper.BindPFlag("post-run", rootCmd.Flags().Lookup("post-run"))
+	_ = viper.BindPFlag("partial", rootCmd.Flags().Lookup("partial"))
+	_ = viper.BindPFlag("gpx-mode", rootCmd.Flags().Lookup("gpx-mode"))
+	_ = viper.BindPFlag("track-gap", rootCmd.Flags().Lookup("track-gap"))
+	_ = viper.BindPFlag("smooth", rootCmd.Flags().Lookup("smooth"))
+	_ = viper.BindPFlag("path", rootCmd.Flags().Lookup("path"))
+	_ = viper.BindPFlag("stop-radius", rootCmd.Flags().Lookup("stop-radius"))
+	_ = viper.BindPFlag("fullscreen", rootCmd.Flags().Lookup("fullscreen"))
+	_ = viper.BindPFlag("scale-bar", rootCmd.Flags().Lookup("scale-bar"))
+	_ = viper.BindPFlag("measure", rootCmd.Flags().Lookup("measure"))
+	_ = viper.BindPFlag("locate", rootCmd.Flags().Lookup("locate"))
+	_ = viper.BindPFlag("minimap", rootCmd.Flags().Lookup("minimap"))
+	_ = viper.BindPFlag("search", rootCmd.Flags().Lookup("search"))
+	_ = viper.BindPFlag("permalink", rootCmd.Flags().Lookup("permalink"))
+	_ = viper.BindPFlag("inline", rootCmd.Flags().Lookup("inline"))
+	_ = viper.BindPFlag("pwa", rootCmd.Flags().Lookup("pwa"))
+	_ = viper.BindPFlag("title", rootCmd.Flags().Lookup("title"))
+	_ = viper.BindPFlag("description", rootCmd.Flags().Lookup("description"))
+	_ = viper.BindPFlag("url", rootCmd.Flags().Lookup("url"))
+	_ = viper.BindPFlag("max-map-points", rootCmd.Flags().Lookup("max-map-points"))
+	_ = viper.BindPFlag("thin-above", rootCmd.Flags().Lookup("thin-above"))
+	_ = viper.BindPFlag("cluster", rootCmd.Flags().Lookup("cluster"))
+	_ = viper.BindPFlag("map-engine", rootCmd.Flags().Lookup("map-engine"))
+	_ = viper.BindPFlag("region", rootCmd.Flags().Lookup("region"))
+	_ = viper.BindPFlag("map-style", rootCmd.Flags().Lookup("map-style"))
+	_ = viper.BindPFlag("map-lang", rootCmd.Flags().Lookup("map-lang"))
+	_ = viper.BindPFlag("tiles", rootCmd.Flags().Lookup("tiles"))
+	_ = viper.BindPFlag("tiles-attribution", rootCmd.Flags().Lookup("tiles-attribution"))
+	_ = viper.BindPFlag("renderer", rootCmd.Flags().Lookup("renderer"))
+	_ = viper.BindPFlag("units", rootCmd.Flags().Lookup("units"))
+	_ = viper.BindPFlag("backup-manifest", rootCmd.Flags().Lookup("backup-manifest"))
+	_ = viper.BindPFlag("legend", rootCmd.Flags().Lookup("legend"))
+	_ = viper.BindPFlag("names", rootCmd.Flags().Lookup("names"))
+	_ = viper.BindPFlag("name-template", rootCmd.Flags().Lookup("name-template"))
+	_ = viper.BindPFlag("number-markers", rootCmd.Flags().Lookup("number-markers"))
+	_ = viper.BindPFlag("spread-duplicates", rootCmd.Flags().Lookup("spread-duplicates"))
+	_ = viper.BindPFlag("thumbnails", rootCmd.Flags().Lookup("thumbnails"))
+	_ = viper.BindPFlag("assets-host", rootCmd.Flags().Lookup("assets-host"))
 
 	// add sub-commands
 	rootCmd.AddCommand(
+		geotagCmd,
+		featuresCmd,
 		man.NewManCmd(),
 		version.Command(),
 	)
@@ -63,16 +265,605 @@ func init() {
 // Core functionality to process the images and output either an HTML map or GPX file
 func run(cmd *cobra.Command, args []string) {
 	dir := viper.GetString("dir")
-	outputType := viper.GetString("output")
-	gpsData := extract.ExtractGPSData(dir)
+	// each format once, in the order given
+	var outputs []string
+	for _, format := range viper.GetStringSlice("output") {
+		if !slices.Contains(outputs, format) {
+			outputs = append(outputs, format)
+		}
+	}
+	extractOpts := extract.Options{
+		UseExiftool:       viper.GetBool("use-exiftool"),
+		Extensions:        append(viper.GetStringSlice("ext"), viper.GetStringSlice("types")...),
+		ExcludeExtensions: viper.GetStringSlice("exclude-ext"),
+		FollowSymlinks:    viper.GetBool("follow-symlinks"),
+		MaxDepth:          viper.GetInt("max-depth"),
+		SkipMarkers:       viper.GetStringSlice("skip-markers"),
+		ScanPreviews:      viper.GetBool("scan-previews"),
+		Mmap:              viper.GetBool("mmap"),
+		Workers:           viper.GetInt("workers"),
+	}
+	if viper.GetBool("no-recursive") {
+		extractOpts.MaxDepth = 1
+	}
+	if log.IsLevelEnabled(log.DebugLevel) {
+		extractOpts.Stats = &extract.Stats{}
+	}
+	regions := regions()
+	strategy, err := dedupe.Parse(viper.GetString("dedupe"))
+	if err != nil {
+		log.Fatalf("Error parsing --dedupe: %v", err)
+	}
+	var minDistance float64
+	if s := viper.GetString("min-distance"); s != "" {
+		if minDistance, err = geodata.ParseDistance(s); err != nil {
+			log.Fatalf("Error parsing --min-distance: %v", err)
+		}
+	}
+	if viper.GetInt("retries") < 0 {
+		log.Fatalf("Invalid --retries %d, expected 0 or more", viper.GetInt("retries"))
+	}
+	if viper.GetDuration("timeout") < 0 {
+		log.Fatalf("Invalid --timeout %v, expected 0 or more", viper.GetDuration("timeout"))
+	}
+	tlsConfig, err := transport.TLSConfig(viper.GetString("ca-cert"), viper.GetBool("insecure-skip-verify"))
+	if err != nil {
+		log.Fatalf("Error reading --ca-cert: %v", err)
+	}
+	if viper.GetBool("insecure-skip-verify") {
+		log.Warn("--insecure-skip-verify is set, so the certificates of the remote sources aren't verified")
+	}
+	var maxBandwidth int64
+	if s := viper.GetString("max-bandwidth"); s != "" {
+		if maxBandwidth, err = ratelimit.Parse(s); err != nil {
+			log.Fatalf("Error parsing --max-bandwidth: %v", err)
+		}
+	}
+	if engine := viper.GetString("map-engine"); !slices.Contains(output.Engines, engine) {
+		log.Fatalf("Unknown --map-engine %q, expected one of %v", engine, output.Engines)
+	} else if ignored := ignoredMapFlags(cmd, engine); len(ignored) > 0 {
+		log.Warnf("--map-engine %s doesn't support %s, ignoring them; of the flags only some maps support, it supports --%s", engine, strings.Join(ignored, ", "), strings.Join(engineFlags[engine], ", --"))
+	}
+	if region := viper.GetString("region"); !output.ValidRegion(region) {
+		log.Fatalf("Unknown --region %q, expected auto, world, china, or a Chinese province or city by its Chinese name", region)
+	}
+	if lang := viper.GetString("map-lang"); lang != "" {
+		switch {
+		case !output.ValidLang(lang):
+			log.Fatalf("Invalid --map-lang %q, expected auto or a language code such as en", lang)
+		case viper.GetString("map-engine") != output.EngineMapLibre:
+			// reported with the other flags the engine doesn't support
+		case viper.GetString("tiles") != "":
+			log.Warn("--map-lang doesn't apply to --tiles, which have their place names drawn in")
+		}
+	}
+	if name := viper.GetString("tiles"); name != "" {
+		switch tiles := tileProvider(); {
+		case viper.GetString("map-engine") == output.EngineECharts:
+			// reported with the other flags echarts doesn't support
+		case viper.GetString("map-engine") == output.EngineMapLibre && viper.GetString("map-style") != "":
+			log.Fatalf("Use either --tiles or --map-style with --map-engine maplibre")
+		case tiles.Attribution == "":
+			log.Warnf("The map credits no one for the tiles of %s; most tile servers require it, see --tiles-attribution", name)
+		}
+	}
+	if viper.GetBool("no-network") && slices.Contains(outputs, "html") {
+		source := output.TileSource(output.MapOptions{
+			Engine: viper.GetString("map-engine"),
+			Style:  viper.GetString("map-style"),
+			Tiles:  tileProvider(),
+		})
+		if source != "" && !output.IsLocalURL(source) {
+			log.Fatalf("The %s map loads its tiles from %s, which --no-network doesn't allow; point --tiles, or --map-style with maplibre, at a tile server on this machine or a file", viper.GetString("map-engine"), source)
+		}
+	}
+	if renderer := viper.GetString("renderer"); !slices.Contains(output.Renderers, renderer) {
+		log.Fatalf("Unknown --renderer %q, expected one of %v", renderer, output.Renderers)
+	}
+	if units := viper.GetString("units"); !slices.Contains(output.Units, units) {
+		log.Fatalf("Unknown --units %q, expected one of %v", units, output.Units)
+	}
+	if legend := viper.GetString("legend"); legend != "" && !slices.Contains(output.Legends, legend) {
+		log.Fatalf("Unknown --legend %q, expected one of %v", legend, output.Legends)
+	}
+	var manifest *backup.Manifest
+	if path := viper.GetString("backup-manifest"); path != "" {
+		if manifest, err = backup.ReadFile(path); err != nil {
+			log.Fatalf("Error reading --backup-manifest: %v", err)
+		}
+		if viper.GetString("legend") == "" {
+			viper.Set("legend", output.LegendBackup)
+		}
+	} else if viper.GetString("legend") == output.LegendBackup {
+		log.Fatalf("--legend backup needs a --backup-manifest to check the photos against")
+	}
+	if mode := viper.GetString("gpx-mode"); !slices.Contains(output.GPXModes, mode) {
+		log.Fatalf("Unknown --gpx-mode %q, expected one of %v", mode, output.GPXModes)
+	}
+	for _, format := range outputs {
+		if !slices.Contains(output.Formats, format) {
+			log.Fatalf("Unknown --output %q, expected one of %v", format, output.Formats)
+		}
+	}
+	if names := viper.GetString("names"); !slices.Contains(geodata.NameStrategies, names) {
+		log.Fatalf("Unknown --names %q, expected one of %v", names, geodata.NameStrategies)
+	}
+	var nameTemplate *template.Template
+	if text := viper.GetString("name-template"); text != "" {
+		if nameTemplate, err = geodata.ParseNameTemplate(text); err != nil {
+			log.Fatalf("Error parsing --name-template: %v", err)
+		}
+	}
+	output.Name = viper.GetString("out-name")
+	if strings.ContainsAny(output.Name, `/\`) || output.Name == "." || output.Name == ".." {
+		log.Fatalf("Invalid --out-name %q, expected a file name without directories; use --out-dir for those", output.Name)
+	}
+	// messages, and the output of hooks, go to stderr when stdout is taken by the output
+	messages := io.Writer(os.Stdout)
+	var stdout io.Writer
+	toStdout := viper.GetBool("stdout")
+	if out := viper.GetString("out"); out != "" {
+		if out != "-" {
+			log.Fatalf("Unsupported --out %q, only - for stdout is; use --out-dir and --out-name to name the output files", out)
+		}
+		toStdout = true
+	}
+	if toStdout {
+		if len(outputs) != 1 || !slices.Contains(stdoutFormats, outputs[0]) {
+			log.Fatalf("Writing to stdout is only supported with a single --output of %v", stdoutFormats)
+		}
+		messages, stdout = os.Stderr, os.Stdout
+	}
+	// the run as described to the --pre-run and --post-run hooks
+	hookEnv := map[string]string{"dir": dir, "output": strings.Join(outputs, ","), "out_dir": output.Dir, "version": version.Version}
+	if hook := viper.GetString("pre-run"); hook != "" {
+		if err := hooks.Run(hook, hookEnv, messages); err != nil {
+			log.Fatalf("Error running --pre-run: %v", err)
+		}
+	}
+	if !viper.GetBool("no-cache") && !viper.GetBool("camera") {
+		extractOpts.Cache = openCache()
+	}
+	var bar *progress.Bar
+	if !viper.GetBool("no-progress") && isTerminal(os.Stderr) {
+		bar = progress.New(os.Stderr)
+		extractOpts.Progress = bar.Update
+	}
+
+	// Ctrl-C stops the scan; from then on the signal is held until the output is written, so it's never cut short
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var gpsData []geodata.Point
+	var skipped []extract.Skipped
+	// the remote sources retry the requests that fail in ways that may pass
+	limiter := ratelimit.New(maxBandwidth)
+	retrying := transport.New(transport.Options{Retries: viper.GetInt("retries"), Timeout: viper.GetDuration("timeout"), Limiter: limiter, TLS: tlsConfig})
+	remote := retrying.Client()
+	switch {
+	case viper.GetBool("camera"):
+		gpsData, skipped = extract.ExtractCameraGPSData(ctx, extractOpts)
+	case viper.GetString("files") != "":
+		gpsData, skipped = extract.ExtractFileListGPSData(ctx, readFileList(viper.GetString("files")), extractOpts)
+	case viper.GetString("urls") != "":
+		requireNetwork("the URLs in " + viper.GetString("urls"))
+		if features.Network {
+			gpsData, skipped = extract.ExtractURLGPSData(ctx, readFileList(viper.GetString("urls")), remote, extractOpts)
+		}
+	case strings.HasPrefix(dir, "s3://"):
+		requireNetwork(dir)
+		if features.Network {
+			gpsData, skipped = extract.ExtractS3GPSData(ctx, dir, s3.New(s3.ConfigFromEnv(), remote), extractOpts)
+		}
+	case strings.HasPrefix(dir, "webdav://"), strings.HasPrefix(dir, "webdav+http://"):
+		requireNetwork(dir)
+		if features.Network {
+			cfg := webdav.ConfigFromEnv()
+			if _, user, _ := webdav.ParseURL(dir); user != "" {
+				cfg.User = user
+			}
+			gpsData, skipped = extract.ExtractWebDAVGPSData(ctx, dir, webdav.New(cfg, remote), extractOpts)
+		}
+	case strings.HasPrefix(dir, "sftp://"):
+		requireNetwork(dir)
+		if features.Network {
+			gpsData, skipped = extract.ExtractSFTPGPSData(ctx, dir, limiter, extractOpts)
+		}
+	case extract.IsArchive(dir):
+		gpsData, skipped = extract.ExtractArchiveGPSData(ctx, dir, extractOpts)
+	default:
+		gpsData, skipped = extract.ExtractGPSData(ctx, dir, extractOpts)
+	}
+	if bar != nil {
+		bar.Finish()
+	}
+	extractOpts.Stats.Log()
+	if extractOpts.Cache != nil {
+		if err := extractOpts.Cache.Save(); err != nil {
+			log.Warnf("Error saving the extraction cache: %v", err)
+		}
+	}
+	interrupted := ctx.Err() != nil
+	if interrupted {
+		if !viper.GetBool("partial") {
+			log.Warn("Interrupted, no output written; use --partial to write the photos scanned so far")
+			os.Exit(exitInterrupted)
+		}
+		log.Warnf("Interrupted, writing the output for the %d photos with GPS data scanned so far", len(gpsData))
+	}
+	if len(skipped) > 0 {
+		output.GenerateSkippedReport(skipped)
+	}
+	output.PrintSummary(messages, gpsData, skipped)
+	if retried := retrying.Retried(); retried > 0 {
+		fmt.Fprintf(messages, "  Requests retried after a network error or a busy server: %d\n", retried)
+	}
+	// outputs list the photos in the order they were taken rather than the order they were scanned in
+	geodata.SortByTime(gpsData)
+	geodata.AssignIDs(gpsData, dir)
+	if strategy != dedupe.None {
+		var dropped int
+		gpsData, dropped = dedupe.Apply(gpsData, strategy)
+		fmt.Fprintf(messages, "Left out %d duplicate photos.\n", dropped)
+	}
+	if viper.GetBool("filter-outliers") {
+		var outliers []geodata.Outlier
+		gpsData, outliers = geodata.RemoveOutliers(gpsData, viper.GetFloat64("max-speed")/3.6)
+		for _, o := range outliers {
+			log.Warnf("Leaving out %s, %s", o.Point.Path, o.Reason)
+		}
+		fmt.Fprintf(messages, "Left out %d photos with wrong coordinates.\n", len(outliers))
+	}
+	if len(regions) > 0 {
+		scanned := len(gpsData)
+		gpsData = geodata.Filter(gpsData, regions...)
+		fmt.Fprintf(messages, "Kept the %d of %d photos inside the given area.\n", len(gpsData), scanned)
+	}
+	if minDistance > 0 {
+		before := len(gpsData)
+		gpsData = geodata.Thin(gpsData, minDistance)
+		fmt.Fprintf(messages, "Kept the %d of %d photos at least %gm apart.\n", len(gpsData), before, minDistance)
+	}
+	if radius := viper.GetFloat64("fuzz"); radius > 0 {
+		gpsData = geodata.Fuzz(gpsData, radius, fuzzKey())
+	}
+	if cmd.Flags().Changed("precision") {
+		gpsData = geodata.Round(gpsData, viper.GetInt("precision"))
+	}
+	if manifest != nil {
+		missing := backup.Mark(gpsData, manifest, dir)
+		fmt.Fprintf(messages, "%d of %d photos aren't in the backup.\n", missing, len(gpsData))
+	}
+	geodata.Disambiguate(gpsData, viper.GetString("names"), dir)
+	if viper.GetBool("number-markers") {
+		geodata.NumberByTime(gpsData)
+	}
+	if nameTemplate != nil {
+		if err := geodata.Rename(gpsData, nameTemplate, dir); err != nil {
+			log.Fatalf("Error applying --name-template: %v", err)
+		}
+	}
 
 	if len(gpsData) > 0 {
-		if outputType == "gpx" {
-			output.GenerateGPX(gpsData)
-		} else {
-			output.GenerateMap(gpsData)
+		for _, format := range outputs {
+			writeOutput(format, gpsData, stdout, extractOpts.Cache)
+		}
+		if viper.GetBool("sidecar-per-folder") {
+			output.GenerateFolderSidecars(gpsData, viper.GetString("units"))
 		}
-	} else {
-		fmt.Println("No GPS data found in the images.")
+	} else if len(regions) == 0 {
+		fmt.Fprintln(messages, "No GPS data found in the images.")
 	}
+	if hook := viper.GetString("post-run"); hook != "" {
+		hookEnv["photos"] = strconv.Itoa(len(gpsData))
+		hookEnv["skipped"] = strconv.Itoa(len(skipped))
+		hookEnv["interrupted"] = strconv.FormatBool(interrupted)
+		hookEnv["files"] = strings.Join(output.Written(), "\n")
+		if err := hooks.Run(hook, hookEnv, messages); err != nil {
+			log.Fatalf("Error running --post-run: %v", err)
+		}
+	}
+	if interrupted {
+		os.Exit(exitInterrupted)
+	}
+	if failed := extract.Failed(skipped); viper.GetBool("strict") && len(failed) > 0 {
+		log.Errorf("%d files couldn't be read", len(failed))
+		os.Exit(1)
+	}
+}
+
+// writeOutput writes the points in one of the output formats, to stdout instead of a file if it isn't nil. The
+// thumbnails of the HTML map are recorded in c if it isn't nil.
+func writeOutput(format string, gpsData []geodata.Point, stdout io.Writer, c *cache.Cache) {
+	switch format {
+	case "gpx":
+		output.GenerateGPX(gpsData, output.GPXOptions{
+			Mode:     viper.GetString("gpx-mode"),
+			Links:    photoLinks(),
+			TrackGap: viper.GetDuration("track-gap"),
+			Smooth:   viper.GetInt("smooth"),
+		}, stdout)
+	case "strava", "komoot":
+		output.GenerateActivityGPX(smoothTrack(gpsData), viper.GetDuration("track-gap"))
+	case "geojson":
+		output.GenerateGeoJSON(gpsData, viper.GetString("units"), stdout)
+	case "shapefile":
+		output.GenerateShapefile(gpsData)
+	case "gpkg":
+		output.GenerateGeoPackage(gpsData)
+	case "fit":
+		output.GenerateFIT(smoothTrack(gpsData))
+	case "pdf":
+		output.GeneratePDF(gpsData, viper.GetDuration("track-gap"), viper.GetString("units"))
+	case "json":
+		output.GenerateJSON(gpsData, stdout)
+	case "umap":
+		output.GenerateUMap(gpsData, viper.GetString("units"))
+	case "felt":
+		output.GenerateFelt(gpsData, viper.GetString("units"))
+	case "mymaps":
+		output.GenerateKML(gpsData, viper.GetString("units"), viper.GetBool("path"), viper.GetFloat64("stop-radius"))
+	case "mymaps-csv":
+		output.GenerateCSV(gpsData, viper.GetString("units"), stdout)
+	default:
+		output.GenerateMaps(gpsData, output.MapOptions{
+			Engine:           viper.GetString("map-engine"),
+			Region:           viper.GetString("region"),
+			Style:            viper.GetString("map-style"),
+			Tiles:            tileProvider(),
+			Lang:             viper.GetString("map-lang"),
+			Path:             viper.GetBool("path"),
+			StopRadius:       viper.GetFloat64("stop-radius"),
+			Fullscreen:       viper.GetBool("fullscreen"),
+			ScaleBar:         viper.GetBool("scale-bar"),
+			Measure:          viper.GetBool("measure"),
+			Locate:           viper.GetBool("locate"),
+			MiniMap:          viper.GetBool("minimap"),
+			Search:           viper.GetBool("search"),
+			Legend:           viper.GetString("legend"),
+			Permalink:        viper.GetBool("permalink"),
+			Inline:           viper.GetBool("inline"),
+			PWA:              viper.GetBool("pwa"),
+			Title:            viper.GetString("title"),
+			Description:      viper.GetString("description"),
+			BaseURL:          viper.GetString("url"),
+			Thumbnails:       viper.GetBool("thumbnails"),
+			ThumbnailCache:   c,
+			ThinAbove:        viper.GetInt("thin-above"),
+			Cluster:          viper.GetBool("cluster"),
+			Units:            viper.GetString("units"),
+			Renderer:         viper.GetString("renderer"),
+			NumberMarkers:    viper.GetBool("number-markers"),
+			SpreadDuplicates: viper.GetBool("spread-duplicates"),
+			AssetsHost:       assetsHost(),
+		}, viper.GetInt("max-map-points"))
+	}
+}
+
+// regions returns the areas given with --bbox and --near that the points are restricted to.
+func regions() []geodata.Region {
+	var regions []geodata.Region
+	if s := viper.GetString("bbox"); s != "" {
+		b, err := geodata.ParseBBox(s)
+		if err != nil {
+			log.Fatalf("Error parsing --bbox: %v", err)
+		}
+		regions = append(regions, b)
+	}
+	if s := viper.GetString("near"); s != "" {
+		c, err := geodata.ParseCircle(s)
+		if err != nil {
+			log.Fatalf("Error parsing --near: %v", err)
+		}
+		regions = append(regions, c)
+	}
+	return regions
+}
+
+// smoothTrack returns the points smoothed with --smooth, for the outputs that are a track through them.
+func smoothTrack(gpsData []geodata.Point) []geodata.Point {
+	return geodata.Smooth(gpsData, viper.GetInt("smooth"), viper.GetDuration("track-gap"))
+}
+
+// mapFlags are the flags of the HTML map that only some of the map engines support. The others, such as --title,
+// --units or --max-map-points, apply to every map.
+var mapFlags = []string{
+	"region", "map-style", "map-lang", "tiles", "tiles-attribution", "path", "stop-radius", "fullscreen", "scale-bar",
+	"measure", "locate", "minimap", "search", "legend", "permalink", "inline", "pwa", "thumbnails", "thin-above",
+	"cluster", "renderer", "number-markers", "spread-duplicates",
+}
+
+// engineFlags are the mapFlags each map engine supports. The leaflet and maplibre maps always embed their points
+// in the page, as --inline asks.
+var engineFlags = map[string][]string{
+	output.EngineECharts: {
+		"region", "path", "stop-radius", "fullscreen", "scale-bar", "measure", "locate", "minimap", "search", "legend",
+		"permalink", "inline", "pwa", "thumbnails", "thin-above", "cluster", "renderer", "number-markers",
+		"spread-duplicates",
+	},
+	output.EngineLeaflet:  {"tiles", "tiles-attribution", "path", "inline", "thumbnails", "number-markers"},
+	output.EngineMapLibre: {"map-style", "map-lang", "tiles", "tiles-attribution", "path", "inline", "thumbnails", "number-markers"},
+}
+
+// ignoredMapFlags returns the mapFlags given, on the command line or in the config file or environment, that the
+// map engine doesn't support, prefixed with --.
+func ignoredMapFlags(cmd *cobra.Command, engine string) []string {
+	var ignored []string
+	for _, name := range mapFlags {
+		// a flag given its default value changes nothing either way
+		if !slices.Contains(engineFlags[engine], name) && viper.GetString(name) != cmd.Flags().Lookup(name).DefValue {
+			ignored = append(ignored, "--"+name)
+		}
+	}
+	return ignored
+}
+
+// tileProvider returns the tiles given with --tiles, or none to use the map engine's default.
+func tileProvider() output.TileProvider {
+	name := viper.GetString("tiles")
+	if name == "" {
+		return output.TileProvider{}
+	}
+	tiles, err := output.Tiles(name, viper.GetString("tiles-attribution"))
+	if err != nil {
+		log.Fatalf("Unknown --tiles: %v", err)
+	}
+	return tiles
+}
+
+// photoLinks returns where --link links the photos. {path} is relative to --dir unless the photos came from
+// somewhere else.
+func photoLinks() output.PhotoLinks {
+	links := output.PhotoLinks{Template: viper.GetString("link")}
+	if !viper.GetBool("camera") && viper.GetString("files") == "" && viper.GetString("urls") == "" {
+		links.Root = viper.GetString("dir")
+	}
+	return links
+}
+
+// fuzzKeyFile is the name of the file in the user's config directory holding the key of the --fuzz offsets
+const fuzzKeyFile = "fuzz.key"
+
+// minFuzzKey is the fewest bytes a --fuzz key may have, so it can't be guessed by trying them all
+const minFuzzKey = 16
+
+// fuzzKey returns the secret key the --fuzz offsets are derived from, read from --fuzz-key-file or else a file in
+// the user's config directory. The file is created with a random key on first use, so the photos keep their
+// offsets from run to run.
+func fuzzKey() []byte {
+	path := viper.GetString("fuzz-key-file")
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			log.Fatalf("Error finding where to keep the --fuzz key, give --fuzz-key-file instead: %v", err)
+		}
+		path = filepath.Join(dir, "photos2map", fuzzKeyFile)
+	}
+	key, err := os.ReadFile(path) // #nosec G304 -- the user's own key file
+	switch {
+	case err == nil && len(key) < minFuzzKey:
+		log.Fatalf("The --fuzz key in %s is too short, %d bytes; it needs at least %d", path, len(key), minFuzzKey)
+	case err == nil:
+		return key
+	case !errors.Is(err, os.ErrNotExist):
+		log.Fatalf("Error reading the --fuzz key: %v", err)
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("Error creating the --fuzz key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		log.Fatalf("Error creating the --fuzz key: %v", err)
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		log.Fatalf("Error creating the --fuzz key: %v", err)
+	}
+	log.Infof("Created %s, the secret key the --fuzz offsets are derived from; keep it to keep the photos' offsets, and private so they can't be undone", path)
+	return key
+}
+
+// requireNetwork exits if scanning url isn't possible because of the nonetwork build tag or --no-network.
+// Callers still guard the network code with features.Network, which lets the compiler leave it out of nonetwork
+// builds.
+func requireNetwork(url string) {
+	if !features.Network {
+		log.Fatalf("Scanning %s needs network support, which this build of photos2map was built without", url)
+	}
+	if viper.GetBool("no-network") {
+		log.Fatalf("Scanning %s needs the network, which --no-network doesn't allow", url)
+	}
+}
+
+// assetsHost returns where the HTML map loads ECharts, Leaflet or MapLibre from. With --no-network it defaults to a
+// directory next to the map, which the user has to fill.
+func assetsHost() string {
+	host := viper.GetString("assets-host")
+	if host != "" && !strings.HasSuffix(host, "/") {
+		host += "/"
+	}
+	if host == "" && viper.GetBool("no-network") {
+		host = "assets/"
+		switch viper.GetString("map-engine") {
+		case output.EngineLeaflet:
+			log.Warn("With --no-network the map loads Leaflet from assets/ next to the map; copy leaflet.js, leaflet.css and images/ from Leaflet's dist there")
+		case output.EngineMapLibre:
+			log.Warn("With --no-network the map loads MapLibre from assets/ next to the map; copy maplibre-gl.js and maplibre-gl.css from MapLibre GL JS's dist there")
+		default:
+			log.Warn("With --no-network the map loads ECharts from assets/ next to the map; copy echarts.min.js, echarts-gl.min.js and maps/ from go-echarts-assets there")
+		}
+	}
+	return host
+}
+
+// readFileList reads the list of files to scan from path, or stdin if path is "-"
+func readFileList(path string) []string {
+	r := os.Stdin
+	if path != "-" {
+		file, err := os.Open(path) // #nosec G304
+		if err != nil {
+			log.Fatalf("Error opening file list: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+	paths, err := extract.ReadFileList(r)
+	if err != nil {
+		log.Fatalf("Error reading file list: %v", err)
+	}
+	return paths
+}
+
+// readConfig reads the --config file, or the config file in the user's config directory if there is one, as
+// defaults for the flags, and the hooks under hooks: as those of --pre-run and --post-run. Flags given on the
+// command line and environment variables take precedence.
+func readConfig() error {
+	path := viper.GetString("config")
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(dir, "photos2map", "config.yaml")
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	// the hooks can also be grouped under hooks:, as pre_run and post_run, below pre-run and post-run themselves
+	for key, flag := range configHooks {
+		if viper.IsSet(key) {
+			viper.SetDefault(flag, viper.GetString(key))
+		}
+	}
+	return nil
+}
+
+// configHooks maps the keys of the hooks grouped under hooks: in the config file to their flags
+var configHooks = map[string]string{"hooks.pre_run": "pre-run", "hooks.post_run": "post-run"}
+
+// openCache opens the extraction cache in --cache-dir or the user's cache directory, or returns nil to scan without it if
+// it can't be read.
+func openCache() *cache.Cache {
+	path, err := cache.DefaultPath()
+	if dir := viper.GetString("cache-dir"); dir != "" {
+		path, err = cache.Path(dir), nil
+	}
+	if err != nil {
+		log.Warnf("Not using the extraction cache: %v", err)
+		return nil
+	}
+	c, err := cache.Open(path)
+	if err != nil {
+		log.Warnf("Not using the extraction cache: %v", err)
+		return nil
+	}
+	return c
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file, where a redrawn progress bar would be noise
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
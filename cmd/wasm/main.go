@@ -0,0 +1,58 @@
+//go:build js && wasm
+
+// Command wasm is photos2map's photo reading and GeoJSON output compiled to WebAssembly, so a web page can map
+// photos in the browser without uploading them anywhere. It sets photos2map.geojson on the global object, which
+// photos2map.js wraps for use with the files picked in a page. make wasm builds it with the bridge and a demo page.
+package main
+
+import (
+	"bytes"
+	"syscall/js"
+	"time"
+
+	"github.com/toozej/photos2map/internal/extract"
+	"github.com/toozej/photos2map/internal/output"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+func main() {
+	js.Global().Set("photos2map", js.ValueOf(map[string]interface{}{
+		"geojson": js.FuncOf(geoJSON),
+	}))
+	// keep the module running for the page to call
+	select {}
+}
+
+// geoJSON takes an array of {name, lastModified, data} objects, data being a Uint8Array with the start of the
+// photo and lastModified in milliseconds since the epoch as in the File API, and returns {geojson, skipped}: the
+// photos with GPS coordinates as GeoJSON, as --output geojson writes them, and the names of the other photos
+// with why they were skipped. Errors are returned as {error}.
+func geoJSON(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeObject {
+		return map[string]interface{}{"error": "expected an array of files"}
+	}
+	files := args[0]
+
+	var gpsData []geodata.Point
+	skipped := []interface{}{}
+	for i := 0; i < files.Length(); i++ {
+		f := files.Index(i)
+		name := f.Get("name").String()
+		data := make([]byte, f.Get("data").Get("length").Int())
+		js.CopyBytesToGo(data, f.Get("data"))
+		p, err := extract.ReadPoint(name, bytes.NewReader(data), time.UnixMilli(int64(f.Get("lastModified").Float())))
+		if err != nil {
+			skipped = append(skipped, map[string]interface{}{"name": name, "error": err.Error()})
+			continue
+		}
+		gpsData = append(gpsData, p)
+	}
+	geodata.SortByTime(gpsData)
+	geodata.AssignIDs(gpsData, "")
+
+	data, err := output.GeoJSON(gpsData, output.UnitsMetric)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return map[string]interface{}{"geojson": string(data), "skipped": skipped}
+}
@@ -0,0 +1,41 @@
+// photos2map.js runs photos2map.wasm in the page, mapping photos without uploading them anywhere. It needs Go's
+// wasm_exec.js to be loaded first; make wasm copies both next to the module.
+(function () {
+	'use strict';
+
+	// headerSize is how much of each JPEG is read, as it keeps its EXIF data within the first 64KiB; other
+	// formats are read whole
+	var headerSize = 128 * 1024;
+	var ready = null;
+
+	// load starts the WebAssembly module once, resolving when photos2map.geojson is available.
+	function load(url) {
+		if (!ready) {
+			var go = new Go();
+			ready = WebAssembly.instantiateStreaming(fetch(url), go.importObject).then(function (result) {
+				go.run(result.instance);
+			});
+		}
+		return ready;
+	}
+
+	// photos2mapGeoJSON reads the start of each File, such as those of an <input type="file" multiple>, and
+	// resolves to {geojson, skipped} with the GeoJSON of the photos with GPS coordinates and the names of the
+	// others with why they were skipped.
+	window.photos2mapGeoJSON = function (files, wasmURL) {
+		return load(wasmURL || 'photos2map.wasm').then(function () {
+			return Promise.all(Array.prototype.map.call(files, function (file) {
+				var jpeg = /\.jpe?g$/i.test(file.name);
+				return (jpeg ? file.slice(0, headerSize) : file).arrayBuffer().then(function (buf) {
+					return {name: file.name, lastModified: file.lastModified, data: new Uint8Array(buf)};
+				});
+			}));
+		}).then(function (photos) {
+			var result = photos2map.geojson(photos);
+			if (result.error) {
+				throw new Error(result.error);
+			}
+			return result;
+		});
+	};
+})();
@@ -0,0 +1,171 @@
+// Package backup checks photos against the list of files in a backup, to find the ones not backed up yet.
+package backup
+
+import (
+	"bufio"
+	"crypto/md5"  // #nosec G501 -- matching md5sum manifests, not for security
+	"crypto/sha1" // #nosec G505 -- matching sha1sum manifests, not for security
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// hashes are the checksums a manifest can list, by the length of their hex encoding
+var hashes = map[int]func() hash.Hash{
+	32:  md5.New,  // #nosec G401
+	40:  sha1.New, // #nosec G401
+	64:  sha256.New,
+	128: sha512.New,
+}
+
+// bsdLine is a checksum line in the BSD format of shasum --tag and md5 -r, e.g. "SHA256 (photo.jpg) = 3f2a..."
+var bsdLine = regexp.MustCompile(`^(?:MD5|SHA1|SHA256|SHA512) \((.*)\) = ([0-9a-fA-F]+)$`)
+
+// Manifest is the set of files in a backup, known by their checksums, their paths or both.
+type Manifest struct {
+	// checksums holds the hex encoded checksums listed, by the length of their encoding
+	checksums map[int]map[string]bool
+	// paths holds the slash-separated paths listed, by base name
+	paths map[string][]string
+}
+
+// ReadFile reads the manifest at path, see Read.
+func ReadFile(path string) (*Manifest, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads a manifest with a file per line: either a checksum and path as written by md5sum, sha1sum,
+// sha256sum or sha512sum, in their default or BSD format, or just a path, such as from find or rsync
+// --list-only. Empty lines and lines starting with # are skipped.
+func Read(r io.Reader) (*Manifest, error) {
+	m := &Manifest{checksums: make(map[int]map[string]bool), paths: make(map[string][]string)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sum, path := parseLine(line)
+		if sum != "" {
+			if m.checksums[len(sum)] == nil {
+				m.checksums[len(sum)] = make(map[string]bool)
+			}
+			m.checksums[len(sum)][strings.ToLower(sum)] = true
+		}
+		if path != "" {
+			path = filepath.ToSlash(path)
+			base := pathBase(path)
+			m.paths[base] = append(m.paths[base], path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading the backup manifest: %w", err)
+	}
+	return m, nil
+}
+
+// parseLine splits a manifest line into its checksum, empty for a plain path, and path.
+func parseLine(line string) (sum, path string) {
+	if match := bsdLine.FindStringSubmatch(line); match != nil && hashes[len(match[2])] != nil {
+		return match[2], match[1]
+	}
+	// md5sum and friends separate the checksum from the path by a space and a space or, in binary mode, a star
+	if i := strings.IndexByte(line, ' '); i > 0 && hashes[i] != nil && isHex(line[:i]) && len(line) > i+2 {
+		if rest := line[i+1:]; rest[0] == ' ' || rest[0] == '*' {
+			return line[:i], rest[1:]
+		}
+	}
+	return "", line
+}
+
+// isHex reports whether s consists of hex digits only.
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// pathBase returns the last element of a slash-separated path.
+func pathBase(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// Contains reports whether the photo at path, which is rel relative to the scanned directory, is in the backup.
+// With checksums listed, it is if its content has one of them, wherever it is in the backup. Otherwise it is if
+// a listed path is the photo's path, or ends with rel, so the backup may be under a different root.
+func (m *Manifest) Contains(path, rel string) (bool, error) {
+	if len(m.checksums) > 0 {
+		return m.containsContent(path)
+	}
+	slashed, rel := filepath.ToSlash(path), filepath.ToSlash(rel)
+	for _, listed := range m.paths[pathBase(slashed)] {
+		if listed == slashed || listed == rel || strings.HasSuffix(listed, "/"+rel) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// containsContent reports whether the checksum of the file at path is listed, computing each kind of checksum
+// the manifest lists in a single read.
+func (m *Manifest) containsContent(path string) (bool, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	sums := make(map[int]hash.Hash, len(m.checksums))
+	writers := make([]io.Writer, 0, len(m.checksums))
+	for length := range m.checksums {
+		sums[length] = hashes[length]()
+		writers = append(writers, sums[length])
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return false, err
+	}
+	for length, h := range sums {
+		if m.checksums[length][hex.EncodeToString(h.Sum(nil))] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Mark sets NotBackedUp on the points whose photo isn't in the backup, with paths relative to root, and returns
+// how many there are. Photos that can't be checked, such as those read from archives or URLs, are left unmarked.
+func Mark(points []geodata.Point, m *Manifest, root string) int {
+	missing := 0
+	for i, p := range points {
+		rel := p.Path
+		if r, err := filepath.Rel(root, p.Path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = r
+		}
+		ok, err := m.Contains(p.Path, rel)
+		if err != nil {
+			log.Warnf("Can't check whether %s is in the backup: %v", p.Path, err)
+			continue
+		}
+		if !ok {
+			points[i].NotBackedUp = true
+			missing++
+		}
+	}
+	return missing
+}
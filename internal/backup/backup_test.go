@@ -0,0 +1,113 @@
+package backup
+
+import (
+	"crypto/md5" // #nosec G501
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestParseLine checks that checksums are told apart from plain paths in each manifest format.
+func TestParseLine(t *testing.T) {
+	sha := strings.Repeat("ab", 32)
+	tests := []struct {
+		line, sum, path string
+	}{
+		{sha + "  photos/a.jpg", sha, "photos/a.jpg"},
+		{sha + " *photos/a b.jpg", sha, "photos/a b.jpg"},
+		{"SHA256 (photos/a.jpg) = " + sha, sha, "photos/a.jpg"},
+		{"photos/a.jpg", "", "photos/a.jpg"},
+		{"cafe  a.jpg", "", "cafe  a.jpg"},
+	}
+	for _, tt := range tests {
+		if sum, path := parseLine(tt.line); sum != tt.sum || path != tt.path {
+			t.Errorf("Expected %q to parse as %q and %q, got %q and %q", tt.line, tt.sum, tt.path, sum, path)
+		}
+	}
+}
+
+// TestContains checks that photos are found in the backup by checksum wherever they are, and by path under any
+// root otherwise.
+func TestContains(t *testing.T) {
+	dir := t.TempDir()
+	backedUp, missing := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg")
+	if err := os.WriteFile(backedUp, []byte("backed up"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(missing, []byte("missing"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sha := sha256.Sum256([]byte("backed up"))
+	md := md5.Sum([]byte("other")) // #nosec G401
+	checksums, err := Read(strings.NewReader("# backup of 2024-05-01\n\n" +
+		hex.EncodeToString(sha[:]) + "  old/name.jpg\n" +
+		"MD5 (b.jpg) = " + hex.EncodeToString(md[:]) + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := Read(strings.NewReader("/mnt/backup/photos/trip/a.jpg\nb.jpg.tmp\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		manifest  *Manifest
+		path, rel string
+		expected  bool
+	}{
+		{"same checksum", checksums, backedUp, "a.jpg", true},
+		{"listed name with another checksum", checksums, missing, "b.jpg", false},
+		{"path under another root", paths, backedUp, "trip/a.jpg", true},
+		{"photo at the root of the scan", paths, backedUp, "a.jpg", true},
+		{"path not listed", paths, missing, "b.jpg", false},
+		{"same name in another folder", paths, backedUp, "rome/a.jpg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := tt.manifest.Contains(tt.path, tt.rel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.expected {
+				t.Errorf("Expected Contains(%q) to be %v", tt.rel, tt.expected)
+			}
+		})
+	}
+}
+
+// TestMark checks that only the photos missing from the backup are marked, and unreadable ones are left alone.
+func TestMark(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("backed up"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.jpg"), []byte("missing"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("backed up"))
+	m, err := Read(strings.NewReader(hex.EncodeToString(sum[:]) + "  a.jpg\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := []geodata.Point{
+		{Path: filepath.Join(dir, "a.jpg")},
+		{Path: filepath.Join(dir, "b.jpg")},
+		{Path: "https://example.com/c.jpg"},
+	}
+	if missing := Mark(points, m, dir); missing != 1 {
+		t.Errorf("Expected 1 photo missing from the backup, got %d", missing)
+	}
+	for i, expected := range []bool{false, true, false} {
+		if points[i].NotBackedUp != expected {
+			t.Errorf("Expected NotBackedUp %v for %s", expected, points[i].Path)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package cache stores the metadata read from files between runs, so unchanged files needn't be decoded again.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/toozej/photos2map/internal/exif"
+)
+
+// Entry is the metadata read from a file, valid as long as the file's size and modification time are unchanged.
+type Entry struct {
+	Size    int64
+	ModTime time.Time
+	// Exiftool is set when the metadata was read with exiftool enabled, which can read files the native
+	// decoder can't, so entries from runs without it aren't reused when it's enabled and vice versa
+	Exiftool bool
+	Meta     exif.Metadata
+	// NoGPS is set when the file was read but has no GPS coordinates
+	NoGPS bool
+}
+
+// Result returns the cached metadata, with exif.ErrNoGPS for files without coordinates.
+func (e Entry) Result() (exif.Metadata, error) {
+	if e.NoGPS {
+		return e.Meta, fmt.Errorf("%w (cached)", exif.ErrNoGPS)
+	}
+	return e.Meta, nil
+}
+
+// Cache holds entries by absolute file path, and the thumbnails generated from the files, see Thumbnail. It's safe
+// for concurrent use.
+type Cache struct {
+	path string
+
+	mu          sync.Mutex
+	entries     map[string]Entry
+	dirty       bool
+	thumbs      map[string]Thumbnail
+	thumbsDirty bool
+}
+
+// fileName is the name of the cache file. Its version is raised whenever exif.Metadata gains a field, so files
+// cached by earlier versions are decoded again rather than missing the new field.
+const fileName = "extract-v2.json"
+
+// DefaultPath returns where the cache is kept unless configured otherwise, in the user's cache directory:
+// $XDG_CACHE_HOME or ~/.cache on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return Path(filepath.Join(dir, "photos2map")), nil
+}
+
+// Path returns the path of the cache file in dir.
+func Path(dir string) string {
+	return filepath.Join(dir, fileName)
+}
+
+// Open loads the cache at path, and the thumbnails recorded next to it, starting empty if they don't exist yet.
+func Open(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: map[string]Entry{}, thumbs: map[string]Thumbnail{}}
+	if err := readJSON(path, &c.entries); err != nil {
+		return nil, err
+	}
+	if err := readJSON(c.thumbsPath(), &c.thumbs); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// readJSON decodes the file at path into v, leaving v as it is if the file doesn't exist.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("reading cache %s: %w", path, err)
+	}
+	return nil
+}
+
+// Lookup returns the entry for the file at path, ok is false if there is none or the file has changed since.
+func (c *Cache) Lookup(path string, info os.FileInfo, exiftool bool) (e Entry, ok bool) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return Entry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok = c.entries[key]
+	if !ok || e.Size != info.Size() || !e.ModTime.Equal(info.ModTime()) || e.Exiftool != exiftool {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Put stores the outcome of reading the file at path. Only files that were read, with or without coordinates,
+// are stored; other errors may not happen again and are left to be retried next time.
+func (c *Cache) Put(path string, info os.FileInfo, exiftool bool, meta exif.Metadata, err error) {
+	noGPS := errors.Is(err, exif.ErrNoGPS)
+	if err != nil && !noGPS {
+		return
+	}
+	key, absErr := filepath.Abs(path)
+	if absErr != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = Entry{Size: info.Size(), ModTime: info.ModTime(), Exiftool: exiftool, Meta: meta, NoGPS: noGPS}
+	c.dirty = true
+}
+
+// Save writes the cache back to its files if anything was added, replacing the files atomically.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dirty {
+		if err := writeJSON(c.path, c.entries); err != nil {
+			return err
+		}
+		c.dirty = false
+	}
+	if c.thumbsDirty {
+		if err := writeJSON(c.thumbsPath(), c.thumbs); err != nil {
+			return err
+		}
+		c.thumbsDirty = false
+	}
+	return nil
+}
+
+// writeJSON encodes v into the file at path, replacing it atomically.
+func writeJSON(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/internal/exif"
+)
+
+// TestCache checks that entries survive a save and are only returned while the file is unchanged.
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "photo.jpg")
+	nogps := filepath.Join(dir, "nogps.jpg")
+	broken := filepath.Join(dir, "broken.jpg")
+	for _, path := range []string{photo, nogps, broken} {
+		if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stat := func(path string) os.FileInfo {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	path := filepath.Join(dir, "cache", "extract.json")
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Put(photo, stat(photo), false, exif.Metadata{Lat: 43, Lon: 11}, nil)
+	c.Put(nogps, stat(nogps), false, exif.Metadata{Make: "NIKON"}, fmt.Errorf("%w: missing", exif.ErrNoGPS))
+	c.Put(broken, stat(broken), false, exif.Metadata{}, errors.New("exif: failed to find exif intro marker"))
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := c.Lookup(photo, stat(photo), false); !ok || e.Meta.Lat != 43 {
+		t.Errorf("Expected the cached coordinates, got %+v, %v", e, ok)
+	}
+	if e, ok := c.Lookup(nogps, stat(nogps), false); !ok {
+		t.Error("Expected the photo without GPS data to be cached")
+	} else if meta, err := e.Result(); !errors.Is(err, exif.ErrNoGPS) || meta.Make != "NIKON" {
+		t.Errorf("Expected ErrNoGPS with the rest of the metadata, got %+v, %v", meta, err)
+	}
+	if _, ok := c.Lookup(broken, stat(broken), false); ok {
+		t.Error("Expected the unreadable photo not to be cached")
+	}
+	if _, ok := c.Lookup(photo, stat(photo), true); ok {
+		t.Error("Expected no entry for a run with exiftool")
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(photo, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Lookup(photo, stat(photo), false); ok {
+		t.Error("Expected no entry for a modified file")
+	}
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// thumbsFileName is the name of the file the thumbnails are recorded in, next to the cache file
+const thumbsFileName = "thumbnails-v1.json"
+
+// Thumbnail records the file a thumbnail was generated from, by its absolute path and its size and modification
+// time at the time. The thumbnail is current as long as the file is unchanged and the thumbnail is still there.
+type Thumbnail struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// thumbsPath returns the path of the file the thumbnails are recorded in.
+func (c *Cache) thumbsPath() string {
+	return filepath.Join(filepath.Dir(c.path), thumbsFileName)
+}
+
+// ThumbnailCurrent reports whether the thumbnail at thumb was generated from the file at path as it is now,
+// described by info, and still exists, so it needn't be generated again.
+func (c *Cache) ThumbnailCurrent(path string, info os.FileInfo, thumb string) bool {
+	key, err := filepath.Abs(thumb)
+	if err != nil {
+		return false
+	}
+	source, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	t, ok := c.thumbs[key]
+	c.mu.Unlock()
+	if !ok || t.Path != source || t.Size != info.Size() || !t.ModTime.Equal(info.ModTime()) {
+		return false
+	}
+	_, err = os.Stat(thumb)
+	return err == nil
+}
+
+// PutThumbnail records that the thumbnail at thumb was generated from the file at path, described by info.
+func (c *Cache) PutThumbnail(path string, info os.FileInfo, thumb string) {
+	key, err := filepath.Abs(thumb)
+	if err != nil {
+		return
+	}
+	source, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.thumbs[key] = Thumbnail{Path: source, Size: info.Size(), ModTime: info.ModTime()}
+	c.thumbsDirty = true
+}
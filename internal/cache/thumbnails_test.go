@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestThumbnails checks that recorded thumbnails survive a save and are only current while the photo is unchanged
+// and the thumbnail exists.
+func TestThumbnails(t *testing.T) {
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "photo.jpg")
+	thumb := filepath.Join(dir, "thumbs", "1234.jpg")
+	if err := os.MkdirAll(filepath.Dir(thumb), 0750); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{photo, thumb} {
+		if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stat := func() os.FileInfo {
+		t.Helper()
+		info, err := os.Stat(photo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	path := filepath.Join(dir, "cache", "extract.json")
+	c, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ThumbnailCurrent(photo, stat(), thumb) {
+		t.Error("Expected no thumbnail before one is recorded")
+	}
+	c.PutThumbnail(photo, stat(), thumb)
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.ThumbnailCurrent(photo, stat(), thumb) {
+		t.Error("Expected the recorded thumbnail to be current")
+	}
+	if c.ThumbnailCurrent(filepath.Join(dir, "other.jpg"), stat(), thumb) {
+		t.Error("Expected the thumbnail not to be current for another photo")
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(photo, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if c.ThumbnailCurrent(photo, stat(), thumb) {
+		t.Error("Expected the thumbnail not to be current for a modified photo")
+	}
+	c.PutThumbnail(photo, stat(), thumb)
+	if err := os.Remove(thumb); err != nil {
+		t.Fatal(err)
+	}
+	if c.ThumbnailCurrent(photo, stat(), thumb) {
+		t.Error("Expected a deleted thumbnail not to be current")
+	}
+}
@@ -0,0 +1,113 @@
+// Package camera reads photos directly from a camera or phone connected over USB with PTP or MTP, through a
+// locally installed gphoto2. Support is experimental and depends on the device's support in libgphoto2.
+package camera
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toozej/photos2map/internal/exif"
+)
+
+// File is a file on the connected device, numbered as gphoto2 lists it.
+type File struct {
+	Number int
+	Folder string
+	Name   string
+	// ModTime is the file's modification time on the device, or the zero value if it isn't reported
+	ModTime time.Time
+}
+
+// Path returns the file's path on the device.
+func (f File) Path() string {
+	return path.Join(f.Folder, f.Name)
+}
+
+// folderLine introduces the files listed in a folder, e.g. "There are 2 files in folder '/store_00010001/DCIM/100CANON':"
+var folderLine = regexp.MustCompile(`^There (?:is|are) \d+ files? in folder '(.*)':$`)
+
+// fileLine is a file listed by gphoto2, e.g. "#1     IMG_0001.JPG    rd  6109 KB 6000x4000 image/jpeg 1714557600"
+var fileLine = regexp.MustCompile(`^#(\d+)\s+(.+?)\s+[r-][d-]\s+(.*)$`)
+
+// Available reports whether a gphoto2 executable is on the PATH.
+func Available() bool {
+	_, err := exec.LookPath("gphoto2")
+	return err == nil
+}
+
+// List returns the files on the connected device, in all of its storages and folders.
+func List() ([]File, error) {
+	out, err := exec.Command("gphoto2", "--list-files", "--quiet").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("running gphoto2: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("running gphoto2: %w", err)
+	}
+	return parseList(out), nil
+}
+
+// parseList reads the files from gphoto2's --list-files output.
+func parseList(out []byte) []File {
+	var files []File
+	var folder string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if m := folderLine.FindStringSubmatch(line); m != nil {
+			folder = m[1]
+			continue
+		}
+		m := fileLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		f := File{Number: n, Folder: folder, Name: m[2]}
+		// the last column is the modification time in seconds since the epoch, if the device reports one
+		details := strings.Fields(m[3])
+		if len(details) > 0 {
+			if sec, err := strconv.ParseInt(details[len(details)-1], 10, 64); err == nil && sec > 0 {
+				f.ModTime = time.Unix(sec, 0)
+			}
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// ReadMetadata streams a file from the device into the EXIF decoder, or exiftool if useExiftool is set,
+// without writing it to disk. With the native decoder the transfer is stopped once the EXIF data is read.
+func ReadMetadata(f File, useExiftool bool) (exif.Metadata, error) {
+	// #nosec G204 -- the only argument is the file number
+	cmd := exec.Command("gphoto2", "--get-file", strconv.Itoa(f.Number), "--stdout", "--quiet")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return exif.Metadata{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return exif.Metadata{}, fmt.Errorf("running gphoto2: %w", err)
+	}
+
+	var meta exif.Metadata
+	if useExiftool {
+		meta, err = exif.ReadExiftool(stdout)
+	} else {
+		meta, err = exif.ReadEXIF(stdout)
+	}
+	// the rest of the file isn't needed, so the download is cut short
+	_ = cmd.Process.Kill()
+	_, _ = io.Copy(io.Discard, stdout)
+	_ = cmd.Wait()
+	return meta, err
+}
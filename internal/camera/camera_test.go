@@ -0,0 +1,32 @@
+package camera
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseList checks that files are read from gphoto2's listing along with their folder and time.
+func TestParseList(t *testing.T) {
+	out := []byte(`There is no file in folder '/'.
+There is no file in folder '/store_00010001'.
+There are 2 files in folder '/store_00010001/DCIM/100CANON':
+#1     IMG_0001.JPG               rd  6109 KB 6000x4000 image/jpeg 1714557600
+#2     IMG_0002.CR2               rd 25430 KB image/x-canon-cr2
+There is 1 file in folder '/store_00010001/DCIM/Camera Roll':
+#3     My photo.jpg               r-   812 KB image/jpeg 1714557610
+`)
+
+	files := parseList(out)
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %+v", files)
+	}
+	if f := files[0]; f.Number != 1 || f.Path() != "/store_00010001/DCIM/100CANON/IMG_0001.JPG" || !f.ModTime.Equal(time.Unix(1714557600, 0)) {
+		t.Errorf("Unexpected first file %+v", f)
+	}
+	if f := files[1]; f.Number != 2 || f.Name != "IMG_0002.CR2" || !f.ModTime.IsZero() {
+		t.Errorf("Unexpected second file %+v", f)
+	}
+	if f := files[2]; f.Number != 3 || f.Path() != "/store_00010001/DCIM/Camera Roll/My photo.jpg" {
+		t.Errorf("Unexpected third file %+v", f)
+	}
+}
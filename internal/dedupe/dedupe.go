@@ -0,0 +1,127 @@
+// Package dedupe finds photos that are copies of one another, such as burst shots and edited copies, so each is
+// mapped once.
+package dedupe
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// Strategy selects which photos count as duplicates.
+type Strategy string
+
+const (
+	// None keeps every photo
+	None Strategy = "none"
+	// Content drops photos whose file has the same content as an earlier one
+	Content Strategy = "content"
+	// Burst drops photos taken in the same second at the same coordinates as an earlier one, which catches burst
+	// shots and copies edited or re-encoded since
+	Burst Strategy = "burst"
+	// All drops the duplicates found by both Content and Burst
+	All Strategy = "all"
+)
+
+// Strategies are the valid strategies, in the order they're listed in the --dedupe help.
+var Strategies = []Strategy{None, Content, Burst, All}
+
+// Parse returns the strategy named s.
+func Parse(s string) (Strategy, error) {
+	for _, strategy := range Strategies {
+		if Strategy(strings.ToLower(strings.TrimSpace(s))) == strategy {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown strategy %q, expected one of %v", s, Strategies)
+}
+
+// Apply returns the points without the duplicates strategy finds, keeping the first of each set of duplicates,
+// and the number of points dropped. Content only compares files on the local disk; photos read from archives or
+// over the network are never content duplicates.
+func Apply(points []geodata.Point, strategy Strategy) ([]geodata.Point, int) {
+	total := len(points)
+	if strategy == Content || strategy == All {
+		points = dedupe(points, contentKeys(points))
+	}
+	if strategy == Burst || strategy == All {
+		keys := make([]string, len(points))
+		for i, p := range points {
+			keys[i] = burstKey(p)
+		}
+		points = dedupe(points, keys)
+	}
+	return points, total - len(points)
+}
+
+// dedupe returns the points whose key hasn't been seen on an earlier point. Points with an empty key are kept.
+func dedupe(points []geodata.Point, keys []string) []geodata.Point {
+	seen := make(map[string]bool, len(points))
+	kept := make([]geodata.Point, 0, len(points))
+	for i, p := range points {
+		if keys[i] != "" {
+			if seen[keys[i]] {
+				log.Debugf("Skipping %s as a duplicate", p.Path)
+				continue
+			}
+			seen[keys[i]] = true
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// burstKey identifies a point by the second it was taken in and its exact coordinates.
+func burstKey(p geodata.Point) string {
+	return fmt.Sprintf("%d %v %v", p.Time.Truncate(time.Second).Unix(), p.Lat, p.Lon)
+}
+
+// contentKeys returns the SHA-256 of each point's file. Only files sharing their size with another are hashed,
+// since files of different sizes can't be copies; the others, and files that can't be read, get an empty key.
+func contentKeys(points []geodata.Point) []string {
+	sizes := make([]int64, len(points))
+	count := make(map[int64]int)
+	for i, p := range points {
+		sizes[i] = -1
+		if info, err := os.Stat(p.Path); err == nil && info.Mode().IsRegular() {
+			sizes[i] = info.Size()
+			count[sizes[i]]++
+		}
+	}
+
+	keys := make([]string, len(points))
+	for i, p := range points {
+		if sizes[i] < 0 || count[sizes[i]] < 2 {
+			continue
+		}
+		sum, err := hashFile(p.Path)
+		if err != nil {
+			log.Debugf("Not checking %s for duplicates: %v", p.Path, err)
+			continue
+		}
+		keys[i] = sum
+	}
+	return keys
+}
+
+// hashFile returns the hex SHA-256 of the file at path.
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
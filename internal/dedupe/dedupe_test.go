@@ -0,0 +1,71 @@
+package dedupe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestParse checks that strategies are parsed in any case and unknown ones are rejected.
+func TestParse(t *testing.T) {
+	if s, err := Parse(" Burst"); err != nil || s != Burst {
+		t.Errorf("Expected burst, got %q, %v", s, err)
+	}
+	if _, err := Parse("fuzzy"); err == nil {
+		t.Error("Expected an error for an unknown strategy")
+	}
+}
+
+// TestApply checks which photos each strategy drops, keeping the first of each set of duplicates.
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	taken := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	points := []geodata.Point{
+		{Name: "a", Path: write("a.jpg", "photo"), Lat: 1, Lon: 2, Time: taken},
+		// a copy of a, moved in time so only its content matches
+		{Name: "copy", Path: write("copy.jpg", "photo"), Lat: 1, Lon: 2, Time: taken.Add(time.Hour)},
+		// a burst shot taken in the same second as a
+		{Name: "burst", Path: write("burst.jpg", "other"), Lat: 1, Lon: 2, Time: taken.Add(300 * time.Millisecond)},
+		// the same size as a but different content
+		{Name: "b", Path: write("b.jpg", "image"), Lat: 3, Lon: 4, Time: taken},
+		// not on disk, so never a content duplicate
+		{Name: "remote", Path: "s3://bucket/a.jpg", Lat: 5, Lon: 6, Time: taken},
+	}
+
+	tests := []struct {
+		strategy Strategy
+		expected []string
+	}{
+		{None, []string{"a", "copy", "burst", "b", "remote"}},
+		{Content, []string{"a", "burst", "b", "remote"}},
+		{Burst, []string{"a", "copy", "b", "remote"}},
+		{All, []string{"a", "b", "remote"}},
+	}
+	for _, tt := range tests {
+		kept, dropped := Apply(points, tt.strategy)
+		var names []string
+		for _, p := range kept {
+			names = append(names, p.Name)
+		}
+		if len(names) != len(tt.expected) || dropped != len(points)-len(tt.expected) {
+			t.Errorf("Expected %v with %s, got %v and %d dropped", tt.expected, tt.strategy, names, dropped)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.expected[i] {
+				t.Errorf("Expected %v with %s, got %v", tt.expected, tt.strategy, names)
+				break
+			}
+		}
+	}
+}
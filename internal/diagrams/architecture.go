@@ -0,0 +1,188 @@
+package diagrams
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/build"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Components introspects the module rooted at root and shows its packages and which import which, grouped by
+// their top-level directory such as internal or pkg. Test files and imports from outside the module are left out,
+// and files are selected by the build constraints of the current platform without extra build tags.
+func Components(root string) (Graph, error) {
+	module, err := modulePath(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return Graph{}, err
+	}
+
+	g := Graph{Name: "components", Title: module + " components"}
+	imports := map[string][]string{}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if p != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		pkg, err := build.ImportDir(p, 0)
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading package in %s: %w", p, err)
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		id := filepath.ToSlash(rel)
+		for _, imp := range pkg.Imports {
+			if dep, ok := strings.CutPrefix(imp, module+"/"); ok {
+				imports[id] = append(imports[id], dep)
+			} else if imp == module {
+				imports[id] = append(imports[id], ".")
+			}
+		}
+		node := Node{ID: id, Label: id}
+		if id == "." {
+			node.Label = path.Base(module)
+		} else if dir, _, ok := strings.Cut(id, "/"); ok {
+			node.Cluster = dir
+		}
+		g.Nodes = append(g.Nodes, node)
+		return nil
+	})
+	if err != nil {
+		return Graph{}, err
+	}
+
+	// nodes come in walk order, which is sorted; edges are sorted the same way for a stable diff
+	var from []string
+	for id := range imports {
+		from = append(from, id)
+	}
+	sort.Strings(from)
+	for _, id := range from {
+		deps := imports[id]
+		sort.Strings(deps)
+		for _, dep := range deps {
+			g.Edges = append(g.Edges, Edge{From: id, To: dep})
+		}
+	}
+	return g, nil
+}
+
+// modulePath reads the module path from a go.mod file.
+func modulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod) // #nosec G304
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no module path in %s", goMod)
+}
+
+// formatLabels are the labels of the output formats' nodes in the data flow diagram; other formats are labelled
+// with their name
+var formatLabels = map[string]string{
+	"html":       "HTML map",
+	"gpx":        "GPX",
+	"geojson":    "GeoJSON",
+	"json":       "JSON",
+	"shapefile":  "Shapefile",
+	"gpkg":       "GeoPackage",
+	"fit":        "FIT course",
+	"pdf":        "PDF report",
+	"strava":     "Strava activity GPX",
+	"komoot":     "Komoot activity GPX",
+	"umap":       "uMap GeoJSON",
+	"felt":       "Felt GeoJSON",
+	"mymaps":     "My Maps KML",
+	"mymaps-csv": "My Maps CSV",
+}
+
+// DataFlow shows how photos travel from the sources through decoding into formats, the output formats, and how
+// the geotag and live commands write positions back as XMP sidecars. The sinks are built from formats so the
+// diagram keeps up with the formats added.
+func DataFlow(formats []string) Graph {
+	g := Graph{
+		Name:  "dataflow",
+		Title: "photos2map data flow",
+		Nodes: []Node{
+			{ID: "dir", Label: "Directory (--dir)", Cluster: "Sources"},
+			{ID: "list", Label: "File list (--files)", Cluster: "Sources"},
+			{ID: "archive", Label: "ZIP / tar archive", Cluster: "Sources"},
+			{ID: "bucket", Label: "S3 bucket (s3://)", Cluster: "Sources"},
+			{ID: "webdav", Label: "WebDAV (webdav://)", Cluster: "Sources"},
+			{ID: "sftp", Label: "SSH server (sftp://)", Cluster: "Sources"},
+			{ID: "urls", Label: "HTTP URL list (--urls)", Cluster: "Sources"},
+			{ID: "device", Label: "Camera over PTP/MTP (--camera)", Cluster: "Sources"},
+			{ID: "transport", Label: "Retries, timeouts and\n--max-bandwidth", Cluster: "Sources"},
+			{ID: "cache", Label: "Extraction cache", Cluster: "Decoding"},
+			{ID: "native", Label: "Native EXIF decoder", Cluster: "Decoding"},
+			{ID: "exiftool", Label: "exiftool (--use-exiftool)", Cluster: "Decoding"},
+			{ID: "points", Label: "geodata.Point"},
+			{ID: "manifest", Label: "Backup manifest\n(--backup-manifest)"},
+			{ID: "report", Label: "Skipped files report\nand summary"},
+			{ID: "sidecars", Label: "Folder sidecars\n(--sidecar-per-folder)", Cluster: "Sinks"},
+			{ID: "tracks", Label: "GPX / FIT / NMEA tracks", Cluster: "Geotagging"},
+			{ID: "gpsd", Label: "gpsd (live)", Cluster: "Geotagging"},
+			{ID: "hotfolder", Label: "Hot folder", Cluster: "Geotagging"},
+			{ID: "geotag", Label: "Interpolation by capture time", Cluster: "Geotagging"},
+			{ID: "xmp", Label: "XMP sidecars\nand geotag report", Cluster: "Geotagging"},
+		},
+		Edges: []Edge{
+			{From: "dir", To: "cache"},
+			{From: "list", To: "cache"},
+			{From: "cache", To: "native", Label: "changed files"},
+			{From: "cache", To: "exiftool", Label: "changed files"},
+			{From: "archive", To: "native", Label: "streamed"},
+			{From: "bucket", To: "transport", Label: "ranged GET"},
+			{From: "webdav", To: "transport", Label: "ranged GET"},
+			{From: "urls", To: "transport", Label: "ranged GET"},
+			{From: "transport", To: "native"},
+			{From: "sftp", To: "native", Label: "SFTP reads,\n--max-bandwidth"},
+			{From: "device", To: "native", Label: "streamed"},
+			{From: "native", To: "points"},
+			{From: "exiftool", To: "points"},
+			{From: "native", To: "report", Label: "failures"},
+			{From: "manifest", To: "points", Label: "missing from backup"},
+			{From: "points", To: "sidecars"},
+			{From: "tracks", To: "geotag"},
+			{From: "gpsd", To: "geotag"},
+			{From: "hotfolder", To: "geotag", Label: "new photos"},
+			{From: "dir", To: "geotag", Label: "photos without GPS"},
+			{From: "geotag", To: "xmp"},
+		},
+	}
+	for _, format := range formats {
+		label, ok := formatLabels[format]
+		if !ok {
+			label = format
+		}
+		g.Nodes = append(g.Nodes, Node{ID: "output-" + format, Label: label, Cluster: "Sinks"})
+		g.Edges = append(g.Edges, Edge{From: "points", To: "output-" + format})
+	}
+	return g
+}
@@ -0,0 +1,103 @@
+// Package diagrams describes photos2map's architecture as Graphviz graphs and renders them to files.
+package diagrams
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoGraphviz is returned by Render when an image format is requested but Graphviz's dot isn't installed.
+var ErrNoGraphviz = errors.New("graphviz dot not found on PATH")
+
+// Node is a box in a diagram, grouped with the other nodes of the same Cluster if set.
+type Node struct {
+	ID      string
+	Label   string
+	Cluster string
+}
+
+// Edge is an arrow between two nodes, with an optional label.
+type Edge struct {
+	From  string
+	To    string
+	Label string
+}
+
+// Graph is a directed diagram, written to a file named after Name.
+type Graph struct {
+	Name  string
+	Title string
+	Nodes []Node
+	Edges []Edge
+}
+
+// DOT renders the graph in the Graphviz DOT language, with clusters in the order their first node appears.
+func (g Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", g.Name)
+	fmt.Fprintf(&b, "  label=%q;\n  labelloc=t;\n  rankdir=LR;\n  node [shape=box, style=rounded, fontname=Helvetica];\n", g.Title)
+
+	var clusters []string
+	byCluster := map[string][]Node{}
+	for _, n := range g.Nodes {
+		if _, ok := byCluster[n.Cluster]; !ok {
+			clusters = append(clusters, n.Cluster)
+		}
+		byCluster[n.Cluster] = append(byCluster[n.Cluster], n)
+	}
+	for i, cluster := range clusters {
+		indent := "  "
+		if cluster != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%q;\n", i, cluster)
+			indent = "    "
+		}
+		for _, n := range byCluster[cluster] {
+			fmt.Fprintf(&b, "%s%q [label=%q];\n", indent, n.ID, n.Label)
+		}
+		if cluster != "" {
+			b.WriteString("  }\n")
+		}
+	}
+
+	for _, e := range g.Edges {
+		if e.Label != "" {
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.From, e.To, e.Label)
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q;\n", e.From, e.To)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Render writes the graph to Name.dot in dir, creating dir if needed, and for the "png" and "svg" formats
+// converts it with Graphviz to Name.png or Name.svg alongside. It returns the path of the file for format.
+func Render(g Graph, dir, format string) (string, error) {
+	if format != "dot" && format != "png" && format != "svg" {
+		return "", fmt.Errorf("unsupported format %q, expected dot, png or svg", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil { // #nosec G301
+		return "", err
+	}
+	dotPath := filepath.Join(dir, g.Name+".dot")
+	if err := os.WriteFile(dotPath, []byte(g.DOT()), 0644); err != nil { // #nosec G306
+		return "", err
+	}
+	if format == "dot" {
+		return dotPath, nil
+	}
+
+	if _, err := exec.LookPath("dot"); err != nil {
+		return dotPath, ErrNoGraphviz
+	}
+	out := filepath.Join(dir, g.Name+"."+format)
+	// #nosec G204 -- format is one of the fixed values checked above
+	if msg, err := exec.Command("dot", "-T"+format, "-o", out, dotPath).CombinedOutput(); err != nil {
+		return dotPath, fmt.Errorf("running dot: %w: %s", err, strings.TrimSpace(string(msg)))
+	}
+	return out, nil
+}
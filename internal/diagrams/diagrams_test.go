@@ -0,0 +1,143 @@
+package diagrams
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/internal/output"
+)
+
+// TestDOT checks that nodes are grouped into clusters and edges are labelled.
+func TestDOT(t *testing.T) {
+	g := Graph{
+		Name:  "test",
+		Title: "Test",
+		Nodes: []Node{{ID: "a", Label: "A", Cluster: "Sources"}, {ID: "b", Label: "B"}, {ID: "c", Label: "C", Cluster: "Sources"}},
+		Edges: []Edge{{From: "a", To: "b", Label: "reads"}, {From: "c", To: "b"}},
+	}
+
+	expected := `digraph "test" {
+  label="Test";
+  labelloc=t;
+  rankdir=LR;
+  node [shape=box, style=rounded, fontname=Helvetica];
+  subgraph cluster_0 {
+    label="Sources";
+    "a" [label="A"];
+    "c" [label="C"];
+  }
+  "b" [label="B"];
+  "a" -> "b" [label="reads"];
+  "c" -> "b";
+}
+`
+	if got := g.DOT(); got != expected {
+		t.Errorf("Unexpected DOT:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+// TestRender checks that the DOT file is written into the given directory, and that image formats need Graphviz.
+func TestRender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "diagrams")
+	components, err := Components(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []Graph{components, DataFlow([]string{"html", "gpx", "shapefile"})} {
+		path, err := Render(g, dir, "dot")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != filepath.Join(dir, g.Name+".dot") {
+			t.Errorf("Unexpected path %s", path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.HasPrefix(string(content), "digraph") {
+			t.Errorf("Expected a DOT file at %s, got %q, %v", path, content, err)
+		}
+
+		// every edge should connect nodes of the graph
+		ids := map[string]bool{}
+		for _, n := range g.Nodes {
+			ids[n.ID] = true
+		}
+		for _, e := range g.Edges {
+			if !ids[e.From] || !ids[e.To] {
+				t.Errorf("Edge %s -> %s in %s refers to an unknown node", e.From, e.To, g.Name)
+			}
+		}
+	}
+
+	if _, err := Render(components, dir, "pdf"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+	t.Setenv("PATH", "")
+	if _, err := Render(components, dir, "svg"); !errors.Is(err, ErrNoGraphviz) {
+		t.Errorf("Expected ErrNoGraphviz without Graphviz installed, got %v", err)
+	}
+}
+
+// TestComponents checks that the packages of a module and the imports between them are found, leaving out test
+// files, testdata and packages from outside the module.
+func TestComponents(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                       "module example.com/app\n\ngo 1.23\n",
+		"main.go":                      "package main\n\nimport _ \"example.com/app/cmd/app\"\n",
+		"cmd/app/app.go":               "package app\n\nimport (\n\t_ \"fmt\"\n\t_ \"example.com/app/internal/store\"\n)\n",
+		"internal/store/store.go":      "package store\n",
+		"internal/store/s_test.go":     "package store\n\nimport _ \"example.com/app/cmd/app\"\n",
+		"internal/store/testdata/x.go": "package x\n",
+		"docs/README.md":               "no Go files here\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := Components(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedNodes := []Node{
+		{ID: ".", Label: "app"},
+		{ID: "cmd/app", Label: "cmd/app", Cluster: "cmd"},
+		{ID: "internal/store", Label: "internal/store", Cluster: "internal"},
+	}
+	expectedEdges := []Edge{{From: ".", To: "cmd/app"}, {From: "cmd/app", To: "internal/store"}}
+	if !reflect.DeepEqual(g.Nodes, expectedNodes) || !reflect.DeepEqual(g.Edges, expectedEdges) {
+		t.Errorf("Expected nodes %+v and edges %+v, got %+v and %+v", expectedNodes, expectedEdges, g.Nodes, g.Edges)
+	}
+
+	if _, err := Components(t.TempDir()); err == nil {
+		t.Error("Expected an error outside a module")
+	}
+}
+
+// TestDataFlow checks that every output format is a sink of the data flow, with a label of its own.
+func TestDataFlow(t *testing.T) {
+	g := DataFlow(output.Formats)
+	sinks := map[string]bool{}
+	for _, n := range g.Nodes {
+		if n.Cluster == "Sinks" {
+			sinks[n.ID] = true
+		}
+	}
+	for _, format := range output.Formats {
+		if !sinks["output-"+format] {
+			t.Errorf("Expected a sink for --output %s", format)
+		}
+		if _, ok := formatLabels[format]; !ok {
+			t.Errorf("Expected a label for --output %s", format)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package exif
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// exiftoolArgs requests the tags photos2map uses as JSON, with numeric values (e.g. signed decimal degrees)
+var exiftoolArgs = []string{
+	"-json", "-n",
+	"-GPSLatitude", "-GPSLongitude", "-GPSAltitude", "-GPSImgDirection",
+	"-SubSecDateTimeOriginal", "-DateTimeOriginal", "-CreateDate",
+	"-Make", "-Model", "-LensModel",
+	"-Description", "-Caption-Abstract", "-ImageDescription",
+}
+
+// exiftoolTimeLayouts are the date formats exiftool emits, with and without sub-seconds and time zone
+var exiftoolTimeLayouts = []string{
+	"2006:01:02 15:04:05.999999999-07:00",
+	"2006:01:02 15:04:05-07:00",
+	"2006:01:02 15:04:05.999999999Z07:00",
+	"2006:01:02 15:04:05Z07:00",
+	"2006:01:02 15:04:05.999999999",
+	"2006:01:02 15:04:05",
+}
+
+// exiftoolResult is the subset of exiftool's JSON output photos2map reads
+type exiftoolResult struct {
+	GPSLatitude     *float64
+	GPSLongitude    *float64
+	GPSAltitude     *float64
+	GPSImgDirection *float64
+	// SubSecDateTimeOriginal is DateTimeOriginal with the fraction of a second of SubSecTimeOriginal, when the
+	// image has it
+	SubSecDateTimeOriginal string
+	DateTimeOriginal       string
+	CreateDate             string
+	Make                   json.RawMessage
+	Model                  json.RawMessage
+	LensModel              json.RawMessage
+	// Description is the XMP caption, Caption-Abstract the IPTC one and ImageDescription the EXIF one
+	Description      json.RawMessage
+	CaptionAbstract  json.RawMessage `json:"Caption-Abstract"`
+	ImageDescription json.RawMessage
+}
+
+// ExiftoolAvailable reports whether an exiftool executable is on the PATH.
+func ExiftoolAvailable() bool {
+	_, err := exec.LookPath("exiftool")
+	return err == nil
+}
+
+// ExtractExiftool reads metadata by running a locally installed exiftool, which supports far more
+// formats than the native decoder.
+func ExtractExiftool(path string) (Metadata, error) {
+	// #nosec G204 -- arguments are fixed apart from the file path, which is passed after "--"
+	out, err := exec.Command("exiftool", append(exiftoolArgs, "--", path)...).Output()
+	if err != nil {
+		return Metadata{}, fmt.Errorf("running exiftool on %s: %w", path, err)
+	}
+	return parseExiftool(out)
+}
+
+// ReadExiftool reads metadata from a stream with a locally installed exiftool, see ExtractExiftool.
+func ReadExiftool(r io.Reader) (Metadata, error) {
+	// #nosec G204 -- arguments are fixed, the file is read from stdin
+	cmd := exec.Command("exiftool", append(exiftoolArgs, "-")...)
+	cmd.Stdin = r
+	out, err := cmd.Output()
+	if err != nil {
+		return Metadata{}, fmt.Errorf("running exiftool: %w", err)
+	}
+	return parseExiftool(out)
+}
+
+// parseExiftool converts exiftool's JSON output for a single file into Metadata.
+func parseExiftool(out []byte) (Metadata, error) {
+	var meta Metadata
+
+	var results []exiftoolResult
+	if err := json.Unmarshal(out, &results); err != nil {
+		return meta, fmt.Errorf("parsing exiftool output: %w", err)
+	}
+	if len(results) != 1 {
+		return meta, fmt.Errorf("expected exiftool output for 1 file, got %d", len(results))
+	}
+	r := results[0]
+
+	meta.Ele = r.GPSAltitude
+	meta.Heading = r.GPSImgDirection
+	meta.Make = jsonString(r.Make)
+	meta.Model = jsonString(r.Model)
+	meta.Lens = jsonString(r.LensModel)
+	for _, raw := range []json.RawMessage{r.Description, r.CaptionAbstract, r.ImageDescription} {
+		if c := caption(jsonString(raw)); c != "" {
+			meta.Caption = c
+			break
+		}
+	}
+
+	for _, s := range []string{r.SubSecDateTimeOriginal, r.DateTimeOriginal, r.CreateDate} {
+		if t, ok := parseExiftoolTime(s); ok {
+			meta.Time = t
+			break
+		}
+	}
+
+	if r.GPSLatitude == nil || r.GPSLongitude == nil {
+		return meta, fmt.Errorf("%w found by exiftool", ErrNoGPS)
+	}
+	meta.Lat, meta.Lon = *r.GPSLatitude, *r.GPSLongitude
+
+	return meta, nil
+}
+
+// parseExiftoolTime parses an exiftool date, ignoring the all-zero dates some cameras write.
+func parseExiftoolTime(s string) (time.Time, bool) {
+	if s == "" || strings.HasPrefix(s, "0000") {
+		return time.Time{}, false
+	}
+	for _, layout := range exiftoolTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// jsonString returns a JSON value as a string; exiftool emits purely numeric values like model names as numbers.
+func jsonString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	return strings.TrimSpace(string(raw))
+}
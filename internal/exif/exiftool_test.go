@@ -0,0 +1,82 @@
+package exif
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Test that exiftool's JSON output is converted into Metadata
+func TestParseExiftool(t *testing.T) {
+	out := []byte(`[{
+		"SourceFile": "IMG_0001.HEIC",
+		"GPSLatitude": 47.6062,
+		"GPSLongitude": -122.3321,
+		"GPSAltitude": 56.2,
+		"GPSImgDirection": 181.5,
+		"DateTimeOriginal": "2023:07:04 18:30:00-07:00",
+		"Make": "Apple",
+		"Model": "iPhone 14 Pro",
+		"LensModel": "iPhone 14 Pro back camera 6.86mm f/1.78",
+		"Caption-Abstract": "Pike Place Market",
+		"ImageDescription": "OLYMPUS DIGITAL CAMERA"
+	}]`)
+
+	meta, err := parseExiftool(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if meta.Lat != 47.6062 || meta.Lon != -122.3321 {
+		t.Errorf("unexpected coordinates: %v, %v", meta.Lat, meta.Lon)
+	}
+	if meta.Ele == nil || *meta.Ele != 56.2 {
+		t.Errorf("unexpected altitude: %v", meta.Ele)
+	}
+	if meta.Heading == nil || *meta.Heading != 181.5 {
+		t.Errorf("unexpected heading: %v", meta.Heading)
+	}
+	expected := time.Date(2023, 7, 5, 1, 30, 0, 0, time.UTC)
+	if !meta.Time.Equal(expected) {
+		t.Errorf("expected time %v, got %v", expected, meta.Time)
+	}
+	if meta.Make != "Apple" || meta.Model != "iPhone 14 Pro" || meta.Lens == "" {
+		t.Errorf("unexpected camera: %q %q %q", meta.Make, meta.Model, meta.Lens)
+	}
+	if meta.Caption != "Pike Place Market" {
+		t.Errorf("expected the IPTC caption, got %q", meta.Caption)
+	}
+
+	burst, err := parseExiftool([]byte(`[{"SubSecDateTimeOriginal": "2023:07:04 18:30:00.25-07:00", "DateTimeOriginal": "2023:07:04 18:30:00-07:00"}]`))
+	if expected := expected.Add(250 * time.Millisecond); !errors.Is(err, ErrNoGPS) || !burst.Time.Equal(expected) {
+		t.Errorf("expected time %v with its fraction of a second, got %v, %v", expected, burst.Time, err)
+	}
+}
+
+// Test that captions cameras write into every photo are left out
+func TestCaption(t *testing.T) {
+	for s, expected := range map[string]string{" Sunset\x00": "Sunset", "OLYMPUS DIGITAL CAMERA": "", "  ": ""} {
+		if got := caption(s); got != expected {
+			t.Errorf("caption(%q) = %q, expected %q", s, got, expected)
+		}
+	}
+}
+
+// Test that files without GPS data are rejected
+func TestParseExiftool_NoGPS(t *testing.T) {
+	meta, err := parseExiftool([]byte(`[{"SourceFile": "IMG_0002.HEIC", "Model": 5, "DateTimeOriginal": "2024:05:01 09:30:00"}]`))
+	if !errors.Is(err, ErrNoGPS) {
+		t.Errorf("expected ErrNoGPS for output without GPS coordinates, got %v", err)
+	}
+	if meta.Time.IsZero() || meta.Model != "5" {
+		t.Errorf("expected the other metadata to be read, got %+v", meta)
+	}
+}
+
+// Test that malformed output is rejected
+func TestParseExiftool_InvalidJSON(t *testing.T) {
+	_, err := parseExiftool([]byte(`not json`))
+	if err == nil {
+		t.Error("expected an error for invalid exiftool output, got none")
+	}
+}
@@ -0,0 +1,236 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+// lenientScanLimit is how much of a file is searched for EXIF data; APP1 segments are at most 64KiB and
+// come first in well-formed JPEGs, so this leaves plenty of room for junk before them
+const lenientScanLimit = 1 << 20
+
+// TIFF tag IDs read by the lenient scanner
+const (
+	tagImageDescription = 0x010E
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagDateTime         = 0x0132
+	tagExifIFDPointer   = 0x8769
+	tagGPSIFDPointer    = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagSubSecTime       = 0x9290
+	tagSubSecTimeOrig   = 0x9291
+	tagGPSLatitudeRef   = 0x01
+	tagGPSLatitude      = 0x02
+	tagGPSLongitudeRef  = 0x03
+	tagGPSLongitude     = 0x04
+	tagGPSAltitudeRef   = 0x05
+	tagGPSAltitude      = 0x06
+	tagGPSImgDirection  = 0x11
+)
+
+// decode runs goexif's decoder, converting any panic on malformed input into an error. Errors limited
+// to sub-IFDs are tolerated as long as the rest of the EXIF data could be read.
+func decode(r io.Reader) (x *exif.Exif, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			x, err = nil, fmt.Errorf("exif: decoder panicked: %v", p)
+		}
+	}()
+
+	x, err = exif.Decode(r)
+	if err != nil && x != nil && !exif.IsCriticalError(err) {
+		return x, nil
+	}
+	return x, err
+}
+
+// scanFile is the recovery path for files goexif can't decode, e.g. truncated or with corrupt JPEG
+// segments. It searches the start of the file for a TIFF header and reads just the IFDs needed for
+// coordinates, skipping any tag that can't be read.
+func scanFile(path string) (meta Metadata, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("exif: lenient scan panicked: %v", p)
+		}
+	}()
+
+	file, err := os.Open(path) //#nosec G304
+	if err != nil {
+		return meta, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, lenientScanLimit))
+	if err != nil {
+		return meta, err
+	}
+
+	return scanTIFF(data)
+}
+
+// scanTIFF locates the TIFF structure inside data and reads metadata from its IFD0 and GPS IFD.
+func scanTIFF(data []byte) (Metadata, error) {
+	var meta Metadata
+
+	start := findTIFFHeader(data)
+	if start < 0 {
+		return meta, errors.New("exif: no TIFF header found")
+	}
+	raw := data[start:]
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if raw[0] == 'M' {
+		order = binary.BigEndian
+	}
+	ifd0 := readIFD(raw, int64(order.Uint32(raw[4:8])), order)
+
+	gpsOffset, ok := tagInt(ifd0[tagGPSIFDPointer])
+	if !ok {
+		return meta, errors.New("exif: no GPS IFD found")
+	}
+	gps := readIFD(raw, gpsOffset, order)
+
+	lat, latOK := tagDegrees(gps[tagGPSLatitude], gps[tagGPSLatitudeRef], "S")
+	lon, lonOK := tagDegrees(gps[tagGPSLongitude], gps[tagGPSLongitudeRef], "W")
+	if !latOK || !lonOK {
+		return meta, errors.New("exif: GPS IFD has no usable coordinates")
+	}
+	meta.Lat, meta.Lon = lat, lon
+
+	if ele, ok := tagRat(gps[tagGPSAltitude]); ok {
+		if ref, ok := tagInt(gps[tagGPSAltitudeRef]); ok && ref == 1 {
+			ele = -ele
+		}
+		meta.Ele = &ele
+	}
+	if heading, ok := tagRat(gps[tagGPSImgDirection]); ok {
+		meta.Heading = &heading
+	}
+
+	meta.Make = tagString(ifd0[tagMake])
+	meta.Model = tagString(ifd0[tagModel])
+	meta.Caption = caption(tagString(ifd0[tagImageDescription]))
+
+	dateTime, subSec := ifd0[tagDateTime], ""
+	if exifOffset, ok := tagInt(ifd0[tagExifIFDPointer]); ok {
+		exifIFD := readIFD(raw, exifOffset, order)
+		subSec = tagString(exifIFD[tagSubSecTime])
+		if original, ok := exifIFD[tagDateTimeOriginal]; ok {
+			dateTime, subSec = original, tagString(exifIFD[tagSubSecTimeOrig])
+		}
+	}
+	if t, err := time.ParseInLocation("2006:01:02 15:04:05", tagString(dateTime), time.Local); err == nil {
+		meta.Time = withSubSec(t, subSec)
+	}
+
+	return meta, nil
+}
+
+// findTIFFHeader returns the offset of the TIFF header following an "Exif\0\0" marker, or of the first
+// bare TIFF header if there is no marker, or -1 if neither is present.
+func findTIFFHeader(data []byte) int {
+	if i := bytes.Index(data, []byte("Exif\x00\x00")); i >= 0 && isTIFFHeader(data[i+6:]) {
+		return i + 6
+	}
+	for _, magic := range [][]byte{[]byte("II*\x00"), []byte("MM\x00*")} {
+		if i := bytes.Index(data, magic); i >= 0 && isTIFFHeader(data[i:]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isTIFFHeader reports whether b starts with a complete TIFF header
+func isTIFFHeader(b []byte) bool {
+	return len(b) >= 8 && (bytes.HasPrefix(b, []byte("II*\x00")) || bytes.HasPrefix(b, []byte("MM\x00*")))
+}
+
+// readIFD reads as many tags as possible from the IFD at offset, stopping at the first unreadable tag
+// rather than discarding the whole directory.
+func readIFD(raw []byte, offset int64, order binary.ByteOrder) map[uint16]*tiff.Tag {
+	tags := map[uint16]*tiff.Tag{}
+	if offset <= 0 || offset+2 > int64(len(raw)) {
+		return tags
+	}
+
+	r := bytes.NewReader(raw)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return tags
+	}
+	var count uint16
+	if err := binary.Read(r, order, &count); err != nil {
+		return tags
+	}
+	for i := 0; i < int(count); i++ {
+		tag, err := tiff.DecodeTag(r, order)
+		if err != nil {
+			break
+		}
+		tags[tag.Id] = tag
+	}
+	return tags
+}
+
+// tagDegrees converts a degrees/minutes/seconds rational tag into signed decimal degrees, negated
+// when the reference tag equals negRef.
+func tagDegrees(tag, ref *tiff.Tag, negRef string) (float64, bool) {
+	if tag == nil || tag.Count < 3 {
+		return 0, false
+	}
+	var dms [3]float64
+	for i := range dms {
+		num, den, err := tag.Rat2(i)
+		if err != nil || den == 0 {
+			return 0, false
+		}
+		dms[i] = float64(num) / float64(den)
+	}
+	deg := dms[0] + dms[1]/60 + dms[2]/3600
+	if strings.EqualFold(tagString(ref), negRef) {
+		deg = -deg
+	}
+	return deg, true
+}
+
+// tagRat reads the first value of a rational tag
+func tagRat(tag *tiff.Tag) (float64, bool) {
+	if tag == nil {
+		return 0, false
+	}
+	num, den, err := tag.Rat2(0)
+	if err != nil || den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// tagInt reads the first value of an integer tag
+func tagInt(tag *tiff.Tag) (int64, bool) {
+	if tag == nil {
+		return 0, false
+	}
+	v, err := tag.Int64(0)
+	return v, err == nil
+}
+
+// tagString reads a string tag with surrounding whitespace and NUL padding removed
+func tagString(tag *tiff.Tag) string {
+	if tag == nil {
+		return ""
+	}
+	v, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Trim(v, "\x00"))
+}
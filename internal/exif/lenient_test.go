@@ -0,0 +1,66 @@
+package exif
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test that GPS data is recovered from files goexif can't decode
+func TestExtractEXIF_Malformed(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatalf("failed to read test image: %v", err)
+	}
+	marker := bytes.Index(data, []byte("Exif\x00\x00"))
+	if marker < 4 {
+		t.Fatalf("test image has no EXIF marker")
+	}
+
+	// corrupt the APP1 segment length so the JPEG segment structure can't be followed
+	badLength := append([]byte{}, data...)
+	badLength[marker-4], badLength[marker-3] = 0xff, 0xff
+
+	variants := map[string][]byte{
+		"truncated.jpg":  data[:marker+6000],
+		"bad-length.jpg": badLength,
+	}
+
+	dir := t.TempDir()
+	for name, content := range variants {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+
+		// goexif alone can't make sense of these files
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", name, err)
+		}
+		if _, err := decode(file); err == nil {
+			t.Errorf("%s: expected the strict decoder to fail", name)
+		}
+		file.Close()
+
+		meta, err := ExtractEXIF(path)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if meta.Lat < 43.46 || meta.Lat > 43.47 || meta.Lon < 11.88 || meta.Lon > 11.89 {
+			t.Errorf("%s: unexpected coordinates %v, %v", name, meta.Lat, meta.Lon)
+		}
+		if meta.Time.IsZero() || meta.Make != "NIKON" {
+			t.Errorf("%s: expected capture time and camera to be recovered, got %+v", name, meta)
+		}
+	}
+}
+
+// Test that files without any TIFF structure are still rejected
+func TestScanTIFF_NoHeader(t *testing.T) {
+	if _, err := scanTIFF([]byte("definitely not an image")); err == nil {
+		t.Error("expected an error for data without a TIFF header, got none")
+	}
+}
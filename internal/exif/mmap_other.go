@@ -0,0 +1,13 @@
+//go:build !(unix && (amd64 || arm64 || loong64 || mips64 || mips64le || ppc64 || ppc64le || riscv64 || s390x))
+
+package exif
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile reports that files aren't memory mapped on this platform, so they're read as usual.
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	return nil, nil, errors.New("memory mapping files isn't supported on this platform")
+}
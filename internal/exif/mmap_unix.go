@@ -0,0 +1,18 @@
+//go:build unix && (amd64 || arm64 || loong64 || mips64 || mips64le || ppc64 || ppc64le || riscv64 || s390x)
+
+package exif
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of an open file read-only into memory, returning the mapping and the function
+// releasing it. Only 64-bit platforms map files, where the address space can't run out.
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
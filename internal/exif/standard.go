@@ -1,23 +1,224 @@
 package exif
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/rwcarlsen/goexif/exif"
 )
 
-func ExtractEXIF(path string) (float64, float64, error) {
+// Metadata holds the values read from an image's EXIF data.
+// Time is the zero value when the image has no DateTimeOriginal or DateTime tag. It includes the fraction of a
+// second of the SubSecTimeOriginal or SubSecTime tag, which tells apart the shots of a burst.
+type Metadata struct {
+	Lat  float64
+	Lon  float64
+	Time time.Time
+	// Ele is the altitude in meters above sea level, or nil if the image has no GPSAltitude tag
+	Ele *float64
+	// Heading is the direction the camera was pointing in degrees clockwise from north,
+	// or nil if the image has no GPSImgDirection tag
+	Heading *float64
+	// Make, Model and Lens describe the camera and lens, empty when not recorded
+	Make  string
+	Model string
+	Lens  string
+	// Caption is the photo's description, empty when not recorded or left at the camera's default
+	Caption string
+}
+
+// defaultCaptions are descriptions cameras write into every photo, which aren't worth showing
+var defaultCaptions = map[string]bool{
+	"OLYMPUS DIGITAL CAMERA":     true,
+	"SONY DSC":                   true,
+	"DIGITAL CAMERA":             true,
+	"KODAK Digital Still Camera": true,
+	"Default":                    true,
+}
+
+// caption returns a photo description with whitespace trimmed, or "" for a camera default.
+func caption(s string) string {
+	s = strings.TrimSpace(strings.Trim(s, "\x00"))
+	if defaultCaptions[s] {
+		return ""
+	}
+	return s
+}
+
+// ErrNoGPS is returned when an image's metadata could be read but holds no usable GPS coordinates.
+// The rest of the Metadata, such as the capture time, is still filled in.
+var ErrNoGPS = errors.New("no GPS coordinates")
+
+// ExtractEXIF reads the metadata of a JPEG or TIFF-based image. Files the decoder rejects as malformed
+// are retried with a lenient scan for the GPS IFD before giving up.
+func ExtractEXIF(path string) (Metadata, error) {
+	file, err := os.Open(path) //#nosec G304
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer file.Close()
+
+	x, err := decode(file)
+	if err != nil {
+		meta, scanErr := scanFile(path)
+		if scanErr != nil {
+			return meta, fmt.Errorf("%w (lenient scan: %v)", err, scanErr)
+		}
+		log.Debugf("Recovered GPS data from malformed EXIF in %s: %v", path, err)
+		return meta, nil
+	}
+
+	return fromExif(x)
+}
+
+// ExtractEXIFMapped is ExtractEXIF reading the file through a read-only memory map, which saves the copies and
+// system calls of reading it on fast local disks. It falls back to ExtractEXIF on platforms without memory mapping,
+// when the file can't be mapped, and when its EXIF data can't be decoded, including because the file was
+// truncated while mapped, which would otherwise crash the program.
+func ExtractEXIFMapped(path string) (Metadata, error) {
 	file, err := os.Open(path) //#nosec G304
 	if err != nil {
-		return 0, 0, err
+		return Metadata{}, err
 	}
 	defer file.Close()
 
-	x, err := exif.Decode(file)
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 || int64(int(info.Size())) != info.Size() {
+		return ExtractEXIF(path)
+	}
+	data, unmap, err := mapFile(file, info.Size())
+	if err != nil {
+		log.Debugf("Reading %s without memory mapping it: %v", path, err)
+		return ExtractEXIF(path)
+	}
+	defer unmap()
+
+	meta, err := readMapped(data)
+	if err != nil && !errors.Is(err, ErrNoGPS) {
+		return ExtractEXIF(path)
+	}
+	return meta, err
+}
+
+// readMapped reads the metadata of a memory-mapped file. Faults reading the mapping, such as past the end of a
+// file truncated since it was mapped, are returned as errors instead of crashing the program.
+func readMapped(data []byte) (meta Metadata, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if p := recover(); p != nil {
+			meta, err = Metadata{}, fmt.Errorf("exif: reading the memory-mapped file failed: %v", p)
+		}
+	}()
+
+	x, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return Metadata{}, err
+	}
+	return fromExif(x)
+}
+
+// ReadEXIF reads the metadata of a JPEG or TIFF-based image from a stream, such as a file being downloaded.
+// Only the start of the image holding the EXIF data is read. Unlike ExtractEXIF, malformed EXIF data isn't
+// recovered from, since that needs to seek through the file.
+func ReadEXIF(r io.Reader) (Metadata, error) {
+	x, err := decode(r)
+	if err != nil {
+		return Metadata{}, err
+	}
+	return fromExif(x)
+}
+
+// fromExif reads Metadata out of decoded EXIF data
+func fromExif(x *exif.Exif) (Metadata, error) {
+	var meta Metadata
+
+	// DateTime prefers DateTimeOriginal and falls back to DateTime
+	if t, err := x.DateTime(); err == nil {
+		subSec := str(x, exif.SubSecTime)
+		if _, err := x.Get(exif.DateTimeOriginal); err == nil {
+			subSec = str(x, exif.SubSecTimeOriginal)
+		}
+		meta.Time = withSubSec(t, subSec)
+	}
+
+	meta.Ele = altitude(x)
+	meta.Heading = rational(x, exif.GPSImgDirection)
+	meta.Make = str(x, exif.Make)
+	meta.Model = str(x, exif.Model)
+	meta.Lens = str(x, exif.LensModel)
+	meta.Caption = caption(str(x, exif.ImageDescription))
+
+	var err error
+	meta.Lat, meta.Lon, err = x.LatLong()
+	if err != nil {
+		return meta, fmt.Errorf("%w: %v", ErrNoGPS, err)
+	}
+
+	return meta, nil
+}
+
+// altitude reads GPSAltitude, negating it when GPSAltitudeRef marks it as below sea level.
+func altitude(x *exif.Exif) *float64 {
+	ele := rational(x, exif.GPSAltitude)
+	if ele == nil {
+		return nil
+	}
+
+	if ref, err := x.Get(exif.GPSAltitudeRef); err == nil {
+		if v, err := ref.Int(0); err == nil && v == 1 {
+			*ele = -*ele
+		}
+	}
+
+	return ele
+}
+
+// rational reads the first value of a rational tag as a float, or nil if the tag is missing or malformed.
+func rational(x *exif.Exif, name exif.FieldName) *float64 {
+	tag, err := x.Get(name)
 	if err != nil {
-		return 0, 0, err
+		return nil
+	}
+	num, den, err := tag.Rat2(0)
+	if err != nil || den == 0 {
+		return nil
 	}
+	v := float64(num) / float64(den)
+	return &v
+}
 
-	lat, lon, err := x.LatLong()
-	return lat, lon, err
+// withSubSec returns t with the fraction of a second of a SubSecTime tag added, digits such as "042" for 42
+// milliseconds, or t itself if the tag is empty or malformed.
+func withSubSec(t time.Time, subSec string) time.Time {
+	digits := strings.TrimSpace(subSec)
+	if digits == "" || len(digits) > 9 || strings.Trim(digits, "0123456789") != "" {
+		return t
+	}
+	n, _ := strconv.Atoi(digits)
+	for range 9 - len(digits) {
+		n *= 10
+	}
+	return t.Add(time.Duration(n))
+}
+
+// str reads a string tag with surrounding whitespace and NUL padding removed, or "" if the tag is missing.
+func str(x *exif.Exif, name exif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+	v, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.Trim(v, "\x00"))
 }
@@ -1,8 +1,11 @@
 package exif
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 // Test for successful EXIF extraction
@@ -15,23 +18,23 @@ import (
 // 	}
 // 	defer os.Remove(file.Name()) // Clean up temp file after test
 //
-// 	lat, lon, err := ExtractEXIF(file.Name())
+// 	meta, err := ExtractEXIF(file.Name())
 // 	if err != nil {
 // 		t.Fatalf("unexpected error: %v", err)
 // 	}
 //
-// 	if lat != 37.7749 {
-// 		t.Errorf("expected latitude 37.7749, got %v", lat)
+// 	if meta.Lat != 37.7749 {
+// 		t.Errorf("expected latitude 37.7749, got %v", meta.Lat)
 // 	}
-// 	if lon != -122.4194 {
-// 		t.Errorf("expected longitude -122.4194, got %v", lon)
+// 	if meta.Lon != -122.4194 {
+// 		t.Errorf("expected longitude -122.4194, got %v", meta.Lon)
 // 	}
 // }
 
 // Test for file open failure
 func TestExtractEXIF_FileOpenError(t *testing.T) {
 	// Try opening a non-existent file
-	_, _, err := ExtractEXIF("nonexistent.jpg")
+	_, err := ExtractEXIF("nonexistent.jpg")
 	if err == nil {
 		t.Error("expected an error when opening non-existent file, got none")
 	}
@@ -46,8 +49,102 @@ func TestExtractEXIF_DecodeError(t *testing.T) {
 	}
 	defer os.Remove(file.Name()) // Clean up temp file after test
 
-	_, _, err = ExtractEXIF(file.Name())
+	_, err = ExtractEXIF(file.Name())
 	if err == nil {
 		t.Error("expected an error during EXIF decoding, got none")
 	}
 }
+
+// Test that metadata is read from a real image with GPS data but no altitude
+func TestExtractEXIF_TestData(t *testing.T) {
+	meta, err := ExtractEXIF(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if meta.Lat == 0 || meta.Lon == 0 {
+		t.Errorf("expected non-zero coordinates, got %v, %v", meta.Lat, meta.Lon)
+	}
+	if meta.Time.IsZero() {
+		t.Error("expected a capture time, got none")
+	}
+	if meta.Ele != nil {
+		t.Errorf("expected no altitude, got %v", *meta.Ele)
+	}
+	if meta.Make != "NIKON" || meta.Model != "COOLPIX P6000" {
+		t.Errorf("expected camera NIKON COOLPIX P6000, got %q %q", meta.Make, meta.Model)
+	}
+}
+
+// Test that a stream yields the same metadata as the file, reading only the start of it
+func TestReadEXIF(t *testing.T) {
+	path := filepath.Join("..", "testdata", "DSCN0010.jpg")
+	expected, err := ExtractEXIF(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := bytes.NewReader(data)
+	meta, err := ReadEXIF(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Lat != expected.Lat || meta.Lon != expected.Lon || !meta.Time.Equal(expected.Time) {
+		t.Errorf("expected %+v, got %+v", expected, meta)
+	}
+	if r.Len() == 0 {
+		t.Error("expected the image data after the EXIF segment to be left unread")
+	}
+}
+
+// Test that reading through a memory map yields the same metadata, and falls back for files it can't decode
+func TestExtractEXIFMapped(t *testing.T) {
+	path := filepath.Join("..", "testdata", "DSCN0010.jpg")
+	expected, err := ExtractEXIF(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	meta, err := ExtractEXIFMapped(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Lat != expected.Lat || meta.Lon != expected.Lon || !meta.Time.Equal(expected.Time) || meta.Model != expected.Model {
+		t.Errorf("expected %+v, got %+v", expected, meta)
+	}
+
+	// an empty file can't be mapped and a file without EXIF data fails to decode, both like ExtractEXIF
+	dir := t.TempDir()
+	for name, content := range map[string]string{"empty.jpg": "", "invalid.jpg": "not an image"} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ExtractEXIFMapped(p); err == nil {
+			t.Errorf("expected an error for %s, got none", name)
+		}
+	}
+	if _, err := ExtractEXIFMapped("nonexistent.jpg"); err == nil {
+		t.Error("expected an error when opening non-existent file, got none")
+	}
+}
+
+// Test that the fraction of a second of a SubSecTime tag is added to the capture time
+func TestWithSubSec(t *testing.T) {
+	taken := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for subSec, expected := range map[string]time.Duration{
+		"":           0,
+		"5":          500 * time.Millisecond,
+		"042":        42 * time.Millisecond,
+		" 123456 ":   123456 * time.Microsecond,
+		"1234567890": 0,
+		"12a":        0,
+	} {
+		if got := withSubSec(taken, subSec); got.Sub(taken) != expected {
+			t.Errorf("expected %q to add %v, got %v", subSec, expected, got.Sub(taken))
+		}
+	}
+}
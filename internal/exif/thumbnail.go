@@ -0,0 +1,20 @@
+package exif
+
+import (
+	"os"
+)
+
+// Thumbnail returns the JPEG thumbnail embedded in an image's EXIF data, if it has one.
+func Thumbnail(path string) ([]byte, error) {
+	file, err := os.Open(path) //#nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	x, err := decode(file)
+	if err != nil {
+		return nil, err
+	}
+	return x.JpegThumbnail()
+}
@@ -0,0 +1,30 @@
+package exif
+
+import (
+	"bytes"
+	"image/jpeg"
+	"testing"
+)
+
+// Test that the embedded thumbnail of the test image is a valid JPEG
+func TestThumbnail(t *testing.T) {
+	thumb, err := Thumbnail("../testdata/DSCN0010.jpg")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("Thumbnail is not a valid JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() == 0 || b.Dx() > 640 {
+		t.Errorf("Unexpected thumbnail size %v", b)
+	}
+}
+
+// Test that a missing file is reported
+func TestThumbnail_Missing(t *testing.T) {
+	if _, err := Thumbnail("../testdata/missing.jpg"); err == nil {
+		t.Error("Expected an error for a missing file, got none")
+	}
+}
@@ -0,0 +1,153 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// errStop ends the iteration over an archive's entries early
+var errStop = errors.New("stop")
+
+// IsArchive reports whether path is a ZIP or tar archive, optionally gzip compressed, judging by its extension.
+func IsArchive(path string) bool {
+	name := strings.ToLower(path)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractArchiveGPSData is ExtractGPSData for the photos in a ZIP or tar archive, optionally gzip compressed,
+// read straight from the archive without unpacking it. Points and skipped files have the archive's path joined
+// with the entry's. Photos are streamed through the decoder, so malformed EXIF data that ExtractGPSData recovers
+// from isn't, and native decoding isn't retried with exiftool. opts.Workers and FollowSymlinks don't apply, and
+// Progress is called with a total of -1 as the number of photos isn't known up front.
+func ExtractArchiveGPSData(ctx context.Context, archive string, opts Options) ([]geodata.Point, []Skipped) {
+	var gpsData []geodata.Point
+	var skipped []Skipped
+
+	opts = prepare(opts)
+
+	done := 0
+	err := readArchive(archive, func(name string, modTime time.Time, r io.Reader) error {
+		if ctx.Err() != nil {
+			return errStop
+		}
+		if !supported(name, opts) || (opts.MaxDepth > 0 && strings.Count(name, "/")+1 > opts.MaxDepth) {
+			return nil
+		}
+
+		p := filepath.Join(archive, filepath.FromSlash(name))
+		meta, err := readStream(name, r, opts)
+		if err != nil {
+			log.Debugf("Skipping %s: %v", p, err)
+			skipped = append(skipped, Skipped{Path: p, Err: err})
+		} else {
+			gpsData = append(gpsData, newPoint(p, meta, modTime))
+		}
+		done++
+		if opts.Progress != nil {
+			opts.Progress(done, -1, len(gpsData), p)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		log.Fatalf("Error reading the archive: %v", err)
+	}
+
+	return gpsData, skipped
+}
+
+// readStream reads the metadata of the file name from r, with exiftool for the formats only it reads.
+func readStream(name string, r io.Reader, opts Options) (exif.Metadata, error) {
+	if opts.UseExiftool && exiftoolExtensions[strings.ToLower(path.Ext(name))] {
+		return timed(opts, name, decoderExiftool, func() (exif.Metadata, error) {
+			return exif.ReadExiftool(r)
+		})
+	}
+	return timed(opts, name, decoderNative, func() (exif.Metadata, error) {
+		return exif.ReadEXIF(r)
+	})
+}
+
+// readArchive calls fn with the name, modification time and contents of each regular file in the archive.
+// It stops at the first error fn returns.
+func readArchive(archive string, fn func(name string, modTime time.Time, r io.Reader) error) error {
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		return readZip(archive, fn)
+	}
+
+	file, err := os.Open(archive) // #nosec G304
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if name := strings.ToLower(archive); strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(hdr.Name, hdr.ModTime, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// readZip is readArchive for ZIP archives.
+func readZip(archive string, fn func(name string, modTime time.Time, r io.Reader) error) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = fn(f.Name, f.Modified, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
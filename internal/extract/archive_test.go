@@ -0,0 +1,107 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// archiveFiles are the entries written to the test archives
+var archiveFiles = []string{"DSCN0010.jpg", "trip/DSCN0012.jpg", "notes.txt"}
+
+// writeZip writes the test images into a ZIP archive.
+func writeZip(t *testing.T, path string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	for _, name := range archiveFiles {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(archiveContent(t, name))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTarGz writes the test images into a gzip compressed tar archive.
+func writeTarGz(t *testing.T, path string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "trip/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, name := range archiveFiles {
+		data := archiveContent(t, name)
+		_ = tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()})
+		_, _ = tw.Write(data)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// archiveContent returns the test image an entry is named after, or text for other entries.
+func archiveContent(t *testing.T, name string) []byte {
+	t.Helper()
+	if filepath.Ext(name) != ".jpg" {
+		return []byte("not a photo")
+	}
+	data, err := os.ReadFile(filepath.Join("..", "testdata", filepath.Base(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestExtractArchiveGPSData checks that photos are read from ZIP and tar archives, down to the maximum depth.
+func TestExtractArchiveGPSData(t *testing.T) {
+	dir := t.TempDir()
+	zipPath, tarPath := filepath.Join(dir, "photos.zip"), filepath.Join(dir, "photos.tar.gz")
+	writeZip(t, zipPath)
+	writeTarGz(t, tarPath)
+
+	for _, archive := range []string{zipPath, tarPath} {
+		if !IsArchive(archive) {
+			t.Errorf("Expected %s to be recognized as an archive", archive)
+		}
+
+		var calls int
+		gpsData, skipped := ExtractArchiveGPSData(context.Background(), archive, Options{
+			Progress: func(done, total, matched int, path string) { calls++ },
+		})
+		if len(gpsData) != 2 || len(skipped) != 0 || calls != 2 {
+			t.Fatalf("Expected 2 photos from %s with progress for each, got %+v, %+v, %d calls", archive, gpsData, skipped, calls)
+		}
+		if gpsData[1].Path != filepath.Join(archive, "trip", "DSCN0012.jpg") || gpsData[1].Lat == 0 {
+			t.Errorf("Unexpected point %+v from %s", gpsData[1], archive)
+		}
+
+		gpsData, _ = ExtractArchiveGPSData(context.Background(), archive, Options{MaxDepth: 1})
+		if len(gpsData) != 1 {
+			t.Errorf("Expected only the top level photo from %s with a maximum depth of 1, got %d", archive, len(gpsData))
+		}
+	}
+
+	if IsArchive(filepath.Join(dir, "photos")) {
+		t.Error("Expected a directory not to be recognized as an archive")
+	}
+}
@@ -0,0 +1,53 @@
+package extract
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/camera"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// ExtractCameraGPSData is ExtractGPSData for the photos on a camera or phone connected over USB with PTP or MTP,
+// read through gphoto2 without copying them to disk first. Points and skipped files have the path on the device.
+// opts.Workers, MaxDepth and FollowSymlinks don't apply; files are read one at a time, as devices only serve
+// one transfer at once. Once ctx is cancelled the results for the files read so far are returned. Experimental.
+func ExtractCameraGPSData(ctx context.Context, opts Options) ([]geodata.Point, []Skipped) {
+	var gpsData []geodata.Point
+	var skipped []Skipped
+
+	if !camera.Available() {
+		log.Fatalf("Reading from a camera needs gphoto2, which is not installed")
+	}
+	opts = prepare(opts)
+
+	listed, err := camera.List()
+	if err != nil {
+		log.Fatalf("Error listing the files on the camera: %v", err)
+	}
+	var files []camera.File
+	for _, f := range listed {
+		if supported(f.Name, opts) {
+			files = append(files, f)
+		}
+	}
+
+	for i, f := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		meta, err := camera.ReadMetadata(f, opts.UseExiftool)
+		if err != nil {
+			log.Debugf("Skipping %s: %v", f.Path(), err)
+			skipped = append(skipped, Skipped{Path: f.Path(), Err: err})
+		} else {
+			gpsData = append(gpsData, newPoint(f.Path(), meta, f.ModTime))
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(files), len(gpsData), f.Path())
+		}
+	}
+
+	return gpsData, skipped
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// fileID identifies a directory regardless of the path it was reached through. Without inodes, the path with
+// all symlinks resolved is used instead.
+type fileID struct {
+	path string
+}
+
+// idOf returns the resolved path of a file, ok is false if it can't be resolved.
+func idOf(path string, info os.FileInfo) (id fileID, ok bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	return fileID{path: resolved}, true
+}
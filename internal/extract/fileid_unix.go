@@ -0,0 +1,23 @@
+//go:build unix
+
+package extract
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a directory regardless of the path it was reached through
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// idOf returns the device and inode of a file, ok is false if the file system doesn't report them.
+func idOf(path string, info os.FileInfo) (id fileID, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: st.Ino}, true // #nosec G115
+}
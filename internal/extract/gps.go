@@ -2,54 +2,318 @@
 package extract
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/toozej/photos2map/internal/cache"
 	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/pkg/geodata"
 )
 
-// ExtractGPSData reads all the images in a given directory and returns a slice of GeoData containing GPS coordinates.
-// Supported formats include JPG, PNG, RAW, DNG, and HEIF.
-func ExtractGPSData(dir string) []opts.GeoData {
-	var gpsData []opts.GeoData
+// Skipped is a file that was considered but left out of the results.
+type Skipped struct {
+	Path string
+	Err  error
+}
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// ErrUnsupported is returned by ReadMetadata for files of a type it doesn't read.
+var ErrUnsupported = errors.New("unsupported file type")
 
-		base := filepath.Base(path)
-		name := strings.TrimSuffix(base, filepath.Ext(base))
-		ext := strings.ToLower(filepath.Ext(path))
-		switch ext {
-		case ".jpg", ".jpeg", ".png":
-			lat, lon, err := exif.ExtractEXIF(path)
-			if err == nil {
-				gpsData = append(gpsData, opts.GeoData{Name: name, Value: []float64{lon, lat}})
-			}
-			// TODO re-enable extracting EXIF data from raw, dng, and heif file types once those libraries work
-			// case ".dng", ".raw":
-			// 	lat, lon, err := exif.ExtractRawEXIF(path)
-			// 	if err == nil {
-			// 		gpsData = append(gpsData, opts.GeoData{Name: name, Value: []float64{lon, lat}})
-			// 	}
-			// case ".heif":
-			// 	lat, lon, err := exif.ExtractHEIFEXIF(path)
-			// 	if err == nil {
-			// 		gpsData = append(gpsData, opts.GeoData{Name: name, Value: []float64{lon, lat}})
-			// 	}
-		}
-
-		return nil
-	})
+// Options controls how images are found and decoded.
+type Options struct {
+	// UseExiftool falls back to a locally installed exiftool for files the native decoder can't read,
+	// and enables the additional formats listed in exiftoolExtensions
+	UseExiftool bool
+	// Extensions, if set, restricts the scan to files with these extensions, given with or without the leading
+	// dot and in any case. Extensions the enabled decoders don't read are still ignored.
+	Extensions []string
+	// ExcludeExtensions skips the files with these extensions, given like Extensions, even those Extensions lists
+	ExcludeExtensions []string
+	// FollowSymlinks scans the directories symlinks point to. Symlinks to files are always followed.
+	FollowSymlinks bool
+	// MaxDepth limits how many directory levels are scanned, counting dir itself, like find's -maxdepth:
+	// 1 scans only the files directly in dir. 0 scans all subdirectories.
+	MaxDepth int
+	// SkipMarkers are the names of files, such as .nomedia, that mark the directory they're in, along with its
+	// subdirectories, not to be scanned. The directory given to scan is scanned regardless.
+	SkipMarkers []string
+	// ScanPreviews also scans the folders photo managers keep the previews and transcodes they generate in, see
+	// previewDirs, which are skipped otherwise since they would count the photos again
+	ScanPreviews bool
+	// Mmap reads files for the native decoder through a memory map, which is faster on local SSDs, falling back
+	// to reading them as usual where that's not possible
+	Mmap bool
+	// Workers is the number of files decoded in parallel, defaulting to GOMAXPROCS when 0
+	Workers int
+	// Cache, if set, is consulted before decoding a file and updated with the files decoded
+	Cache *cache.Cache
+	// Stats, if set, collects how long decoding took per file format and decoder
+	Stats *Stats
+	// Progress, if set, is called after each file is decoded with the number of files done out of the total,
+	// how many of them had GPS data, and the file's path. It is never called concurrently.
+	Progress func(done, total, matched int, path string)
+}
 
+// file is a file found while walking the directory, to be decoded by a worker
+type file struct {
+	path string
+	info os.FileInfo
+}
+
+// result is the outcome of decoding a file
+type result struct {
+	meta exif.Metadata
+	err  error
+	// done is false for files that weren't decoded because the scan was cancelled
+	done bool
+}
+
+// nativeExtensions are the file types the built-in EXIF decoder is attempted on
+var nativeExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+// exiftoolExtensions are the additional file types read only when exiftool is enabled
+var exiftoolExtensions = map[string]bool{
+	".heic": true, ".heif": true, ".avif": true, ".webp": true, ".tif": true, ".tiff": true,
+	".dng": true, ".raw": true, ".cr2": true, ".cr3": true, ".nef": true, ".nrw": true, ".arw": true,
+	".orf": true, ".rw2": true, ".raf": true, ".pef": true, ".srw": true,
+	".mp4": true, ".mov": true, ".m4v": true, ".3gp": true,
+}
+
+// ExtractGPSData reads all the images in a given directory and returns a slice of Points containing GPS coordinates,
+// along with the supported files that had to be skipped because no GPS data could be read from them.
+// JPG and PNG are decoded natively; many more formats, including RAW, DNG and HEIF, are supported through exiftool.
+// Once ctx is cancelled no more files are decoded and the results for the files decoded so far are returned,
+// or none if the directory hadn't been fully walked yet.
+func ExtractGPSData(ctx context.Context, dir string, opts Options) ([]geodata.Point, []Skipped) {
+	opts = prepare(opts)
+
+	files, err := findFiles(ctx, dir, opts)
+	if ctx.Err() != nil {
+		return nil, nil
+	}
 	if err != nil {
 		log.Fatalf("Error walking the directory: %v", err)
 	}
 
-	return gpsData
+	return decodeFiles(ctx, files, opts)
+}
+
+// decodeFiles decodes the files in parallel, collecting the results in the order of files so the output doesn't
+// depend on scheduling.
+func decodeFiles(ctx context.Context, files []file, opts Options) ([]geodata.Point, []Skipped) {
+	var gpsData []geodata.Point
+	var skipped []Skipped
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	results := readAll(ctx, paths, func(i int) (exif.Metadata, error) {
+		return readCached(files[i], opts)
+	}, opts)
+	for i, f := range files {
+		if !results[i].done {
+			continue
+		}
+		if err := results[i].err; err != nil {
+			log.Debugf("Skipping %s: %v", f.path, err)
+			skipped = append(skipped, Skipped{Path: f.path, Err: err})
+			continue
+		}
+		gpsData = append(gpsData, newPoint(f.path, results[i].meta, f.info.ModTime()))
+	}
+
+	return gpsData, skipped
+}
+
+// Failed returns the skipped files whose metadata couldn't be read, leaving out those that were read but have
+// no GPS coordinates.
+func Failed(skipped []Skipped) []Skipped {
+	var failed []Skipped
+	for _, s := range skipped {
+		if !errors.Is(s.Err, exif.ErrNoGPS) {
+			failed = append(failed, s)
+		}
+	}
+	return failed
+}
+
+// prepare checks the options against the installed decoders, warning about the ones that can't be used,
+// and normalizes the extensions the scan is restricted to or skips.
+func prepare(opts Options) Options {
+	if opts.UseExiftool && !exif.ExiftoolAvailable() {
+		log.Warn("exiftool was requested but is not installed, continuing with the native decoder only")
+		opts.UseExiftool = false
+	}
+	opts.Extensions = normalizeExtensions(opts.Extensions)
+	opts.ExcludeExtensions = normalizeExtensions(opts.ExcludeExtensions)
+	for _, ext := range opts.Extensions {
+		switch {
+		case nativeExtensions[ext], opts.UseExiftool && exiftoolExtensions[ext]:
+		case exiftoolExtensions[ext]:
+			log.Warnf("%s files are only read with --use-exiftool, ignoring them", ext)
+		default:
+			log.Warnf("%s files aren't a supported format, ignoring them", ext)
+		}
+	}
+	return opts
+}
+
+// readAll reads the metadata of each of paths with read, which is passed the path's index, using a pool of
+// opts.Workers workers. The results are returned in the same order as paths. Once ctx is cancelled the files
+// being read are finished and the rest are left undone.
+func readAll(ctx context.Context, paths []string, read func(i int) (exif.Metadata, error), opts Options) []result {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	results := make([]result, len(paths))
+	jobs := make(chan int)
+	finished := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].meta, results[i].err = read(i)
+				results[i].done = true
+				finished <- i
+			}
+		}()
+	}
+	go func() {
+	dispatch:
+		for i := range paths {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(finished)
+	}()
+
+	// progress is reported from this goroutine only, as results come in
+	done, matched := 0, 0
+	for i := range finished {
+		done++
+		if results[i].err == nil {
+			matched++
+		}
+		if opts.Progress != nil {
+			opts.Progress(done, len(paths), matched, paths[i])
+		}
+	}
+
+	return results
+}
+
+// readCached reads a file's metadata from opts.Cache if it's unchanged since it was cached, and decodes it
+// otherwise, caching the result.
+func readCached(f file, opts Options) (exif.Metadata, error) {
+	if opts.Cache == nil {
+		return ReadMetadata(f.path, opts)
+	}
+	if e, ok := opts.Cache.Lookup(f.path, f.info, opts.UseExiftool); ok {
+		return e.Result()
+	}
+	meta, err := ReadMetadata(f.path, opts)
+	opts.Cache.Put(f.path, f.info, opts.UseExiftool, meta, err)
+	return meta, err
+}
+
+// supported reports whether ReadMetadata handles the file type of path with the decoders enabled in opts,
+// and whether it's one of the extensions opts is restricted to, which are expected to be normalized.
+func supported(path string, opts Options) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if len(opts.Extensions) > 0 && !slices.Contains(opts.Extensions, ext) || slices.Contains(opts.ExcludeExtensions, ext) {
+		return false
+	}
+	return nativeExtensions[ext] || (opts.UseExiftool && exiftoolExtensions[ext])
+}
+
+// normalizeExtensions lowercases extensions and adds the leading dot filepath.Ext includes.
+func normalizeExtensions(extensions []string) []string {
+	normalized := make([]string, len(extensions))
+	for i, ext := range extensions {
+		normalized[i] = "." + strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+	}
+	return normalized
+}
+
+// ReadMetadata reads a single file's metadata with the decoders enabled in opts, returning ErrUnsupported for
+// files that aren't one of the image or video types they handle. opts.UseExiftool is expected to have been
+// checked against ExiftoolAvailable.
+func ReadMetadata(path string, opts Options) (exif.Metadata, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	switch {
+	case nativeExtensions[ext]:
+		extractEXIF := exif.ExtractEXIF
+		if opts.Mmap {
+			extractEXIF = exif.ExtractEXIFMapped
+		}
+		meta, err := timed(opts, path, decoderNative, func() (exif.Metadata, error) {
+			return extractEXIF(path)
+		})
+		if err != nil && opts.UseExiftool {
+			log.Debugf("Native EXIF decoding failed for %s, retrying with exiftool: %v", path, err)
+			return timed(opts, path, decoderExiftool, func() (exif.Metadata, error) {
+				return exif.ExtractExiftool(path)
+			})
+		}
+		return meta, err
+	case opts.UseExiftool && exiftoolExtensions[ext]:
+		return timed(opts, path, decoderExiftool, func() (exif.Metadata, error) {
+			return exif.ExtractExiftool(path)
+		})
+	// TODO re-enable natively extracting EXIF data from raw, dng, and heif file types once those libraries work
+	// case ".dng", ".raw":
+	// 	return exif.ExtractRawEXIF(path)
+	// case ".heif":
+	// 	return exif.ExtractHEIFEXIF(path)
+	default:
+		return exif.Metadata{}, ErrUnsupported
+	}
+}
+
+// newPoint builds a Point named after the image file from its EXIF metadata, falling back to the file's
+// modification time when the image has no capture time.
+func newPoint(path string, meta exif.Metadata, modTime time.Time) geodata.Point {
+	base := filepath.Base(path)
+	t := meta.Time
+	if t.IsZero() {
+		t = modTime
+	}
+
+	return geodata.Point{
+		Name:    strings.TrimSuffix(base, filepath.Ext(base)),
+		Path:    path,
+		Lat:     meta.Lat,
+		Lon:     meta.Lon,
+		Time:    t,
+		Ele:     meta.Ele,
+		Heading: meta.Heading,
+		Make:    meta.Make,
+		Model:   meta.Model,
+		Lens:    meta.Lens,
+		Caption: meta.Caption,
+	}
 }
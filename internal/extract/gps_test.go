@@ -1,8 +1,13 @@
 package extract
 
 import (
+	"context"
+	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/toozej/photos2map/internal/cache"
+	"github.com/toozej/photos2map/internal/exif"
 )
 
 // TestExtractGPSData ensures GPS coordinates are correctly extracted from test images.
@@ -12,7 +17,7 @@ func TestExtractGPSData(t *testing.T) {
 	testDir := filepath.Join("..", "testdata")
 
 	// Call the function
-	gpsData := ExtractGPSData(testDir)
+	gpsData, skipped := ExtractGPSData(context.Background(), testDir, Options{})
 
 	// Assert GPS data is non-empty for valid test images
 	if len(gpsData) == 0 {
@@ -20,8 +25,205 @@ func TestExtractGPSData(t *testing.T) {
 	}
 
 	// Example: Check if first entry contains valid GPS data
-	//nolint:gocritic:sloppyTypeAssert
-	if gpsData[0].Name == "" || len(gpsData[0].Value.([]float64)) != 2 {
+	if gpsData[0].Name == "" || (gpsData[0].Lat == 0 && gpsData[0].Lon == 0) {
 		t.Errorf("Invalid GPS data for first image: %+v", gpsData[0])
 	}
+
+	// Both test images have GPS data, so nothing should be skipped
+	if len(skipped) != 0 {
+		t.Errorf("Expected no skipped files, got %+v", skipped)
+	}
+
+	// Every point should carry a timestamp, either from EXIF or the file's mtime, and the file it came from
+	for _, p := range gpsData {
+		if p.Time.IsZero() {
+			t.Errorf("Expected a timestamp for %s, but got none", p.Name)
+		}
+		if p.Path != filepath.Join(testDir, p.Name+".jpg") {
+			t.Errorf("Unexpected path %q for %s", p.Path, p.Name)
+		}
+	}
+}
+
+// TestExtractGPSDataWorkers checks that the results don't depend on the number of workers.
+func TestExtractGPSDataWorkers(t *testing.T) {
+	testDir := filepath.Join("..", "testdata")
+
+	serial, _ := ExtractGPSData(context.Background(), testDir, Options{Workers: 1})
+	parallel, _ := ExtractGPSData(context.Background(), testDir, Options{Workers: 8})
+
+	if len(serial) != len(parallel) {
+		t.Fatalf("Expected %d points with 8 workers, got %d", len(serial), len(parallel))
+	}
+	for i := range serial {
+		if serial[i].Path != parallel[i].Path || serial[i].Lat != parallel[i].Lat {
+			t.Errorf("Point %d differs: %+v vs %+v", i, serial[i], parallel[i])
+		}
+	}
+}
+
+// TestExtractGPSDataMmap checks that reading files through a memory map finds the same points.
+func TestExtractGPSDataMmap(t *testing.T) {
+	testDir := filepath.Join("..", "testdata")
+
+	read, _ := ExtractGPSData(context.Background(), testDir, Options{})
+	mapped, _ := ExtractGPSData(context.Background(), testDir, Options{Mmap: true})
+
+	if len(read) != len(mapped) {
+		t.Fatalf("Expected %d points when memory mapping files, got %d", len(read), len(mapped))
+	}
+	for i := range read {
+		if read[i].Path != mapped[i].Path || read[i].Lat != mapped[i].Lat || read[i].Lon != mapped[i].Lon {
+			t.Errorf("Point %d differs: %+v vs %+v", i, read[i], mapped[i])
+		}
+	}
+}
+
+// TestExtractGPSDataProgress checks that progress is reported once per file, ending with the totals.
+func TestExtractGPSDataProgress(t *testing.T) {
+	var calls, lastDone, lastTotal, lastMatched int
+	gpsData, _ := ExtractGPSData(context.Background(), filepath.Join("..", "testdata"), Options{
+		Workers: 2,
+		Progress: func(done, total, matched int, path string) {
+			calls++
+			lastDone, lastTotal, lastMatched = done, total, matched
+		},
+	})
+
+	if calls != lastTotal || lastDone != lastTotal || lastMatched != len(gpsData) {
+		t.Errorf("Expected progress for every file ending at %d/%d with %d matched, got %d calls ending at %d/%d with %d matched",
+			lastTotal, lastTotal, len(gpsData), calls, lastDone, lastTotal, lastMatched)
+	}
+}
+
+// TestExtractGPSDataMaxDepth checks that subdirectories beyond the maximum depth aren't scanned.
+func TestExtractGPSDataMaxDepth(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, sub := range []string{"", "a", filepath.Join("a", "b")} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, sub, "photo.jpg"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for maxDepth, expected := range map[int]int{0: 3, 1: 1, 2: 2, 3: 3} {
+		gpsData, _ := ExtractGPSData(context.Background(), dir, Options{MaxDepth: maxDepth})
+		if len(gpsData) != expected {
+			t.Errorf("Expected %d photos with a maximum depth of %d, got %d", expected, maxDepth, len(gpsData))
+		}
+	}
+}
+
+// TestExtractGPSDataExtensions checks that only files with the given extensions are scanned, less the excluded ones.
+func TestExtractGPSDataExtensions(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "b.JPEG", "c.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		extensions []string
+		excluded   []string
+		expected   int
+	}{
+		{nil, nil, 3},
+		{[]string{"jpg"}, nil, 1},
+		{[]string{".JPG", "jpeg"}, nil, 2},
+		{[]string{" png"}, nil, 1},
+		{[]string{"heic"}, nil, 0},
+		{nil, []string{"PNG"}, 2},
+		{[]string{"jpg", "png"}, []string{".png"}, 1},
+	}
+	for _, tt := range tests {
+		opts := Options{Extensions: tt.extensions, ExcludeExtensions: tt.excluded}
+		gpsData, skipped := ExtractGPSData(context.Background(), dir, opts)
+		if len(gpsData) != tt.expected {
+			t.Errorf("Expected %d photos with extensions %v less %v, got %d", tt.expected, tt.extensions, tt.excluded, len(gpsData))
+		}
+		if len(gpsData)+len(skipped) > 3 {
+			t.Errorf("Scanned more files than there are with extensions %v", tt.extensions)
+		}
+	}
+}
+
+// TestExtractGPSDataCancel checks that a cancelled scan stops decoding and returns what was decoded so far.
+func TestExtractGPSDataCancel(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg", "d.jpg", "e.jpg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if gpsData, skipped := ExtractGPSData(ctx, dir, Options{}); len(gpsData) != 0 || len(skipped) != 0 {
+		t.Errorf("Expected nothing to be scanned with a cancelled context, got %d points and %d skipped", len(gpsData), len(skipped))
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	gpsData, skipped := ExtractGPSData(ctx, dir, Options{
+		Workers: 1,
+		Progress: func(done, total, matched int, path string) {
+			cancel()
+		},
+	})
+	if len(gpsData) == 0 || len(gpsData) >= 5 || len(skipped) != 0 {
+		t.Errorf("Expected the scan to stop early with the decoded photos kept, got %d points and %d skipped", len(gpsData), len(skipped))
+	}
+}
+
+// TestExtractGPSDataCache checks that unchanged files are read from the cache and new ones are added to it.
+func TestExtractGPSDataCache(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cached, decoded := filepath.Join(dir, "cached.jpg"), filepath.Join(dir, "decoded.jpg")
+	for _, path := range []string{cached, decoded} {
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := cache.Open(filepath.Join(t.TempDir(), "extract.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a position the file doesn't have shows the cache was used
+	c.Put(cached, info, false, exif.Metadata{Lat: 1, Lon: 2}, nil)
+
+	gpsData, _ := ExtractGPSData(context.Background(), dir, Options{Cache: c})
+	if len(gpsData) != 2 || gpsData[0].Lat != 1 || gpsData[1].Lat == 1 {
+		t.Fatalf("Expected the cached position for cached.jpg only, got %+v", gpsData)
+	}
+	info, err = os.Stat(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := c.Lookup(decoded, info, false); !ok || e.Meta.Lat != gpsData[1].Lat {
+		t.Errorf("Expected decoded.jpg to be cached, got %+v, %v", e, ok)
+	}
 }
@@ -0,0 +1,50 @@
+package extract
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// ReadFileList reads a newline-delimited list of file paths, such as the output of find, ignoring blank lines.
+func ReadFileList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path := strings.TrimRight(scanner.Text(), "\r"); strings.TrimSpace(path) != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// ExtractFileListGPSData is ExtractGPSData for a list of files instead of a directory, in the order given.
+// Directories and files of types that aren't read are ignored, and files that can't be found are skipped.
+// opts.MaxDepth and FollowSymlinks don't apply.
+func ExtractFileListGPSData(ctx context.Context, paths []string, opts Options) ([]geodata.Point, []Skipped) {
+	opts = prepare(opts)
+
+	var files []file
+	var missing []Skipped
+	for _, path := range paths {
+		if !supported(path, opts) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			missing = append(missing, Skipped{Path: path, Err: err})
+			continue
+		}
+		if info.IsDir() {
+			continue
+		}
+		files = append(files, file{path: path, info: info})
+	}
+
+	gpsData, skipped := decodeFiles(ctx, files, opts)
+	return gpsData, append(missing, skipped...)
+}
@@ -0,0 +1,41 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReadFileList checks that blank lines and Windows line endings are dropped.
+func TestReadFileList(t *testing.T) {
+	paths, err := ReadFileList(strings.NewReader("./a.jpg\r\n\n  \n./photos/with space.jpg\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != "./a.jpg" || paths[1] != "./photos/with space.jpg" {
+		t.Errorf("Unexpected paths %q", paths)
+	}
+}
+
+// TestExtractFileListGPSData checks that only the listed photos are read, in the order given.
+func TestExtractFileListGPSData(t *testing.T) {
+	testDir := filepath.Join("..", "testdata")
+	paths := []string{
+		filepath.Join(testDir, "DSCN0012.jpg"),
+		testDir,
+		filepath.Join(testDir, "notes.txt"),
+		filepath.Join(testDir, "missing.jpg"),
+		filepath.Join(testDir, "DSCN0010.jpg"),
+	}
+
+	gpsData, skipped := ExtractFileListGPSData(context.Background(), paths, Options{})
+	if len(gpsData) != 2 || gpsData[0].Name != "DSCN0012" || gpsData[1].Name != "DSCN0010" {
+		t.Errorf("Expected DSCN0012 then DSCN0010, got %+v", gpsData)
+	}
+	if len(skipped) != 1 || skipped[0].Path != paths[3] || !errors.Is(skipped[0].Err, os.ErrNotExist) {
+		t.Errorf("Expected missing.jpg to be skipped as not found, got %+v", skipped)
+	}
+}
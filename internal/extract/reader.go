@@ -0,0 +1,25 @@
+package extract
+
+import (
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// ReadPoint reads the photo called name from r with the native decoder, for photos photos2map can't open
+// itself, such as those a browser hands to the WebAssembly build. modTime is used when the photo has no capture
+// time. Formats the native decoder doesn't handle give ErrUnsupported.
+func ReadPoint(name string, r io.Reader, modTime time.Time) (geodata.Point, error) {
+	if !nativeExtensions[strings.ToLower(path.Ext(name))] {
+		return geodata.Point{}, ErrUnsupported
+	}
+	meta, err := exif.ReadEXIF(r)
+	if err != nil {
+		return geodata.Point{}, err
+	}
+	return newPoint(name, meta, modTime), nil
+}
@@ -0,0 +1,30 @@
+package extract
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReadPoint checks that a photo is read from a stream, and formats the native decoder can't read are refused.
+func TestReadPoint(t *testing.T) {
+	f, err := os.Open("../testdata/DSCN0010.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	p, err := ReadPoint("DSCN0010.jpg", f, time.Time{})
+	if err != nil {
+		t.Fatalf("Error reading the photo: %v", err)
+	}
+	if p.Name != "DSCN0010" || p.Lat == 0 || p.Lon == 0 || p.Time.IsZero() {
+		t.Errorf("Unexpected point %+v", p)
+	}
+
+	if _, err := ReadPoint("notes.txt", strings.NewReader("not a photo"), time.Time{}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for a text file, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// remoteHeaderSize is how much of each JPEG is fetched from remote storage: the EXIF data is in an APP1 segment
+// of at most 64KiB near the start of the file, leaving room for an APP0 segment and padding before it
+const remoteHeaderSize = 128 * 1024
+
+// remoteExiftoolHeaderSize is how much of the files read with exiftool is fetched; RAW and HEIF files may keep
+// their metadata further in
+const remoteExiftoolHeaderSize = 1024 * 1024
+
+// remoteWorkers is the number of remote files fetched in parallel when opts.Workers isn't set. Fetching is
+// bound by round trips rather than CPU, so more requests are kept in flight than there are cores.
+const remoteWorkers = 16
+
+// remoteFile is a file listed in remote storage, identified by a URL used in the points and skipped files
+type remoteFile struct {
+	url     string
+	name    string
+	modTime time.Time
+}
+
+// decodeRemote decodes the metadata of remote files from their first bytes, fetched with readRange, returning
+// the results in the order of files. Files read with exiftool are passed only their first megabyte, which
+// covers most but not all formats. readRange may set files[i].modTime when it isn't known before fetching. It's
+// expected to retry the failures that may pass itself, as the transport package does for the HTTP sources;
+// files it still can't fetch are skipped.
+func decodeRemote(ctx context.Context, files []remoteFile, readRange func(i int, n int64) ([]byte, error), opts Options) ([]geodata.Point, []Skipped) {
+	var gpsData []geodata.Point
+	var skipped []Skipped
+
+	if opts.Workers <= 0 {
+		opts.Workers = remoteWorkers
+	}
+	urls := make([]string, len(files))
+	for i, f := range files {
+		urls[i] = f.url
+	}
+	results := readAll(ctx, urls, func(i int) (exif.Metadata, error) {
+		exiftool := opts.UseExiftool && exiftoolExtensions[strings.ToLower(path.Ext(files[i].name))]
+		size := int64(remoteHeaderSize)
+		if exiftool {
+			size = remoteExiftoolHeaderSize
+		}
+		data, err := readRange(i, size)
+		if err != nil {
+			return exif.Metadata{}, err
+		}
+		if exiftool {
+			return timed(opts, files[i].name, decoderExiftool, func() (exif.Metadata, error) {
+				return exif.ReadExiftool(bytes.NewReader(data))
+			})
+		}
+		return timed(opts, files[i].name, decoderNative, func() (exif.Metadata, error) {
+			return exif.ReadEXIF(bytes.NewReader(data))
+		})
+	}, opts)
+	for i, f := range files {
+		if !results[i].done {
+			continue
+		}
+		if err := results[i].err; err != nil {
+			log.Debugf("Skipping %s: %v", f.url, err)
+			skipped = append(skipped, Skipped{Path: f.url, Err: err})
+			continue
+		}
+		gpsData = append(gpsData, newPoint(f.url, results[i].meta, f.modTime))
+	}
+
+	return gpsData, skipped
+}
+
+// tooDeep reports whether a file at the slash-separated path rel, relative to the directory scanned, is beyond
+// opts.MaxDepth.
+func tooDeep(rel string, opts Options) bool {
+	return opts.MaxDepth > 0 && strings.Count(strings.Trim(rel, "/"), "/")+1 > opts.MaxDepth
+}
@@ -0,0 +1,43 @@
+package extract
+
+import (
+	"context"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/s3"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// ExtractS3GPSData is ExtractGPSData for the objects under an s3://bucket/prefix URL, fetching only the start of
+// each photo with a ranged GET rather than downloading it. Points and skipped files have the object's s3:// URL.
+// opts.MaxDepth counts the levels of the keys below the prefix; FollowSymlinks and Cache don't apply.
+func ExtractS3GPSData(ctx context.Context, url string, client *s3.Client, opts Options) ([]geodata.Point, []Skipped) {
+	bucket, prefix, ok := s3.ParseURL(url)
+	if !ok {
+		log.Fatalf("Invalid S3 URL %s, expected s3://bucket/prefix", url)
+	}
+	opts = prepare(opts)
+
+	listed, err := client.List(ctx, bucket, prefix)
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Fatalf("Error listing %s: %v", url, err)
+	}
+	var files []remoteFile
+	var keys []string
+	for _, o := range listed {
+		if strings.HasSuffix(o.Key, "/") || !supported(o.Key, opts) || tooDeep(strings.TrimPrefix(o.Key, prefix), opts) {
+			continue
+		}
+		files = append(files, remoteFile{url: "s3://" + bucket + "/" + o.Key, name: o.Key, modTime: o.LastModified})
+		keys = append(keys, o.Key)
+	}
+
+	return decodeRemote(ctx, files, func(i int, n int64) ([]byte, error) {
+		return client.ReadRange(ctx, bucket, keys[i], n)
+	}, opts)
+}
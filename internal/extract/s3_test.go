@@ -0,0 +1,62 @@
+package extract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/toozej/photos2map/internal/s3"
+)
+
+// TestExtractS3GPSData checks that photos are read from the start of each object in a bucket.
+func TestExtractS3GPSData(t *testing.T) {
+	photo, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photos/":
+			_, _ = w.Write([]byte(`<ListBucketResult>` +
+				`<Contents><Key>2024/a.jpg</Key></Contents>` +
+				`<Contents><Key>2024/trip/b.jpg</Key></Contents>` +
+				`<Contents><Key>2024/notes.txt</Key></Contents>` +
+				`<IsTruncated>false</IsTruncated></ListBucketResult>`))
+		case "/photos/2024/a.jpg", "/photos/2024/trip/b.jpg":
+			mu.Lock()
+			ranges = append(ranges, r.Header.Get("Range"))
+			mu.Unlock()
+			end, _ := strconv.Atoi(strings.TrimPrefix(r.Header.Get("Range"), "bytes=0-"))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(photo[:min(end+1, len(photo))])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client := s3.New(s3.Config{Endpoint: srv.URL, Region: "us-east-1"}, nil)
+
+	gpsData, skipped := ExtractS3GPSData(context.Background(), "s3://photos/2024/", client, Options{Workers: 1})
+	if len(gpsData) != 2 || len(skipped) != 0 {
+		t.Fatalf("Expected 2 photos, got %+v, %+v", gpsData, skipped)
+	}
+	if gpsData[1].Path != "s3://photos/2024/trip/b.jpg" || gpsData[1].Name != "b" || gpsData[1].Lat == 0 {
+		t.Errorf("Unexpected point %+v", gpsData[1])
+	}
+	if len(ranges) != 2 || ranges[0] != "bytes=0-"+strconv.Itoa(remoteHeaderSize-1) {
+		t.Errorf("Expected only the header of each photo to be fetched, got ranges %v", ranges)
+	}
+
+	gpsData, _ = ExtractS3GPSData(context.Background(), "s3://photos/2024/", client, Options{MaxDepth: 1})
+	if len(gpsData) != 1 {
+		t.Errorf("Expected only the top level photo with a maximum depth of 1, got %d", len(gpsData))
+	}
+}
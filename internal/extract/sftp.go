@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"context"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/ratelimit"
+	"github.com/toozej/photos2map/internal/ssh"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// ExtractSFTPGPSData is ExtractGPSData for a directory on a server given as an sftp:// URL, reading only the start
+// of each photo over a single SFTP session rather than downloading it. Points and skipped files have the file's
+// sftp:// URL. Files are read at the rate of limiter, if set. FollowSymlinks and Cache don't apply.
+func ExtractSFTPGPSData(ctx context.Context, url string, limiter *ratelimit.Limiter, opts Options) ([]geodata.Point, []Skipped) {
+	target, ok := ssh.ParseURL(url)
+	if !ok {
+		log.Fatalf("Invalid SFTP URL %s, expected sftp://[user@]host[:port]/path", url)
+	}
+	if !ssh.Available() {
+		log.Fatalf("Scanning %s needs the OpenSSH client, which is not installed", url)
+	}
+	opts = prepare(opts)
+
+	client, err := ssh.Dial(ctx, target, limiter)
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Fatalf("Error connecting to %s: %v", url, err)
+	}
+	defer client.Close()
+
+	listed, err := client.List(ctx, opts.MaxDepth)
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Fatalf("Error listing %s: %v", url, err)
+	}
+	var files []remoteFile
+	var paths []string
+	for _, f := range listed {
+		if !supported(f.Path, opts) {
+			continue
+		}
+		files = append(files, remoteFile{url: target.URL(f.Path), name: path.Base(f.Path), modTime: f.ModTime})
+		paths = append(paths, f.Path)
+	}
+
+	return decodeRemote(ctx, files, func(i int, n int64) ([]byte, error) {
+		return client.ReadRange(ctx, paths[i], n)
+	}, opts)
+}
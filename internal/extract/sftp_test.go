@@ -0,0 +1,50 @@
+package extract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sftpServers are where OpenSSH's sftp-server is installed on common systems
+var sftpServers = []string{"/usr/lib/openssh/sftp-server", "/usr/libexec/openssh/sftp-server", "/usr/libexec/sftp-server", "/usr/lib/ssh/sftp-server"}
+
+// TestExtractSFTPGPSData checks that photos are read over SFTP, using a stand-in for ssh that runs OpenSSH's
+// sftp-server locally.
+func TestExtractSFTPGPSData(t *testing.T) {
+	server := ""
+	for _, s := range sftpServers {
+		if _, err := os.Stat(s); err == nil {
+			server = s
+		}
+	}
+	if server == "" {
+		t.Skip("sftp-server not installed")
+	}
+	bin := t.TempDir()
+	fakeSSH := "#!/bin/sh\nexec " + server + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(fakeSSH), 0700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	photo, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"photo.jpg", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), photo, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gpsData, skipped := ExtractSFTPGPSData(context.Background(), "sftp://me@localhost:2222"+dir, nil, Options{})
+	if len(gpsData) != 1 || len(skipped) != 0 {
+		t.Fatalf("Expected 1 photo, got %+v, %+v", gpsData, skipped)
+	}
+	if gpsData[0].Path != "sftp://localhost:2222"+filepath.Join(dir, "photo.jpg") || gpsData[0].Name != "photo" || gpsData[0].Lat == 0 {
+		t.Errorf("Unexpected point %+v", gpsData[0])
+	}
+}
@@ -0,0 +1,116 @@
+package extract
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/exif"
+)
+
+// The decoders Stats tells apart
+const (
+	decoderNative   = "native"
+	decoderExiftool = "exiftool"
+)
+
+// Stats collects how long decoding took per file format and decoder, to tell whether exiftool or the native
+// decoder is worth it for a library. Its methods are safe for concurrent use, and do nothing on a nil Stats.
+type Stats struct {
+	mu      sync.Mutex
+	formats map[formatKey]*FormatStats
+}
+
+// formatKey identifies the files of one extension read by one decoder
+type formatKey struct {
+	ext, decoder string
+}
+
+// FormatStats are the decoding times of the files of one format read by one decoder.
+type FormatStats struct {
+	// Ext is the lowercase file extension, with its leading dot
+	Ext string
+	// Decoder is "native" or "exiftool"
+	Decoder string
+	// Files is the number of files decoded, Failed how many of them no metadata could be read from
+	Files, Failed int
+	// Total is the time spent decoding all the files
+	Total time.Duration
+}
+
+// Average returns the average time spent decoding a file.
+func (f FormatStats) Average() time.Duration {
+	if f.Files == 0 {
+		return 0
+	}
+	return f.Total / time.Duration(f.Files)
+}
+
+// record adds the decoding of one file to the stats. Files without GPS data count as decoded, since their
+// metadata was read.
+func (s *Stats) record(ext, decoder string, d time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.formats == nil {
+		s.formats = make(map[formatKey]*FormatStats)
+	}
+	key := formatKey{strings.ToLower(ext), decoder}
+	f, ok := s.formats[key]
+	if !ok {
+		f = &FormatStats{Ext: key.ext, Decoder: decoder}
+		s.formats[key] = f
+	}
+	f.Files++
+	f.Total += d
+	if err != nil && !errors.Is(err, exif.ErrNoGPS) {
+		f.Failed++
+	}
+}
+
+// Formats returns the stats of each format and decoder, ordered by extension and then decoder.
+func (s *Stats) Formats() []FormatStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	formats := make([]FormatStats, 0, len(s.formats))
+	for _, f := range s.formats {
+		formats = append(formats, *f)
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		if formats[i].Ext != formats[j].Ext {
+			return formats[i].Ext < formats[j].Ext
+		}
+		return formats[i].Decoder < formats[j].Decoder
+	})
+	return formats
+}
+
+// Log writes the average decoding time of each format and decoder to the debug log. Files read from the cache
+// aren't decoded and so aren't counted.
+func (s *Stats) Log() {
+	for _, f := range s.Formats() {
+		log.Debugf("Decoding %s files with the %s decoder: %d files in %v, %v per file on average, %d failed",
+			f.Ext, f.Decoder, f.Files, f.Total.Round(time.Millisecond), f.Average().Round(time.Microsecond), f.Failed)
+	}
+}
+
+// timed calls decode, recording how long it took in opts.Stats under the extension of name and decoder.
+func timed(opts Options, name, decoder string, decode func() (exif.Metadata, error)) (exif.Metadata, error) {
+	if opts.Stats == nil {
+		return decode()
+	}
+	start := time.Now()
+	meta, err := decode()
+	opts.Stats.record(filepath.Ext(name), decoder, time.Since(start), err)
+	return meta, err
+}
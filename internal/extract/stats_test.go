@@ -0,0 +1,46 @@
+package extract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStats checks that decoding times are collected per format and decoder, counting files without GPS data as
+// decoded and unreadable ones as failed.
+func TestStats(t *testing.T) {
+	dir := t.TempDir()
+	photo, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"a.jpg": photo, "b.JPG": photo, "c.jpg": []byte("not an image"), "d.png": []byte("not an image")} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := &Stats{}
+	ExtractGPSData(context.Background(), dir, Options{Stats: stats})
+
+	formats := stats.Formats()
+	if len(formats) != 2 {
+		t.Fatalf("Expected stats for .jpg and .png, got %+v", formats)
+	}
+	if f := formats[0]; f.Ext != ".jpg" || f.Decoder != decoderNative || f.Files != 3 || f.Failed != 1 || f.Total <= 0 {
+		t.Errorf("Expected 3 .jpg files decoded natively with 1 failed, got %+v", f)
+	}
+	if f := formats[1]; f.Ext != ".png" || f.Files != 1 || f.Failed != 1 {
+		t.Errorf("Expected 1 failed .png file, got %+v", f)
+	}
+	if avg := formats[0].Average(); avg != formats[0].Total/3 {
+		t.Errorf("Expected the average of 3 files, got %v of %v", avg, formats[0].Total)
+	}
+
+	var none *Stats
+	none.record(".jpg", decoderNative, 1, nil)
+	if none.Formats() != nil {
+		t.Error("Expected no stats from a nil Stats")
+	}
+}
@@ -0,0 +1,61 @@
+package extract
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strings"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// ExtractURLGPSData is ExtractGPSData for a list of http:// and https:// URLs, such as the photos of a published
+// gallery, in the order given. Only the start of each photo is fetched with a Range request, falling back to
+// reading just as much of the response from servers that ignore the range. URLs whose path has an extension of
+// a type that isn't read are ignored, while those without one, common on CDNs, are decoded natively; URLs that
+// can't be fetched are skipped. The Last-Modified header stands in for missing capture times.
+// opts.MaxDepth, FollowSymlinks and Cache don't apply.
+func ExtractURLGPSData(ctx context.Context, urls []string, client *http.Client, opts Options) ([]geodata.Point, []Skipped) {
+	opts = prepare(opts)
+
+	var files []remoteFile
+	var invalid []Skipped
+	for _, raw := range urls {
+		u, err := neturl.Parse(strings.TrimSpace(raw))
+		if err == nil && u.Scheme != "http" && u.Scheme != "https" {
+			err = fmt.Errorf("unsupported URL scheme %q, expected http or https", u.Scheme)
+		}
+		if err != nil {
+			invalid = append(invalid, Skipped{Path: raw, Err: err})
+			continue
+		}
+		if path.Ext(u.Path) != "" && !supported(u.Path, opts) {
+			continue
+		}
+		files = append(files, remoteFile{url: u.String(), name: u.Path})
+	}
+
+	gpsData, skipped := decodeRemote(ctx, files, func(i int, n int64) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, files[i].url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+			return nil, fmt.Errorf("fetching: %s", resp.Status)
+		}
+		if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+			files[i].modTime = modTime
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, n))
+	}, opts)
+	return gpsData, append(invalid, skipped...)
+}
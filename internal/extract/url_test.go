@@ -0,0 +1,51 @@
+package extract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestExtractURLGPSData checks that photos are read from the start of each URL, with failures skipped.
+func TestExtractURLGPSData(t *testing.T) {
+	photo, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photos/a.jpg", "/img/12345":
+			// a server ignoring the range, sending the whole photo
+			w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+			_, _ = w.Write(photo)
+		case "/photos/notes.txt":
+			t.Error("Expected notes.txt not to be fetched")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	urls := []string{
+		srv.URL + "/photos/a.jpg",
+		srv.URL + "/photos/notes.txt",
+		srv.URL + "/img/12345",
+		srv.URL + "/photos/missing.jpg",
+		"ftp://example.com/b.jpg",
+	}
+	gpsData, skipped := ExtractURLGPSData(context.Background(), urls, srv.Client(), Options{})
+	if len(gpsData) != 2 || gpsData[0].Path != urls[0] || gpsData[1].Path != urls[2] || gpsData[0].Lat == 0 {
+		t.Fatalf("Expected a.jpg and 12345 to be read, got %+v", gpsData)
+	}
+	if len(skipped) != 2 || skipped[0].Path != urls[4] || skipped[1].Path != urls[3] {
+		t.Errorf("Expected the invalid and missing URLs to be skipped, got %+v", skipped)
+	}
+	if gpsData[0].Time.IsZero() {
+		t.Error("Expected a capture time")
+	}
+}
@@ -0,0 +1,124 @@
+package extract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSkipMarkers are the files marking directories not to scan by default: Android's .nomedia, and the
+// .ppstorage PhotoPrism puts in its storage folder of sidecar files and cached previews.
+var DefaultSkipMarkers = []string{".nomedia", ".ppstorage"}
+
+// previewDirs are the folders photo managers keep generated previews and transcodes in, by name, with the file
+// marking them as such, or "" if the name is distinctive enough
+var previewDirs = map[string]string{
+	// Synology Photos and DSM's indexer, in every folder they index
+	"@eaDir": "",
+	// Immich, in its upload location
+	"thumbs":        ".immich",
+	"encoded-video": ".immich",
+}
+
+// walker collects the files to decode under a directory, following symlinks if enabled in opts.
+type walker struct {
+	opts Options
+	// visited holds the directories already scanned, so that a symlink looping back to one of its parents,
+	// or two links to the same directory, don't scan it again
+	visited map[fileID]bool
+	files   []file
+}
+
+// findFiles returns the files under dir that ReadMetadata handles with the options in opts, in lexical order.
+// Symlinks to files are always included, symlinks to directories only with opts.FollowSymlinks. The walk stops
+// with ctx's error once it's cancelled.
+func findFiles(ctx context.Context, dir string, opts Options) ([]file, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	w := &walker{opts: opts, visited: map[fileID]bool{}}
+	if err := w.walkDir(ctx, dir, info, 0); err != nil {
+		return nil, err
+	}
+	return w.files, nil
+}
+
+// walkDir collects the files in the directory at path, depth levels below the scanned directory.
+func (w *walker) walkDir(ctx context.Context, path string, info os.FileInfo, depth int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if id, ok := idOf(path, info); ok {
+		if w.visited[id] {
+			log.Warnf("Not scanning %s again, it links to a directory that was already scanned", path)
+			return nil
+		}
+		w.visited[id] = true
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	if depth > 0 && w.skip(path, entries) {
+		return nil
+	}
+	for _, entry := range entries {
+		p := filepath.Join(path, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			// files are described by their target, so the cache notices when it changes
+			target, err := os.Stat(p)
+			switch {
+			case err != nil && w.opts.FollowSymlinks:
+				log.Warnf("Not following broken symlink %s: %v", p, err)
+				continue
+			case err != nil, target.IsDir() && !w.opts.FollowSymlinks:
+			default:
+				info = target
+			}
+		}
+
+		switch {
+		case info.IsDir():
+			if w.opts.MaxDepth > 0 && depth+1 >= w.opts.MaxDepth {
+				continue
+			}
+			if err := w.walkDir(ctx, p, info, depth+1); err != nil {
+				return err
+			}
+		case supported(p, w.opts):
+			w.files = append(w.files, file{path: p, info: info})
+		}
+	}
+	return nil
+}
+
+// skip reports whether the directory at path, holding entries, is one not to scan: one containing a skip marker,
+// or a photo manager's previews folder.
+func (w *walker) skip(path string, entries []os.DirEntry) bool {
+	marker, previews := previewDirs[filepath.Base(path)]
+	previews = previews && !w.opts.ScanPreviews
+	marked := marker == ""
+	for _, entry := range entries {
+		if slices.Contains(w.opts.SkipMarkers, entry.Name()) {
+			log.Debugf("Not scanning %s, it contains %s", path, entry.Name())
+			return true
+		}
+		if entry.Name() == marker {
+			marked = true
+		}
+	}
+	if previews && marked {
+		log.Debugf("Not scanning %s, it holds the previews of a photo manager", path)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package extract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestFindFilesSymlinks checks that symlinked directories are only scanned when following symlinks, and that
+// a symlink looping back to a parent doesn't scan the same directory again.
+func TestFindFilesSymlinks(t *testing.T) {
+	root := t.TempDir()
+	library := filepath.Join(root, "library")
+	album := filepath.Join(root, "albums", "italy")
+	for _, dir := range []string{library, album} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{filepath.Join(library, "a.jpg"), filepath.Join(album, "b.jpg")} {
+		if err := os.WriteFile(path, []byte("not decoded"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(library, "italy"):  album,
+		filepath.Join(album, "library"):  library,
+		filepath.Join(library, "broken"): filepath.Join(root, "missing"),
+		filepath.Join(library, "c.jpg"):  filepath.Join(album, "b.jpg"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("Symlinks aren't supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		opts     Options
+		expected []string
+	}{
+		{Options{}, []string{"a.jpg", "c.jpg"}},
+		{Options{FollowSymlinks: true}, []string{"a.jpg", "c.jpg", filepath.Join("italy", "b.jpg")}},
+		{Options{FollowSymlinks: true, MaxDepth: 1}, []string{"a.jpg", "c.jpg"}},
+	}
+	for _, tt := range tests {
+		files, err := findFiles(context.Background(), library, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found []string
+		for _, f := range files {
+			rel, _ := filepath.Rel(library, f.path)
+			found = append(found, rel)
+		}
+		if len(found) != len(tt.expected) {
+			t.Errorf("Expected %v with %+v, got %v", tt.expected, tt.opts, found)
+			continue
+		}
+		for i := range found {
+			if found[i] != tt.expected[i] {
+				t.Errorf("Expected %v with %+v, got %v", tt.expected, tt.opts, found)
+				break
+			}
+		}
+	}
+}
+
+// TestFindFilesSkipped checks that directories with a skip marker and photo managers' previews folders are
+// skipped, unless they're the directory scanned or previews are asked for.
+func TestFindFilesSkipped(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"a.jpg",
+		"hidden/.nomedia", "hidden/b.jpg", "hidden/sub/c.jpg",
+		"@eaDir/a.jpg/SYNOPHOTO_THUMB_XL.jpg",
+		"immich/thumbs/.immich", "immich/thumbs/d.jpg",
+		"trip/thumbs/e.jpg",
+	}
+	for _, name := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("not decoded"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir      string
+		opts     Options
+		expected []string
+	}{
+		{root, Options{SkipMarkers: DefaultSkipMarkers}, []string{"a.jpg", "trip/thumbs/e.jpg"}},
+		{root, Options{}, []string{"a.jpg", "hidden/b.jpg", "hidden/sub/c.jpg", "trip/thumbs/e.jpg"}},
+		{root, Options{SkipMarkers: DefaultSkipMarkers, ScanPreviews: true},
+			[]string{"@eaDir/a.jpg/SYNOPHOTO_THUMB_XL.jpg", "a.jpg", "immich/thumbs/d.jpg", "trip/thumbs/e.jpg"}},
+		{filepath.Join(root, "hidden"), Options{SkipMarkers: DefaultSkipMarkers}, []string{"b.jpg", "sub/c.jpg"}},
+	}
+	for _, tt := range tests {
+		found, err := findFiles(context.Background(), tt.dir, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rels []string
+		for _, f := range found {
+			rel, _ := filepath.Rel(tt.dir, f.path)
+			rels = append(rels, filepath.ToSlash(rel))
+		}
+		if !slices.Equal(rels, tt.expected) {
+			t.Errorf("Expected %v in %s with %+v, got %v", tt.expected, tt.dir, tt.opts, rels)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package extract
+
+import (
+	"context"
+	neturl "net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/webdav"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// ExtractWebDAVGPSData is ExtractGPSData for the files in a WebDAV collection given as a webdav:// or
+// webdav+http:// URL, fetching only the start of each photo with a ranged GET rather than downloading it.
+// Points and skipped files have the file's webdav:// URL. FollowSymlinks and Cache don't apply.
+func ExtractWebDAVGPSData(ctx context.Context, url string, client *webdav.Client, opts Options) ([]geodata.Point, []Skipped) {
+	collection, _, ok := webdav.ParseURL(url)
+	if !ok {
+		log.Fatalf("Invalid WebDAV URL %s, expected webdav://host/path", url)
+	}
+	scheme, _, _ := strings.Cut(url, "://")
+	opts = prepare(opts)
+
+	listed, err := client.List(ctx, collection, opts.MaxDepth)
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+	if err != nil {
+		log.Fatalf("Error listing %s: %v", url, err)
+	}
+	var files []remoteFile
+	var urls []*neturl.URL
+	for _, f := range listed {
+		if !supported(f.URL.Path, opts) {
+			continue
+		}
+		files = append(files, remoteFile{url: scheme + "://" + f.URL.Host + f.URL.Path, name: f.URL.Path, modTime: f.LastModified})
+		urls = append(urls, f.URL)
+	}
+
+	return decodeRemote(ctx, files, func(i int, n int64) ([]byte, error) {
+		return client.ReadRange(ctx, urls[i], n)
+	}, opts)
+}
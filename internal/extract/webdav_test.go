@@ -0,0 +1,49 @@
+package extract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/internal/webdav"
+)
+
+// TestExtractWebDAVGPSData checks that photos are read from the start of each file in a WebDAV collection.
+func TestExtractWebDAVGPSData(t *testing.T) {
+	photo, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PROPFIND" && r.URL.Path == "/dav/photos/":
+			w.WriteHeader(http.StatusMultiStatus)
+			_, _ = w.Write([]byte(`<d:multistatus xmlns:d="DAV:">` +
+				`<d:response><d:href>/dav/photos/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>` +
+				`<d:response><d:href>/dav/photos/notes.txt</d:href><d:propstat><d:prop><d:resourcetype/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>` +
+				`<d:response><d:href>/dav/photos/my%20photo.jpg</d:href><d:propstat><d:prop><d:resourcetype/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>` +
+				`</d:multistatus>`))
+		case r.Method == http.MethodGet && r.URL.Path == "/dav/photos/my photo.jpg":
+			end, _ := strconv.Atoi(strings.TrimPrefix(r.Header.Get("Range"), "bytes=0-"))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(photo[:min(end+1, len(photo))])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	url := "webdav+http://" + strings.TrimPrefix(srv.URL, "http://") + "/dav/photos"
+	gpsData, skipped := ExtractWebDAVGPSData(context.Background(), url, webdav.New(webdav.Config{}, nil), Options{})
+	if len(gpsData) != 1 || len(skipped) != 0 {
+		t.Fatalf("Expected 1 photo, got %+v, %+v", gpsData, skipped)
+	}
+	if gpsData[0].Path != url+"/my photo.jpg" || gpsData[0].Name != "my photo" || gpsData[0].Lat == 0 {
+		t.Errorf("Unexpected point %+v", gpsData[0])
+	}
+}
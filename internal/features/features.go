@@ -0,0 +1,69 @@
+// Package features reports what this build of photos2map can do on this machine.
+package features
+
+import (
+	"runtime/debug"
+
+	"github.com/toozej/photos2map/internal/camera"
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/internal/ssh"
+)
+
+// Feature is an optional capability and whether it's available.
+type Feature struct {
+	Name    string
+	Enabled bool
+	// Detail explains how the feature is provided, or why it isn't available
+	Detail string
+}
+
+// List returns the optional features, checking for the external tools some of them need.
+func List() []Feature {
+	exiftool := Feature{Name: "RAW, HEIF and video metadata (--use-exiftool)", Detail: "exiftool not found on PATH"}
+	if exif.ExiftoolAvailable() {
+		exiftool.Enabled, exiftool.Detail = true, "through exiftool"
+	}
+	gphoto2 := Feature{Name: "Camera source over PTP/MTP (--camera)", Detail: "gphoto2 not found on PATH"}
+	if camera.Available() {
+		gphoto2.Enabled, gphoto2.Detail = true, "through gphoto2, experimental"
+	}
+	network := "built in"
+	if !Network {
+		network = "left out by the nonetwork build tag"
+	}
+	sftp := Feature{Name: "SFTP source (sftp:// URLs)", Detail: network}
+	if Network {
+		sftp.Detail = "ssh not found on PATH"
+		if ssh.Available() {
+			sftp.Enabled, sftp.Detail = true, "through the OpenSSH client and the server's sftp subsystem"
+		}
+	}
+
+	return []Feature{
+		{Name: "JPEG and PNG metadata", Enabled: true, Detail: "built in"},
+		{Name: "Native HEIF and RAW decoding", Enabled: false, Detail: "not built in"},
+		exiftool,
+		gphoto2,
+		{Name: "ZIP and tar archive source", Enabled: true, Detail: "built in"},
+		{Name: "S3 source (s3:// URLs)", Enabled: Network, Detail: network},
+		{Name: "WebDAV source (webdav:// URLs)", Enabled: Network, Detail: network},
+		{Name: "HTTP URL list source (--urls)", Enabled: Network, Detail: network},
+		sftp,
+		{Name: "Live geotagging from gpsd (live)", Enabled: Network, Detail: network},
+		{Name: "Telemetry and update checks", Enabled: false, Detail: "none in any build"},
+	}
+}
+
+// BuildTags returns the build tags the binary was compiled with, or "" if there were none.
+func BuildTags() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, s := range info.Settings {
+		if s.Key == "-tags" {
+			return s.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package features
+
+import "testing"
+
+// TestList checks that the network features follow the build tag and nothing reports telemetry.
+func TestList(t *testing.T) {
+	found := map[string]Feature{}
+	for _, f := range List() {
+		found[f.Name] = f
+		if f.Detail == "" {
+			t.Errorf("Expected a detail for %s", f.Name)
+		}
+	}
+	if f := found["S3 source (s3:// URLs)"]; f.Enabled != Network {
+		t.Errorf("Expected the S3 source to be enabled only with network support, got %+v", f)
+	}
+	if f := found["WebDAV source (webdav:// URLs)"]; f.Enabled != Network {
+		t.Errorf("Expected the WebDAV source to be enabled only with network support, got %+v", f)
+	}
+	if f, ok := found["Telemetry and update checks"]; !ok || f.Enabled {
+		t.Errorf("Expected telemetry to be listed as absent, got %+v", f)
+	}
+}
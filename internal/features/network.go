@@ -0,0 +1,7 @@
+//go:build !nonetwork
+
+package features
+
+// Network is false in binaries built with the nonetwork tag, which leaves out every feature connecting to
+// other machines
+const Network = true
@@ -0,0 +1,111 @@
+// Package geotag assigns positions from a reference track to photos that have none.
+package geotag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/internal/extract"
+	"github.com/toozej/photos2map/internal/track"
+)
+
+// Options controls how photos are matched to the track.
+type Options struct {
+	// Offset is added to each photo's capture time before it's looked up on the track, to correct
+	// a camera clock that was off or set to a different time zone
+	Offset time.Duration
+	// UseExiftool reads the capture time of the formats only exiftool supports, see extract.Options
+	UseExiftool bool
+	// Overwrite replaces existing XMP sidecars instead of skipping their photos
+	Overwrite bool
+	// MaxGap skips photos taken further than this from the nearest track point, whose interpolated position
+	// can't be trusted. 0 disables the check.
+	MaxGap time.Duration
+}
+
+// Tagged is a photo that was given a position, along with how reliable the position is.
+type Tagged struct {
+	Path  string
+	Match track.Match
+}
+
+// ErrHasGPS is returned by Tag for photos that already have GPS coordinates.
+var ErrHasGPS = errors.New("already has GPS coordinates")
+
+// ErrOutsideTrack is returned by Tag for photos taken before the start or after the end of the track.
+var ErrOutsideTrack = errors.New("outside the track")
+
+// Apply writes an XMP sidecar with its position on trk for every photo under dir that has a capture time but
+// no GPS coordinates. Photos that already have coordinates are left alone; photos that can't be tagged are
+// returned as skipped along with the reason.
+func Apply(dir string, trk track.Track, opts Options) ([]Tagged, []extract.Skipped) {
+	var tagged []Tagged
+	var skipped []extract.Skipped
+
+	if opts.UseExiftool && !exif.ExiftoolAvailable() {
+		log.Warn("exiftool was requested but is not installed, continuing with the native decoder only")
+		opts.UseExiftool = false
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		m, err := Tag(path, trk, opts)
+		switch {
+		case errors.Is(err, extract.ErrUnsupported):
+		case errors.Is(err, ErrHasGPS):
+			log.Debugf("Not geotagging %s, it already has GPS coordinates", path)
+		case err != nil:
+			skipped = append(skipped, extract.Skipped{Path: path, Err: err})
+		default:
+			log.Debugf("Geotagged %s at %f, %f with %s confidence", path, m.Lat, m.Lon, m.Confidence())
+			tagged = append(tagged, Tagged{Path: path, Match: m})
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Fatalf("Error walking the directory: %v", err)
+	}
+
+	return tagged, skipped
+}
+
+// Tag writes an XMP sidecar with the position on trk of a single photo, returning the matched position.
+// It returns extract.ErrUnsupported for files that aren't photos, ErrHasGPS for photos that already have
+// coordinates and ErrOutsideTrack for photos taken outside the track. opts.UseExiftool is expected to have
+// been checked against exif.ExiftoolAvailable.
+func Tag(path string, trk track.Track, opts Options) (track.Match, error) {
+	meta, err := extract.ReadMetadata(path, extract.Options{UseExiftool: opts.UseExiftool})
+	switch {
+	case errors.Is(err, extract.ErrUnsupported):
+		return track.Match{}, err
+	case err == nil:
+		return track.Match{}, ErrHasGPS
+	case !errors.Is(err, exif.ErrNoGPS):
+		return track.Match{}, err
+	case meta.Time.IsZero():
+		return track.Match{}, errors.New("no capture time")
+	}
+
+	when := meta.Time.Add(opts.Offset)
+	m, ok := trk.Locate(when)
+	if !ok {
+		return track.Match{}, fmt.Errorf("taken at %s, %w", when.Format(time.RFC3339), ErrOutsideTrack)
+	}
+	if opts.MaxGap > 0 && m.Gap > opts.MaxGap {
+		return track.Match{}, fmt.Errorf("taken %s from the nearest track point, more than the maximum gap of %s", m.Gap, opts.MaxGap)
+	}
+	if err := WriteXMP(path, m.Point, opts.Overwrite); err != nil {
+		return track.Match{}, err
+	}
+	return m, nil
+}
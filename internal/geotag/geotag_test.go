@@ -0,0 +1,124 @@
+package geotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/internal/extract"
+	"github.com/toozej/photos2map/internal/track"
+)
+
+// jpegTakenAt returns a small JPEG whose EXIF data only holds a DateTimeOriginal, like a photo from a camera without GPS.
+func jpegTakenAt(t *testing.T, when time.Time) []byte {
+	t.Helper()
+
+	// TIFF header, IFD0 with a pointer to the Exif IFD, and the Exif IFD with DateTimeOriginal
+	var tiff bytes.Buffer
+	le := binary.LittleEndian
+	tiff.WriteString("II")
+	_ = binary.Write(&tiff, le, []uint16{42})
+	_ = binary.Write(&tiff, le, []uint32{8})
+	_ = binary.Write(&tiff, le, []uint16{1, 0x8769, 4})
+	_ = binary.Write(&tiff, le, []uint32{1, 26, 0})
+	_ = binary.Write(&tiff, le, []uint16{1, 0x9003, 2})
+	_ = binary.Write(&tiff, le, []uint32{20, 44, 0})
+	tiff.WriteString(when.Format("2006:01:02 15:04:05") + "\x00")
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	out.Write([]byte{0xff, 0xd8, 0xff, 0xe1})
+	_ = binary.Write(&out, binary.BigEndian, uint16(2+6+tiff.Len()))
+	out.WriteString("Exif\x00\x00")
+	out.Write(tiff.Bytes())
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+// TestApply checks that only photos without coordinates but within the track are tagged.
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	trk := track.Track{
+		{Lat: 43, Lon: 11, Time: start},
+		{Lat: 44, Lon: 12, Time: start.Add(10 * time.Minute)},
+	}
+
+	files := map[string][]byte{
+		"during.jpg": jpegTakenAt(t, start.Add(5*time.Minute)),
+		"later.jpg":  jpegTakenAt(t, start.Add(time.Hour)),
+		"notes.txt":  []byte("not a photo"),
+	}
+	tagged, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["tagged.jpg"] = tagged
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, skipped := Apply(dir, trk, Options{})
+
+	if len(result) != 1 || result[0].Path != filepath.Join(dir, "during.jpg") {
+		t.Fatalf("Expected only during.jpg to be tagged, got %+v", result)
+	}
+	if p := result[0].Match; p.Lat != 43.5 || p.Lon != 11.5 || p.Gap != 5*time.Minute {
+		t.Errorf("Expected the interpolated midpoint, got %+v", p)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "during.jpg.xmp")); err != nil {
+		t.Errorf("Expected a sidecar for during.jpg: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0].Path != filepath.Join(dir, "later.jpg") {
+		t.Errorf("Expected later.jpg to be skipped as outside the track, got %+v", skipped)
+	}
+
+	// an offset moves the later photo into the track, unless it's too far from the track points
+	result, skipped = Apply(dir, trk, Options{Offset: -55 * time.Minute, MaxGap: 2 * time.Minute})
+	if len(result) != 0 || len(skipped) != 2 {
+		t.Errorf("Expected later.jpg to be skipped for its gap to the track, got %+v, %+v", result, skipped)
+	}
+	result, _ = Apply(dir, trk, Options{Offset: -55 * time.Minute})
+	if len(result) != 1 || result[0].Path != filepath.Join(dir, "later.jpg") {
+		t.Errorf("Expected later.jpg to be tagged with the offset applied, got %+v", result)
+	}
+}
+
+// TestTag checks the errors Tag returns for photos it leaves alone.
+func TestTag(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	trk := track.Track{{Lat: 43, Lon: 11, Time: start}, {Lat: 44, Lon: 12, Time: start.Add(time.Minute)}}
+
+	later := filepath.Join(dir, "later.jpg")
+	if err := os.WriteFile(later, jpegTakenAt(t, start.Add(2*time.Minute)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Tag(later, trk, Options{}); !errors.Is(err, ErrOutsideTrack) {
+		t.Errorf("Expected ErrOutsideTrack, got %v", err)
+	}
+	if _, err := Tag(filepath.Join("..", "testdata", "DSCN0010.jpg"), trk, Options{}); !errors.Is(err, ErrHasGPS) {
+		t.Errorf("Expected ErrHasGPS, got %v", err)
+	}
+	if _, err := Tag(filepath.Join(dir, "notes.txt"), trk, Options{}); !errors.Is(err, extract.ErrUnsupported) {
+		t.Errorf("Expected extract.ErrUnsupported, got %v", err)
+	}
+
+	// once the track covers the capture time the photo is tagged
+	trk = append(trk, track.Point{Lat: 45, Lon: 13, Time: start.Add(3 * time.Minute)})
+	if m, err := Tag(later, trk, Options{}); err != nil || m.Lat != 44.5 {
+		t.Errorf("Expected later.jpg to be tagged at the midpoint, got %+v, %v", m, err)
+	}
+}
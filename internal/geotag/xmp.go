@@ -0,0 +1,99 @@
+package geotag
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/toozej/photos2map/internal/track"
+)
+
+// xmpTemplate is a minimal XMP packet holding the EXIF GPS properties, which Lightroom, darktable, digiKam
+// and exiftool all read from sidecars
+const xmpTemplate = `<?xpacket begin="` + "\ufeff" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="photos2map">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:exif="http://ns.adobe.com/exif/1.0/"
+    exif:GPSVersionID="2.2.0.0"
+    exif:GPSMapDatum="WGS-84"
+    exif:GPSLatitude="%s"
+    exif:GPSLongitude="%s"%s
+    exif:GPSTimeStamp="%s"/>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>
+`
+
+// ErrSidecarExists is returned by WriteXMP when the photo already has a sidecar and overwrite isn't set.
+var ErrSidecarExists = errors.New("sidecar exists, use --overwrite to replace it")
+
+// SidecarPath returns the XMP sidecar path for a photo, the photo's path with .xmp appended. Keeping the
+// extension gives IMG_1.CR2 and IMG_1.JPG shot as a pair sidecars of their own.
+func SidecarPath(path string) string {
+	return path + ".xmp"
+}
+
+// WriteXMP writes an XMP sidecar with the position p for the photo at path. An existing sidecar is only
+// replaced when overwrite is set, since it may hold other edits, and ErrSidecarExists is returned otherwise.
+func WriteXMP(path string, p track.Point, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(SidecarPath(path), flags, 0644) // #nosec G302 G304
+	if errors.Is(err, os.ErrExist) {
+		return ErrSidecarExists
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(xmpPacket(p))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// xmpPacket renders the sidecar contents for a position.
+func xmpPacket(p track.Point) string {
+	var altitude string
+	if p.Ele != nil {
+		ref := 0
+		if *p.Ele < 0 {
+			ref = 1
+		}
+		alt := new(big.Rat).SetFloat64(math.Round(math.Abs(*p.Ele) * 100))
+		alt.Quo(alt, big.NewRat(100, 1))
+		altitude = fmt.Sprintf("\n    exif:GPSAltitudeRef=\"%d\"\n    exif:GPSAltitude=\"%s\"", ref, alt.String())
+	}
+
+	return fmt.Sprintf(xmpTemplate,
+		xmpCoordinate(p.Lat, 'N', 'S'),
+		xmpCoordinate(p.Lon, 'E', 'W'),
+		altitude,
+		p.Time.UTC().Format(time.RFC3339),
+	)
+}
+
+// xmpCoordinate formats decimal degrees in the XMP GPSCoordinate form "DDD,MM.mmmmmmR", where R is pos or neg
+// depending on the sign. The minutes are rounded to the 6 decimals written, carrying into the degrees when they
+// round up to a full 60.
+func xmpCoordinate(deg float64, pos, neg byte) string {
+	ref := pos
+	if deg < 0 {
+		ref = neg
+		deg = -deg
+	}
+	whole := math.Floor(deg)
+	minutes := math.Round((deg-whole)*60*1e6) / 1e6
+	if minutes >= 60 {
+		whole++
+		minutes = 0
+	}
+	return fmt.Sprintf("%d,%.6f%c", int(whole), minutes, ref)
+}
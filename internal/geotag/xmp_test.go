@@ -0,0 +1,72 @@
+package geotag
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/internal/track"
+)
+
+// TestXMPCoordinate checks the degrees and decimal minutes format of XMP coordinates.
+func TestXMPCoordinate(t *testing.T) {
+	tests := []struct {
+		deg      float64
+		expected string
+	}{
+		{43.5, "43,30.000000N"},
+		{-0.1276, "0,7.656000S"},
+		{0, "0,0.000000N"},
+		{10.99999999999, "11,0.000000N"},
+		{-10.99999999999, "11,0.000000S"},
+	}
+	for _, test := range tests {
+		if got := xmpCoordinate(test.deg, 'N', 'S'); got != test.expected {
+			t.Errorf("xmpCoordinate(%v) = %q, expected %q", test.deg, got, test.expected)
+		}
+	}
+}
+
+// TestWriteXMP checks the sidecar contents and that existing sidecars are only replaced when asked to.
+func TestWriteXMP(t *testing.T) {
+	photo := filepath.Join(t.TempDir(), "IMG_0001.JPG")
+	ele := -12.5
+	p := track.Point{Lat: 51.5074, Lon: -0.1276, Ele: &ele, Time: time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC)}
+
+	if err := WriteXMP(photo, p, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(SidecarPath(photo))
+	if err != nil {
+		t.Fatalf("Expected the sidecar to be written: %v", err)
+	}
+	for _, expected := range []string{
+		`exif:GPSLatitude="51,30.444000N"`,
+		`exif:GPSLongitude="0,7.656000W"`,
+		`exif:GPSAltitudeRef="1"`,
+		`exif:GPSAltitude="25/2"`,
+		`exif:GPSTimeStamp="2024-05-01T10:05:00Z"`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected the sidecar to contain %s, got:\n%s", expected, content)
+		}
+	}
+
+	if err := WriteXMP(photo, p, false); !errors.Is(err, ErrSidecarExists) {
+		t.Errorf("Expected an existing sidecar to be kept, got %v", err)
+	}
+	if err := WriteXMP(photo, p, true); err != nil {
+		t.Errorf("Expected an existing sidecar to be replaced with overwrite set, got %v", err)
+	}
+}
+
+// TestSidecarPath checks that a raw and JPEG pair sharing a name get sidecars of their own.
+func TestSidecarPath(t *testing.T) {
+	raw, jpeg := SidecarPath(filepath.Join("photos", "IMG_1.CR2")), SidecarPath(filepath.Join("photos", "IMG_1.JPG"))
+	if raw != filepath.Join("photos", "IMG_1.CR2.xmp") || jpeg != filepath.Join("photos", "IMG_1.JPG.xmp") {
+		t.Errorf("Expected IMG_1.CR2.xmp and IMG_1.JPG.xmp, got %s and %s", raw, jpeg)
+	}
+}
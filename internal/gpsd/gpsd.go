@@ -0,0 +1,153 @@
+// Package gpsd records the live position reported by a gpsd daemon as a track.
+package gpsd
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/toozej/photos2map/internal/track"
+)
+
+// DefaultAddr is the address gpsd listens on by default
+const DefaultAddr = "localhost:2947"
+
+// watchCommand asks gpsd to stream reports from all devices as JSON
+const watchCommand = `?WATCH={"enable":true,"json":true};` + "\n"
+
+// IsLocal reports whether addr, a host and port, is on this machine, so connecting to it stays off the network.
+// Host names are resolved and must only have loopback addresses.
+func IsLocal(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback()
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() {
+			return false
+		}
+	}
+	return true
+}
+
+// Client is a connection to gpsd that records every fix it reports.
+type Client struct {
+	conn net.Conn
+	done chan struct{}
+
+	mu    sync.Mutex
+	track track.Track
+	err   error
+}
+
+// Dial connects to the gpsd daemon at addr and starts recording its fixes.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(conn, watchCommand); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := &Client{conn: conn, done: make(chan struct{})}
+	go c.read()
+	return c, nil
+}
+
+// read records fixes until the connection is closed.
+func (c *Client) read() {
+	defer close(c.done)
+
+	scanner := bufio.NewScanner(c.conn)
+	// SKY reports listing every satellite can be longer than the default token size
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		p, ok := parseTPV(scanner.Bytes())
+		if !ok {
+			continue
+		}
+		c.mu.Lock()
+		// with several receivers attached the same moment can be reported more than once, keep the first
+		if n := len(c.track); n == 0 || p.Time.After(c.track[n-1].Time) {
+			c.track = append(c.track, p)
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	c.err = scanner.Err()
+	if c.err == nil {
+		c.err = io.EOF
+	}
+	c.mu.Unlock()
+}
+
+// Track returns the fixes recorded so far, ordered by time.
+func (c *Client) Track() track.Track {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(track.Track(nil), c.track...)
+}
+
+// Done is closed when the connection to gpsd ends, after which Err reports why.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error that ended the connection, or nil while it's still open.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close disconnects from gpsd.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// tpv is the part of a gpsd TPV (time-position-velocity) report needed for a track point
+type tpv struct {
+	Class  string    `json:"class"`
+	Mode   int       `json:"mode"`
+	Time   time.Time `json:"time"`
+	Lat    *float64  `json:"lat"`
+	Lon    *float64  `json:"lon"`
+	AltMSL *float64  `json:"altMSL"`
+	Alt    *float64  `json:"alt"`
+}
+
+// parseTPV reads a track point from a gpsd report, ok is false for other report classes and for TPV reports
+// without a 2D or 3D fix.
+func parseTPV(line []byte) (p track.Point, ok bool) {
+	var r tpv
+	if err := json.Unmarshal(line, &r); err != nil || r.Class != "TPV" {
+		return track.Point{}, false
+	}
+	if r.Mode < 2 || r.Lat == nil || r.Lon == nil || r.Time.IsZero() {
+		return track.Point{}, false
+	}
+
+	p = track.Point{Lat: *r.Lat, Lon: *r.Lon, Time: r.Time}
+	// altitude only comes with a 3D fix; gpsd 3.20 replaced alt with altMSL and altHAE
+	if r.Mode == 3 {
+		if r.AltMSL != nil {
+			p.Ele = r.AltMSL
+		} else {
+			p.Ele = r.Alt
+		}
+	}
+	return p, true
+}
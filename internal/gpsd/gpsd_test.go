@@ -0,0 +1,102 @@
+package gpsd
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseTPV checks that only TPV reports with a fix become track points.
+func TestParseTPV(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+		ele  float64
+	}{
+		{`{"class":"TPV","mode":3,"time":"2024-05-01T10:00:00.000Z","lat":43.5,"lon":11.25,"altMSL":120.5,"alt":99}`, true, 120.5},
+		{`{"class":"TPV","mode":3,"time":"2024-05-01T10:00:00.000Z","lat":43.5,"lon":11.25,"alt":99}`, true, 99},
+		{`{"class":"TPV","mode":2,"time":"2024-05-01T10:00:00.000Z","lat":43.5,"lon":11.25,"alt":99}`, true, 0},
+		{`{"class":"TPV","mode":1,"time":"2024-05-01T10:00:00.000Z"}`, false, 0},
+		{`{"class":"SKY","satellites":[]}`, false, 0},
+		{`not json`, false, 0},
+	}
+	for _, tt := range tests {
+		p, ok := parseTPV([]byte(tt.line))
+		if ok != tt.ok {
+			t.Errorf("Expected ok %v for %s, got %v", tt.ok, tt.line, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if p.Lat != 43.5 || p.Lon != 11.25 || !p.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected point %+v for %s", p, tt.line)
+		}
+		if (p.Ele == nil) != (tt.ele == 0) || (p.Ele != nil && *p.Ele != tt.ele) {
+			t.Errorf("Expected altitude %g for %s, got %v", tt.ele, tt.line, p.Ele)
+		}
+	}
+}
+
+// TestIsLocal checks that only loopback addresses count as local.
+func TestIsLocal(t *testing.T) {
+	for addr, expected := range map[string]bool{
+		"127.0.0.1:2947":    true,
+		"[::1]:2947":        true,
+		"192.168.1.20:2947": false,
+		"127.0.0.1":         false,
+	} {
+		if got := IsLocal(addr); got != expected {
+			t.Errorf("Expected IsLocal(%q) to be %v, got %v", addr, expected, got)
+		}
+	}
+}
+
+// TestClient checks that the client enables watching and records the fixes it's sent.
+func TestClient(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	commands := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte(`{"class":"VERSION","release":"3.25"}` + "\n"))
+		command, _ := bufio.NewReader(conn).ReadString('\n')
+		commands <- command
+		_, _ = conn.Write([]byte(strings.Join([]string{
+			`{"class":"TPV","mode":2,"time":"2024-05-01T10:00:00Z","lat":43,"lon":11}`,
+			`{"class":"TPV","mode":2,"time":"2024-05-01T10:00:00Z","lat":50,"lon":20}`,
+			`{"class":"TPV","mode":2,"time":"2024-05-01T10:00:01Z","lat":44,"lon":12}`,
+		}, "\n") + "\n"))
+	}()
+
+	c, err := Dial(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if command := <-commands; command != watchCommand {
+		t.Errorf("Expected the watch command, got %q", command)
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the connection to end")
+	}
+	if c.Err() == nil {
+		t.Error("Expected an error once the connection ended")
+	}
+	if trk := c.Track(); len(trk) != 2 || trk[0].Lat != 43 || trk[1].Lat != 44 {
+		t.Errorf("Expected two fixes with the duplicate dropped, got %+v", trk)
+	}
+}
@@ -0,0 +1,46 @@
+// Package hooks runs the user's own commands before and after photos2map does its work, such as to sync the
+// photos first or publish the map afterwards.
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// envPrefix is prepended to the names of the variables describing the run
+const envPrefix = "PHOTOS2MAP_"
+
+// Run runs command with the system shell, passing its output through to stdout and its errors to photos2map's.
+// The command sees photos2map's environment plus each entry of env as a variable named with a PHOTOS2MAP_ prefix
+// and the key in upper case, such as PHOTOS2MAP_OUT_DIR for "out_dir".
+func Run(command string, env map[string]string, stdout io.Writer) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command) // #nosec G204
+	} else {
+		cmd = exec.Command("sh", "-c", command) // #nosec G204
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), Environ(env)...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%q: %w", command, err)
+	}
+	return nil
+}
+
+// Environ returns env as the variables Run sets, sorted by name.
+func Environ(env map[string]string) []string {
+	vars := make([]string, 0, len(env))
+	for key, value := range env {
+		vars = append(vars, envPrefix+strings.ToUpper(key)+"="+value)
+	}
+	sort.Strings(vars)
+	return vars
+}
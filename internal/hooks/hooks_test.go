@@ -0,0 +1,36 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// TestEnviron checks that the run metadata is named with the PHOTOS2MAP_ prefix.
+func TestEnviron(t *testing.T) {
+	vars := Environ(map[string]string{"photos": "12", "out_dir": "out"})
+	if expected := []string{"PHOTOS2MAP_OUT_DIR=out", "PHOTOS2MAP_PHOTOS=12"}; !reflect.DeepEqual(vars, expected) {
+		t.Errorf("Expected %v, got %v", expected, vars)
+	}
+}
+
+// TestRun checks that the command sees the run metadata and that its failure is reported.
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test command is written for sh")
+	}
+	out := filepath.Join(t.TempDir(), "hook.txt")
+	t.Setenv("HOOK_OUT", out)
+	if err := Run(`echo "$PHOTOS2MAP_PHOTOS photos" > "$HOOK_OUT"`, map[string]string{"photos": "12"}, os.Stdout); err != nil {
+		t.Fatalf("Error running the hook: %v", err)
+	}
+	if content, err := os.ReadFile(out); err != nil || string(content) != "12 photos\n" {
+		t.Errorf("Expected the hook to write the photo count, got %q, %v", content, err)
+	}
+
+	if err := Run("exit 3", nil, os.Stdout); err == nil {
+		t.Error("Expected an error for a failing hook")
+	}
+}
@@ -0,0 +1,72 @@
+// Package hotfolder detects files added to a directory, such as photos written by tethering software.
+package hotfolder
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// Folder tracks the files in a directory tree between polls.
+type Folder struct {
+	dir string
+	// sizes holds the size of new files seen in the last poll that may still be being written
+	sizes map[string]int64
+	// done holds the files that were already there or have been returned by Poll
+	done map[string]bool
+}
+
+// New starts tracking dir. Files already in it are never returned by Poll.
+func New(dir string) (*Folder, error) {
+	f := &Folder{dir: dir, sizes: map[string]int64{}, done: map[string]bool{}}
+	err := f.walk(func(path string, size int64) {
+		f.done[path] = true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Poll returns the files added since the folder was created that are complete, in lexical order. A file is
+// considered complete once its size hasn't changed since the previous poll, so a file is returned at the
+// earliest on the second poll after it appears.
+func (f *Folder) Poll() ([]string, error) {
+	var ready []string
+	sizes := map[string]int64{}
+	err := f.walk(func(path string, size int64) {
+		if f.done[path] {
+			return
+		}
+		if prev, ok := f.sizes[path]; ok && prev == size {
+			f.done[path] = true
+			ready = append(ready, path)
+			return
+		}
+		sizes[path] = size
+	})
+	if err != nil {
+		return nil, err
+	}
+	// files that disappeared before they were complete are forgotten
+	f.sizes = sizes
+	return ready, nil
+}
+
+// walk calls fn with the path and size of every regular file under the directory.
+func (f *Folder) walk(fn func(path string, size int64)) error {
+	return filepath.WalkDir(f.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// the file was removed since the directory was read
+			return nil
+		}
+		fn(path, info.Size())
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package hotfolder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestPoll checks that only new files are returned, once they've stopped growing.
+func TestPoll(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	poll := func(f *Folder, expected ...string) {
+		t.Helper()
+		ready, err := f.Poll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range expected {
+			expected[i] = filepath.Join(dir, expected[i])
+		}
+		if !slices.Equal(ready, expected) {
+			t.Errorf("Expected %v, got %v", expected, ready)
+		}
+	}
+
+	write("old.jpg", "old")
+	f, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("new.jpg", "part")
+	write(filepath.Join("session", "b.jpg"), "whole")
+	poll(f)
+	write("new.jpg", "partial")
+	poll(f, filepath.Join("session", "b.jpg"))
+	poll(f, "new.jpg")
+	write("old.jpg", "changed")
+	poll(f)
+	poll(f)
+}
@@ -0,0 +1,126 @@
+// Package logfile writes the log to a file rotated by size, for long-running commands such as live that
+// shouldn't depend on the service manager capturing their output.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Writer appends to a log file, rotating it once it would grow beyond MaxSize bytes: the file is renamed with a .1
+// suffix, older rotations move up a number, and those beyond Backups are deleted.
+type Writer struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open opens the log file at path for appending, creating it if needed. maxSize is the size in bytes the file is
+// rotated at, or 0 to never rotate, and backups the number of rotated files kept.
+func Open(path string, maxSize int64, backups int) (*Writer, error) {
+	w := &Writer{path: path, maxSize: maxSize, backups: backups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends p to the log file, rotating it first if p would take it beyond the maximum size. An entry larger
+// than the maximum size is still written whole to a fresh file.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// open opens the log file for appending and reads its current size.
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) // #nosec G304
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+// rotate moves the log file and its backups up a number, dropping the oldest, and starts a new file.
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if w.backups <= 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+	_ = os.Remove(backup(w.path, w.backups))
+	for i := w.backups - 1; i >= 1; i-- {
+		if err := os.Rename(backup(w.path, i), backup(w.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(w.path, backup(w.path, 1)); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// backup returns the path of the i-th most recent rotation of the log file.
+func backup(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// Hook is a logrus hook copying every entry to a Writer as plain text with full timestamps, leaving the
+// logger's own output, usually the terminal, as it is.
+type Hook struct {
+	w         *Writer
+	formatter log.Formatter
+}
+
+// NewHook returns a hook writing entries to w.
+func NewHook(w *Writer) *Hook {
+	return &Hook{w: w, formatter: &log.TextFormatter{DisableColors: true, FullTimestamp: true}}
+}
+
+// Levels returns all levels, so the file gets every entry the logger lets through.
+func (h *Hook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire writes the entry to the log file.
+func (h *Hook) Fire(entry *log.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.w.Write(line)
+	return err
+}
@@ -0,0 +1,77 @@
+package logfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TestWriterRotate checks that the file is rotated before it grows beyond the maximum size, keeping the given
+// number of backups.
+func TestWriterRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photos2map.log")
+	w, err := Open(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]string{"": "fourth\n", ".1": "third\n", ".2": "second\n"} {
+		if content, err := os.ReadFile(path + name); err != nil || string(content) != expected {
+			t.Errorf("Expected %q in %s, got %q, %v", expected, path+name, content, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected no more than 2 backups, got %v", err)
+	}
+}
+
+// TestWriterAppend checks that an existing file is appended to, counting towards its size.
+func TestWriterAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photos2map.log")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := Open(path, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("later\n")); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "later\n" {
+		t.Errorf("Expected the full file to be replaced without backups, got %q", content)
+	}
+}
+
+// TestHook checks that log entries are copied to the file as plain text.
+func TestHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "photos2map.log")
+	w, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(NewHook(w))
+	logger.Warn("Geotagged photo.jpg")
+
+	content, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(content), `level=warning msg="Geotagged photo.jpg"`) {
+		t.Errorf("Expected the entry in the log file, got %q, %v", content, err)
+	}
+}
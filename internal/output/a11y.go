@@ -0,0 +1,127 @@
+package output
+
+import (
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// a11yOption enables echarts' own accessibility support, which labels the chart container with a generated
+// description of its series for screen readers
+var a11yOption = map[string]interface{}{
+	"enabled": true,
+	"label": map[string]interface{}{
+		"description": "Map of photo locations. Use the arrow keys to pan, plus and minus to zoom, " +
+			"and N or P to step through the photos. A table of all photos follows the map.",
+	},
+}
+
+// a11yJS makes the map usable from the keyboard and adds a table of the points as a fallback view.
+// The focused map pans with the arrow keys, zooms with +/- and steps through the markers with n/p,
+// announcing each marker through a live region. It reads the first series, so it has to run after the
+// point data is in place.
+const a11yJS = `
+	(function (chart) {
+		var dom = chart.getDom();
+		dom.tabIndex = 0;
+		dom.setAttribute('role', 'application');
+		dom.setAttribute('aria-roledescription', 'map');
+		var live = document.createElement('div');
+		live.className = 'photos2map-live';
+		live.setAttribute('aria-live', 'polite');
+		live.style.cssText = 'position:absolute;width:1px;height:1px;overflow:hidden;clip:rect(0 0 0 0);';
+		dom.parentNode.insertBefore(live, dom.nextSibling);
+		function text(html) {
+			var el = document.createElement('div');
+			el.innerHTML = String(html || '').replace(/<br\s*\/?>/g, ', ');
+			return el.textContent;
+		}
+		function markers() {
+			return chart.getOption().series[0].data || [];
+		}
+		var current = -1;
+		function select(i) {
+			var data = markers();
+			if (!data.length) {
+				live.textContent = 'No photos to show.';
+				return;
+			}
+			chart.dispatchAction({type: 'downplay', seriesIndex: 0});
+			current = (i + data.length) % data.length;
+			chart.dispatchAction({type: 'highlight', seriesIndex: 0, dataIndex: current});
+			chart.dispatchAction({type: 'showTip', seriesIndex: 0, dataIndex: current});
+			var d = data[current];
+			live.textContent = 'Photo ' + (current + 1) + ' of ' + data.length + ': ' + text(d.name) + ', ' + text(d.value[2]);
+		}
+		function roam(dx, dy, zoom) {
+			chart.dispatchAction({
+				type: 'geoRoam', componentType: 'geo',
+				dx: dx, dy: dy, zoom: zoom,
+				originX: dom.clientWidth / 2, originY: dom.clientHeight / 2
+			});
+		}
+		dom.addEventListener('keydown', function (e) {
+			var step = 50;
+			switch (e.key) {
+			case 'ArrowLeft': roam(step, 0); break;
+			case 'ArrowRight': roam(-step, 0); break;
+			case 'ArrowUp': roam(0, step); break;
+			case 'ArrowDown': roam(0, -step); break;
+			case '+': case '=': roam(0, 0, 1.25); break;
+			case '-': case '_': roam(0, 0, 0.8); break;
+			case 'n': case 'N': select(current + 1); break;
+			case 'p': case 'P': select(current - 1); break;
+			case 'Escape': chart.dispatchAction({type: 'hideTip'}); break;
+			default: return;
+			}
+			e.preventDefault();
+		});
+		var details = document.createElement('details');
+		details.className = 'photos2map-table';
+		details.style.cssText = 'margin:10px auto;width:' + dom.style.width + ';font:13px sans-serif;';
+		var summary = document.createElement('summary');
+		summary.textContent = 'Photo list (' + markers().length + ' photos)';
+		details.appendChild(summary);
+		var table = document.createElement('table');
+		table.style.cssText = 'border-collapse:collapse;width:100%;';
+		var caption = table.createCaption();
+		caption.textContent = 'Photos shown on the map';
+		caption.style.cssText = 'text-align:left;';
+		var head = table.createTHead().insertRow();
+		['Photo', 'Latitude', 'Longitude', 'Details', ''].forEach(function (label) {
+			var th = document.createElement('th');
+			th.scope = 'col';
+			th.textContent = label;
+			th.style.cssText = 'text-align:left;border-bottom:1px solid #999;padding:2px 6px;';
+			head.appendChild(th);
+		});
+		var body = table.createTBody();
+		markers().forEach(function (d, i) {
+			var row = body.insertRow();
+			var th = document.createElement('th');
+			th.scope = 'row';
+			th.style.cssText = 'text-align:left;font-weight:normal;';
+			th.innerHTML = d.name;
+			row.appendChild(th);
+			row.insertCell().textContent = Number(d.value[1]).toFixed(5);
+			row.insertCell().textContent = Number(d.value[0]).toFixed(5);
+			row.insertCell().innerHTML = d.value[2] || '';
+			var btn = document.createElement('button');
+			btn.type = 'button';
+			btn.textContent = 'Show on map';
+			btn.addEventListener('click', function () {
+				dom.focus();
+				select(i);
+			});
+			row.insertCell().appendChild(btn);
+			Array.prototype.forEach.call(row.cells, function (cell) {
+				cell.style.padding = '2px 6px';
+			});
+		});
+		details.appendChild(table);
+		live.parentNode.insertBefore(details, live.nextSibling);
+	})(%MY_ECHARTS%);
+`
+
+// addA11y adds keyboard navigation and the table view of the points to the map.
+func addA11y(geo *charts.Geo) {
+	geo.AddJSFuncs(a11yJS)
+}
@@ -0,0 +1,35 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// TestAddA11y checks that the keyboard navigation and table scripts are added.
+func TestAddA11y(t *testing.T) {
+	geo := charts.NewGeo()
+	addA11y(geo)
+
+	var js string
+	for _, fn := range geo.JSFunctions.Fns {
+		js += string(fn)
+	}
+	for _, expected := range []string{"keydown", "aria-live", "photos2map-table", "geoRoam"} {
+		if !strings.Contains(js, expected) {
+			t.Errorf("Expected the accessibility script to contain %q, got %s", expected, js)
+		}
+	}
+}
+
+// TestMapVisitorAria checks that the echarts aria option is enabled in the chart option.
+func TestMapVisitorAria(t *testing.T) {
+	geo := charts.NewGeo()
+	geo.Accept(mapVisitor{})
+
+	aria, ok := geo.JSON()["aria"].(map[string]interface{})
+	if !ok || aria["enabled"] != true {
+		t.Errorf("Expected aria to be enabled, got %v", geo.JSON()["aria"])
+	}
+}
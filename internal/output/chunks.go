@@ -0,0 +1,185 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// chunk is one of the maps a large set of points is split into
+type chunk struct {
+	Name   string
+	Dir    string
+	Points []geodata.Point
+}
+
+// indexTemplate is the page linking to the maps the points were split into
+var indexTemplate = template.Must(template.New("index").Funcs(template.FuncMap{"dateRange": dateRange}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 40em; padding: 0 1em; }
+li { margin: 0.5em 0; }
+.details { color: #555; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Total}} photos, split into {{len .Chunks}} maps by region.</p>
+<ul>
+{{- range .Chunks}}
+<li><a href="{{.Dir}}/map.html">{{.Name}}</a> <span class="details">{{len .Points}} photos{{with dateRange .Points}}, {{.}}{{end}}</span></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+// GenerateMaps is GenerateMap for more points than one page displays smoothly. With more than maxPoints points,
+// they are split by continent, and continents with more than maxPoints further into areas with about as many
+// photos each. Each part is mapped into a subdirectory of Dir, and map.html becomes an index linking to them.
+// With maxPoints 0 or fewer points, a single map is written as by GenerateMap.
+func GenerateMaps(gpsData []geodata.Point, mapOpts MapOptions, maxPoints int) {
+	if maxPoints <= 0 || len(gpsData) <= maxPoints {
+		GenerateMap(gpsData, mapOpts)
+		return
+	}
+
+	title := mapOpts.Title
+	if title == "" {
+		title = defaultTitle
+	}
+	chunks := splitPoints(gpsData, maxPoints)
+	for _, c := range chunks {
+		dir := filepath.Join(Dir, c.Dir)
+		chunkOpts := mapOpts
+		chunkOpts.Title = title + ": " + c.Name
+		if mapOpts.BaseURL != "" {
+			chunkOpts.BaseURL = strings.TrimSuffix(mapOpts.BaseURL, "/") + "/" + c.Dir + "/"
+		}
+		if host := mapOpts.AssetsHost; host != "" && !strings.HasPrefix(host, "/") {
+			if u, err := url.Parse(host); err == nil && u.Scheme == "" {
+				// relative to the index rather than the maps a directory further down
+				chunkOpts.AssetsHost = "../" + host
+			}
+		}
+		generateMap(c.Points, chunkOpts, dir, "map.html")
+	}
+
+	var index bytes.Buffer
+	err := indexTemplate.Execute(&index, struct {
+		Title  string
+		Total  int
+		Chunks []chunk
+	}{title, len(gpsData), chunks})
+	if err != nil {
+		log.Fatalf("Error rendering map index: %v", err)
+	}
+	path := outputPath("map.html")
+	if err := writeFile(path, index.Bytes()); err != nil {
+		log.Fatalf("Error creating map index: %v", err)
+	}
+	log.Printf("%d HTML maps generated successfully, see %s for the index.", len(chunks), path)
+}
+
+// splitPoints groups the points by continent, in the order of geodata.Continents, and splits continents with
+// more than maxPoints points into numbered areas.
+func splitPoints(gpsData []geodata.Point, maxPoints int) []chunk {
+	byContinent := map[string][]geodata.Point{}
+	for _, p := range gpsData {
+		continent := geodata.Continent(p.Lat, p.Lon)
+		byContinent[continent] = append(byContinent[continent], p)
+	}
+
+	var chunks []chunk
+	for _, continent := range geodata.Continents {
+		points := byContinent[continent]
+		if len(points) == 0 {
+			continue
+		}
+		dir := strings.ToLower(strings.ReplaceAll(continent, " ", "-"))
+		parts := splitArea(points, maxPoints)
+		if len(parts) == 1 {
+			chunks = append(chunks, chunk{Name: continent, Dir: dir, Points: points})
+			continue
+		}
+		for i, part := range parts {
+			chunks = append(chunks, chunk{
+				Name:   fmt.Sprintf("%s %d", continent, i+1),
+				Dir:    fmt.Sprintf("%s-%d", dir, i+1),
+				Points: part,
+			})
+		}
+	}
+	return chunks
+}
+
+// splitArea halves the points across the longer side of their bounding box until no part has more than
+// maxPoints, keeping each part's points in their original order.
+func splitArea(points []geodata.Point, maxPoints int) [][]geodata.Point {
+	if len(points) <= maxPoints {
+		return [][]geodata.Point{points}
+	}
+
+	minLat, maxLat, minLon, maxLon := points[0].Lat, points[0].Lat, points[0].Lon, points[0].Lon
+	for _, p := range points {
+		minLat, maxLat = min(minLat, p.Lat), max(maxLat, p.Lat)
+		minLon, maxLon = min(minLon, p.Lon), max(maxLon, p.Lon)
+	}
+	coord := func(p geodata.Point) float64 { return p.Lat }
+	if maxLon-minLon > maxLat-minLat {
+		coord = func(p geodata.Point) float64 { return p.Lon }
+	}
+
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return coord(points[order[a]]) < coord(points[order[b]]) })
+	first := make([]bool, len(points))
+	for _, i := range order[:len(points)/2] {
+		first[i] = true
+	}
+	var low, high []geodata.Point
+	for i, p := range points {
+		if first[i] {
+			low = append(low, p)
+		} else {
+			high = append(high, p)
+		}
+	}
+
+	return append(splitArea(low, maxPoints), splitArea(high, maxPoints)...)
+}
+
+// dateRange describes when the points were taken, like defaultDescription.
+func dateRange(points []geodata.Point) string {
+	var first, last string
+	for _, p := range points {
+		if p.Time.IsZero() {
+			continue
+		}
+		day := p.Time.Format("2006-01-02")
+		if first == "" || day < first {
+			first = day
+		}
+		if last == "" || day > last {
+			last = day
+		}
+	}
+	if first == last {
+		return first
+	}
+	return first + " to " + last
+}
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestSplitPoints checks that points are grouped by continent and large continents split into numbered areas.
+func TestSplitPoints(t *testing.T) {
+	var gpsData []geodata.Point
+	for i := 0; i < 5; i++ {
+		// along the Rhine, from south to north
+		gpsData = append(gpsData, geodata.Point{Name: fmt.Sprintf("rhine%d", i), Lat: 47.5 + float64(i), Lon: 7.6})
+	}
+	gpsData = append(gpsData, geodata.Point{Name: "nyc", Lat: 40.7128, Lon: -74.0060})
+
+	chunks := splitPoints(gpsData, 2)
+	var got []string
+	for _, c := range chunks {
+		var names []string
+		for _, p := range c.Points {
+			names = append(names, p.Name)
+		}
+		got = append(got, c.Name+" ("+c.Dir+"): "+strings.Join(names, ","))
+	}
+	expected := []string{
+		"North America (north-america): nyc",
+		"Europe 1 (europe-1): rhine0,rhine1",
+		"Europe 2 (europe-2): rhine2",
+		"Europe 3 (europe-3): rhine3,rhine4",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected chunks:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+// TestGenerateMaps checks that a map is written per region with an index linking to them.
+func TestGenerateMaps(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Name: "London", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "Paris", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)},
+		{Name: "Tokyo", Lat: 35.6762, Lon: 139.6503, Time: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	defer os.Remove("out/map.html")
+	defer os.RemoveAll("out/europe")
+	defer os.RemoveAll("out/asia")
+
+	GenerateMaps(gpsData, MapOptions{Title: "Trips", AssetsHost: "assets/"}, 2)
+
+	index, err := os.ReadFile("out/map.html")
+	if err != nil {
+		t.Fatalf("Expected an index page: %v", err)
+	}
+	for _, s := range []string{"<title>Trips</title>", `<a href="europe/map.html">Europe</a>`, "2 photos, 2024-05-01 to 2024-05-02", `<a href="asia/map.html">Asia</a>`} {
+		if !strings.Contains(string(index), s) {
+			t.Errorf("Expected the index to contain %q, got:\n%s", s, index)
+		}
+	}
+
+	page, err := os.ReadFile(filepath.Join("out", "europe", "map.html"))
+	if err != nil {
+		t.Fatalf("Expected a map of Europe: %v", err)
+	}
+	if !strings.Contains(string(page), "<title>Trips: Europe</title>") || !strings.Contains(string(page), `src="../assets/echarts.min.js"`) {
+		t.Errorf("Expected the map of Europe to be titled after it and load ECharts from the index's assets")
+	}
+	if _, err := os.Stat(filepath.Join("out", "asia", pointsFile)); err != nil {
+		t.Errorf("Expected the points of Asia next to its map: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/types"
+)
+
+// clusterCell is the size in degrees of the grid cells markers are clustered in at zoom 1, about 40 pixels of
+// the world map
+const clusterCell = 10.0
+
+// clusterMaxLevel is the zoom level, each doubling the zoom, from which markers are no longer clustered
+const clusterMaxLevel = 16
+
+// clusterColor is the color of the cluster markers, set apart from the photos' markers
+const clusterColor = "#1565c0"
+
+// clusterSize grows the cluster markers with the number of photos in them, up to a limit
+const clusterSize = `function (value, params) { return Math.min(56, 20 + 4 * Math.log2(params.data.count)); }`
+
+// clusterLabel labels a cluster marker with the number of photos in it
+const clusterLabel = `function (params) { return params.data.count; }`
+
+// clusterJS replaces the markers sharing a grid cell with a cluster marker counting them whenever the map is
+// zoomed, the cells halving in size every time the zoom doubles, and zooms in on a cluster when it's clicked. A
+// search or legend filter, when present, is applied first through photos2map.matches.
+const clusterJS = `
+	(function (chart) {
+		var full = chart.getOption().series[0].data;
+		var current = -1;
+		photos2map.cluster = function (force) {
+			var zoom = chart.getOption().geo[0].zoom || 1;
+			var level = Math.min(%d, Math.max(0, Math.floor(Math.log2(zoom))));
+			if (level === current && !force) {
+				return;
+			}
+			current = level;
+			var data = photos2map.matches ? full.filter(photos2map.matches) : full;
+			var markers = data, clusters = [];
+			if (level < %d) {
+				var cell = %g / Math.pow(2, level), cells = {}, keys = [];
+				data.forEach(function (d) {
+					var key = Math.floor(d.value[0] / cell) + ',' + Math.floor(d.value[1] / cell);
+					if (!cells[key]) {
+						cells[key] = [];
+						keys.push(key);
+					}
+					cells[key].push(d);
+				});
+				markers = [];
+				keys.forEach(function (key) {
+					var members = cells[key];
+					if (members.length === 1) {
+						markers.push(members[0]);
+						return;
+					}
+					var lon = 0, lat = 0;
+					members.forEach(function (d) { lon += d.value[0]; lat += d.value[1]; });
+					clusters.push({name: members.length + ' photos', value: [lon / members.length, lat / members.length, ''], count: members.length});
+				});
+			}
+			chart.setOption({series: [{name: 'geo', data: markers}, {name: 'clusters', data: clusters}]});
+		};
+		chart.on('click', {seriesName: 'clusters'}, function (params) {
+			var zoom = chart.getOption().geo[0].zoom || 1;
+			chart.setOption({geo: {center: params.value.slice(0, 2), zoom: zoom * 4}});
+			photos2map.cluster(false);
+		});
+		photos2map.cluster(true);
+		chart.on('georoam', function () { photos2map.cluster(false); });
+		window.addEventListener('hashchange', function () { photos2map.cluster(false); });
+	})(%%MY_ECHARTS%%);
+`
+
+// addClusters groups nearby markers into cluster markers that split up as the map is zoomed in, so maps with
+// thousands of photos stay readable. It must be added after the search box, legend and permalinks, whose
+// filters and view it applies, and replaces the level of detail thinning.
+func addClusters(geo *charts.Geo) {
+	geo.MultiSeries = append(geo.MultiSeries, charts.SingleSeries{
+		Name:        "clusters",
+		Type:        types.ChartScatter,
+		CoordSystem: types.ChartGeo,
+		SymbolSize:  opts.FuncOpts(clusterSize),
+		ItemStyle:   &opts.ItemStyle{Color: clusterColor, Opacity: 0.85},
+		Label: &opts.Label{
+			Show:      opts.Bool(true),
+			Position:  "inside",
+			Color:     "#ffffff",
+			Formatter: string(opts.FuncOpts(clusterLabel)),
+		},
+		Data: []interface{}{},
+	})
+	geo.AddJSFuncs(`var photos2map = photos2map || {};`,
+		fmt.Sprintf(clusterJS, clusterMaxLevel, clusterMaxLevel, clusterCell))
+}
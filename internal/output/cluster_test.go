@@ -0,0 +1,39 @@
+package output
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateMapClusters checks that clustering adds the cluster series and script in place of the level of
+// detail thinning, and that the filters recluster the markers they show.
+func TestGenerateMapClusters(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Name: "Image1", Lat: 48.0, Lon: 2.0},
+		{Name: "Image2", Lat: 48.01, Lon: 2.01},
+	}
+	defer os.Remove("out/map.html")
+	defer os.Remove("out/" + previewFile)
+
+	GenerateMap(gpsData, MapOptions{Inline: true, Search: true, ThinAbove: 1, Cluster: true})
+	page, err := os.ReadFile("out/map.html")
+	if err != nil {
+		t.Fatalf("Error reading map.html: %v", err)
+	}
+	for _, expected := range []string{
+		`"name":"clusters","type":"scatter","coordinateSystem":"geo"`,
+		"var cell = 10 / Math.pow(2, level)",
+		"photos2map.cluster(true);",
+		"})(goecharts_photos2map);",
+	} {
+		if !strings.Contains(string(page), expected) {
+			t.Errorf("Expected the map to contain %s, got:\n%s", expected, page)
+		}
+	}
+	if strings.Contains(string(page), "photos2map.lod = ") {
+		t.Errorf("Expected no thinning with clustering, got:\n%s", page)
+	}
+}
@@ -0,0 +1,198 @@
+package output
+
+import (
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// controlsJS defines the toolbar and distance helpers shared by the map controls. Distances are shown in miles and
+// feet when photos2map.imperial is set, and distanceUnit gives the length in meters of the unit a distance is
+// best rounded in. It is only added to the page when at least one control is enabled.
+const controlsJS = `
+	var photos2map = photos2map || {};
+	photos2map.toolbar = function (chart) {
+		var dom = chart.getDom();
+		var bar = dom.querySelector('.photos2map-toolbar');
+		if (!bar) {
+			dom.style.position = 'relative';
+			bar = document.createElement('div');
+			bar.className = 'photos2map-toolbar';
+			bar.style.cssText = 'position:absolute;top:40px;right:10px;z-index:10;display:flex;flex-direction:column;gap:4px;';
+			dom.appendChild(bar);
+		}
+		return bar;
+	};
+	photos2map.button = function (chart, label, title, onClick) {
+		var btn = document.createElement('button');
+		btn.type = 'button';
+		btn.textContent = label;
+		btn.title = title;
+		btn.setAttribute('aria-label', title);
+		btn.style.cssText = 'min-width:32px;height:32px;background:#fff;border:1px solid #999;border-radius:4px;cursor:pointer;';
+		btn.addEventListener('click', onClick);
+		photos2map.toolbar(chart).appendChild(btn);
+		return btn;
+	};
+	photos2map.distance = function (a, b) {
+		var rad = Math.PI / 180;
+		var dLat = (b[1] - a[1]) * rad;
+		var dLon = (b[0] - a[0]) * rad;
+		var h = Math.sin(dLat / 2) * Math.sin(dLat / 2) +
+			Math.cos(a[1] * rad) * Math.cos(b[1] * rad) * Math.sin(dLon / 2) * Math.sin(dLon / 2);
+		return 2 * 6371000 * Math.asin(Math.min(1, Math.sqrt(h)));
+	};
+	photos2map.formatDistance = function (meters) {
+		if (photos2map.imperial) {
+			var feet = meters / 0.3048;
+			return feet >= 5280 ? (feet / 5280).toFixed(feet >= 52800 ? 0 : 1) + ' mi' : Math.round(feet) + ' ft';
+		}
+		return meters >= 1000 ? (meters / 1000).toFixed(meters >= 10000 ? 0 : 1) + ' km' : Math.round(meters) + ' m';
+	};
+	photos2map.distanceUnit = function (meters) {
+		if (photos2map.imperial) {
+			return meters >= 1609.344 ? 1609.344 : 0.3048;
+		}
+		return 1;
+	};
+`
+
+// fullscreenJS toggles the map container in and out of fullscreen, resizing the chart to match
+const fullscreenJS = `
+	(function (chart) {
+		var dom = chart.getDom();
+		photos2map.button(chart, '⛶', 'Toggle fullscreen', function () {
+			if (document.fullscreenElement) {
+				document.exitFullscreen();
+			} else {
+				dom.requestFullscreen();
+			}
+		});
+		var size = {width: dom.style.width, height: dom.style.height};
+		document.addEventListener('fullscreenchange', function () {
+			if (document.fullscreenElement === dom) {
+				dom.style.width = '100vw';
+				dom.style.height = '100vh';
+			} else {
+				dom.style.width = size.width;
+				dom.style.height = size.height;
+			}
+			chart.resize();
+		});
+	})(%MY_ECHARTS%);
+`
+
+// scaleBarJS draws a scale bar in the bottom-left corner, recomputed whenever the map is panned or zoomed, with a
+// round length in the distance units shown
+const scaleBarJS = `
+	(function (chart) {
+		var dom = chart.getDom();
+		dom.style.position = 'relative';
+		var bar = document.createElement('div');
+		bar.className = 'photos2map-scale';
+		bar.style.cssText = 'position:absolute;left:10px;bottom:10px;z-index:10;border:2px solid #333;border-top:none;background:rgba(255,255,255,0.7);font:11px sans-serif;text-align:center;';
+		dom.appendChild(bar);
+		var maxWidth = 100;
+		function update() {
+			var y = dom.clientHeight - 20;
+			var a = chart.convertFromPixel({geoIndex: 0}, [10, y]);
+			var b = chart.convertFromPixel({geoIndex: 0}, [10 + maxWidth, y]);
+			if (!a || !b) {
+				return;
+			}
+			var maxMeters = photos2map.distance(a, b);
+			var unit = photos2map.distanceUnit(maxMeters);
+			var max = maxMeters / unit;
+			var pow = Math.pow(10, Math.floor(Math.log10(max)));
+			var meters = unit * ([5, 2, 1].map(function (m) { return m * pow; }).find(function (m) { return m <= max; }) || pow);
+			bar.style.width = Math.round(maxWidth * meters / maxMeters) + 'px';
+			bar.textContent = photos2map.formatDistance(meters);
+		}
+		chart.on('georoam', update);
+		chart.on('finished', update);
+		update();
+	})(%MY_ECHARTS%);
+`
+
+// measureJS lets the user click out a polyline on the map and shows its total length
+const measureJS = `
+	(function (chart) {
+		var active = false;
+		var coords = [];
+		var btn = photos2map.button(chart, '📏', 'Measure distance', function () {
+			active = !active;
+			coords = [];
+			btn.style.background = active ? '#cde' : '#fff';
+			draw();
+		});
+		function draw() {
+			var lines = [];
+			var total = 0;
+			for (var i = 1; i < coords.length; i++) {
+				total += photos2map.distance(coords[i - 1], coords[i]);
+				lines.push({coords: [coords[i - 1], coords[i]]});
+			}
+			chart.setOption({series: [{
+				id: 'photos2map-measure', name: 'measure', type: 'lines', coordinateSystem: 'geo', silent: true,
+				lineStyle: {color: '#1565c0', width: 3, type: 'dotted'}, data: lines
+			}]});
+			btn.textContent = coords.length > 1 ? photos2map.formatDistance(total) : '📏';
+		}
+		chart.getZr().on('click', function (e) {
+			if (!active) {
+				return;
+			}
+			var coord = chart.convertFromPixel({geoIndex: 0}, [e.offsetX, e.offsetY]);
+			if (coord) {
+				coords.push(coord);
+				draw();
+			}
+		});
+	})(%MY_ECHARTS%);
+`
+
+// locateJS centers the map on the viewer's current position and marks it
+const locateJS = `
+	(function (chart) {
+		if (!navigator.geolocation) {
+			return;
+		}
+		photos2map.button(chart, '⌖', 'Show my location', function () {
+			navigator.geolocation.getCurrentPosition(function (pos) {
+				var here = [pos.coords.longitude, pos.coords.latitude];
+				chart.setOption({
+					geo: {center: here, zoom: 8},
+					series: [{
+						id: 'photos2map-locate', name: 'My location', type: 'effectScatter', coordinateSystem: 'geo',
+						itemStyle: {color: '#1565c0'}, data: [{name: 'My location', value: here.concat([''])}]
+					}]
+				});
+			}, function (err) {
+				alert('Unable to determine your location: ' + err.message);
+			});
+		});
+	})(%MY_ECHARTS%);
+`
+
+// addControls injects the JavaScript for each map control enabled in mapOpts, showing distances in mapOpts.Units.
+func addControls(geo *charts.Geo, mapOpts MapOptions) {
+	var fns []string
+	if mapOpts.Fullscreen {
+		fns = append(fns, fullscreenJS)
+	}
+	if mapOpts.ScaleBar {
+		fns = append(fns, scaleBarJS)
+	}
+	if mapOpts.Measure {
+		fns = append(fns, measureJS)
+	}
+	if mapOpts.Locate {
+		fns = append(fns, locateJS)
+	}
+	if len(fns) == 0 {
+		return
+	}
+
+	if mapOpts.Units == UnitsImperial {
+		fns = append([]string{`photos2map.imperial = true;`}, fns...)
+	}
+	geo.AddJSFuncs(append([]string{controlsJS}, fns...)...)
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// TestAddControls checks that only the enabled controls are injected into the page.
+func TestAddControls(t *testing.T) {
+	geo := charts.NewGeo()
+	addControls(geo, MapOptions{})
+	if len(geo.JSFunctions.Fns) != 0 {
+		t.Errorf("Expected no controls, got %d JS functions", len(geo.JSFunctions.Fns))
+	}
+
+	geo = charts.NewGeo()
+	addControls(geo, MapOptions{Fullscreen: true, Measure: true})
+	var js string
+	for _, fn := range geo.JSFunctions.Fns {
+		js += string(fn)
+	}
+	if !strings.Contains(js, "photos2map.toolbar") {
+		t.Errorf("Expected shared control helpers to be injected")
+	}
+	if !strings.Contains(js, "requestFullscreen") || !strings.Contains(js, "photos2map-measure") {
+		t.Errorf("Expected fullscreen and measure controls to be injected")
+	}
+	if strings.Contains(js, "photos2map-scale") || strings.Contains(js, "geolocation") {
+		t.Errorf("Expected scale bar and locate controls to be omitted")
+	}
+}
+
+// TestAddControlsImperial checks that the controls are told to show imperial distances.
+func TestAddControlsImperial(t *testing.T) {
+	for units, expected := range map[string]bool{UnitsMetric: false, UnitsImperial: true} {
+		geo := charts.NewGeo()
+		addControls(geo, MapOptions{ScaleBar: true, Units: units})
+		var js string
+		for _, fn := range geo.JSFunctions.Fns {
+			js += string(fn)
+		}
+		if strings.Contains(js, "photos2map.imperial = true;") != expected {
+			t.Errorf("Expected imperial distances %v with %s units", expected, units)
+		}
+	}
+}
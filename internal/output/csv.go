@@ -0,0 +1,82 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// myMapsHeader are the leading columns Google My Maps recognizes on import: the title column and the
+// latitude/longitude position columns
+var myMapsHeader = []string{"Name", "Latitude", "Longitude", "Description"}
+
+// myMapsColumn is a named value of a point in Google My Maps' data table
+type myMapsColumn struct {
+	name  string
+	value string
+}
+
+// myMapsColumns returns a point's columns: the myMapsHeader ones, followed by the details as separate columns
+// so they can be filtered and styled by in My Maps, with the altitude in the given units.
+func myMapsColumns(p geodata.Point, units string) []myMapsColumn {
+	columns := []myMapsColumn{
+		{"Name", p.Name},
+		{"Latitude", fmt.Sprintf("%f", p.Lat)},
+		{"Longitude", fmt.Sprintf("%f", p.Lon)},
+		{"Description", strings.Join(pointDetails(p, units), "\n")},
+		{"Taken", ""},
+		{"Altitude", ""},
+		{"Heading", ""},
+		{"Camera", p.Camera()},
+		{"Lens", p.Lens},
+		{"ID", p.ID},
+	}
+	if !p.Time.IsZero() {
+		columns[4].value = p.Time.Format("2006-01-02 15:04:05")
+	}
+	if p.Ele != nil {
+		columns[5].value = altitudeValue(*p.Ele, units)
+	}
+	if p.Heading != nil {
+		columns[6].value = fmt.Sprintf("%.0f", *p.Heading)
+	}
+	return columns
+}
+
+// GenerateCSV creates a CSV file for importing into Google My Maps, saved to "mymaps.csv", or written to out if it
+// isn't nil, with altitudes in the given units.
+func GenerateCSV(gpsData []geodata.Point, units string, out io.Writer) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	for i, p := range gpsData {
+		columns := myMapsColumns(p, units)
+		if i == 0 {
+			header := make([]string, len(columns))
+			for j, c := range columns {
+				header[j] = c.name
+			}
+			_ = w.Write(header)
+		}
+		row := make([]string, len(columns))
+		for j, c := range columns {
+			row[j] = c.value
+		}
+		_ = w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatalf("Error writing CSV file: %v", err)
+	}
+	if err := writeTo(out, outputPath("mymaps.csv"), b.Bytes()); err != nil {
+		log.Fatalf("Error writing CSV file: %v", err)
+	}
+	if out == nil {
+		log.Println("CSV file generated successfully.")
+	}
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateCSV checks the My Maps columns and that details with newlines stay in one field.
+func TestGenerateCSV(t *testing.T) {
+	ele := 35.0
+	GenerateCSV([]geodata.Point{
+		{ID: "3f2a9c1b7d4e", Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele, Make: "NIKON", Model: "COOLPIX P6000"},
+		{Name: "Image2, Paris", Lat: 48.8566, Lon: 2.3522},
+	}, UnitsMetric, nil)
+	defer os.Remove("out/mymaps.csv")
+
+	file, err := os.Open("out/mymaps.csv")
+	if err != nil {
+		t.Fatalf("Expected mymaps.csv to be generated: %v", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("mymaps.csv is not valid CSV: %v", err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %v", rows)
+	}
+	if strings.Join(rows[0], ",") != "Name,Latitude,Longitude,Description,Taken,Altitude,Heading,Camera,Lens,ID" {
+		t.Errorf("Unexpected header %v", rows[0])
+	}
+	if rows[1][1] != "51.507400" || rows[1][2] != "-0.127600" || rows[1][5] != "35" || rows[1][7] != "NIKON COOLPIX P6000" || rows[1][9] != "3f2a9c1b7d4e" {
+		t.Errorf("Unexpected row %v", rows[1])
+	}
+	if rows[1][3] != "Taken: 2024-05-01 10:00:00\nAltitude: 35 m\nCamera: NIKON COOLPIX P6000" {
+		t.Errorf("Unexpected description %q", rows[1][3])
+	}
+	if rows[2][0] != "Image2, Paris" || rows[2][4] != "" {
+		t.Errorf("Unexpected row %v", rows[2])
+	}
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Formats are the output formats photos2map writes, the valid values of --output
+var Formats = []string{"html", "gpx", "geojson", "json", "shapefile", "gpkg", "fit", "pdf", "strava", "komoot", "umap", "felt", "mymaps", "mymaps-csv"}
+
+// Dir is the directory the outputs are written to. It's created, along with any missing parents, when the first
+// file is written.
+var Dir = "out"
+
+// Name is the base name, without extension, of the main output file, such as "output" of output.gpx and "map" of
+// map.html, or empty to keep each format's own name. The files of import presets, such as umap.geojson, are
+// prefixed with it instead so they don't replace the main output of the same type, and files alongside the main
+// one, such as a map's points.js, keep their name.
+var Name string
+
+// written holds the paths of the files written so far, see Written
+var written struct {
+	mu    sync.Mutex
+	paths []string
+	seen  map[string]bool
+}
+
+// Written returns the paths of the files written so far, each once in the order first written, so callers can
+// pass them on.
+func Written() []string {
+	written.mu.Lock()
+	defer written.mu.Unlock()
+	return append([]string(nil), written.paths...)
+}
+
+// outputPath returns the path in Dir of the output file with the given name, named after Name if set, see Name.
+func outputPath(file string) string {
+	if Name != "" {
+		ext := filepath.Ext(file)
+		switch strings.TrimSuffix(file, ext) {
+		case "output", "map":
+			file = Name + ext
+		default:
+			file = Name + "-" + file
+		}
+	}
+	return filepath.Join(Dir, file)
+}
+
+// writeTo writes data to w if it isn't nil, such as stdout for pipelines, and to the file at path otherwise.
+func writeTo(w io.Writer, path string, data []byte) error {
+	if w != nil {
+		_, err := w.Write(data)
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// writeFile writes data to the file at path with replaceFile, and adds it to the files Written.
+func writeFile(path string, data []byte) error {
+	if err := replaceFile(path, data); err != nil {
+		return err
+	}
+
+	written.mu.Lock()
+	defer written.mu.Unlock()
+	if !written.seen[path] {
+		if written.seen == nil {
+			written.seen = make(map[string]bool)
+		}
+		written.seen[path] = true
+		written.paths = append(written.paths, path)
+	}
+	return nil
+}
+
+// replaceFile writes data to a temporary file next to path and renames it into place, so an interrupted run
+// leaves either the previous file or the complete new one, never a truncated one. The directory is created if
+// it doesn't exist yet.
+func replaceFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil { // #nosec G301
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil { // #nosec G302
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,62 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestWriteFile checks that the file is replaced with the new contents and no temporary file is left behind.
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.gpx")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFile(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("Expected the new contents, got %q, %v", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v, %v", info.Mode().Perm(), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the output file, got %v", entries)
+	}
+}
+
+// TestOutputPath checks that Name replaces the base name of the main output file and prefixes preset files, and
+// that files are written into a missing Dir and listed once.
+func TestOutputPath(t *testing.T) {
+	defer func(dir, name string) { Dir, Name = dir, name }(Dir, Name)
+	Dir = filepath.Join(t.TempDir(), "exports", "2024")
+
+	if got, want := outputPath("output.gpx"), filepath.Join(Dir, "output.gpx"); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	Name = "trip"
+	path := outputPath("output.gpx")
+	if want := filepath.Join(Dir, "trip.gpx"); path != want {
+		t.Errorf("Expected %s, got %s", want, path)
+	}
+	if got, want := outputPath("umap.geojson"), filepath.Join(Dir, "trip-umap.geojson"); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	for range 2 {
+		if err := writeFile(path, []byte("gpx")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(slices.DeleteFunc(Written(), func(p string) bool { return p != path })); n != 1 {
+		t.Errorf("Expected %s to be listed once, got %d times in %v", path, n, Written())
+	}
+}
@@ -0,0 +1,187 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// fitEpoch is the start of FIT timestamps, which count seconds from it
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// fitNameSize is the size in bytes of the course and course point names, including the terminating zero. Devices
+// show only the first 15 or so characters of either.
+const fitNameSize = 16
+
+// fitCRCTable is the table of the CRC-16 the FIT protocol checks the header and the file with
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// The FIT base types of the fields written
+const (
+	fitEnum   = 0x00
+	fitUint8  = 0x02
+	fitUint16 = 0x84
+	fitSint32 = 0x85
+	fitUint32 = 0x86
+	fitString = 0x07
+)
+
+// fitField is a field of a FIT message definition: its number in the FIT profile, size in bytes and base type.
+type fitField struct {
+	num, size, baseType byte
+}
+
+// fitMessage is a FIT message definition, the message's number in the FIT profile and the fields written to it
+type fitMessage struct {
+	global uint16
+	fields []fitField
+}
+
+// The messages of a course file, each with the fields in the order their values are written
+var (
+	// type, manufacturer, product and time created
+	fitFileID = fitMessage{0, []fitField{{0, 1, fitEnum}, {1, 2, fitUint16}, {2, 2, fitUint16}, {4, 4, fitUint32}}}
+	// sport and name
+	fitCourse = fitMessage{31, []fitField{{4, 1, fitEnum}, {5, fitNameSize, fitString}}}
+	// timestamp, event, event type and event group
+	fitEvent = fitMessage{21, []fitField{{253, 4, fitUint32}, {0, 1, fitEnum}, {1, 1, fitEnum}, {4, 1, fitUint8}}}
+	// timestamp, position, altitude and distance
+	fitRecord = fitMessage{20, []fitField{{253, 4, fitUint32}, {0, 4, fitSint32}, {1, 4, fitSint32}, {2, 2, fitUint16}, {5, 4, fitUint32}}}
+	// message index, timestamp, position, distance, type and name
+	fitCoursePoint = fitMessage{32, []fitField{{254, 2, fitUint16}, {1, 4, fitUint32}, {2, 4, fitSint32}, {3, 4, fitSint32}, {4, 4, fitUint32}, {5, 1, fitEnum}, {6, fitNameSize, fitString}}}
+	// timestamp, start time, start and end position, elapsed and timer time, and distance
+	fitLap = fitMessage{19, []fitField{{253, 4, fitUint32}, {2, 4, fitUint32}, {3, 4, fitSint32}, {4, 4, fitSint32}, {5, 4, fitSint32}, {6, 4, fitSint32}, {7, 4, fitUint32}, {8, 4, fitUint32}, {9, 4, fitUint32}}}
+)
+
+// GenerateFIT creates a Garmin FIT course saved to "output.fit", for watches and bike computers that handle FIT
+// courses better than GPX. The course runs through the points in capture order, with the same points left out as
+// for activity uploads, see activityPoints, and has a course point named after each photo so the device can
+// announce it.
+func GenerateFIT(gpsData []geodata.Point) {
+	if err := writeFile(outputPath("output.fit"), fitCourseFile(activityPoints(gpsData))); err != nil {
+		log.Fatalf("Error writing FIT file: %v", err)
+	}
+	log.Println("FIT course generated successfully.")
+}
+
+// fitCourseFile returns a FIT course file through points, which are in capture order with increasing times. Its
+// creation time is that of the last photo, so the same photos give the same file.
+func fitCourseFile(points []geodata.Point) []byte {
+	var body bytes.Buffer
+	var local byte
+	define := func(m fitMessage) byte {
+		// a definition message, for little-endian values
+		body.WriteByte(0x40 | local)
+		body.Write([]byte{0, 0})
+		_ = binary.Write(&body, binary.LittleEndian, m.global)
+		body.WriteByte(byte(len(m.fields)))
+		for _, f := range m.fields {
+			body.Write([]byte{f.num, f.size, f.baseType})
+		}
+		local++
+		return local - 1
+	}
+	write := func(local byte, values ...any) {
+		body.WriteByte(local)
+		for _, v := range values {
+			_ = binary.Write(&body, binary.LittleEndian, v)
+		}
+	}
+
+	first, last := points[0], points[len(points)-1]
+	distances := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		distances[i] = distances[i-1] + geodata.Distance(points[i-1].Lat, points[i-1].Lon, points[i].Lat, points[i].Lon)
+	}
+	total := distances[len(distances)-1]
+
+	// file type 6 is a course, manufacturer 255 development
+	write(define(fitFileID), uint8(6), uint16(255), uint16(0), fitTime(last.Time))
+	// sport 0 is generic
+	write(define(fitCourse), uint8(0), fitName("photos2map"))
+
+	// the timer event 0 starts with type 0 and stops all with type 4
+	event := define(fitEvent)
+	write(event, fitTime(first.Time), uint8(0), uint8(0), uint8(0))
+	record := define(fitRecord)
+	for i, p := range points {
+		lat, lon := fitPosition(p)
+		write(record, fitTime(p.Time), lat, lon, fitAltitude(p.Ele), uint32(math.Round(distances[i]*100)))
+	}
+	coursePoint := define(fitCoursePoint)
+	for i, p := range points {
+		lat, lon := fitPosition(p)
+		write(coursePoint, uint16(i), fitTime(p.Time), lat, lon, uint32(math.Round(distances[i]*100)), uint8(0), fitName(p.Label()))
+	}
+	write(event, fitTime(last.Time), uint8(0), uint8(4), uint8(0))
+
+	startLat, startLon := fitPosition(first)
+	endLat, endLon := fitPosition(last)
+	elapsed := uint32(last.Time.Sub(first.Time).Milliseconds())
+	write(define(fitLap), fitTime(last.Time), fitTime(first.Time), startLat, startLon, endLat, endLon,
+		elapsed, elapsed, uint32(math.Round(total*100)))
+
+	header := make([]byte, 14)
+	header[0] = 14
+	// protocol version 1.0 and profile version 21.00
+	header[1] = 0x10
+	binary.LittleEndian.PutUint16(header[2:], 2100)
+	binary.LittleEndian.PutUint32(header[4:], uint32(body.Len()))
+	copy(header[8:], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:], fitCRC(header[:12]))
+
+	file := append(header, body.Bytes()...)
+	return binary.LittleEndian.AppendUint16(file, fitCRC(file))
+}
+
+// fitTime returns t in seconds since the FIT epoch.
+func fitTime(t time.Time) uint32 {
+	return uint32(t.Sub(fitEpoch) / time.Second)
+}
+
+// fitPosition returns a point's latitude and longitude in semicircles, where 2^31 semicircles make 180 degrees.
+func fitPosition(p geodata.Point) (int32, int32) {
+	return int32(math.Round(p.Lat * (1 << 31) / 180)), int32(math.Round(p.Lon * (1 << 31) / 180))
+}
+
+// fitAltitude returns an altitude in meters in the FIT encoding of fifths of a meter above 500 m below sea level,
+// or the invalid value for unknown altitudes and those out of range.
+func fitAltitude(ele *float64) uint16 {
+	if ele == nil {
+		return math.MaxUint16
+	}
+	v := math.Round((*ele + 500) * 5)
+	if v < 0 || v >= math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(v)
+}
+
+// fitName returns a name as a FIT string field, cut to whole characters and padded with zeros.
+func fitName(name string) [fitNameSize]byte {
+	var out [fitNameSize]byte
+	copy(out[:], truncateUTF8(name, fitNameSize-1))
+	return out
+}
+
+// fitCRC returns the FIT CRC-16 of data.
+func fitCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[b&0xF]
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[(b>>4)&0xF]
+	}
+	return crc
+}
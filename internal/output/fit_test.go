@@ -0,0 +1,101 @@
+package output
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestFitCourseFile checks the header and checksums of the FIT file, and walks its messages to check that every
+// photo has a record and a course point.
+func TestFitCourseFile(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	ele := 35.0
+	fit := fitCourseFile([]geodata.Point{
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: start, Ele: &ele},
+		{Name: "Image2", Lat: 51.5080, Lon: -0.1200, Time: start.Add(time.Hour)},
+	})
+
+	if fit[0] != 14 || string(fit[8:12]) != ".FIT" || binary.LittleEndian.Uint16(fit[12:]) != fitCRC(fit[:12]) {
+		t.Fatalf("Unexpected header % x", fit[:14])
+	}
+	if size := binary.LittleEndian.Uint32(fit[4:]); int(size) != len(fit)-16 {
+		t.Errorf("Expected a data size of %d, got %d", len(fit)-16, size)
+	}
+	// the CRC of a file ending in its own CRC is 0
+	if crc := fitCRC(fit); crc != 0 {
+		t.Errorf("Expected the file CRC to check out, got %04x", crc)
+	}
+
+	definitions := make(map[byte]fitMessage)
+	counts := make(map[uint16]int)
+	var records [][]byte
+	for data := fit[14 : len(fit)-2]; len(data) > 0; {
+		header := data[0]
+		if header&0x40 != 0 {
+			m := fitMessage{global: binary.LittleEndian.Uint16(data[3:])}
+			for i := 0; i < int(data[5]); i++ {
+				f := data[6+3*i:]
+				m.fields = append(m.fields, fitField{f[0], f[1], f[2]})
+			}
+			definitions[header&0x0F] = m
+			data = data[6+3*len(m.fields):]
+			continue
+		}
+		m, ok := definitions[header&0x0F]
+		if !ok {
+			t.Fatalf("Data message of undefined local type %d", header&0x0F)
+		}
+		size := 0
+		for _, f := range m.fields {
+			size += int(f.size)
+		}
+		counts[m.global]++
+		if m.global == fitRecord.global {
+			records = append(records, data[1:1+size])
+		}
+		data = data[1+size:]
+	}
+
+	for global, expected := range map[uint16]int{0: 1, 31: 1, 21: 2, 20: 2, 32: 2, 19: 1} {
+		if counts[global] != expected {
+			t.Errorf("Expected %d messages %d, got %d", expected, global, counts[global])
+		}
+	}
+	if len(records) != 2 {
+		t.FailNow()
+	}
+	first := records[0]
+	if ts := binary.LittleEndian.Uint32(first); ts != fitTime(start) || ts != 1083488400 {
+		t.Errorf("Unexpected first timestamp %d", ts)
+	}
+	if lat := int32(binary.LittleEndian.Uint32(first[4:])); lat != 614507218 {
+		t.Errorf("Unexpected first latitude %d semicircles", lat)
+	}
+	if alt := binary.LittleEndian.Uint16(first[12:]); alt != 2675 {
+		t.Errorf("Expected the altitude in fifths of a meter above -500 m, got %d", alt)
+	}
+	if alt := binary.LittleEndian.Uint16(records[1][12:]); alt != 0xFFFF {
+		t.Errorf("Expected the invalid value for an unknown altitude, got %d", alt)
+	}
+	if distance := binary.LittleEndian.Uint32(records[1][14:]); distance < 50000 || distance > 55000 {
+		t.Errorf("Expected about 530 m in centimeters to the second record, got %d", distance)
+	}
+}
+
+// TestFitCRC checks the CRC against the check value of CRC-16/ARC, which the FIT CRC is.
+func TestFitCRC(t *testing.T) {
+	if crc := fitCRC([]byte("123456789")); crc != 0xBB3D {
+		t.Errorf("Expected 0xbb3d, got %04x", crc)
+	}
+}
+
+// TestFitName checks that names are cut to whole characters and terminated.
+func TestFitName(t *testing.T) {
+	name := fitName("Château de Versailles")
+	if string(name[:15]) != "Château de Ver" || name[15] != 0 {
+		t.Errorf("Unexpected name %q", name)
+	}
+}
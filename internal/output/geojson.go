@@ -0,0 +1,117 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// umapOptions styles uMap markers to match the HTML map
+var umapOptions = map[string]string{
+	"color":     "DarkCyan",
+	"iconClass": "Circle",
+}
+
+// featureCollection is a GeoJSON FeatureCollection of points
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+// feature is a GeoJSON Feature with a Point geometry, identified by the point's ID
+type feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   pointGeometry          `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// pointGeometry is a GeoJSON Point, with coordinates in longitude, latitude[, elevation] order
+type pointGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// GenerateGeoJSON creates a plain GeoJSON file for web maps and GIS tools, saved to "output.geojson", or written to
+// w if it isn't nil.
+// Besides the name and description, every feature has the capture time, the altitude in meters, null when
+// unknown, and the path of the photo, so the attribute table has the same columns for every point. Only the
+// description follows units; the altitude property stays in meters for GIS tools.
+func GenerateGeoJSON(gpsData []geodata.Point, units string, w io.Writer) {
+	writeGeoJSON(w, gpsData, outputPath("output.geojson"), units, plainProperties)
+	if w == nil {
+		log.Println("GeoJSON file generated successfully.")
+	}
+}
+
+// GeoJSON returns the points as GenerateGeoJSON writes them, for callers without a file system to write to.
+func GeoJSON(gpsData []geodata.Point, units string) ([]byte, error) {
+	return marshalGeoJSON(gpsData, units, plainProperties)
+}
+
+// plainProperties adds the properties of plain GeoJSON output.
+func plainProperties(p geodata.Point, props map[string]interface{}) {
+	props["time"] = p.Time.Format(time.RFC3339)
+	props["altitude"] = p.Ele
+	props["path"] = p.Path
+}
+
+// GenerateUMap creates a GeoJSON file for importing into uMap, saved to "umap.geojson".
+// Markers are styled through uMap's _umap_options property and described in the popup.
+func GenerateUMap(gpsData []geodata.Point, units string) {
+	writeGeoJSON(nil, gpsData, outputPath("umap.geojson"), units, func(p geodata.Point, props map[string]interface{}) {
+		props["_umap_options"] = umapOptions
+	})
+	log.Println("uMap GeoJSON file generated successfully.")
+}
+
+// GenerateFelt creates a GeoJSON file for importing into Felt, saved to "felt.geojson".
+// Felt shows every property in the element's details, so the time is kept machine readable.
+func GenerateFelt(gpsData []geodata.Point, units string) {
+	writeGeoJSON(nil, gpsData, outputPath("felt.geojson"), units, func(p geodata.Point, props map[string]interface{}) {
+		props["time"] = p.Time.Format(time.RFC3339)
+	})
+	log.Println("Felt GeoJSON file generated successfully.")
+}
+
+// writeGeoJSON writes the points to path, or to w if it isn't nil, as a FeatureCollection with a name and
+// description, in the given units, per point. extra adds the properties specific to the service the file is
+// meant for.
+func writeGeoJSON(w io.Writer, gpsData []geodata.Point, path, units string, extra func(geodata.Point, map[string]interface{})) {
+	data, err := marshalGeoJSON(gpsData, units, extra)
+	if err != nil {
+		log.Fatalf("Error marshalling GeoJSON: %v", err)
+	}
+	if err := writeTo(w, path, data); err != nil {
+		log.Fatalf("Error writing GeoJSON file: %v", err)
+	}
+}
+
+// marshalGeoJSON encodes the points as an indented FeatureCollection, with the properties writeGeoJSON describes.
+func marshalGeoJSON(gpsData []geodata.Point, units string, extra func(geodata.Point, map[string]interface{})) ([]byte, error) {
+	fc := featureCollection{Type: "FeatureCollection", Features: make([]feature, 0, len(gpsData))}
+	for _, p := range gpsData {
+		coordinates := []float64{p.Lon, p.Lat}
+		if p.Ele != nil {
+			coordinates = append(coordinates, *p.Ele)
+		}
+		props := map[string]interface{}{
+			"name":        p.Name,
+			"description": strings.Join(pointDetails(p, units), "\n"),
+		}
+		extra(p, props)
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			ID:         p.ID,
+			Geometry:   pointGeometry{Type: "Point", Coordinates: coordinates},
+			Properties: props,
+		})
+	}
+
+	return json.MarshalIndent(fc, "", "  ")
+}
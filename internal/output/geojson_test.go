@@ -0,0 +1,90 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// readGeoJSON reads back a generated GeoJSON file.
+func readGeoJSON(t *testing.T, path string) featureCollection {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected %s to be generated: %v", path, err)
+	}
+	var fc featureCollection
+	if err := json.Unmarshal(content, &fc); err != nil {
+		t.Fatalf("%s is not valid JSON: %v", path, err)
+	}
+	return fc
+}
+
+// TestGenerateGeoJSON checks the time, altitude and path properties of the plain GeoJSON output.
+func TestGenerateGeoJSON(t *testing.T) {
+	ele := 35.0
+	GenerateGeoJSON([]geodata.Point{
+		{Name: "Image1", Path: "photos/Image1.jpg", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele},
+		{Name: "Image2", Path: "photos/Image2.jpg", Lat: 48.8566, Lon: 2.3522},
+	}, UnitsMetric, nil)
+	defer os.Remove("out/output.geojson")
+
+	fc := readGeoJSON(t, "out/output.geojson")
+	if len(fc.Features) != 2 {
+		t.Fatalf("Expected 2 features, got %+v", fc.Features)
+	}
+	props := fc.Features[0].Properties
+	if props["name"] != "Image1" || props["time"] != "2024-05-01T10:00:00Z" || props["altitude"] != 35.0 || props["path"] != "photos/Image1.jpg" {
+		t.Errorf("Unexpected properties %v", props)
+	}
+	if altitude, ok := fc.Features[1].Properties["altitude"]; !ok || altitude != nil {
+		t.Errorf("Expected a null altitude for a point without one, got %v", fc.Features[1].Properties)
+	}
+}
+
+// TestGenerateUMap checks the features and uMap styling of the uMap preset.
+func TestGenerateUMap(t *testing.T) {
+	ele := 35.0
+	GenerateUMap([]geodata.Point{
+		{ID: "3f2a9c1b7d4e", Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele, Make: "NIKON", Model: "COOLPIX P6000"},
+		{Name: "Image2", Lat: 48.8566, Lon: 2.3522},
+	}, UnitsMetric)
+	defer os.Remove("out/umap.geojson")
+
+	fc := readGeoJSON(t, "out/umap.geojson")
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 2 {
+		t.Fatalf("Expected a FeatureCollection of 2 features, got %+v", fc)
+	}
+	f := fc.Features[0]
+	if f.ID != "3f2a9c1b7d4e" || fc.Features[1].ID != "" {
+		t.Errorf("Expected the first feature only to have an ID, got %q and %q", f.ID, fc.Features[1].ID)
+	}
+	if c := f.Geometry.Coordinates; len(c) != 3 || c[0] != -0.1276 || c[1] != 51.5074 || c[2] != 35 {
+		t.Errorf("Unexpected coordinates %v", c)
+	}
+	if f.Properties["name"] != "Image1" || f.Properties["description"] != "Taken: 2024-05-01 10:00:00\nAltitude: 35 m\nCamera: NIKON COOLPIX P6000" {
+		t.Errorf("Unexpected properties %v", f.Properties)
+	}
+	if _, ok := f.Properties["_umap_options"]; !ok {
+		t.Errorf("Expected uMap marker options, got %v", f.Properties)
+	}
+	if len(fc.Features[1].Geometry.Coordinates) != 2 {
+		t.Errorf("Expected no elevation for a point without altitude")
+	}
+}
+
+// TestGenerateFelt checks the time property of the Felt preset.
+func TestGenerateFelt(t *testing.T) {
+	GenerateFelt([]geodata.Point{
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}, UnitsMetric)
+	defer os.Remove("out/felt.geojson")
+
+	fc := readGeoJSON(t, "out/felt.geojson")
+	if len(fc.Features) != 1 || fc.Features[0].Properties["time"] != "2024-05-01T10:00:00Z" {
+		t.Errorf("Expected a feature with an RFC 3339 time, got %+v", fc.Features)
+	}
+}
@@ -0,0 +1,127 @@
+package output
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/sqlite"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+const (
+	// gpkgApplicationID is the application_id of GeoPackage files, "GPKG" in ASCII
+	gpkgApplicationID = 0x47504B47
+	// gpkgVersion is the user_version of the GeoPackage version the file follows, 1.3.0
+	gpkgVersion = 10300
+	// gpkgTable is the name of the feature table holding the photos
+	gpkgTable = "photos"
+)
+
+// gpkgWGS84 is the definition of EPSG:4326 in the spatial reference system table, as the GeoPackage
+// specification gives it
+const gpkgWGS84 = `GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563,AUTHORITY["EPSG","7030"]],AUTHORITY["EPSG","6326"]],PRIMEM["Greenwich",0,AUTHORITY["EPSG","8901"]],UNIT["degree",0.0174532925199433,AUTHORITY["EPSG","9122"]],AUTHORITY["EPSG","4326"]]`
+
+// The CREATE TABLE statements of the tables every GeoPackage has, from the GeoPackage specification, and of the
+// feature table
+const (
+	gpkgSpatialRefSysSQL = `CREATE TABLE gpkg_spatial_ref_sys (srs_name TEXT NOT NULL, srs_id INTEGER PRIMARY KEY, ` +
+		`organization TEXT NOT NULL, organization_coordsys_id INTEGER NOT NULL, definition TEXT NOT NULL, description TEXT)`
+	gpkgContentsSQL = `CREATE TABLE gpkg_contents (table_name TEXT NOT NULL PRIMARY KEY, data_type TEXT NOT NULL, ` +
+		`identifier TEXT UNIQUE, description TEXT DEFAULT '', ` +
+		`last_change DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ','now')), ` +
+		`min_x DOUBLE, min_y DOUBLE, max_x DOUBLE, max_y DOUBLE, srs_id INTEGER, ` +
+		`CONSTRAINT fk_gc_r_srs_id FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys(srs_id))`
+	gpkgGeometryColumnsSQL = `CREATE TABLE gpkg_geometry_columns (table_name TEXT NOT NULL, column_name TEXT NOT NULL, ` +
+		`geometry_type_name TEXT NOT NULL, srs_id INTEGER NOT NULL, z TINYINT NOT NULL, m TINYINT NOT NULL, ` +
+		`CONSTRAINT pk_geom_cols PRIMARY KEY (table_name, column_name), ` +
+		`CONSTRAINT uk_gc_table_name UNIQUE (table_name), ` +
+		`CONSTRAINT fk_gc_tn FOREIGN KEY (table_name) REFERENCES gpkg_contents(table_name), ` +
+		`CONSTRAINT fk_gc_srs FOREIGN KEY (srs_id) REFERENCES gpkg_spatial_ref_sys (srs_id))`
+	gpkgPhotosSQL = `CREATE TABLE photos (fid INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL, geom POINT, id TEXT, ` +
+		`name TEXT, path TEXT, time DATETIME, altitude DOUBLE, heading DOUBLE, camera TEXT, lens TEXT, caption TEXT)`
+)
+
+// GenerateGeoPackage creates an OGC GeoPackage saved to "output.gpkg", the SQLite based format QGIS and other
+// GIS tools open directly. Its "photos" feature table has a WGS 84 point geometry per photo and the photo's ID,
+// name, path, time, altitude in meters, heading, camera, lens and caption.
+func GenerateGeoPackage(gpsData []geodata.Point) {
+	data, err := geoPackage(gpsData)
+	if err != nil {
+		log.Fatalf("Error creating GeoPackage: %v", err)
+	}
+	if err := writeFile(outputPath("output.gpkg"), data); err != nil {
+		log.Fatalf("Error writing GeoPackage: %v", err)
+	}
+	log.Println("GeoPackage generated successfully.")
+}
+
+// geoPackage returns the GeoPackage file of the points, see GenerateGeoPackage. Its last change time is that of
+// the newest photo rather than now, so the same photos give the same file.
+func geoPackage(gpsData []geodata.Point) ([]byte, error) {
+	bbox := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	updated := time.Unix(0, 0).UTC()
+	photos := make([][]any, len(gpsData))
+	for i, p := range gpsData {
+		bbox[0], bbox[1] = math.Min(bbox[0], p.Lon), math.Min(bbox[1], p.Lat)
+		bbox[2], bbox[3] = math.Max(bbox[2], p.Lon), math.Max(bbox[3], p.Lat)
+		if p.Time.After(updated) {
+			updated = p.Time
+		}
+		photos[i] = []any{nil, gpkgPoint(p), p.ID, p.Name, p.Path, p.Time.UTC().Format(time.RFC3339),
+			optionalValue(p.Ele), optionalValue(p.Heading), p.Camera(), p.Lens, p.Caption}
+	}
+	var bounds []any
+	for _, v := range bbox {
+		bounds = append(bounds, v)
+	}
+	if len(gpsData) == 0 {
+		bounds = []any{nil, nil, nil, nil}
+	}
+
+	db := sqlite.Database{
+		ApplicationID: gpkgApplicationID,
+		UserVersion:   gpkgVersion,
+		Tables: []sqlite.Table{
+			{Name: "gpkg_spatial_ref_sys", SQL: gpkgSpatialRefSysSQL, Rows: [][]any{
+				{"Undefined cartesian SRS", nil, "NONE", int64(-1), "undefined", "undefined cartesian coordinate reference system"},
+				{"Undefined geographic SRS", nil, "NONE", int64(0), "undefined", "undefined geographic coordinate reference system"},
+				{"WGS 84 geodetic", nil, "EPSG", int64(4326), gpkgWGS84, "longitude/latitude coordinates in decimal degrees on the WGS 84 spheroid"},
+			}, RowIDs: []int64{-1, 0, 4326}},
+			{Name: "gpkg_contents", SQL: gpkgContentsSQL, Rows: [][]any{
+				append([]any{gpkgTable, "features", "photos2map", "", updated.Format("2006-01-02T15:04:05.000Z")},
+					append(bounds, int64(4326))...),
+			}, Indexes: []sqlite.Index{{Columns: []int{0}}, {Columns: []int{2}}}},
+			{Name: "gpkg_geometry_columns", SQL: gpkgGeometryColumnsSQL, Rows: [][]any{
+				{gpkgTable, "geom", "POINT", int64(4326), int64(0), int64(0)},
+			}, Indexes: []sqlite.Index{{Columns: []int{0, 1}}, {Columns: []int{0}}}},
+			{Name: gpkgTable, SQL: gpkgPhotosSQL, Rows: photos},
+			// AUTOINCREMENT keeps the largest fid it handed out here
+			{Name: "sqlite_sequence", SQL: "CREATE TABLE sqlite_sequence(name,seq)", Rows: [][]any{
+				{gpkgTable, int64(len(gpsData))},
+			}},
+		},
+	}
+	return db.Bytes()
+}
+
+// gpkgPoint returns a point as GeoPackage geometry: a header with the "GP" magic, version 0, flags for
+// little-endian without an envelope and the SRS ID, followed by the point in well-known binary.
+func gpkgPoint(p geodata.Point) []byte {
+	out := []byte{'G', 'P', 0, 0x01}
+	out = binary.LittleEndian.AppendUint32(out, 4326)
+	out = append(out, 0x01)
+	out = binary.LittleEndian.AppendUint32(out, 1)
+	out = binary.LittleEndian.AppendUint64(out, math.Float64bits(p.Lon))
+	return binary.LittleEndian.AppendUint64(out, math.Float64bits(p.Lat))
+}
+
+// optionalValue returns a value for a nullable column, nil for no value.
+func optionalValue(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
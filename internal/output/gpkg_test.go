@@ -0,0 +1,44 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGeoPackage checks that the file is a SQLite database marked as a GeoPackage holding the required tables.
+func TestGeoPackage(t *testing.T) {
+	gpkg, err := geoPackage([]geodata.Point{
+		{ID: "3f2a9c1b7d4e", Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(gpkg, []byte("SQLite format 3\x00")) || binary.BigEndian.Uint32(gpkg[68:]) != gpkgApplicationID {
+		t.Fatalf("Expected a SQLite file with the GeoPackage application ID, got % x", gpkg[:72])
+	}
+	for _, expected := range []string{"CREATE TABLE gpkg_spatial_ref_sys", "CREATE TABLE gpkg_contents",
+		"CREATE TABLE gpkg_geometry_columns", "CREATE TABLE photos", "sqlite_autoindex_gpkg_contents_2",
+		"2024-05-01T10:00:00.000Z", "3f2a9c1b7d4e"} {
+		if !bytes.Contains(gpkg, []byte(expected)) {
+			t.Errorf("Expected %q in the GeoPackage", expected)
+		}
+	}
+}
+
+// TestGpkgPoint checks the GeoPackage geometry header and the well-known binary point.
+func TestGpkgPoint(t *testing.T) {
+	geom := gpkgPoint(geodata.Point{Lat: 51.5074, Lon: -0.1276})
+	if len(geom) != 29 || string(geom[:2]) != "GP" || geom[3] != 0x01 || binary.LittleEndian.Uint32(geom[4:]) != 4326 {
+		t.Fatalf("Unexpected header % x", geom)
+	}
+	if geom[8] != 0x01 || binary.LittleEndian.Uint32(geom[9:]) != 1 ||
+		math.Float64frombits(binary.LittleEndian.Uint64(geom[13:])) != -0.1276 ||
+		math.Float64frombits(binary.LittleEndian.Uint64(geom[21:])) != 51.5074 {
+		t.Errorf("Unexpected point % x", geom[8:])
+	}
+}
@@ -2,48 +2,83 @@ package output
 
 import (
 	"encoding/xml"
-	"os"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/go-echarts/go-echarts/v2/opts"
 	"github.com/twpayne/go-gpx"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// extensionNamespace is the XML namespace of photos2map's GPX extension elements
+const extensionNamespace = "https://github.com/toozej/photos2map"
+
+// The ways GenerateGPX can write the points
+const (
+	// GPXWaypoints writes a waypoint per photo
+	GPXWaypoints = "wpt"
+	// GPXTrack writes a track through the photos in capture order
+	GPXTrack = "trk"
+	// GPXBoth writes both the waypoints and the track
+	GPXBoth = "both"
+	// GPXRoute writes a route through the photos in capture order, for route planners and GPS units that import
+	// routes rather than tracks
+	GPXRoute = "rte"
 )
 
+// GPXModes are the valid values of GPXOptions.Mode.
+var GPXModes = []string{GPXWaypoints, GPXTrack, GPXBoth, GPXRoute}
+
+// GPXOptions controls what GenerateGPX writes.
+type GPXOptions struct {
+	// Mode is one of GPXModes, defaulting to GPXWaypoints when empty
+	Mode string
+	// Links describes where waypoints link to their photo, so GPX viewers can open it
+	Links PhotoLinks
+	// TrackGap splits the track into segments wherever more than this passed between photos, if set
+	TrackGap time.Duration
+	// Smooth is the window of the median filter the track is smoothed with, see geodata.Smooth. The waypoints
+	// and route keep the photos' positions.
+	Smooth int
+}
+
 // GenerateGPX creates a GPX file from the extracted GPS data.
-// It takes a slice of GeoData and outputs a GPX file named `output.gpx`.
-func GenerateGPX(gpsData []opts.GeoData) {
+// It takes a slice of Points and outputs a GPX file named `output.gpx`, or writes it to w if it isn't nil, with a
+// waypoint per photo, a track through them in capture order, both, or a route through them in capture order, as
+// gpxOpts.Mode says.
+func GenerateGPX(gpsData []geodata.Point, gpxOpts GPXOptions, w io.Writer) {
 	g := gpx.GPX{
 		Version: "1.1",
 		Creator: "photos2map",
-		Wpt:     make([]*gpx.WptType, len(gpsData)),
-	}
-
-	for i, data := range gpsData {
-		// Type assert data.Value as []float64
-		coords, ok := data.Value.([]float64)
-		if !ok || len(coords) != 2 {
-			log.Printf("Invalid GPS data for %s, skipping...", data.Name)
-			continue
-		}
-
-		lat, lon := coords[1], coords[0]
-		g.Wpt[i] = &gpx.WptType{
-			Lat:  lat,
-			Lon:  lon,
-			Name: data.Name,
-		}
+		XMLAttrs: map[string]string{
+			"xmlns:photos2map": extensionNamespace,
+		},
 	}
-
-	// create output.gpx file
-	file, err := os.Create("out/output.gpx")
-	if err != nil {
-		log.Fatalf("Error creating GPX file: %v", err)
+	sorted := make([]geodata.Point, len(gpsData))
+	copy(sorted, gpsData)
+	geodata.SortByTime(sorted)
+	switch gpxOpts.Mode {
+	case GPXTrack:
+		track := geodata.Smooth(sorted, gpxOpts.Smooth, gpxOpts.TrackGap)
+		g.Trk = []*gpx.TrkType{{Name: "photos2map", TrkSeg: trackSegments(track, gpxOpts.TrackGap)}}
+	case GPXBoth:
+		track := geodata.Smooth(sorted, gpxOpts.Smooth, gpxOpts.TrackGap)
+		g.Wpt = waypoints(gpsData, gpxOpts.Links)
+		g.Trk = []*gpx.TrkType{{Name: "photos2map", TrkSeg: trackSegments(track, gpxOpts.TrackGap)}}
+	case GPXRoute:
+		// route points keep the photos' names, which route planners show as the stops along the way
+		g.Rte = []*gpx.RteType{{Name: "photos2map", RtePt: waypoints(sorted, gpxOpts.Links)}}
+	default:
+		g.Wpt = waypoints(gpsData, gpxOpts.Links)
 	}
-	defer file.Close()
 
 	// Marshal the GPX struct into indented XML
-	gpxData, err := xml.MarshalIndent(g, "", "  ")
+	gpxData, err := xml.MarshalIndent(&g, "", "  ")
 	if err != nil {
 		log.Errorf("Error marshalling GPX struct to XML: %v", err)
 	}
@@ -53,10 +88,125 @@ func GenerateGPX(gpsData []opts.GeoData) {
 	gpxData = append(header, gpxData...)
 
 	// write out the gpx file
-	_, err = file.Write(gpxData)
+	if err := writeTo(w, outputPath("output.gpx"), gpxData); err != nil {
+		log.Fatalf("Error writing GPS data to GPX file: %v", err)
+	}
+	if w == nil {
+		log.Println("GPX file generated successfully.")
+	}
+}
+
+// waypoints converts the points into waypoints named after their photo, preceded by its number if numbered, and
+// described by the camera, linked to the photo as described by links.
+func waypoints(gpsData []geodata.Point, links PhotoLinks) []*gpx.WptType {
+	wpts := make([]*gpx.WptType, len(gpsData))
+	for i, data := range gpsData {
+		wpts[i] = &gpx.WptType{
+			Lat:  data.Lat,
+			Lon:  data.Lon,
+			Time: data.Time,
+			Name: data.Label(),
+			Desc: strings.Join(waypointDescription(data), "\n"),
+		}
+		if data.Ele != nil {
+			wpts[i].Ele = *data.Ele
+		}
+		if href := links.URL(data); href != "" {
+			wpts[i].Link = []*gpx.LinkType{{HREF: href, Text: data.Name}}
+		}
+		var extensions []string
+		if data.ID != "" {
+			extensions = append(extensions, fmt.Sprintf("<photos2map:id>%s</photos2map:id>", data.ID))
+		}
+		if data.Heading != nil {
+			extensions = append(extensions, fmt.Sprintf("<photos2map:heading>%g</photos2map:heading>", *data.Heading))
+		}
+		if len(extensions) > 0 {
+			wpts[i].Extensions = &gpx.ExtensionsType{XML: []byte(strings.Join(extensions, ""))}
+		}
+	}
+	return wpts
+}
+
+// GenerateActivityGPX creates a GPX file that Strava and Komoot accept as an activity upload, saved to "activity.gpx".
+// It holds a single track through the points in capture order with strictly increasing timestamps and no waypoints,
+// see activityPoints. The track is split into segments wherever more than gap passed between photos, so days
+// apart aren't joined by a straight line; a gap of 0 never splits it.
+func GenerateActivityGPX(gpsData []geodata.Point, gap time.Duration) {
+	g := gpx.GPX{
+		Version: "1.1",
+		Creator: "photos2map",
+		Trk:     []*gpx.TrkType{{Name: "photos2map", TrkSeg: trackSegments(activityPoints(gpsData), gap)}},
+	}
+
+	gpxData, err := xml.MarshalIndent(&g, "", "  ")
 	if err != nil {
-		log.Errorf("Error writing GPS data to GPX file: %v", err)
+		log.Fatalf("Error marshalling GPX struct to XML: %v", err)
+	}
+	if err := writeFile(outputPath("activity.gpx"), append([]byte(xml.Header), gpxData...)); err != nil {
+		log.Fatalf("Error writing activity GPX file: %v", err)
+	}
+
+	log.Println("Activity GPX file generated successfully.")
+}
+
+// activityPoints orders the points by capture time and drops those an activity upload would reject: points
+// taken in the same second as the previous one, such as bursts, and points repeating the previous position.
+func activityPoints(gpsData []geodata.Point) []geodata.Point {
+	sorted := make([]geodata.Point, len(gpsData))
+	copy(sorted, gpsData)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	var points []geodata.Point
+	for _, p := range sorted {
+		if n := len(points); n > 0 {
+			prev := points[n-1]
+			if !p.Time.Truncate(time.Second).After(prev.Time.Truncate(time.Second)) || (p.Lat == prev.Lat && p.Lon == prev.Lon) {
+				continue
+			}
+		}
+		points = append(points, p)
 	}
+	return points
+}
 
-	log.Println("GPX file generated successfully.")
+// trackSegments converts points in capture order into track segments, starting a new segment wherever more than
+// gap passed between consecutive points, or never if gap is 0.
+func trackSegments(points []geodata.Point, gap time.Duration) []*gpx.TrkSegType {
+	var segments []*gpx.TrkSegType
+	for i, p := range points {
+		if i == 0 || (gap > 0 && p.Time.Sub(points[i-1].Time) > gap) {
+			segments = append(segments, &gpx.TrkSegType{})
+		}
+		pt := &gpx.WptType{Lat: p.Lat, Lon: p.Lon, Time: p.Time.UTC()}
+		if p.Ele != nil {
+			pt.Ele = *p.Ele
+		}
+		seg := segments[len(segments)-1]
+		seg.TrkPt = append(seg.TrkPt, pt)
+	}
+	return segments
+}
+
+// waypointDescription is a waypoint's caption followed by the camera and lens it was taken with, one line each.
+// The time and elevation have their own GPX elements.
+func waypointDescription(p geodata.Point) []string {
+	if p.Caption == "" {
+		return cameraDetails(p)
+	}
+	return append([]string{p.Caption}, cameraDetails(p)...)
+}
+
+// cameraDetails describes the camera and lens a point was taken with, one line each.
+func cameraDetails(p geodata.Point) []string {
+	var details []string
+	if camera := p.Camera(); camera != "" {
+		details = append(details, "Camera: "+camera)
+	}
+	if p.Lens != "" {
+		details = append(details, "Lens: "+p.Lens)
+	}
+	return details
 }
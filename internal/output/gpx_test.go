@@ -1,26 +1,203 @@
 package output
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/toozej/photos2map/pkg/geodata"
 )
 
 // TestGenerateGPX checks if a valid GPX file is generated.
 func TestGenerateGPX(t *testing.T) {
-	gpsData := []opts.GeoData{
-		{Name: "Image1", Value: []float64{-0.1276, 51.5074}},
-		{Name: "Image2", Value: []float64{2.3522, 48.8566}},
+	ele, heading := 35.0, 90.0
+	gpsData := []geodata.Point{
+		{ID: "3f2a9c1b7d4e", Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele, Heading: &heading, Make: "NIKON", Model: "COOLPIX P6000"},
+		{Name: "Image2", Caption: "Eiffel Tower", Make: "Apple", Model: "iPhone 15", Path: "2024/Image 2.jpg", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)},
 	}
 
-	GenerateGPX(gpsData)
+	GenerateGPX(gpsData, GPXOptions{Links: PhotoLinks{Template: "https://example.com/{path}"}}, nil)
 
 	// Check if the GPX file is created
 	if _, err := os.Stat("out/output.gpx"); os.IsNotExist(err) {
 		t.Fatalf("Expected output.gpx to be generated, but it does not exist")
 	}
 
+	// Check that waypoints carry their capture time
+	content, err := os.ReadFile("out/output.gpx")
+	if err != nil {
+		t.Fatalf("Error reading output.gpx: %v", err)
+	}
+	if !strings.Contains(string(content), "<time>2024-05-01T10:00:00Z</time>") {
+		t.Errorf("Expected output.gpx to contain waypoint times, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "<ele>35</ele>") {
+		t.Errorf("Expected output.gpx to contain waypoint elevation, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "<photos2map:id>3f2a9c1b7d4e</photos2map:id><photos2map:heading>90</photos2map:heading>") {
+		t.Errorf("Expected output.gpx to contain waypoint IDs and headings, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), `<link href="https://example.com/2024/Image%202.jpg">`) {
+		t.Errorf("Expected output.gpx to link waypoints to their photos, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "<desc>Camera: NIKON COOLPIX P6000</desc>") {
+		t.Errorf("Expected output.gpx to contain waypoint camera descriptions, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "<desc>Eiffel Tower&#xA;Camera: Apple iPhone 15</desc>") {
+		t.Errorf("Expected output.gpx to start waypoint descriptions with the caption, got:\n%s", content)
+	}
+
+	if strings.Contains(string(content), "<trk>") {
+		t.Errorf("Expected no track by default, got:\n%s", content)
+	}
+
 	// Clean up after test
 	os.Remove("out/output.gpx")
 }
+
+// TestGenerateGPXModes checks that the track holds every photo in capture order, with or without the waypoints.
+func TestGenerateGPXModes(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Name: "Image2", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)},
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "Image1-burst", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	defer os.Remove("out/output.gpx")
+
+	for mode, waypoints := range map[string]int{GPXTrack: 0, GPXBoth: 3} {
+		GenerateGPX(gpsData, GPXOptions{Mode: mode, TrackGap: 6 * time.Hour}, nil)
+		content, err := os.ReadFile("out/output.gpx")
+		if err != nil {
+			t.Fatalf("Error reading output.gpx: %v", err)
+		}
+		s := string(content)
+		if strings.Count(s, "<wpt") != waypoints || strings.Count(s, "<trkseg>") != 2 || strings.Count(s, "<trkpt") != 3 {
+			t.Errorf("Expected %d waypoints and a track of 3 points in 2 segments with --gpx-mode %s, got:\n%s", waypoints, mode, s)
+		}
+		track := s[strings.Index(s, "<trk>"):]
+		if strings.Index(track, "2024-05-01T10:00:00Z") > strings.Index(track, "2024-05-02T14:30:00Z") {
+			t.Errorf("Expected track points in time order, got:\n%s", s)
+		}
+	}
+}
+
+// TestGenerateGPXSmooth checks that the track is smoothed while the waypoints keep the photos' positions.
+func TestGenerateGPXSmooth(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	gpsData := []geodata.Point{
+		{Name: "Image1", Lat: 45.0, Lon: 7.0, Time: start},
+		{Name: "Image2", Lat: 45.5, Lon: 7.1, Time: start.Add(time.Minute)},
+		{Name: "Image3", Lat: 45.0, Lon: 7.2, Time: start.Add(2 * time.Minute)},
+	}
+	defer os.Remove("out/output.gpx")
+
+	GenerateGPX(gpsData, GPXOptions{Mode: GPXBoth, Smooth: 3}, nil)
+	content, err := os.ReadFile("out/output.gpx")
+	if err != nil {
+		t.Fatalf("Error reading output.gpx: %v", err)
+	}
+	s := string(content)
+	if !strings.Contains(s, `<wpt lat="45.5" lon="7.1">`) || !strings.Contains(s, `<trkpt lat="45" lon="7.1">`) {
+		t.Errorf("Expected the waypoint at the photo and the track point smoothed, got:\n%s", s)
+	}
+}
+
+// TestGenerateGPXRoute checks that the route holds every photo in capture order, named after it, without
+// waypoints or a track.
+func TestGenerateGPXRoute(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Name: "Image2", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)},
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	defer os.Remove("out/output.gpx")
+
+	GenerateGPX(gpsData, GPXOptions{Mode: GPXRoute}, nil)
+	content, err := os.ReadFile("out/output.gpx")
+	if err != nil {
+		t.Fatalf("Error reading output.gpx: %v", err)
+	}
+	s := string(content)
+	if strings.Count(s, "<rte>") != 1 || strings.Count(s, "<rtept") != 2 || strings.Contains(s, "<wpt") || strings.Contains(s, "<trk") {
+		t.Errorf("Expected only a route of 2 points, got:\n%s", s)
+	}
+	if strings.Index(s, "<name>Image1</name>") > strings.Index(s, "<name>Image2</name>") {
+		t.Errorf("Expected route points in time order, got:\n%s", s)
+	}
+
+	var buf bytes.Buffer
+	GenerateGPX(gpsData, GPXOptions{Mode: GPXRoute}, &buf)
+	if buf.String() != s {
+		t.Errorf("Expected the same GPX on the writer as in the file, got:\n%s", buf.String())
+	}
+}
+
+// TestActivityPoints checks that points are ordered and bursts and repeated positions are dropped.
+func TestActivityPoints(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	points := activityPoints([]geodata.Point{
+		{Name: "c", Lat: 3, Lon: 3, Time: start.Add(2 * time.Minute)},
+		{Name: "a", Lat: 1, Lon: 1, Time: start},
+		{Name: "burst", Lat: 1.1, Lon: 1.1, Time: start.Add(500 * time.Millisecond)},
+		{Name: "b", Lat: 2, Lon: 2, Time: start.Add(time.Minute)},
+		{Name: "same spot", Lat: 2, Lon: 2, Time: start.Add(90 * time.Second)},
+	})
+
+	var names []string
+	for _, p := range points {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("Expected points a,b,c, got %v", names)
+	}
+}
+
+// TestTrackSegments checks that a new segment is started after every gap longer than the threshold.
+func TestTrackSegments(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	points := []geodata.Point{
+		{Lat: 1, Lon: 1, Time: start},
+		{Lat: 2, Lon: 2, Time: start.Add(time.Hour)},
+		{Lat: 3, Lon: 3, Time: start.Add(24 * time.Hour)},
+		{Lat: 4, Lon: 4, Time: start.Add(25 * time.Hour)},
+		{Lat: 5, Lon: 5, Time: start.Add(31 * time.Hour)},
+	}
+
+	tests := map[time.Duration][]int{0: {5}, time.Hour: {2, 2, 1}, 6 * time.Hour: {2, 3}, 24 * time.Hour: {5}}
+	for gap, expected := range tests {
+		segments := trackSegments(points, gap)
+		var sizes []int
+		for _, seg := range segments {
+			sizes = append(sizes, len(seg.TrkPt))
+		}
+		if fmt.Sprint(sizes) != fmt.Sprint(expected) {
+			t.Errorf("Expected segments of %v points with a gap of %s, got %v", expected, gap, sizes)
+		}
+	}
+	if segments := trackSegments(nil, time.Hour); len(segments) != 0 {
+		t.Errorf("Expected no segments without points, got %d", len(segments))
+	}
+}
+
+// TestGenerateActivityGPX checks that the points are written as a single track without waypoints.
+func TestGenerateActivityGPX(t *testing.T) {
+	GenerateActivityGPX([]geodata.Point{
+		{Name: "Image2", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)},
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}, 0)
+	defer os.Remove("out/activity.gpx")
+
+	content, err := os.ReadFile("out/activity.gpx")
+	if err != nil {
+		t.Fatalf("Expected activity.gpx to be generated: %v", err)
+	}
+	s := string(content)
+	if strings.Contains(s, "<wpt") || strings.Count(s, "<trk>") != 1 || strings.Count(s, "<trkpt") != 2 {
+		t.Errorf("Expected a single track of 2 points and no waypoints, got:\n%s", s)
+	}
+	if strings.Index(s, "2024-05-01T10:00:00Z") > strings.Index(s, "2024-05-02T14:30:00Z") {
+		t.Errorf("Expected track points in time order, got:\n%s", s)
+	}
+}
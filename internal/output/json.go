@@ -0,0 +1,70 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// jsonPoints is the document written by GenerateJSON, an object so fields besides the points can be added later
+type jsonPoints struct {
+	Points []jsonPoint `json:"points"`
+}
+
+// jsonPoint is a point with all its metadata, leaving out what isn't known
+type jsonPoint struct {
+	ID          string    `json:"id,omitempty"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	Lat         float64   `json:"lat"`
+	Lon         float64   `json:"lon"`
+	Time        time.Time `json:"time"`
+	Ele         *float64  `json:"altitude,omitempty"`
+	Heading     *float64  `json:"heading,omitempty"`
+	Make        string    `json:"make,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	Lens        string    `json:"lens,omitempty"`
+	Caption     string    `json:"caption,omitempty"`
+	Number      int       `json:"number,omitempty"`
+	NotBackedUp bool      `json:"not_backed_up,omitempty"`
+}
+
+// GenerateJSON writes the points with all their metadata as JSON, for scripts to post-process, to
+// "output.json", or to w if it isn't nil.
+func GenerateJSON(gpsData []geodata.Point, w io.Writer) {
+	doc := jsonPoints{Points: make([]jsonPoint, len(gpsData))}
+	for i, p := range gpsData {
+		doc.Points[i] = jsonPoint{
+			ID:          p.ID,
+			Name:        p.Name,
+			Path:        p.Path,
+			Lat:         p.Lat,
+			Lon:         p.Lon,
+			Time:        p.Time,
+			Ele:         p.Ele,
+			Heading:     p.Heading,
+			Make:        p.Make,
+			Model:       p.Model,
+			Lens:        p.Lens,
+			Caption:     p.Caption,
+			Number:      p.Number,
+			NotBackedUp: p.NotBackedUp,
+		}
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshalling points to JSON: %v", err)
+	}
+	data = append(data, '\n')
+	if err := writeTo(w, outputPath("output.json"), data); err != nil {
+		log.Fatalf("Error writing JSON: %v", err)
+	}
+	if w == nil {
+		log.Println("JSON file generated successfully.")
+	}
+}
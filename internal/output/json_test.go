@@ -0,0 +1,47 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateJSON checks that the points are written with their metadata, to output.json or the given writer.
+func TestGenerateJSON(t *testing.T) {
+	ele, heading := 35.0, 90.0
+	gpsData := []geodata.Point{
+		{ID: "3f2a9c1b7d4e", Name: "Image1", Path: "photos/Image1.jpg", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele, Heading: &heading, Make: "NIKON", Model: "COOLPIX P6000", Caption: "Big Ben"},
+		{Name: "Image2", Path: "photos/Image2.jpg", Lat: 48.8566, Lon: 2.3522},
+	}
+	GenerateJSON(gpsData, nil)
+	defer os.Remove("out/output.json")
+
+	content, err := os.ReadFile("out/output.json")
+	if err != nil {
+		t.Fatalf("Expected output.json to be generated: %v", err)
+	}
+	var doc struct {
+		Points []map[string]interface{} `json:"points"`
+	}
+	if err := json.Unmarshal(content, &doc); err != nil || len(doc.Points) != 2 {
+		t.Fatalf("Expected 2 points, got %s, %v", content, err)
+	}
+	first := doc.Points[0]
+	if first["id"] != "3f2a9c1b7d4e" || first["time"] != "2024-05-01T10:00:00Z" || first["altitude"] != 35.0 || first["heading"] != 90.0 ||
+		first["model"] != "COOLPIX P6000" || first["caption"] != "Big Ben" || first["path"] != "photos/Image1.jpg" {
+		t.Errorf("Unexpected first point %v", first)
+	}
+	if _, ok := doc.Points[1]["altitude"]; ok {
+		t.Errorf("Expected no altitude for a point without one, got %v", doc.Points[1])
+	}
+
+	var buf bytes.Buffer
+	GenerateJSON(gpsData, &buf)
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("Expected the same JSON on the writer as in the file, got %s", buf.Bytes())
+	}
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/segment"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// kmlDocument is the subset of KML 2.2 needed for a layer of placemarks
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Namespace  string         `xml:"xmlns,attr"`
+	Name       string         `xml:"Document>name"`
+	Styles     []kmlStyle     `xml:"Document>Style"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+// kmlPlacemark is a single point, or a leg of the path drawn as lines. Google My Maps imports the ExtendedData as
+// columns of the layer's data table.
+type kmlPlacemark struct {
+	Name        string           `xml:"name"`
+	Description string           `xml:"description,omitempty"`
+	TimeStamp   string           `xml:"TimeStamp>when,omitempty"`
+	StyleURL    string           `xml:"styleUrl,omitempty"`
+	Data        []kmlData        `xml:"ExtendedData>Data,omitempty"`
+	Point       *kmlCoordinates  `xml:"Point,omitempty"`
+	Lines       []kmlCoordinates `xml:"MultiGeometry>LineString,omitempty"`
+}
+
+// kmlCoordinates are the coordinates of a Point or LineString, lon,lat[,alt] tuples separated by spaces
+type kmlCoordinates struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlStyle is a shared line style the path's placemarks refer to by ID
+type kmlStyle struct {
+	ID    string `xml:"id,attr"`
+	Color string `xml:"LineStyle>color"`
+	Width int    `xml:"LineStyle>width"`
+}
+
+// kmlDashes is the number of pieces a stationary leg is cut into, every other one drawn, since KML lines can't be
+// dashed
+const kmlDashes = 9
+
+// kmlData is a named value of a placemark's ExtendedData
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// GenerateKML creates a KML file for importing into Google My Maps, saved to "mymaps.kml". Descriptions and data
+// columns show altitudes in the given units, while the coordinates keep them in meters as KML requires. With path,
+// the photos are also connected in capture order as on the HTML map: legs between photos no more than stopRadius
+// meters apart dashed, the others colored by speed.
+func GenerateKML(gpsData []geodata.Point, units string, path bool, stopRadius float64) {
+	doc := kmlDocument{
+		Namespace:  "http://www.opengis.net/kml/2.2",
+		Name:       "photos2map",
+		Placemarks: make([]kmlPlacemark, 0, len(gpsData)),
+	}
+	for _, p := range gpsData {
+		placemark := kmlPlacemark{
+			Name:        p.Name,
+			Description: strings.Join(pointDetails(p, units), "\n"),
+			Point:       &kmlCoordinates{Coordinates: fmt.Sprintf("%f,%f", p.Lon, p.Lat)},
+		}
+		if !p.Time.IsZero() {
+			placemark.TimeStamp = p.Time.Format(time.RFC3339)
+		}
+		if p.Ele != nil {
+			placemark.Point.Coordinates += fmt.Sprintf(",%f", *p.Ele)
+		}
+		for _, column := range myMapsColumns(p, units)[len(myMapsHeader):] {
+			if column.value != "" {
+				placemark.Data = append(placemark.Data, kmlData{Name: column.name, Value: column.value})
+			}
+		}
+		doc.Placemarks = append(doc.Placemarks, placemark)
+	}
+	if path {
+		addKMLPath(&doc, gpsData, stopRadius, units)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshalling KML: %v", err)
+	}
+	if err := writeFile(outputPath("mymaps.kml"), append([]byte(xml.Header), data...)); err != nil {
+		log.Fatalf("Error writing KML file: %v", err)
+	}
+	log.Println("KML file generated successfully.")
+}
+
+// addKMLPath adds a placemark for each leg of the path between the points in capture order, styled as
+// newPathLine styles them on the HTML map, and the styles they refer to.
+func addKMLPath(doc *kmlDocument, gpsData []geodata.Point, stopRadius float64, units string) {
+	styles := map[string]bool{}
+	for _, s := range segment.Split(gpsData, stopRadius) {
+		line := newPathLine(s, units)
+		id := "path-" + strings.TrimPrefix(line.LineStyle.Color, "#")
+		if s.Stationary {
+			id = "path-stationary"
+		}
+		if !styles[id] {
+			styles[id] = true
+			doc.Styles = append(doc.Styles, kmlStyle{ID: id, Color: kmlColor(line.LineStyle.Color), Width: int(line.LineStyle.Width)})
+		}
+
+		pieces := 1
+		if s.Stationary {
+			pieces = kmlDashes
+		}
+		placemark := kmlPlacemark{Name: line.Name, StyleURL: "#" + id}
+		for i := 0; i < pieces; i += 2 {
+			from, to := float64(i)/float64(pieces), float64(i+1)/float64(pieces)
+			placemark.Lines = append(placemark.Lines, kmlCoordinates{Coordinates: fmt.Sprintf("%f,%f %f,%f",
+				s.From.Lon+(s.To.Lon-s.From.Lon)*from, s.From.Lat+(s.To.Lat-s.From.Lat)*from,
+				s.From.Lon+(s.To.Lon-s.From.Lon)*to, s.From.Lat+(s.To.Lat-s.From.Lat)*to)})
+		}
+		doc.Placemarks = append(doc.Placemarks, placemark)
+	}
+}
+
+// kmlColor converts a #rrggbb color into KML's opaque aabbggrr.
+func kmlColor(color string) string {
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) != 6 {
+		return "ff000000"
+	}
+	return "ff" + hex[4:6] + hex[2:4] + hex[0:2]
+}
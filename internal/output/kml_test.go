@@ -0,0 +1,73 @@
+package output
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateKML checks that placemarks carry their position, time and data columns, with no path unless asked.
+func TestGenerateKML(t *testing.T) {
+	ele := 35.0
+	GenerateKML([]geodata.Point{
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele, Make: "NIKON", Model: "COOLPIX P6000"},
+		{Name: "Image2 & co", Lat: 48.8566, Lon: 2.3522},
+	}, UnitsMetric, false, 0)
+	defer os.Remove("out/mymaps.kml")
+
+	content, err := os.ReadFile("out/mymaps.kml")
+	if err != nil {
+		t.Fatalf("Expected mymaps.kml to be generated: %v", err)
+	}
+	for _, expected := range []string{
+		`<kml xmlns="http://www.opengis.net/kml/2.2">`,
+		"<coordinates>-0.127600,51.507400,35.000000</coordinates>",
+		"<when>2024-05-01T10:00:00Z</when>",
+		`<Data name="Camera">`,
+		"<name>Image2 &amp; co</name>",
+		"<coordinates>2.352200,48.856600</coordinates>",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected mymaps.kml to contain %s, got:\n%s", expected, content)
+		}
+	}
+	if strings.Contains(string(content), "LineString") {
+		t.Error("Expected no path without path set")
+	}
+}
+
+// TestGenerateKMLPath checks that the path's legs are dashed when stationary and colored by speed when moving.
+func TestGenerateKMLPath(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	GenerateKML([]geodata.Point{
+		{Name: "a", Lat: 51.5, Lon: -0.1, Time: start},
+		{Name: "b", Lat: 51.5, Lon: -0.1001, Time: start.Add(10 * time.Minute)},
+		// about 7 km in an hour, faster than walking
+		{Name: "c", Lat: 51.5, Lon: 0, Time: start.Add(70 * time.Minute)},
+	}, UnitsMetric, true, 50)
+	defer os.Remove("out/mymaps.kml")
+
+	content, err := os.ReadFile("out/mymaps.kml")
+	if err != nil {
+		t.Fatalf("Expected mymaps.kml to be generated: %v", err)
+	}
+	for _, expected := range []string{
+		`<Style id="path-stationary">`,
+		"<color>ff757575</color>",
+		`<Style id="path-f9a825">`,
+		"<color>ff25a8f9</color>",
+		"<styleUrl>#path-stationary</styleUrl>",
+		"<styleUrl>#path-f9a825</styleUrl>",
+		"<name>Stationary for 10m0s</name>",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected mymaps.kml to contain %s, got:\n%s", expected, content)
+		}
+	}
+	if dashes := strings.Count(string(content), "<LineString>"); dashes != (kmlDashes+1)/2+1 {
+		t.Errorf("Expected %d dashes of the stationary leg and a line of the moving one, got %d lines", (kmlDashes+1)/2, dashes)
+	}
+}
@@ -0,0 +1,185 @@
+package output
+
+import (
+	"bytes"
+	"html/template"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// The engines the HTML map can be drawn with
+const (
+	// EngineECharts draws the points on an ECharts geo chart
+	EngineECharts = "echarts"
+	// EngineLeaflet draws the points with Leaflet on OpenStreetMap tiles, which pan and zoom to anywhere in the
+	// world down to street level
+	EngineLeaflet = "leaflet"
+	// EngineMapLibre draws the points with MapLibre GL on the vector tiles of a MapLibre style, see
+	// MapOptions.Style
+	EngineMapLibre = "maplibre"
+)
+
+// Engines are the valid values of MapOptions.Engine.
+var Engines = []string{EngineECharts, EngineLeaflet, EngineMapLibre}
+
+// leafletCDN is where Leaflet is loaded from unless MapOptions.AssetsHost says otherwise
+const leafletCDN = "https://unpkg.com/leaflet@1.9.4/dist/"
+
+// tilePoint is a marker of the Leaflet and MapLibre maps. Name and Details are plain text, put into the page as
+// such.
+type tilePoint struct {
+	Lat     float64  `json:"lat"`
+	Lon     float64  `json:"lon"`
+	Name    string   `json:"name"`
+	Details []string `json:"details,omitempty"`
+	Thumb   string   `json:"thumb,omitempty"`
+	Number  int      `json:"number,omitempty"`
+	// NotBackedUp colors the marker like on the ECharts map
+	NotBackedUp bool `json:"notBackedUp,omitempty"`
+}
+
+// leafletTemplate is the page of the Leaflet map. The points are embedded in the page, and the path, if any, is
+// drawn through them in the order given.
+var leafletTemplate = template.Must(template.New("leaflet").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Assets}}leaflet.css">
+<script src="{{.Assets}}leaflet.js"></script>
+<style>
+html, body, #map { height: 100%; margin: 0; }
+.photos2map-title { position: absolute; top: 10px; left: 50%; transform: translateX(-50%); z-index: 1000; margin: 0; padding: 4px 12px; background: rgba(255, 255, 255, 0.85); border-radius: 4px; font: bold 16px sans-serif; }
+.photos2map-popup img { display: block; max-width: 240px; max-height: 240px; margin-top: 6px; }
+</style>
+</head>
+<body>
+<h1 class="photos2map-title">{{.Title}}</h1>
+<div id="map" role="application" aria-label="{{.Title}}"></div>
+<script>
+(function () {
+	var points = {{.Points}};
+	var map = L.map('map', {preferCanvas: true, worldCopyJump: true});
+	L.tileLayer({{.Tiles.URL}}, {
+		maxZoom: {{.Tiles.MaxZoom}},
+		subdomains: {{.Tiles.Subdomains}},
+		attribution: {{.Tiles.Attribution}}
+	}).addTo(map);
+	{{- if .Path}}
+	L.polyline(points.map(function (p) { return [p.lat, p.lon]; }), {color: '#006666', weight: 2, opacity: 0.6}).addTo(map);
+	{{- end}}
+	var markers = points.map(function (p) {
+		var label = (p.number ? p.number + '. ' : '') + p.name;
+		var popup = document.createElement('div');
+		popup.className = 'photos2map-popup';
+		var title = document.createElement('strong');
+		title.textContent = label;
+		popup.appendChild(title);
+		(p.details || []).forEach(function (line) {
+			popup.appendChild(document.createElement('br'));
+			popup.appendChild(document.createTextNode(line));
+		});
+		if (p.thumb) {
+			var img = document.createElement('img');
+			img.src = p.thumb;
+			img.alt = label;
+			popup.appendChild(img);
+		}
+		var marker = L.circleMarker([p.lat, p.lon], {radius: 7, color: '#ffffff', weight: 2, fillColor: p.notBackedUp ? '{{.NotBackedUpColor}}' : '#006666', fillOpacity: 1});
+		marker.bindPopup(popup);
+		{{- if .NumberMarkers}}
+		if (p.number) {
+			marker.bindTooltip(String(p.number), {permanent: true, direction: 'top'});
+		}
+		{{- else}}
+		marker.bindTooltip(label);
+		{{- end}}
+		return marker;
+	});
+	var group = L.featureGroup(markers).addTo(map);
+	map.fitBounds(group.getBounds(), {padding: [30, 30], maxZoom: 15});
+})();
+</script>
+</body>
+</html>
+`))
+
+// generateTileMap writes the map drawn with Leaflet or MapLibre, as mapOpts.Engine says, to the file named page in
+// dir, along with its link preview and the thumbnails if mapOpts asks for them. Of the other options only the path
+// and numbered markers apply.
+func generateTileMap(gpsData []geodata.Point, mapOpts MapOptions, dir, page string) {
+	tiles := mapOpts.Tiles
+	if tiles.URL == "" {
+		tiles = TileProviders[TilesOSM]
+	}
+	tmpl, assets := leafletTemplate, leafletCDN
+	var style any
+	if mapOpts.Engine == EngineMapLibre {
+		tmpl, assets = maplibreTemplate, maplibreCDN
+		switch {
+		case mapOpts.Style != "":
+			style = mapOpts.Style
+		case mapOpts.Tiles.URL != "":
+			style = rasterStyle(mapOpts.Tiles)
+		default:
+			style = DefaultStyle
+		}
+	}
+	if mapOpts.AssetsHost != "" {
+		assets = mapOpts.AssetsHost
+	}
+
+	title := mapOpts.Title
+	if title == "" {
+		title = defaultTitle
+	}
+	description := mapOpts.Description
+	if description == "" {
+		description = defaultDescription(gpsData)
+	}
+
+	var thumbs map[string]string
+	if mapOpts.Thumbnails {
+		thumbs = writeThumbnails(gpsData, dir, mapOpts.ThumbnailCache)
+	}
+	points := make([]tilePoint, len(gpsData))
+	for i, p := range gpsData {
+		points[i] = tilePoint{
+			Lat:         p.Lat,
+			Lon:         p.Lon,
+			Name:        p.Name,
+			Details:     pointDetails(p, mapOpts.Units),
+			Thumb:       thumbs[p.Path],
+			Number:      p.Number,
+			NotBackedUp: p.NotBackedUp,
+		}
+	}
+
+	var rendered bytes.Buffer
+	err := tmpl.Execute(&rendered, struct {
+		Title            string
+		Assets           string
+		Tiles            TileProvider
+		Style            any
+		Lang             string
+		Points           []tilePoint
+		Path             bool
+		NumberMarkers    bool
+		NotBackedUpColor string
+	}{title, assets, tiles, style, mapOpts.Lang, points, mapOpts.Path, mapOpts.NumberMarkers, notBackedUpColor})
+	if err != nil {
+		log.Fatalf("Error rendering %s map: %v", mapOpts.Engine, err)
+	}
+
+	content := addSocialMeta(rendered.Bytes(), socialMeta(title, description, mapOpts.BaseURL, page))
+	if err := writeFile(filepath.Join(dir, page), content); err != nil {
+		log.Fatalf("Error creating map file: %v", err)
+	}
+	if err := writePreview(gpsData, filepath.Join(dir, previewFile)); err != nil {
+		log.Errorf("Error writing link preview image: %v", err)
+	}
+}
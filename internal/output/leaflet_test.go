@@ -0,0 +1,50 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateLeafletMap checks that the Leaflet map embeds the points as data, loads Leaflet and the tiles, and
+// keeps the link previews of the ECharts map.
+func TestGenerateLeafletMap(t *testing.T) {
+	defer func(dir string) { Dir = dir }(Dir)
+	Dir = t.TempDir()
+	gpsData := []geodata.Point{
+		{Name: `<b>Rome</b>`, Lat: 41.9028, Lon: 12.4964, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Number: 1},
+		{Name: "Sydney", Lat: -33.8688, Lon: 151.2093, Number: 2},
+	}
+
+	GenerateMap(gpsData, MapOptions{Engine: EngineLeaflet, Path: true, Title: "Trip", BaseURL: "https://example.com/"})
+	data, err := os.ReadFile(filepath.Join(Dir, "map.html"))
+	if err != nil {
+		t.Fatalf("Expected map.html to be written: %v", err)
+	}
+	page := string(data)
+	for _, expected := range []string{
+		`<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js">`,
+		`https://tile.openstreetmap.org/{z}/{x}/{y}.png`,
+		`"lat":-33.8688,"lon":151.2093,"name":"Sydney"`,
+		`"Taken: 2024-05-01 10:00:00"`,
+		`L.polyline(`,
+		`<meta property="og:url" content="https://example.com/map.html">`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the map to contain %s, got:\n%s", expected, page)
+		}
+	}
+	if strings.Contains(page, "<b>Rome</b>") {
+		t.Errorf("Expected the name to be escaped in the page, got:\n%s", page)
+	}
+	if strings.Contains(page, "echarts") {
+		t.Errorf("Expected no ECharts in the Leaflet map, got:\n%s", page)
+	}
+	if _, err := os.Stat(filepath.Join(Dir, previewFile)); err != nil {
+		t.Errorf("Expected the link preview to be written: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package output
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// The ways the legend of the HTML map can group the markers
+const (
+	// LegendFolder groups the photos by the folder they are in
+	LegendFolder = "folder"
+	// LegendDay groups the photos by the day they were taken
+	LegendDay = "day"
+	// LegendCamera groups the photos by the camera they were taken with
+	LegendCamera = "camera"
+	// LegendBackup groups the photos by whether they were found in a backup, see geodata.Point.NotBackedUp
+	LegendBackup = "backup"
+)
+
+// Legends are the valid values of MapOptions.Legend other than "" for no legend.
+var Legends = []string{LegendFolder, LegendDay, LegendCamera, LegendBackup}
+
+// legendJS adds a panel listing the groups in photos2map.legend with the number of photos in each. Unticking a
+// group hides its markers, and its "only" button hides every other group, or shows them all again if it's
+// already the only one shown. It filters through photos2map.filters, so it combines with the search box.
+const legendJS = `
+	(function (chart) {
+		var dom = chart.getDom();
+		dom.style.position = 'relative';
+		var box = document.createElement('div');
+		box.className = 'photos2map-legend';
+		box.setAttribute('role', 'group');
+		box.setAttribute('aria-label', 'Photo groups');
+		box.style.cssText = 'position:absolute;top:72px;left:10px;z-index:10;max-height:50%;overflow:auto;background:rgba(255,255,255,0.9);border:1px solid #999;border-radius:4px;padding:4px 6px;font:12px sans-serif;';
+		dom.appendChild(box);
+		var hidden = {};
+		var checks = {};
+		function update() {
+			photos2map.legend.forEach(function (g) {
+				checks[g.name].checked = !hidden[g.name];
+			});
+			photos2map.filters.legend = function (d) {
+				return !hidden[d.group];
+			};
+			photos2map.refilter();
+		}
+		photos2map.legend.forEach(function (g) {
+			var row = document.createElement('div');
+			row.style.cssText = 'display:flex;align-items:center;gap:4px;white-space:nowrap;';
+			var label = document.createElement('label');
+			label.style.cssText = 'flex:1;cursor:pointer;';
+			var check = document.createElement('input');
+			check.type = 'checkbox';
+			check.checked = true;
+			check.addEventListener('change', function () {
+				hidden[g.name] = !check.checked;
+				update();
+			});
+			checks[g.name] = check;
+			var badge = document.createElement('span');
+			badge.textContent = g.count;
+			badge.setAttribute('aria-label', g.count + ' photos');
+			badge.style.cssText = 'display:inline-block;min-width:16px;margin-left:4px;padding:0 4px;border-radius:8px;background:#006666;color:#fff;text-align:center;';
+			label.appendChild(check);
+			label.appendChild(document.createTextNode(' ' + g.name));
+			label.appendChild(badge);
+			var only = document.createElement('button');
+			only.type = 'button';
+			only.textContent = 'only';
+			only.title = 'Show only ' + g.name + ', or every group if it is the only one shown';
+			only.style.cssText = 'border:1px solid #999;border-radius:4px;background:#fff;font-size:11px;cursor:pointer;';
+			only.addEventListener('click', function () {
+				var alone = photos2map.legend.every(function (o) {
+					return !hidden[o.name] === (o.name === g.name);
+				});
+				photos2map.legend.forEach(function (o) {
+					hidden[o.name] = !alone && o.name !== g.name;
+				});
+				update();
+			});
+			row.appendChild(label);
+			row.appendChild(only);
+			box.appendChild(row);
+		});
+	})(%MY_ECHARTS%);
+`
+
+// legendGroup is an entry of the legend, a group of photos and how many there are
+type legendGroup struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// addLegend adds the legend grouping the markers as legend says. It must be added after addFilters.
+func addLegend(geo *charts.Geo, gpsData []geodata.Point, legend string) {
+	groups, err := json.Marshal(legendGroups(gpsData, legend))
+	if err != nil {
+		return
+	}
+	geo.AddJSFuncs(`var photos2map = photos2map || {};`, `photos2map.legend = `+string(groups)+`;`, legendJS)
+}
+
+// legendGroups returns the groups the points fall into with the number of points in each, sorted by name, so
+// days are in order.
+func legendGroups(gpsData []geodata.Point, legend string) []legendGroup {
+	counts := make(map[string]int)
+	for _, p := range gpsData {
+		counts[legendKey(p, legend)]++
+	}
+	groups := make([]legendGroup, 0, len(counts))
+	for name, count := range counts {
+		groups = append(groups, legendGroup{Name: name, Count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// legendKey returns the name of the group p falls into when grouping as legend says, or "" for no legend.
+func legendKey(p geodata.Point, legend string) string {
+	switch legend {
+	case LegendFolder:
+		// cut at the last slash rather than using path.Dir, which would also collapse the slashes of a URL
+		path := filepath.ToSlash(p.Path)
+		if i := strings.LastIndex(path, "/"); i > 0 {
+			return path[:i]
+		}
+		return "."
+	case LegendDay:
+		if p.Time.IsZero() {
+			return "Unknown date"
+		}
+		return p.Time.Format(time.DateOnly)
+	case LegendCamera:
+		if camera := p.Camera(); camera != "" {
+			return camera
+		}
+		return "Unknown camera"
+	case LegendBackup:
+		if p.NotBackedUp {
+			return "Not backed up"
+		}
+		return "Backed up"
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestLegendGroups checks that points are counted per folder, day and camera, with the groups sorted by name.
+func TestLegendGroups(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Path: "photos/2024/b.jpg", Time: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC), Make: "Apple", Model: "iPhone 15"},
+		{Path: "photos/2024/a.jpg", Time: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), Make: "Apple", Model: "iPhone 15"},
+		{Path: "https://example.com/photos/c.jpg", Time: time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)},
+		{Path: "d.jpg", NotBackedUp: true},
+	}
+	tests := map[string][]legendGroup{
+		LegendFolder: {{".", 1}, {"https://example.com/photos", 1}, {"photos/2024", 2}},
+		LegendDay:    {{"2024-05-01", 2}, {"2024-05-02", 1}, {"Unknown date", 1}},
+		LegendCamera: {{"Apple iPhone 15", 2}, {"Unknown camera", 2}},
+		LegendBackup: {{"Backed up", 3}, {"Not backed up", 1}},
+	}
+	for legend, expected := range tests {
+		if groups := legendGroups(gpsData, legend); !reflect.DeepEqual(groups, expected) {
+			t.Errorf("Expected the groups %+v by %s, got %+v", expected, legend, groups)
+		}
+	}
+}
+
+// TestAddLegend checks that the legend script is added with the groups, and markers are tagged with theirs.
+func TestAddLegend(t *testing.T) {
+	gpsData := []geodata.Point{{Name: "Image1", Make: "NIKON", Model: "COOLPIX P6000"}}
+	geo := charts.NewGeo()
+	addLegend(geo, gpsData, LegendCamera)
+
+	var js string
+	for _, fn := range geo.JSFunctions.Fns {
+		js += string(fn)
+	}
+	if !strings.Contains(js, "photos2map-legend") || !strings.Contains(js, `photos2map.legend = [{"name":"NIKON COOLPIX P6000","count":1}];`) {
+		t.Errorf("Expected the legend script with its groups to be added, got %s", js)
+	}
+	if markers := toMarkers(gpsData, nil, LegendCamera, UnitsMetric); markers[0].Group != "NIKON COOLPIX P6000" {
+		t.Errorf("Expected the marker in the camera's group, got %+v", markers[0])
+	}
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// LinkFiles is the PhotoLinks template linking to the photos' files on the local disk
+const LinkFiles = "file"
+
+// PhotoLinks describes the URLs outputs link each point's photo at.
+type PhotoLinks struct {
+	// Template is empty for no links, LinkFiles for file:// URLs of the local files, or a URL in which {path},
+	// {file}, {name} and {id} are replaced by the photo's path relative to Root, its file name with and without
+	// the extension, and its ID, each escaped for use in a URL
+	Template string
+	// Root is the directory, archive or URL that was scanned, which {path} is relative to
+	Root string
+}
+
+// URL returns the link to p's photo, or "" if there's none. With LinkFiles, photos read over http(s) link to
+// where they were read from, and photos that aren't files on the local disk, such as those in archives, aren't
+// linked.
+func (l PhotoLinks) URL(p geodata.Point) string {
+	switch l.Template {
+	case "":
+		return ""
+	case LinkFiles:
+		if strings.HasPrefix(p.Path, "http://") || strings.HasPrefix(p.Path, "https://") {
+			return p.Path
+		}
+		if info, err := os.Stat(p.Path); err != nil || !info.Mode().IsRegular() {
+			return ""
+		}
+		abs, err := filepath.Abs(p.Path)
+		if err != nil {
+			return ""
+		}
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	}
+
+	file := filepath.Base(p.Path)
+	return strings.NewReplacer(
+		"{path}", escapePath(l.relative(p.Path)),
+		"{file}", url.PathEscape(file),
+		"{name}", url.PathEscape(strings.TrimSuffix(file, filepath.Ext(file))),
+		"{id}", url.PathEscape(p.ID),
+	).Replace(l.Template)
+}
+
+// relative returns path relative to l.Root with slash separators, or the whole path if it isn't inside l.Root.
+func (l PhotoLinks) relative(path string) string {
+	root := strings.TrimRight(l.Root, `/\`)
+	if root == "" || root == "." {
+		return filepath.ToSlash(path)
+	}
+	if rel, ok := strings.CutPrefix(path, root); ok && (strings.HasPrefix(rel, "/") || strings.HasPrefix(rel, string(filepath.Separator))) {
+		return filepath.ToSlash(rel[1:])
+	}
+	return filepath.ToSlash(path)
+}
+
+// escapePath escapes each segment of a slash-separated path for use in a URL, keeping the slashes.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,49 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestPhotoLinksTemplate checks that the placeholders are filled in and escaped, relative to the scanned root.
+func TestPhotoLinksTemplate(t *testing.T) {
+	p := geodata.Point{ID: "3f2a9c1b7d4e", Path: filepath.Join("photos", "2024 trip", "DSC#1.jpg")}
+	tests := []struct {
+		links    PhotoLinks
+		expected string
+	}{
+		{PhotoLinks{}, ""},
+		{PhotoLinks{Template: "https://example.com/{path}", Root: "photos"}, "https://example.com/2024%20trip/DSC%231.jpg"},
+		{PhotoLinks{Template: "https://example.com/{path}", Root: "photos/"}, "https://example.com/2024%20trip/DSC%231.jpg"},
+		{PhotoLinks{Template: "https://example.com/{path}", Root: "pho"}, "https://example.com/photos/2024%20trip/DSC%231.jpg"},
+		{PhotoLinks{Template: "https://example.com/{name}/{file}?id={id}"}, "https://example.com/DSC%231/DSC%231.jpg?id=3f2a9c1b7d4e"},
+	}
+	for _, tt := range tests {
+		if got := tt.links.URL(p); got != tt.expected {
+			t.Errorf("Expected %q for %+v, got %q", tt.expected, tt.links, got)
+		}
+	}
+}
+
+// TestPhotoLinksFiles checks that file links point to local files only, and photos read over http(s) link there.
+func TestPhotoLinksFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a b.jpg")
+	if err := os.WriteFile(path, []byte("photo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	links := PhotoLinks{Template: LinkFiles}
+
+	if got := links.URL(geodata.Point{Path: path}); !strings.HasPrefix(got, "file:///") || !strings.HasSuffix(got, "/a%20b.jpg") {
+		t.Errorf("Expected a file URL for %s, got %q", path, got)
+	}
+	if got := links.URL(geodata.Point{Path: "https://example.com/a.jpg"}); got != "https://example.com/a.jpg" {
+		t.Errorf("Expected the photo's own URL, got %q", got)
+	}
+	if got := links.URL(geodata.Point{Path: filepath.Join("photos.zip", "a.jpg")}); got != "" {
+		t.Errorf("Expected no link for a photo inside an archive, got %q", got)
+	}
+}
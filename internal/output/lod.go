@@ -0,0 +1,80 @@
+package output
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// lodCell is the size in degrees of the grid cells points are thinned to at the map's initial zoom, about
+// 8 pixels for a map a few thousand kilometers across
+const lodCell = 0.5
+
+// lodMaxLevels is the most zoom levels thinned, each halving the cell size, beyond which all points are shown
+const lodMaxLevels = 16
+
+// lodJS swaps the markers for the thinned set of the current zoom level whenever the map is zoomed: level n,
+// the nth entry of photos2map.lod, is used from zoom 2^n, and all markers once past the last level. A search
+// filter, when present, is applied on top through photos2map.matches.
+const lodJS = `
+	(function (chart) {
+		var full = chart.getOption().series[0].data;
+		var current = -1;
+		photos2map.thin = function (force) {
+			var zoom = chart.getOption().geo[0].zoom || 1;
+			var level = Math.max(0, Math.floor(Math.log2(zoom)));
+			if (level >= photos2map.lod.length) {
+				level = photos2map.lod.length;
+			}
+			if (level === current && !force) {
+				return;
+			}
+			current = level;
+			var data = level < photos2map.lod.length ? photos2map.lod[level].map(function (i) { return full[i]; }) : full;
+			if (photos2map.matches) {
+				data = data.filter(photos2map.matches);
+			}
+			chart.setOption({series: [{data: data}]});
+		};
+		photos2map.thin(true);
+		chart.on('georoam', function () { photos2map.thin(false); });
+		window.addEventListener('hashchange', function () { photos2map.thin(false); });
+	})(%MY_ECHARTS%);
+`
+
+// addLevelOfDetail thins the markers shown when zoomed out, so maps with many points stay smooth to pan and
+// zoom. It must be added after the search box and permalinks, whose filter and view it applies.
+func addLevelOfDetail(geo *charts.Geo, gpsData []geodata.Point) {
+	levels, err := json.Marshal(lodLevels(gpsData))
+	if err != nil {
+		return
+	}
+	geo.AddJSFuncs(`var photos2map = photos2map || {};`, `photos2map.lod = `+string(levels)+`;`, lodJS)
+}
+
+// lodLevels returns for each zoom level, from the initial zoom in, the indices of the points shown: the first
+// point in each cell of a grid whose cells halve in size every level. The levels stop before the first that
+// would show every point.
+func lodLevels(gpsData []geodata.Point) [][]int {
+	levels := [][]int{}
+	for level := 0; level < lodMaxLevels; level++ {
+		cell := lodCell / math.Pow(2, float64(level))
+		seen := make(map[[2]int64]bool)
+		var shown []int
+		for i, p := range gpsData {
+			key := [2]int64{int64(math.Floor(p.Lon / cell)), int64(math.Floor(p.Lat / cell))}
+			if !seen[key] {
+				seen[key] = true
+				shown = append(shown, i)
+			}
+		}
+		if len(shown) == len(gpsData) {
+			break
+		}
+		levels = append(levels, shown)
+	}
+	return levels
+}
@@ -0,0 +1,57 @@
+package output
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestLODLevels checks that each level keeps the first point per grid cell, with cells halving every level.
+func TestLODLevels(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Lat: 48.0, Lon: 2.0},
+		{Lat: 48.1, Lon: 2.1},   // same cell as the first down to 0.125°
+		{Lat: 48.01, Lon: 2.01}, // same cell as the first down to 0.015625°, the sixth level
+		{Lat: 10.0, Lon: 20.0},
+	}
+
+	levels := lodLevels(gpsData)
+	if len(levels) != 6 {
+		t.Fatalf("Expected 6 levels before every point is shown, got %v", levels)
+	}
+	expected := [][]int{{0, 3}, {0, 3}, {0, 3}, {0, 1, 3}}
+	if !reflect.DeepEqual(levels[:4], expected) {
+		t.Errorf("Expected the first levels %v, got %v", expected, levels[:4])
+	}
+	if len(levels[5]) != 3 {
+		t.Errorf("Expected the last level to still hide a point, got %v", levels[5])
+	}
+
+	if levels := lodLevels(gpsData[:1]); len(levels) != 0 {
+		t.Errorf("Expected no levels when all points are always shown, got %v", levels)
+	}
+}
+
+// TestGenerateMapLevelOfDetail checks that thinning is only added to maps above the threshold.
+func TestGenerateMapLevelOfDetail(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Name: "Image1", Lat: 48.0, Lon: 2.0},
+		{Name: "Image2", Lat: 48.01, Lon: 2.01},
+	}
+	defer os.Remove("out/map.html")
+	defer os.Remove("out/" + previewFile)
+
+	for threshold, expected := range map[int]bool{0: false, 1: true, 2: false} {
+		GenerateMap(gpsData, MapOptions{Inline: true, Search: true, ThinAbove: threshold})
+		page, err := os.ReadFile("out/map.html")
+		if err != nil {
+			t.Fatalf("Error reading map.html: %v", err)
+		}
+		if got := strings.Contains(string(page), "photos2map.lod = [[0],"); got != expected {
+			t.Errorf("Expected thinning %v above %d points, got %v", expected, threshold, got)
+		}
+	}
+}
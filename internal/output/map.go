@@ -2,45 +2,386 @@
 package output
 
 import (
-	"os"
+	"bytes"
+	"fmt"
+	"html"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 	"github.com/go-echarts/go-echarts/v2/types"
+
+	"github.com/toozej/photos2map/internal/cache"
+	"github.com/toozej/photos2map/pkg/geodata"
 )
 
+// tooltipFormatter shows the point's name followed by the details rendered into the third value dimension.
+// Path segments have no value and only show their name.
+const tooltipFormatter = `function (params) {
+		if (params.seriesType === 'lines') {
+			return params.name;
+		}
+		return params.name + '<br/>' + params.value[2];
+}`
+
+// numberFormatter labels a marker with the photo's number in capture order
+const numberFormatter = `function (params) { return params.data.number; }`
+
+// defaultTitle is the map's title when none is given
+const defaultTitle = "photos2map: GPS Image Map"
+
+// chartID identifies the map's chart in the page
+const chartID = "photos2map"
+
+// MapOptions controls optional features of the generated HTML map.
+type MapOptions struct {
+	// Engine is what the map is drawn with, one of Engines, defaulting to ECharts when empty. The Leaflet and
+	// MapLibre maps only support Path, NumberMarkers, Thumbnails, Units and the options of the page and its link
+	// previews, and always embed the points in the page.
+	Engine string
+	// Style is the URL of the MapLibre style the MapLibre map is drawn with. When empty it's drawn on Tiles if
+	// set, and DefaultStyle otherwise.
+	Style string
+	// Tiles are the tiles the Leaflet map is drawn on, OpenStreetMap's when unset
+	Tiles TileProvider
+	// Lang, if set, is the language the labels of the MapLibre map's style are shown in, see ValidLang. Raster
+	// tiles have their labels drawn in.
+	Lang string
+	// Path connects the points in capture order, styled by speed and stops
+	Path bool
+	// StopRadius is the distance in meters within which consecutive photos are considered taken at the same spot
+	StopRadius float64
+	// Fullscreen, ScaleBar, Measure and Locate add the corresponding controls to the map
+	Fullscreen bool
+	ScaleBar   bool
+	Measure    bool
+	Locate     bool
+	// MiniMap adds an inset world map showing the area currently in view
+	MiniMap bool
+	// Search adds a box filtering the visible markers by name, date or other details
+	Search bool
+	// Legend, one of Legends, adds a panel grouping the markers by folder, day or camera, with the number of
+	// photos in each group and a checkbox showing or hiding it
+	Legend string
+	// Permalink keeps the current view and search filter in the URL hash
+	Permalink bool
+	// Inline embeds the points in the HTML page instead of writing them to a separate points.js
+	Inline bool
+	// PWA writes a web app manifest and service worker so the map works offline and can be installed
+	PWA bool
+	// Title and Description are shown on the map and in link previews; Description defaults to a summary of the photos
+	Title       string
+	Description string
+	// BaseURL is where the map will be published, used to give link previews the absolute URLs they need
+	BaseURL string
+	// Thumbnails writes a thumbnail of every photo to a thumbs directory and shows it when a marker is clicked
+	Thumbnails bool
+	// ThumbnailCache, if set, records the thumbnails written so later runs only make those of new or edited photos
+	ThumbnailCache *cache.Cache
+	// ThinAbove, if set, shows only some of the markers while zoomed out on maps with more points than this,
+	// more of them the further in, so the map stays smooth to pan and zoom
+	ThinAbove int
+	// Cluster groups nearby markers into markers counting them, which split up as the map is zoomed in, instead
+	// of thinning them out with ThinAbove
+	Cluster bool
+	// NumberMarkers labels the markers with the photos' numbers, see geodata.NumberByTime. The WebGL renderer
+	// doesn't draw labels.
+	NumberMarkers bool
+	// SpreadDuplicates moves markers sharing a position apart by a few meters so each can be clicked
+	SpreadDuplicates bool
+	// Region is the map the ECharts map is drawn on, RegionAuto or empty for the world zoomed in on the photos, or
+	// another of the maps ValidRegion accepts
+	Region string
+	// Units is what distances, altitudes and speeds are shown in, one of Units, defaulting to metric when empty
+	Units string
+	// Renderer is what the markers are drawn with, one of Renderers, defaulting to a canvas when empty
+	Renderer string
+	// AssetsHost is the URL, or path relative to the map, ECharts and its map data, Leaflet or MapLibre are
+	// loaded from, by default the go-echarts CDN or unpkg. It must end with a slash.
+	AssetsHost string
+}
+
+// roamingGeo extends the geo component with panning and zooming, and the initial view, which opts.GeoComponent
+// has no fields for
+type roamingGeo struct {
+	opts.GeoComponent
+	Roam   bool      `json:"roam"`
+	Center []float64 `json:"center,omitempty"`
+	Zoom   float64   `json:"zoom,omitempty"`
+}
+
+// mapVisitor customizes the echarts option object beyond what go-echarts exposes
+type mapVisitor struct {
+	charts.BaseConfigurationVisitor
+	// center and zoom are the initial view of the map, the whole map if unset
+	center []float64
+	zoom   float64
+}
+
+// VisitGeo enables roaming on the geo component, and sets its initial view.
+func (v mapVisitor) VisitGeo(geo opts.GeoComponent) interface{} {
+	return roamingGeo{GeoComponent: geo, Roam: true, Center: v.center, Zoom: v.zoom}
+}
+
+// Visit enables the accessibility options, which go-echarts doesn't expose.
+func (mapVisitor) Visit(chart map[string]interface{}) {
+	chart["aria"] = a11yOption
+}
+
 // GenerateMap creates an HTML file with a world map and pins based on GPS coordinates extracted from images.
-// The map is saved to "map.html".
-func GenerateMap(gpsData []opts.GeoData) {
+// The map is saved to "map.html" in Dir, with the points in "points.js" alongside it unless mapOpts.Inline is set.
+func GenerateMap(gpsData []geodata.Point, mapOpts MapOptions) {
+	generateMap(gpsData, mapOpts, Dir, filepath.Base(outputPath("map.html")))
+	log.Println("HTML map generated successfully.")
+}
+
+// generateMap writes the map to the file named page in dir, and the files it loads alongside it.
+func generateMap(gpsData []geodata.Point, mapOpts MapOptions, dir, page string) {
+	if mapOpts.Engine == EngineLeaflet || mapOpts.Engine == EngineMapLibre {
+		generateTileMap(gpsData, mapOpts, dir, page)
+		return
+	}
+
+	title := mapOpts.Title
+	if title == "" {
+		title = defaultTitle
+	}
+	description := mapOpts.Description
+	if description == "" {
+		description = defaultDescription(gpsData)
+	}
+
+	region, center, zoom := geoView(gpsData, mapOpts.Region)
 	geo := charts.NewGeo()
+	geo.Accept(mapVisitor{center: center, zoom: zoom})
 	geo.SetGlobalOptions(
-		charts.WithTitleOpts(opts.Title{Title: "photos2map: GPS Image Map"}),
+		// a fixed chart ID rather than go-echarts' random one keeps the page the same between runs on the same photos
+		charts.WithInitializationOpts(opts.Initialization{PageTitle: title, AssetsHost: mapOpts.AssetsHost, ChartID: chartID}),
+		charts.WithTitleOpts(opts.Title{Title: title}),
+		charts.WithTooltipOpts(opts.Tooltip{Formatter: opts.FuncOpts(tooltipFormatter)}),
 		charts.WithGeoComponentOpts(opts.GeoComponent{
-			// map comes from https://github.com/echarts-maps/echarts-countries-js/tree/master/echarts-countries-js
-			Map:       "USA",
+			// the map is loaded from go-echarts-assets' maps
+			Map:       region,
 			ItemStyle: &opts.ItemStyle{Color: "#006666"},
 		}),
 	)
 
-	geo.AddSeries("geo", types.ChartEffectScatter, gpsData,
-		charts.WithRippleEffectOpts(opts.RippleEffect{
+	var thumbs map[string]string
+	if mapOpts.Thumbnails {
+		thumbs = writeThumbnails(gpsData, dir, mapOpts.ThumbnailCache)
+	}
+
+	// only the markers are spread, the path and preview keep the real positions
+	markers := gpsData
+	if mapOpts.SpreadDuplicates {
+		markers = spreadDuplicates(gpsData)
+	}
+	if useWebGL(mapOpts.Renderer, len(gpsData)) {
+		addWebGLSeries(geo, toMarkers(markers, thumbs, mapOpts.Legend, mapOpts.Units))
+	} else {
+		series := charts.SingleSeries{
+			Name:        "geo",
+			Type:        types.ChartEffectScatter,
+			CoordSystem: types.ChartGeo,
+			Data:        toMarkers(markers, thumbs, mapOpts.Legend, mapOpts.Units),
+		}
+		if mapOpts.NumberMarkers {
+			series.Label = &opts.Label{Show: opts.Bool(true), Position: "top", Formatter: string(opts.FuncOpts(numberFormatter))}
+		}
+		series.ConfigureSeriesOpts(charts.WithRippleEffectOpts(opts.RippleEffect{
 			Period:    4,
 			Scale:     6,
 			BrushType: "stroke",
-		}),
-	)
+		}))
+		geo.MultiSeries = append(geo.MultiSeries, series)
+	}
 
-	file, err := os.Create("out/map.html")
-	if err != nil {
-		log.Fatalf("Error creating map file: %v", err)
+	addHeadingSeries(geo, markers, thumbs, mapOpts.Legend, mapOpts.Units)
+	addA11y(geo)
+	if mapOpts.Path {
+		addPathSeries(geo, gpsData, mapOpts.StopRadius, mapOpts.Units)
+	}
+	addControls(geo, mapOpts)
+	if mapOpts.MiniMap {
+		addMiniMap(geo)
+	}
+	if mapOpts.Search || mapOpts.Legend != "" {
+		addFilters(geo)
+	}
+	if mapOpts.Search {
+		addSearch(geo)
+	}
+	if mapOpts.Legend != "" {
+		addLegend(geo, gpsData, mapOpts.Legend)
+	}
+	if mapOpts.Permalink {
+		addPermalink(geo)
+	}
+	if mapOpts.Thumbnails {
+		addThumbnails(geo)
+	}
+	switch {
+	case mapOpts.Cluster:
+		addClusters(geo)
+	case mapOpts.ThinAbove > 0 && len(gpsData) > mapOpts.ThinAbove:
+		addLevelOfDetail(geo, gpsData)
 	}
-	defer file.Close()
 
-	err = geo.Render(file)
+	pages := []string{page}
+	var data []byte
+	if !mapOpts.Inline {
+		var err error
+		data, err = splitData(geo)
+		if err != nil {
+			log.Fatalf("Error serializing map points: %v", err)
+		}
+		if err := writeFile(filepath.Join(dir, pointsFile), data); err != nil {
+			log.Fatalf("Error writing map points file: %v", err)
+		}
+		pages = append(pages, pointsFile)
+	}
+	pages = append(pages, slices.Sorted(maps.Values(thumbs))...)
+	if mapOpts.PWA {
+		addPWA(geo)
+	}
+
+	var rendered bytes.Buffer
+	err := geo.Render(&rendered)
 	if err != nil {
 		log.Errorf("Error rendering map file to html: %v", err)
 	}
-	log.Println("HTML map generated successfully.")
+
+	content := addSocialMeta(rendered.Bytes(), socialMeta(title, description, mapOpts.BaseURL, page))
+	if err := writeFile(filepath.Join(dir, page), content); err != nil {
+		log.Fatalf("Error creating map file: %v", err)
+	}
+	if err := writePreview(gpsData, filepath.Join(dir, previewFile)); err != nil {
+		log.Errorf("Error writing link preview image: %v", err)
+	}
+
+	if mapOpts.PWA {
+		if err := writePWA(geo, dir, pages, append(content, data...)); err != nil {
+			log.Errorf("Error writing offline web app files: %v", err)
+		}
+	}
+}
+
+// marker is a data item of the marker series. Unlike opts.GeoData it carries the point's ID, which permalinks
+// to a photo refer to, its number, which numbered markers are labelled with, and its legend group.
+type marker struct {
+	ID     string        `json:"id,omitempty"`
+	Name   string        `json:"name"`
+	Value  []interface{} `json:"value"`
+	Number int           `json:"number,omitempty"`
+	Group  string        `json:"group,omitempty"`
+	// ItemStyle sets the marker apart, for photos not backed up
+	ItemStyle *opts.ItemStyle `json:"itemStyle,omitempty"`
+}
+
+// notBackedUpColor is the color of the markers of photos not backed up
+const notBackedUpColor = "#e65100"
+
+// toMarkers converts Points into the marker series' data, see pointValue for the value dimensions, in the legend
+// groups legend says.
+func toMarkers(gpsData []geodata.Point, thumbs map[string]string, legend, units string) []marker {
+	markers := make([]marker, 0, len(gpsData))
+	for _, p := range gpsData {
+		m := marker{
+			ID:     p.ID,
+			Name:   html.EscapeString(p.Name),
+			Value:  pointValue(p, thumbs, units),
+			Number: p.Number,
+			Group:  legendKey(p, legend),
+		}
+		if p.NotBackedUp {
+			m.ItemStyle = &opts.ItemStyle{Color: notBackedUpColor}
+		}
+		markers = append(markers, m)
+	}
+	return markers
+}
+
+// pointValue is the value of a marker: longitude, latitude and the tooltip details in the given units, followed
+// by the thumbnail URL when the point has one.
+func pointValue(p geodata.Point, thumbs map[string]string, units string) []interface{} {
+	value := []interface{}{p.Lon, p.Lat, tooltipDetails(p, units)}
+	if url, ok := thumbs[p.Path]; ok {
+		value = append(value, url)
+	}
+	return value
+}
+
+// headingMarker is a data item of the heading series, an arrow rotated to point where the camera was facing
+type headingMarker struct {
+	Name  string        `json:"name"`
+	Value []interface{} `json:"value"`
+	// SymbolRotate is counterclockwise in degrees, the opposite of a compass heading
+	SymbolRotate float64 `json:"symbolRotate"`
+	Group        string  `json:"group,omitempty"`
+}
+
+// addHeadingSeries overlays an arrow on every point with a known camera heading, in the legend groups legend
+// says.
+func addHeadingSeries(geo *charts.Geo, gpsData []geodata.Point, thumbs map[string]string, legend, units string) {
+	var markers []headingMarker
+	for _, p := range gpsData {
+		if p.Heading == nil {
+			continue
+		}
+		markers = append(markers, headingMarker{
+			Name:         html.EscapeString(p.Name),
+			Value:        pointValue(p, thumbs, units),
+			SymbolRotate: -*p.Heading,
+			Group:        legendKey(p, legend),
+		})
+	}
+	if len(markers) == 0 {
+		return
+	}
+
+	geo.MultiSeries = append(geo.MultiSeries, charts.SingleSeries{
+		Name:        "heading",
+		Type:        types.ChartScatter,
+		CoordSystem: types.ChartGeo,
+		Symbol:      "arrow",
+		SymbolSize:  14,
+		Data:        markers,
+	})
+}
+
+// tooltipDetails renders the HTML shown beneath a point's name in its tooltip.
+func tooltipDetails(p geodata.Point, units string) string {
+	lines := pointDetails(p, units)
+	for i := range lines {
+		lines[i] = html.EscapeString(lines[i])
+	}
+	return strings.Join(lines, "<br/>")
+}
+
+// pointDetails gives a point's caption, then describes when, at what altitude and heading, and with what camera
+// it was taken, one line each, with the altitude in the given units.
+func pointDetails(p geodata.Point, units string) []string {
+	var lines []string
+	if p.Caption != "" {
+		lines = append(lines, p.Caption)
+	}
+	if !p.Time.IsZero() {
+		lines = append(lines, "Taken: "+p.Time.Format("2006-01-02 15:04:05"))
+	}
+	if p.Ele != nil {
+		lines = append(lines, "Altitude: "+formatAltitude(*p.Ele, units))
+	}
+	if p.Heading != nil {
+		lines = append(lines, fmt.Sprintf("Heading: %.0f°", *p.Heading))
+	}
+	lines = append(lines, cameraDetails(p)...)
+	if p.NotBackedUp {
+		lines = append(lines, "Not backed up")
+	}
+	return lines
 }
@@ -2,25 +2,135 @@ package output
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/toozej/photos2map/pkg/geodata"
 )
 
 // TestGenerateMap checks that the HTML map file is created correctly.
 func TestGenerateMap(t *testing.T) {
-	gpsData := []opts.GeoData{
-		{Name: "Image1", Value: []float64{-0.1276, 51.5074}},
-		{Name: "Image2", Value: []float64{2.3522, 48.8566}},
+	ele, heading := 35.0, 90.0
+	gpsData := []geodata.Point{
+		{ID: "3f2a9c1b7d4e", Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele, Heading: &heading, Make: "NIKON", Model: "COOLPIX P6000"},
+		{Name: "Image2", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)},
 	}
 
-	GenerateMap(gpsData)
+	GenerateMap(gpsData, MapOptions{Path: true, StopRadius: 50, Inline: true, Title: "Holiday & more"})
+	defer os.Remove("out/" + previewFile)
 
 	// Check if the map file is created
 	if _, err := os.Stat("out/map.html"); os.IsNotExist(err) {
 		t.Fatalf("Expected map.html to be generated, but it does not exist")
 	}
 
+	// Check that the tooltips include the capture time
+	content, err := os.ReadFile("out/map.html")
+	if err != nil {
+		t.Fatalf("Error reading map.html: %v", err)
+	}
+	if !strings.Contains(string(content), "Taken: 2024-05-01 10:00:00") {
+		t.Errorf("Expected map.html to contain capture times in tooltips")
+	}
+	if !strings.Contains(string(content), `{"id":"3f2a9c1b7d4e","name":"Image1"`) {
+		t.Errorf("Expected map.html to identify markers by their point's ID")
+	}
+	if !strings.Contains(string(content), `"type":"lines"`) {
+		t.Errorf("Expected map.html to contain a path series")
+	}
+	if !strings.Contains(string(content), `src="https://go-echarts.github.io/go-echarts-assets/assets/echarts.min.js"`) {
+		t.Errorf("Expected map.html to load ECharts from the CDN by default")
+	}
+	if !strings.Contains(string(content), "Altitude: 35 m") {
+		t.Errorf("Expected map.html to contain altitudes in tooltips")
+	}
+	if !strings.Contains(string(content), `"symbolRotate":-90`) {
+		t.Errorf("Expected map.html to contain heading arrows")
+	}
+	if !strings.Contains(string(content), "Camera: NIKON COOLPIX P6000") {
+		t.Errorf("Expected map.html to contain cameras in tooltips")
+	}
+	if !strings.Contains(string(content), `<meta property="og:title" content="Holiday &amp; more">`) ||
+		!strings.Contains(string(content), "<title>Holiday &amp; more</title>") {
+		t.Errorf("Expected map.html to use the given title in its page title and link preview")
+	}
+
+	// The points are inlined, so no separate payload should be written
+	if _, err := os.Stat("out/points.js"); !os.IsNotExist(err) {
+		t.Errorf("Expected no points.js to be generated for an inline map")
+	}
+
 	// Clean up after test
 	os.Remove("out/map.html")
 }
+
+// TestGenerateMapSeparateData checks that the points are written to points.js by default.
+func TestGenerateMapSeparateData(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}
+
+	GenerateMap(gpsData, MapOptions{AssetsHost: "assets/"})
+	defer os.Remove("out/map.html")
+	defer os.Remove("out/" + previewFile)
+	defer os.Remove("out/points.js")
+
+	page, err := os.ReadFile("out/map.html")
+	if err != nil {
+		t.Fatalf("Error reading map.html: %v", err)
+	}
+	if strings.Contains(string(page), "Image1") || !strings.Contains(string(page), `<script src="points.js"></script>`) {
+		t.Errorf("Expected map.html to load its points from points.js")
+	}
+	if !strings.Contains(string(page), `src="assets/echarts.min.js"`) || strings.Contains(string(page), "https://") {
+		t.Errorf("Expected map.html to load ECharts from the given assets host only")
+	}
+
+	points, err := os.ReadFile("out/points.js")
+	if err != nil {
+		t.Fatalf("Expected points.js to be generated: %v", err)
+	}
+	if !strings.Contains(string(points), "Image1") {
+		t.Errorf("Expected points.js to contain the points, got %s", points)
+	}
+}
+
+// TestGenerateMapNumberMarkers checks that numbered markers carry their number and are labelled with it.
+func TestGenerateMapNumberMarkers(t *testing.T) {
+	gpsData := []geodata.Point{{Name: "Image1", Lat: 48.0, Lon: 2.0, Number: 7}}
+	defer os.Remove("out/map.html")
+	defer os.Remove("out/" + previewFile)
+
+	GenerateMap(gpsData, MapOptions{Inline: true, NumberMarkers: true})
+	page, err := os.ReadFile("out/map.html")
+	if err != nil {
+		t.Fatalf("Error reading map.html: %v", err)
+	}
+	if !strings.Contains(string(page), `"number":7`) || !strings.Contains(string(page), "params.data.number") {
+		t.Errorf("Expected the marker labelled with its number, got:\n%s", page)
+	}
+}
+
+// TestGenerateMapReproducible checks that the same points give the same page, so maps can be diffed and cached.
+func TestGenerateMapReproducible(t *testing.T) {
+	gpsData := []geodata.Point{
+		{Name: "Image1", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "Image2", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 14, 30, 0, 0, time.UTC)},
+	}
+	defer os.Remove("out/map.html")
+	defer os.Remove("out/" + previewFile)
+
+	var pages [2][]byte
+	for i := range pages {
+		GenerateMap(gpsData, MapOptions{Path: true, Inline: true})
+		page, err := os.ReadFile("out/map.html")
+		if err != nil {
+			t.Fatalf("Error reading map.html: %v", err)
+		}
+		pages[i] = page
+	}
+	if string(pages[0]) != string(pages[1]) {
+		t.Error("Expected the same map.html for the same points")
+	}
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"html/template"
+	"regexp"
+)
+
+// DefaultStyle is the MapLibre style of the MapLibre map unless MapOptions.Style says otherwise, OpenFreeMap's
+// Liberty, which needs no API key
+const DefaultStyle = "https://tiles.openfreemap.org/styles/liberty"
+
+// LangAuto labels the MapLibre map in the language of the viewer's browser
+const LangAuto = "auto"
+
+// langCode matches the language codes the name:xx fields of OpenMapTiles and OpenStreetMap are keyed by, such
+// as en, de or zh-Hant
+var langCode = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{2,4})?$`)
+
+// ValidLang reports whether lang is a valid MapOptions.Lang: LangAuto or a language code.
+func ValidLang(lang string) bool {
+	return lang == LangAuto || langCode.MatchString(lang)
+}
+
+// maplibreCDN is where MapLibre GL is loaded from unless MapOptions.AssetsHost says otherwise
+const maplibreCDN = "https://unpkg.com/maplibre-gl@4.7.1/dist/"
+
+// maplibreTemplate is the page of the MapLibre map. The points are embedded in the page and drawn as a circle
+// layer on top of the style, and the path, if any, as a line through them in the order given. With a language
+// the style's place labels show the names in it where the tiles have them, falling back to the local names.
+var maplibreTemplate = template.Must(template.New("maplibre").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Assets}}maplibre-gl.css">
+<script src="{{.Assets}}maplibre-gl.js"></script>
+<style>
+html, body, #map { height: 100%; margin: 0; }
+.photos2map-title { position: absolute; top: 10px; left: 50%; transform: translateX(-50%); z-index: 1000; margin: 0; padding: 4px 12px; background: rgba(255, 255, 255, 0.85); border-radius: 4px; font: bold 16px sans-serif; }
+.photos2map-popup img { display: block; max-width: 240px; max-height: 240px; margin-top: 6px; }
+.photos2map-number { padding: 0 4px; background: #ffffff; border: 1px solid #006666; border-radius: 3px; font: bold 11px sans-serif; pointer-events: none; }
+</style>
+</head>
+<body>
+<h1 class="photos2map-title">{{.Title}}</h1>
+<div id="map" role="application" aria-label="{{.Title}}"></div>
+<script>
+(function () {
+	var points = {{.Points}};
+	var bounds = new maplibregl.LngLatBounds();
+	points.forEach(function (p) { bounds.extend([p.lon, p.lat]); });
+	var map = new maplibregl.Map({
+		container: 'map',
+		style: {{.Style}},
+		bounds: bounds,
+		fitBoundsOptions: {padding: 40, maxZoom: 15}
+	});
+	map.addControl(new maplibregl.NavigationControl());
+
+	function label(p) {
+		return (p.number ? p.number + '. ' : '') + p.name;
+	}
+	function popup(p) {
+		var content = document.createElement('div');
+		content.className = 'photos2map-popup';
+		var title = document.createElement('strong');
+		title.textContent = label(p);
+		content.appendChild(title);
+		(p.details || []).forEach(function (line) {
+			content.appendChild(document.createElement('br'));
+			content.appendChild(document.createTextNode(line));
+		});
+		if (p.thumb) {
+			var img = document.createElement('img');
+			img.src = p.thumb;
+			img.alt = label(p);
+			content.appendChild(img);
+		}
+		return content;
+	}
+
+	map.on('load', function () {
+		{{- if .Lang}}
+		var lang = {{.Lang}};
+		if (lang === 'auto') {
+			lang = (navigator.language || 'en').split('-')[0];
+		}
+		map.getStyle().layers.forEach(function (layer) {
+			var field = layer.type === 'symbol' && map.getLayoutProperty(layer.id, 'text-field');
+			if (field && JSON.stringify(field).indexOf('name') !== -1) {
+				map.setLayoutProperty(layer.id, 'text-field', ['coalesce', ['get', 'name:' + lang], ['get', 'name']]);
+			}
+		});
+		{{- end}}
+		{{- if .Path}}
+		map.addSource('photos2map-path', {type: 'geojson', data: {
+			type: 'Feature',
+			properties: {},
+			geometry: {type: 'LineString', coordinates: points.map(function (p) { return [p.lon, p.lat]; })}
+		}});
+		map.addLayer({id: 'photos2map-path', type: 'line', source: 'photos2map-path',
+			paint: {'line-color': '#006666', 'line-width': 2, 'line-opacity': 0.6}});
+		{{- end}}
+		map.addSource('photos2map', {type: 'geojson', data: {
+			type: 'FeatureCollection',
+			features: points.map(function (p, i) {
+				return {type: 'Feature', properties: {index: i, notBackedUp: !!p.notBackedUp},
+					geometry: {type: 'Point', coordinates: [p.lon, p.lat]}};
+			})
+		}});
+		map.addLayer({id: 'photos2map', type: 'circle', source: 'photos2map', paint: {
+			'circle-radius': 7,
+			'circle-color': ['case', ['get', 'notBackedUp'], '{{.NotBackedUpColor}}', '#006666'],
+			'circle-stroke-color': '#ffffff',
+			'circle-stroke-width': 2
+		}});
+
+		var hover = new maplibregl.Popup({closeButton: false, closeOnClick: false, offset: 10});
+		map.on('click', 'photos2map', function (e) {
+			var p = points[e.features[0].properties.index];
+			new maplibregl.Popup({offset: 10}).setLngLat([p.lon, p.lat]).setDOMContent(popup(p)).addTo(map);
+		});
+		map.on('mousemove', 'photos2map', function (e) {
+			var p = points[e.features[0].properties.index];
+			map.getCanvas().style.cursor = 'pointer';
+			hover.setLngLat([p.lon, p.lat]).setText(label(p)).addTo(map);
+		});
+		map.on('mouseleave', 'photos2map', function () {
+			map.getCanvas().style.cursor = '';
+			hover.remove();
+		});
+		{{- if .NumberMarkers}}
+		points.forEach(function (p) {
+			if (!p.number) {
+				return;
+			}
+			var number = document.createElement('div');
+			number.className = 'photos2map-number';
+			number.textContent = p.number;
+			new maplibregl.Marker({element: number, anchor: 'bottom', offset: [0, -9]}).setLngLat([p.lon, p.lat]).addTo(map);
+		});
+		{{- end}}
+	});
+})();
+</script>
+</body>
+</html>
+`))
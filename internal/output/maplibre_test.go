@@ -0,0 +1,68 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestValidLang checks that language codes and auto are accepted, and language names aren't.
+func TestValidLang(t *testing.T) {
+	for lang, expected := range map[string]bool{
+		"en": true, "fil": true, "zh-Hant": true, "pt-BR": true, LangAuto: true,
+		"": false, "English": false, "EN": false, "en_US": false, "name:en": false,
+	} {
+		if ValidLang(lang) != expected {
+			t.Errorf("Expected ValidLang(%q) to be %v", lang, expected)
+		}
+	}
+}
+
+// TestGenerateMapLibreMap checks that the MapLibre map embeds the points as data, loads MapLibre and the given
+// style, numbers the markers when asked to and relabels the style in the language asked for.
+func TestGenerateMapLibreMap(t *testing.T) {
+	defer func(dir string) { Dir = dir }(Dir)
+	Dir = t.TempDir()
+	gpsData := []geodata.Point{
+		{Name: `<b>Rome</b>`, Lat: 41.9028, Lon: 12.4964, Number: 1},
+		{Name: "Sydney", Lat: -33.8688, Lon: 151.2093, Number: 2, NotBackedUp: true},
+	}
+
+	mapOpts := MapOptions{Engine: EngineMapLibre, NumberMarkers: true, Style: "https://tiles.example.com/style.json"}
+	GenerateMap(gpsData, mapOpts)
+	data, err := os.ReadFile(filepath.Join(Dir, "map.html"))
+	if err != nil {
+		t.Fatalf("Expected map.html to be written: %v", err)
+	}
+	page := string(data)
+	for _, expected := range []string{
+		`<script src="https://unpkg.com/maplibre-gl@4.7.1/dist/maplibre-gl.js">`,
+		`style: "https://tiles.example.com/style.json"`,
+		`"lat":-33.8688,"lon":151.2093,"name":"Sydney","details":["Not backed up"],"number":2,"notBackedUp":true`,
+		`new maplibregl.Marker(`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the map to contain %s, got:\n%s", expected, page)
+		}
+	}
+	for _, unexpected := range []string{"<b>Rome</b>", "photos2map-path", "L.map(", "name:"} {
+		if strings.Contains(page, unexpected) {
+			t.Errorf("Expected the map not to contain %s, got:\n%s", unexpected, page)
+		}
+	}
+
+	GenerateMap(gpsData, MapOptions{Engine: EngineMapLibre, AssetsHost: "assets/"})
+	if data, _ := os.ReadFile(filepath.Join(Dir, "map.html")); !strings.Contains(string(data), `href="assets/maplibre-gl.css"`) ||
+		!strings.Contains(string(data), `style: "`+DefaultStyle+`"`) {
+		t.Errorf("Expected MapLibre from the assets host and the default style, got:\n%s", data)
+	}
+
+	GenerateMap(gpsData, MapOptions{Engine: EngineMapLibre, Lang: "de"})
+	if data, _ := os.ReadFile(filepath.Join(Dir, "map.html")); !strings.Contains(string(data), `var lang = "de";`) ||
+		!strings.Contains(string(data), `['get', 'name:' + lang]`) {
+		t.Errorf("Expected the labels to be switched to German, got:\n%s", data)
+	}
+}
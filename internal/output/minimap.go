@@ -0,0 +1,58 @@
+package output
+
+import (
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// miniMapJS renders a small world map in the bottom-right corner of the main map, outlining the area
+// currently in view. Clicking the inset recenters the main map on that spot.
+const miniMapJS = `
+	(function (chart) {
+		var dom = chart.getDom();
+		dom.style.position = 'relative';
+		var box = document.createElement('div');
+		box.className = 'photos2map-minimap';
+		box.style.cssText = 'position:absolute;right:10px;bottom:10px;width:180px;height:110px;z-index:10;background:#fff;border:1px solid #999;';
+		dom.appendChild(box);
+		var inset = echarts.init(box);
+		var points = [];
+		chart.getOption().series.forEach(function (s) {
+			if (s.coordinateSystem === 'geo' && s.type !== 'lines') {
+				s.data.forEach(function (d) { points.push(d.value.slice(0, 2)); });
+			}
+		});
+		inset.setOption({
+			geo: {map: 'world', silent: true, left: 0, right: 0, top: 0, bottom: 0, itemStyle: {color: '#ccc', borderColor: '#fff'}},
+			series: [
+				{type: 'scatter', coordinateSystem: 'geo', silent: true, symbolSize: 3, itemStyle: {color: '#006666'}, data: points},
+				{id: 'view', type: 'lines', coordinateSystem: 'geo', silent: true, polyline: true, lineStyle: {color: '#c62828', width: 1.5}, data: []}
+			]
+		});
+		function update() {
+			var w = dom.clientWidth, h = dom.clientHeight;
+			var corners = [[0, 0], [w, 0], [w, h], [0, h], [0, 0]].map(function (px) {
+				return chart.convertFromPixel({geoIndex: 0}, px);
+			});
+			if (corners.some(function (c) { return !c; })) {
+				return;
+			}
+			inset.setOption({series: [{id: 'view', data: [{coords: corners}]}]});
+		}
+		inset.getZr().on('click', function (e) {
+			var coord = inset.convertFromPixel({geoIndex: 0}, [e.offsetX, e.offsetY]);
+			if (coord) {
+				chart.setOption({geo: {center: coord}});
+				update();
+			}
+		});
+		chart.on('georoam', update);
+		chart.on('finished', update);
+		update();
+	})(%MY_ECHARTS%);
+`
+
+// addMiniMap adds the overview inset along with the world map it is drawn on.
+func addMiniMap(geo *charts.Geo) {
+	geo.JSAssets.Add("maps/world.js")
+	geo.AddJSFuncs(miniMapJS)
+}
@@ -0,0 +1,21 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// TestAddMiniMap checks that the inset script and its world map asset are added.
+func TestAddMiniMap(t *testing.T) {
+	geo := charts.NewGeo()
+	addMiniMap(geo)
+
+	if !geo.JSAssets.Contains("maps/world.js") {
+		t.Errorf("Expected the world map asset to be added, got %v", geo.JSAssets.Values)
+	}
+	if len(geo.JSFunctions.Fns) != 1 || !strings.Contains(string(geo.JSFunctions.Fns[0]), "photos2map-minimap") {
+		t.Errorf("Expected the mini-map script to be added")
+	}
+}
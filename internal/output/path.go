@@ -0,0 +1,86 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/types"
+
+	"github.com/toozej/photos2map/internal/segment"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// pathLine is a single data item of an echarts "lines" series
+type pathLine struct {
+	Name      string         `json:"name"`
+	Coords    [][]float64    `json:"coords"`
+	LineStyle opts.LineStyle `json:"lineStyle"`
+}
+
+// speedColors maps upper speed bounds in km/h to the color segments below that speed are drawn in
+var speedColors = []struct {
+	maxKmh float64
+	color  string
+}{
+	{6, "#2e7d32"},   // walking
+	{25, "#f9a825"},  // cycling
+	{130, "#ef6c00"}, // driving
+}
+
+const (
+	// fastestColor is used for segments faster than every entry in speedColors, e.g. flights
+	fastestColor = "#c62828"
+	// stationaryColor is used for segments between photos taken at the same spot
+	stationaryColor = "#757575"
+)
+
+// addPathSeries adds a "lines" series connecting the points in capture order,
+// drawing stationary segments dashed and moving segments solid and colored by speed, described in the given units.
+func addPathSeries(geo *charts.Geo, gpsData []geodata.Point, stopRadius float64, units string) {
+	segments := segment.Split(gpsData, stopRadius)
+	if len(segments) == 0 {
+		return
+	}
+
+	lines := make([]pathLine, 0, len(segments))
+	for _, s := range segments {
+		lines = append(lines, newPathLine(s, units))
+	}
+
+	geo.MultiSeries = append(geo.MultiSeries, charts.SingleSeries{
+		Name:        "path",
+		Type:        "lines",
+		CoordSystem: types.ChartGeo,
+		Data:        lines,
+	})
+}
+
+// newPathLine styles a segment according to whether it is stationary and how fast it was travelled, naming it
+// with the distance and speed in the given units.
+func newPathLine(s segment.Segment, units string) pathLine {
+	line := pathLine{
+		Coords: [][]float64{{s.From.Lon, s.From.Lat}, {s.To.Lon, s.To.Lat}},
+	}
+
+	if s.Stationary {
+		line.Name = fmt.Sprintf("Stationary for %s", s.Duration)
+		line.LineStyle = opts.LineStyle{Color: stationaryColor, Width: 2, Type: "dashed"}
+		return line
+	}
+
+	kmh := s.Speed * 3.6
+	line.Name = fmt.Sprintf("Moving %s over %s (%s)", formatDistance(s.Distance, units), s.Duration, formatSpeed(s.Speed, units))
+	line.LineStyle = opts.LineStyle{Color: speedColor(kmh), Width: 2, Type: "solid"}
+	return line
+}
+
+// speedColor returns the color for a segment travelled at the given speed in km/h.
+func speedColor(kmh float64) string {
+	for _, c := range speedColors {
+		if kmh < c.maxKmh {
+			return c.color
+		}
+	}
+	return fastestColor
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/internal/segment"
+)
+
+// TestNewPathLine checks that stationary segments are dashed and moving segments are colored by speed.
+func TestNewPathLine(t *testing.T) {
+	stationary := newPathLine(segment.Segment{Stationary: true, Duration: 10 * time.Minute}, UnitsMetric)
+	if stationary.LineStyle.Type != "dashed" || stationary.LineStyle.Color != stationaryColor {
+		t.Errorf("Unexpected stationary line style: %+v", stationary.LineStyle)
+	}
+
+	// 1000m in 10 minutes is 6km/h, just over walking speed
+	moving := newPathLine(segment.Segment{Distance: 1000, Duration: 10 * time.Minute, Speed: 1000.0 / 600}, UnitsMetric)
+	if moving.LineStyle.Type != "solid" || moving.LineStyle.Color != "#f9a825" {
+		t.Errorf("Unexpected moving line style: %+v", moving.LineStyle)
+	}
+	if moving.Name != "Moving 1.0 km over 10m0s (6.0 km/h)" {
+		t.Errorf("Unexpected moving line name %q", moving.Name)
+	}
+	if imperial := newPathLine(segment.Segment{Distance: 1000, Duration: 10 * time.Minute, Speed: 1000.0 / 600}, UnitsImperial); imperial.Name != "Moving 0.6 mi over 10m0s (3.7 mph)" {
+		t.Errorf("Unexpected moving line name in imperial units %q", imperial.Name)
+	}
+}
+
+// TestSpeedColor checks speed bucket boundaries.
+func TestSpeedColor(t *testing.T) {
+	tests := map[float64]string{
+		3:   "#2e7d32",
+		20:  "#f9a825",
+		100: "#ef6c00",
+		800: fastestColor,
+	}
+	for kmh, expected := range tests {
+		if got := speedColor(kmh); got != expected {
+			t.Errorf("speedColor(%v) = %q, expected %q", kmh, got, expected)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// The layout of the contact sheet, in PDF points on A4 paper
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 40
+	// pdfColumns and pdfRows are the number of thumbnails across and down a page of thumbnails
+	pdfColumns = 3
+	pdfRows    = 4
+	// pdfThumbWidth and pdfThumbHeight are the box thumbnails are fitted into
+	pdfThumbWidth  = 160
+	pdfThumbHeight = 120
+	// pdfNameLength is the number of characters of a photo's name that fit under its thumbnail
+	pdfNameLength = 32
+)
+
+// GeneratePDF creates a printable contact sheet saved to "output.pdf". The photos are split into trips wherever
+// more than gap passed between them, or never if gap is 0. Each trip gets a page plotting where its photos were
+// taken and the route between them, followed by pages of thumbnails captioned with the photo's number on the
+// plot, its name, time, coordinates and altitude. The plot has no basemap, which would need downloading tiles.
+func GeneratePDF(gpsData []geodata.Point, gap time.Duration, units string) {
+	if err := writeFile(outputPath("output.pdf"), contactSheet(gpsData, gap, units)); err != nil {
+		log.Fatalf("Error writing PDF: %v", err)
+	}
+	log.Println("PDF contact sheet generated successfully.")
+}
+
+// contactSheet returns the PDF contact sheet of the points, see GeneratePDF.
+func contactSheet(gpsData []geodata.Point, gap time.Duration, units string) []byte {
+	sorted := make([]geodata.Point, len(gpsData))
+	copy(sorted, gpsData)
+	geodata.SortByTime(sorted)
+
+	doc := newPDFDocument()
+	trips := splitTrips(sorted, gap)
+	number := 1
+	for i, trip := range trips {
+		title := "Photos"
+		if len(trips) > 1 {
+			title = fmt.Sprintf("Trip %d of %d", i+1, len(trips))
+		}
+		doc.addPage(tripMap(trip, title, number, units), nil)
+		perPage := pdfColumns * pdfRows
+		for start := 0; start < len(trip); start += perPage {
+			end := min(start+perPage, len(trip))
+			content, images := thumbnailPage(doc, trip[start:end], title, number+start, units)
+			doc.addPage(content, images)
+		}
+		number += len(trip)
+	}
+	return doc.bytes()
+}
+
+// splitTrips splits points in capture order wherever more than gap passed between consecutive points, or never
+// if gap is 0.
+func splitTrips(points []geodata.Point, gap time.Duration) [][]geodata.Point {
+	var trips [][]geodata.Point
+	for i, p := range points {
+		if i == 0 || (gap > 0 && p.Time.Sub(points[i-1].Time) > gap) {
+			trips = append(trips, nil)
+		}
+		trips[len(trips)-1] = append(trips[len(trips)-1], p)
+	}
+	return trips
+}
+
+// tripMap returns the content of a trip's map page: its title, dates, number of photos and length, and a plot
+// of the photos numbered from first joined in capture order.
+func tripMap(trip []geodata.Point, title string, first int, units string) []byte {
+	var page bytes.Buffer
+	pdfText(&page, "F2", 18, pdfMargin, pdfPageHeight-pdfMargin-18, title)
+
+	var length float64
+	for i := 1; i < len(trip); i++ {
+		length += geodata.Distance(trip[i-1].Lat, trip[i-1].Lon, trip[i].Lat, trip[i].Lon)
+	}
+	summary := fmt.Sprintf("%s · %d photos · %s", tripDates(trip), len(trip), formatDistance(length, units))
+	pdfText(&page, "F1", 11, pdfMargin, pdfPageHeight-pdfMargin-38, summary)
+
+	// the plot fills the page below the heading, in an equirectangular projection with longitudes narrowed
+	// by the cosine of the latitude so shapes aren't stretched east to west
+	left, bottom := float64(pdfMargin), float64(pdfMargin)
+	width, height := float64(pdfPageWidth-2*pdfMargin), float64(pdfPageHeight-2*pdfMargin-60)
+	fmt.Fprintf(&page, "0.9 0.94 0.94 rg %.2f %.2f %.2f %.2f re f\n", left, bottom, width, height)
+
+	minLon, maxLon, minLat, maxLat := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, p := range trip {
+		minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+		minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+	}
+	aspect := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	// keep a minimum extent so a single spot doesn't get divided by zero, and a margin around the points
+	const margin = 30
+	scale := math.Min(
+		(width-2*margin)/math.Max((maxLon-minLon)*aspect, 0.01),
+		(height-2*margin)/math.Max(maxLat-minLat, 0.01),
+	)
+	cx, cy := (minLon+maxLon)/2, (minLat+maxLat)/2
+	project := func(p geodata.Point) (float64, float64) {
+		return left + width/2 + (p.Lon-cx)*aspect*scale, bottom + height/2 + (p.Lat-cy)*scale
+	}
+
+	if len(trip) > 1 {
+		page.WriteString("0.5 0.5 0.5 RG 1 w\n")
+		for i, p := range trip {
+			x, y := project(p)
+			op := "l"
+			if i == 0 {
+				op = "m"
+			}
+			fmt.Fprintf(&page, "%.2f %.2f %s\n", x, y, op)
+		}
+		page.WriteString("S\n")
+	}
+	page.WriteString("0 0.4 0.4 rg 1 1 1 RG 1 w\n")
+	for _, p := range trip {
+		x, y := project(p)
+		pdfCircle(&page, x, y, 4)
+		page.WriteString("B\n")
+	}
+	page.WriteString("0 0 0 rg\n")
+	for i, p := range trip {
+		x, y := project(p)
+		pdfText(&page, "F1", 7, x+5, y+3, fmt.Sprint(first+i))
+	}
+	return page.Bytes()
+}
+
+// tripDates is the day a trip was on, or its first and last day.
+func tripDates(trip []geodata.Point) string {
+	first, last := trip[0].Time, trip[len(trip)-1].Time
+	if first.IsZero() {
+		return "Unknown date"
+	}
+	const layout = "2 Jan 2006"
+	if first.Format(layout) == last.Format(layout) {
+		return first.Format(layout)
+	}
+	return first.Format(layout) + " – " + last.Format(layout)
+}
+
+// thumbnailPage returns the content of a page of thumbnails of points numbered from first, and the object numbers
+// of the images it uses. Photos without a thumbnail get an empty box.
+func thumbnailPage(doc *pdfDocument, points []geodata.Point, title string, first int, units string) ([]byte, []int) {
+	var page bytes.Buffer
+	var images []int
+	pdfText(&page, "F2", 12, pdfMargin, pdfPageHeight-pdfMargin-12, title)
+
+	cellWidth := float64(pdfPageWidth-2*pdfMargin) / pdfColumns
+	cellHeight := float64(pdfPageHeight-2*pdfMargin-30) / pdfRows
+	for i, p := range points {
+		x := pdfMargin + float64(i%pdfColumns)*cellWidth
+		top := pdfPageHeight - pdfMargin - 30 - float64(i/pdfColumns)*cellHeight
+
+		if obj, w, h, err := doc.addImage(p.Path); err == nil {
+			images = append(images, obj)
+			scale := math.Min(pdfThumbWidth/float64(w), pdfThumbHeight/float64(h))
+			dw, dh := float64(w)*scale, float64(h)*scale
+			fmt.Fprintf(&page, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n",
+				dw, dh, x+(pdfThumbWidth-dw)/2, top-pdfThumbHeight+(pdfThumbHeight-dh)/2, obj)
+		} else {
+			log.Debugf("No thumbnail for %s in the PDF: %v", p.Path, err)
+			fmt.Fprintf(&page, "0.6 0.6 0.6 RG 0.5 w %.2f %.2f %d %d re S\n", x, top-pdfThumbHeight, pdfThumbWidth, pdfThumbHeight)
+		}
+
+		label := fmt.Sprintf("%d. %s", first+i, p.Name)
+		if runes := []rune(label); len(runes) > pdfNameLength {
+			label = string(runes[:pdfNameLength-3]) + "..."
+		}
+		when := "Unknown date"
+		if !p.Time.IsZero() {
+			when = p.Time.Format("2 Jan 2006 15:04")
+		}
+		where := fmt.Sprintf("%.5f, %.5f", p.Lat, p.Lon)
+		if p.Ele != nil {
+			where += " · " + formatAltitude(*p.Ele, units)
+		}
+		page.WriteString("0 0 0 rg\n")
+		pdfText(&page, "F2", 9, x, top-pdfThumbHeight-12, label)
+		pdfText(&page, "F1", 8, x, top-pdfThumbHeight-23, when)
+		pdfText(&page, "F1", 8, x, top-pdfThumbHeight-33, where)
+	}
+	return page.Bytes(), images
+}
+
+// pdfText writes a line of text at x, y in a font of the document, see pdfDocument.
+func pdfText(page *bytes.Buffer, font string, size, x, y float64, s string) {
+	fmt.Fprintf(page, "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// pdfCircle adds a circle of radius r around x, y to the current path, drawn as four Bézier curves.
+func pdfCircle(page *bytes.Buffer, x, y, r float64) {
+	k := r * 0.5523
+	fmt.Fprintf(page, "%.2f %.2f m\n", x+r, y)
+	fmt.Fprintf(page, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+r, y+k, x+k, y+r, x, y+r)
+	fmt.Fprintf(page, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-k, y+r, x-r, y+k, x-r, y)
+	fmt.Fprintf(page, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x-r, y-k, x-k, y-r, x, y-r)
+	fmt.Fprintf(page, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", x+k, y-r, x+r, y-k, x+r, y)
+}
+
+// pdfString escapes s for a PDF string in the WinAnsi encoding of the standard fonts. Latin-1 characters map to
+// themselves and the en dash has its own code; other characters the fonts can't show become question marks.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		case r == '–':
+			b.WriteByte(0x96)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfDocument writes a PDF with the Helvetica and Helvetica-Bold standard fonts, as F1 and F2, and JPEG images.
+// Objects 1 to 4 are the catalog, the page tree and the fonts, written last once every page is known.
+type pdfDocument struct {
+	buf     bytes.Buffer
+	offsets []int
+	pages   []int
+}
+
+// newPDFDocument starts a PDF, with a comment of high bytes that marks the file as binary.
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{offsets: make([]int, 5)}
+	doc.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	return doc
+}
+
+// object writes object n, a dictionary or other value followed by an optional stream.
+func (d *pdfDocument) object(n int, value string, stream []byte) {
+	d.offsets[n] = d.buf.Len()
+	fmt.Fprintf(&d.buf, "%d 0 obj\n%s\n", n, value)
+	if stream != nil {
+		d.buf.WriteString("stream\n")
+		d.buf.Write(stream)
+		d.buf.WriteString("\nendstream\n")
+	}
+	d.buf.WriteString("endobj\n")
+}
+
+// next returns the number of a new object.
+func (d *pdfDocument) next() int {
+	d.offsets = append(d.offsets, 0)
+	return len(d.offsets) - 1
+}
+
+// addPage adds a page drawn by content, using the images with the given object numbers, each named Im followed
+// by its number.
+func (d *pdfDocument) addPage(content []byte, images []int) {
+	stream := d.next()
+	d.object(stream, fmt.Sprintf("<< /Length %d >>", len(content)), content)
+
+	var xobjects strings.Builder
+	for _, obj := range images {
+		fmt.Fprintf(&xobjects, " /Im%d %d 0 R", obj, obj)
+	}
+	page := d.next()
+	d.object(page, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents %d 0 R "+
+		"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject <<%s >> >> >>",
+		pdfPageWidth, pdfPageHeight, stream, xobjects.String()), nil)
+	d.pages = append(d.pages, page)
+}
+
+// addImage adds a photo's thumbnail, which is already a JPEG and so is embedded as it is. It returns the image's
+// object number and size in pixels.
+func (d *pdfDocument) addImage(path string) (int, int, int, error) {
+	data, err := thumbnail(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	colorSpace := "DeviceRGB"
+	switch config.ColorModel {
+	case color.GrayModel:
+		colorSpace = "DeviceGray"
+	case color.CMYKModel:
+		// Adobe's inverted CMYK JPEGs would print as negatives without a decode array guessed per file
+		return 0, 0, 0, image.ErrFormat
+	}
+	obj := d.next()
+	d.object(obj, fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s "+
+		"/BitsPerComponent 8 /Filter /DCTDecode /Length %d >>", config.Width, config.Height, colorSpace, len(data)), data)
+	return obj, config.Width, config.Height, nil
+}
+
+// bytes finishes the document with the catalog, page tree, fonts and cross-reference table, and returns it.
+func (d *pdfDocument) bytes() []byte {
+	kids := make([]string, len(d.pages))
+	for i, page := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	d.object(1, "<< /Type /Catalog /Pages 2 0 R >>", nil)
+	d.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)), nil)
+	d.object(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	d.object(4, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+
+	xref := d.buf.Len()
+	fmt.Fprintf(&d.buf, "xref\n0 %d\n0000000000 65535 f \n", len(d.offsets))
+	for _, offset := range d.offsets[1:] {
+		fmt.Fprintf(&d.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&d.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.offsets), xref)
+	return d.buf.Bytes()
+}
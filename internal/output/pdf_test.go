@@ -0,0 +1,65 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestContactSheet checks that each trip gets a map page and a page of thumbnails, that thumbnails are embedded,
+// and that the cross-reference table points at every object.
+func TestContactSheet(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	ele := 120.0
+	pdf := contactSheet([]geodata.Point{
+		{Name: "later", Lat: 48.8566, Lon: 2.3522, Time: start.Add(72 * time.Hour)},
+		{Name: "DSCN0010", Path: filepath.Join("..", "testdata", "DSCN0010.jpg"), Lat: 43.4671, Lon: 11.8851, Time: start, Ele: &ele},
+		{Name: "(same day)", Lat: 43.47, Lon: 11.89, Time: start.Add(time.Hour)},
+	}, 6*time.Hour, UnitsMetric)
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("Expected a PDF file, got %q...", pdf[:20])
+	}
+	for _, expected := range []string{"/Count 4", "(Trip 1 of 2)", "(Trip 2 of 2)", "/Filter /DCTDecode", "(1. DSCN0010)",
+		"(2. \\(same day\\))", "(3. later)", "(43.46710, 11.88510 \xb7 120 m)", "(1 May 2024 \xb7 2 photos \xb7 0.5 km)"} {
+		if !bytes.Contains(pdf, []byte(expected)) {
+			t.Errorf("Expected %q in the PDF", expected)
+		}
+	}
+
+	xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllSubmatch(pdf, -1)
+	if len(xref) < 4 {
+		t.Fatalf("Expected a cross-reference table, got %d entries", len(xref))
+	}
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(pdf[offset:], fmt.Appendf(nil, "%d 0 obj\n", i+1)) {
+			t.Errorf("Expected object %d at offset %d, got %q", i+1, offset, pdf[offset:offset+10])
+		}
+	}
+}
+
+// TestSplitTrips checks that points are split where more than the gap passed, and not at all without a gap.
+func TestSplitTrips(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	points := []geodata.Point{{Time: start}, {Time: start.Add(time.Hour)}, {Time: start.Add(24 * time.Hour)}}
+	if trips := splitTrips(points, 6*time.Hour); len(trips) != 2 || len(trips[0]) != 2 || len(trips[1]) != 1 {
+		t.Errorf("Expected trips of 2 and 1 points, got %v", trips)
+	}
+	if trips := splitTrips(points, 0); len(trips) != 1 {
+		t.Errorf("Expected a single trip without a gap, got %d", len(trips))
+	}
+}
+
+// TestPDFString checks that PDF strings are escaped and encoded in WinAnsi.
+func TestPDFString(t *testing.T) {
+	if s := pdfString(`Café (1) \ 東京 – x`); s != "Caf\xe9 \\(1\\) \\\\ ?? \x96 x" {
+		t.Errorf("Unexpected PDF string %q", s)
+	}
+}
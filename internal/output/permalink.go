@@ -0,0 +1,87 @@
+package output
+
+import (
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// permalinkJS keeps the map's center, zoom, search filter and the last photo clicked in the URL hash, and
+// restores them on load, so any view of the map or photo on it can be bookmarked or shared. Photos are referred
+// to by ID, which stays the same across runs; opening a link to one shows its tooltip once any thinning of the
+// markers has run, centering the map on it unless the link gives a center.
+const permalinkJS = `
+	(function (chart) {
+		var query = '';
+		var photo = '';
+		function save() {
+			var geo = chart.getOption().geo[0];
+			var params = new URLSearchParams();
+			if (geo.center) {
+				params.set('center', geo.center.map(function (c) { return Number(c).toFixed(5); }).join(','));
+			}
+			if (geo.zoom) {
+				params.set('zoom', Number(geo.zoom).toFixed(2));
+			}
+			if (query) {
+				params.set('q', query);
+			}
+			if (photo) {
+				params.set('photo', photo);
+			}
+			history.replaceState(null, '', '#' + params.toString());
+		}
+		function restore() {
+			var params = new URLSearchParams(location.hash.slice(1));
+			var view = {};
+			var center = (params.get('center') || '').split(',').map(Number);
+			if (center.length === 2 && center.every(isFinite)) {
+				view.center = center;
+			}
+			var zoom = Number(params.get('zoom'));
+			if (zoom > 0) {
+				view.zoom = zoom;
+			}
+			chart.setOption({geo: view});
+			if (params.get('q') && photos2map.filter) {
+				photos2map.filter(params.get('q'));
+			}
+			photo = params.get('photo') || '';
+			if (photo) {
+				setTimeout(function () { focus(photo, !view.center); });
+			}
+		}
+		function focus(id, center) {
+			var data = chart.getOption().series[0].data || [];
+			for (var i = 0; i < data.length; i++) {
+				if (data[i].id === id) {
+					if (center) {
+						chart.setOption({geo: {center: data[i].value.slice(0, 2)}});
+					}
+					chart.dispatchAction({type: 'showTip', seriesIndex: 0, dataIndex: i});
+					return;
+				}
+			}
+		}
+		if (photos2map.filter) {
+			var filter = photos2map.filter;
+			photos2map.filter = function (q) {
+				filter(q);
+				query = q;
+				save();
+			};
+		}
+		restore();
+		chart.on('georoam', save);
+		chart.on('click', function (params) {
+			if (params.seriesIndex === 0 && params.data && params.data.id) {
+				photo = params.data.id;
+				save();
+			}
+		});
+		window.addEventListener('hashchange', restore);
+	})(%MY_ECHARTS%);
+`
+
+// addPermalink keeps the map view and filters in the URL. It must be added after any filter controls.
+func addPermalink(geo *charts.Geo) {
+	geo.AddJSFuncs(`var photos2map = photos2map || {};`, permalinkJS)
+}
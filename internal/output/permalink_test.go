@@ -0,0 +1,25 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// TestAddPermalink checks that the permalink script is added after the search script it wraps.
+func TestAddPermalink(t *testing.T) {
+	geo := charts.NewGeo()
+	addSearch(geo)
+	addPermalink(geo)
+
+	var js string
+	for _, fn := range geo.JSFunctions.Fns {
+		js += string(fn)
+	}
+	search := strings.Index(js, "photos2map-search")
+	permalink := strings.Index(js, "history.replaceState")
+	if search == -1 || permalink == -1 || permalink < search {
+		t.Errorf("Expected the permalink script to follow the search script")
+	}
+}
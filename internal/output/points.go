@@ -0,0 +1,61 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// pointsFile is the name of the script holding the points, written next to the map when the data is not inlined.
+// It's a script rather than JSON because browsers refuse to fetch files from file:// URLs, but run their scripts.
+const pointsFile = "points.js"
+
+// pointsVar is the global variable pointsFile assigns the points to
+const pointsVar = "photos2mapPoints"
+
+// loaderJS sets the series data pointsFile loaded before the page's scripts and then runs the map's other scripts,
+// which expect the data to be present. A hint is shown if pointsFile is missing.
+const loaderJS = `
+	if (typeof ` + pointsVar + ` === 'undefined') {
+		var msg = document.createElement('p');
+		msg.style.cssText = 'text-align:center;color:#c62828;font:14px sans-serif;';
+		msg.textContent = 'Could not load ` + pointsFile + `, which holds the points of this map. Keep it next to the page, or regenerate the map with --inline.';
+		document.body.insertBefore(msg, document.body.firstChild);
+	} else {
+		%MY_ECHARTS%.setOption({series: ` + pointsVar + `.series.map(function (d) { return {data: d}; })});
+		%SCRIPTS%
+	}
+`
+
+// pointsPayload is the structure of the points in pointsFile, holding each series' data in series order
+type pointsPayload struct {
+	Series []interface{} `json:"series"`
+}
+
+// splitData moves the series data out of the chart into the script pointsFile, returning it for writing next to
+// the map, and has the page load it. All scripts already added to the chart are deferred until the data is set.
+func splitData(geo *charts.Geo) ([]byte, error) {
+	payload := pointsPayload{Series: make([]interface{}, len(geo.MultiSeries))}
+	for i := range geo.MultiSeries {
+		payload.Series[i] = geo.MultiSeries[i].Data
+		geo.MultiSeries[i].Data = []interface{}{}
+	}
+
+	// json.Marshal escapes U+2028 and U+2029, so the JSON is valid JavaScript too
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	data = append(append([]byte("var "+pointsVar+" = "), data...), ";\n"...)
+	geo.AddCustomizedJSAssets(pointsFile)
+
+	var scripts strings.Builder
+	for _, fn := range geo.JSFunctions.Fns {
+		scripts.WriteString(string(fn))
+	}
+	geo.JSFunctions.Fns = nil
+	geo.AddJSFuncs(strings.Replace(loaderJS, "%SCRIPTS%", scripts.String(), 1))
+
+	return data, nil
+}
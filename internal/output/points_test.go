@@ -0,0 +1,51 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/types"
+)
+
+// TestSplitData checks that series data is moved into the script loaded by the page and scripts are deferred until
+// it's set.
+func TestSplitData(t *testing.T) {
+	geo := charts.NewGeo()
+	geo.AddSeries("geo", types.ChartScatter, []opts.GeoData{{Name: "Image1", Value: []interface{}{-0.1276, 51.5074, ""}}})
+	geo.AddJSFuncs("console.log('feature');")
+
+	data, err := splitData(geo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	js, ok := strings.CutPrefix(string(data), "var "+pointsVar+" = ")
+	if !ok || !strings.HasSuffix(js, ";\n") {
+		t.Fatalf("Expected the payload to assign %s, got %s", pointsVar, data)
+	}
+	var payload pointsPayload
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(js, ";\n")), &payload); err != nil {
+		t.Fatalf("Error parsing payload: %v", err)
+	}
+	if len(payload.Series) != 1 || !strings.Contains(string(data), "Image1") {
+		t.Errorf("Expected the payload to contain the series data, got %s", data)
+	}
+
+	if d, ok := geo.MultiSeries[0].Data.([]interface{}); !ok || len(d) != 0 {
+		t.Errorf("Expected the series data to be removed from the chart, got %v", geo.MultiSeries[0].Data)
+	}
+
+	if len(geo.JSFunctions.Fns) != 1 {
+		t.Fatalf("Expected a single loader script, got %d", len(geo.JSFunctions.Fns))
+	}
+	loader := string(geo.JSFunctions.Fns[0])
+	if strings.Index(loader, pointsVar+".series") > strings.Index(loader, "console.log('feature')") {
+		t.Errorf("Expected existing scripts to run after the data is set, got %s", loader)
+	}
+	if geo.CustomizedJSAssets.Values[0] != pointsFile {
+		t.Errorf("Expected the page to load %s, got %v", pointsFile, geo.CustomizedJSAssets.Values)
+	}
+}
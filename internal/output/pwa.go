@@ -0,0 +1,168 @@
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+const (
+	manifestFile = "manifest.webmanifest"
+	swFile       = "sw.js"
+	// themeColor matches the color of the map's land areas
+	themeColor = "#006666"
+)
+
+// iconSizes are the square icon sizes browsers require for an installable web app
+var iconSizes = []int{192, 512}
+
+// pwaRegisterJS links the web app manifest and registers the service worker. Service workers are only
+// available over HTTP(S), so nothing is registered when the map is opened from file://.
+const pwaRegisterJS = `
+	(function () {
+		var link = document.createElement('link');
+		link.rel = 'manifest';
+		link.href = '` + manifestFile + `';
+		document.head.appendChild(link);
+		var meta = document.createElement('meta');
+		meta.name = 'theme-color';
+		meta.content = '` + themeColor + `';
+		document.head.appendChild(meta);
+		if ('serviceWorker' in navigator && location.protocol.indexOf('http') === 0) {
+			navigator.serviceWorker.register('` + swFile + `');
+		}
+	})();
+`
+
+// swJS precaches the map and its assets on install, then serves every request cache-first, caching
+// anything else fetched (e.g. tiles or thumbnails) as it is used. %CACHE% and %URLS% are filled in by writePWA.
+const swJS = `"use strict";
+const CACHE = %CACHE%;
+const URLS = %URLS%;
+
+self.addEventListener('install', (event) => {
+	event.waitUntil(caches.open(CACHE).then((cache) => Promise.all(URLS.map((url) =>
+		// cross-origin assets are cached as opaque responses, which cache.addAll would reject
+		fetch(new Request(url, {mode: new URL(url, self.location).origin === self.location.origin ? 'same-origin' : 'no-cors'}))
+			.then((resp) => cache.put(url, resp))
+	))).then(() => self.skipWaiting()));
+});
+
+self.addEventListener('activate', (event) => {
+	event.waitUntil(caches.keys().then((keys) => Promise.all(
+		keys.filter((key) => key.startsWith('photos2map-') && key !== CACHE).map((key) => caches.delete(key))
+	)).then(() => self.clients.claim()));
+});
+
+self.addEventListener('fetch', (event) => {
+	if (event.request.method !== 'GET') {
+		return;
+	}
+	event.respondWith(caches.match(event.request).then((cached) => cached || fetch(event.request).then((resp) => {
+		const copy = resp.clone();
+		caches.open(CACHE).then((cache) => cache.put(event.request, copy));
+		return resp;
+	})));
+});
+`
+
+// webManifest is the subset of the Web App Manifest written for the map
+type webManifest struct {
+	Name            string         `json:"name"`
+	ShortName       string         `json:"short_name"`
+	StartURL        string         `json:"start_url"`
+	Display         string         `json:"display"`
+	BackgroundColor string         `json:"background_color"`
+	ThemeColor      string         `json:"theme_color"`
+	Icons           []manifestIcon `json:"icons"`
+}
+
+type manifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// addPWA makes the rendered map register its manifest and service worker.
+func addPWA(geo *charts.Geo) {
+	geo.AddJSFuncs(pwaRegisterJS)
+}
+
+// writePWA writes the manifest, icons and service worker for a rendered map into dir. pages are the
+// generated files to precache alongside the chart's script assets, and version identifies their content
+// so that regenerating the map replaces the old cache.
+func writePWA(geo *charts.Geo, dir string, pages []string, version []byte) error {
+	manifest := webManifest{
+		Name:            geo.Initialization.PageTitle,
+		ShortName:       "photos2map",
+		StartURL:        pages[0],
+		Display:         "standalone",
+		BackgroundColor: "#ffffff",
+		ThemeColor:      themeColor,
+	}
+	urls := append([]string{}, pages...)
+	urls = append(urls, manifestFile)
+
+	for _, size := range iconSizes {
+		name := fmt.Sprintf("icon-%d.png", size)
+		if err := writeIcon(filepath.Join(dir, name), size); err != nil {
+			return err
+		}
+		manifest.Icons = append(manifest.Icons, manifestIcon{Src: name, Sizes: fmt.Sprintf("%dx%d", size, size), Type: "image/png"})
+		urls = append(urls, name)
+	}
+	urls = append(urls, geo.JSAssets.Values...)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, manifestFile), manifestJSON); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(version)
+	cacheName, err := json.Marshal("photos2map-" + hex.EncodeToString(sum[:8]))
+	if err != nil {
+		return err
+	}
+	urlsJSON, err := json.Marshal(urls)
+	if err != nil {
+		return err
+	}
+	sw := bytes.Replace([]byte(swJS), []byte("%CACHE%"), cacheName, 1)
+	sw = bytes.Replace(sw, []byte("%URLS%"), urlsJSON, 1)
+	return writeFile(filepath.Join(dir, swFile), sw)
+}
+
+// writeIcon draws a simple map pin icon: a white dot on the theme color.
+func writeIcon(path string, size int) error {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	bg := color.RGBA{R: 0x00, G: 0x66, B: 0x66, A: 0xff}
+	r := float64(size) / 4
+	c := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)+0.5-c, float64(y)+0.5-c
+			if dx*dx+dy*dy <= r*r {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, bg)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return err
+	}
+	return writeFile(path, b.Bytes())
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// TestWritePWA checks that the manifest, icons and service worker are written with the pages precached.
+func TestWritePWA(t *testing.T) {
+	dir := t.TempDir()
+	geo := charts.NewGeo()
+	geo.JSAssets.Add("https://example.com/echarts.min.js")
+
+	if err := writePWA(geo, dir, []string{"map.html", "points.js"}, []byte("v1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		t.Fatalf("Expected manifest to be written: %v", err)
+	}
+	var manifest webManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Error parsing manifest: %v", err)
+	}
+	if manifest.StartURL != "map.html" || len(manifest.Icons) != len(iconSizes) {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+	for _, icon := range manifest.Icons {
+		if _, err := os.Stat(filepath.Join(dir, icon.Src)); err != nil {
+			t.Errorf("Expected icon %s to be written: %v", icon.Src, err)
+		}
+	}
+
+	sw, err := os.ReadFile(filepath.Join(dir, swFile))
+	if err != nil {
+		t.Fatalf("Expected service worker to be written: %v", err)
+	}
+	for _, url := range []string{"map.html", "points.js", manifestFile, "https://example.com/echarts.min.js"} {
+		if !strings.Contains(string(sw), `"`+url+`"`) {
+			t.Errorf("Expected service worker to precache %s", url)
+		}
+	}
+	if strings.Contains(string(sw), "%CACHE%") || strings.Contains(string(sw), "%URLS%") {
+		t.Errorf("Expected service worker placeholders to be replaced")
+	}
+}
@@ -0,0 +1,52 @@
+package output
+
+import (
+	"math"
+
+	"github.com/go-echarts/go-echarts/v2/datasets"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// RegionAuto draws the ECharts map on the world map, zoomed in on the photos
+const RegionAuto = "auto"
+
+// regionWorld is the world map, the one RegionAuto zooms in on
+const regionWorld = "world"
+
+// worldExtent is the area the world map shows at zoom 1, in degrees. It leaves out Antarctica.
+var worldExtent = geodata.BBox{MinLon: -180, MinLat: -60, MaxLon: 180, MaxLat: 84}
+
+// maxRegionZoom caps how far RegionAuto zooms in, so that the photos of a single town still show the country
+// around them, the world map having no finer detail
+const maxRegionZoom = 10
+
+// regionMargin is the share of the view the photos take up with RegionAuto, leaving a margin around them
+const regionMargin = 0.8
+
+// ValidRegion reports whether name is a valid MapOptions.Region: RegionAuto or one of the maps go-echarts has,
+// "world", "china" and the Chinese provinces and cities by their Chinese names.
+func ValidRegion(name string) bool {
+	_, ok := datasets.MapFileNames[name]
+	return name == RegionAuto || ok
+}
+
+// geoView returns the map the ECharts map of the points is drawn on for region, see MapOptions.Region, and the
+// center and zoom of its initial view, nil and 0 for the whole map.
+func geoView(points []geodata.Point, region string) (string, []float64, float64) {
+	if region != "" && region != RegionAuto {
+		return region, nil, 0
+	}
+	if len(points) == 0 {
+		return regionWorld, nil, 0
+	}
+	b := geodata.Bounds(points)
+	zoom := regionMargin * math.Min(
+		(worldExtent.MaxLon-worldExtent.MinLon)/math.Max(b.MaxLon-b.MinLon, 1e-9),
+		(worldExtent.MaxLat-worldExtent.MinLat)/math.Max(b.MaxLat-b.MinLat, 1e-9))
+	if zoom <= 1 {
+		return regionWorld, nil, 0
+	}
+	center := []float64{(b.MinLon + b.MaxLon) / 2, (b.MinLat + b.MaxLat) / 2}
+	return regionWorld, center, math.Round(min(zoom, maxRegionZoom)*100) / 100
+}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestValidRegion checks that go-echarts' maps and auto are accepted, and other names aren't.
+func TestValidRegion(t *testing.T) {
+	for name, expected := range map[string]bool{RegionAuto: true, "world": true, "china": true, "北京": true, "USA": false, "": false} {
+		if ValidRegion(name) != expected {
+			t.Errorf("Expected ValidRegion(%q) to be %v", name, expected)
+		}
+	}
+}
+
+// TestGeoView checks that the automatic view zooms in on the photos, as far as maxRegionZoom, and that other
+// regions show their whole map.
+func TestGeoView(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []geodata.Point
+		region string
+		mapped string
+		center []float64
+		zoom   float64
+	}{
+		{"one country", []geodata.Point{{Lat: 30, Lon: -120}, {Lat: 48, Lon: -75}}, RegionAuto, "world", []float64{-97.5, 39}, 6.4},
+		{"one town", []geodata.Point{{Lat: 41.9, Lon: 12.5}}, "", "world", []float64{12.5, 41.9}, maxRegionZoom},
+		{"around the world", []geodata.Point{{Lat: 61.2, Lon: -149.9}, {Lat: -33.9, Lon: 151.2}}, RegionAuto, "world", nil, 0},
+		{"another map", []geodata.Point{{Lat: 39.9, Lon: 116.4}}, "china", "china", nil, 0},
+		{"no points", nil, RegionAuto, "world", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapped, center, zoom := geoView(tt.points, tt.region)
+			if mapped != tt.mapped || !reflect.DeepEqual(center, tt.center) || zoom != tt.zoom {
+				t.Errorf("Expected the %s map at %v zoomed %v, got the %s map at %v zoomed %v",
+					tt.mapped, tt.center, tt.zoom, mapped, center, zoom)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/extract"
+	"github.com/toozej/photos2map/internal/geotag"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// GenerateSkippedReport writes the files skipped during extraction and the reason for each to
+// skipped.txt in Dir, one tab-separated path and reason per line.
+func GenerateSkippedReport(skipped []extract.Skipped) {
+	var b strings.Builder
+	for _, s := range skipped {
+		fmt.Fprintf(&b, "%s\t%s\n", s.Path, strings.ReplaceAll(s.Err.Error(), "\n", " "))
+	}
+
+	path := filepath.Join(Dir, "skipped.txt")
+	if err := writeFile(path, []byte(b.String())); err != nil {
+		log.Errorf("Error writing skipped files report: %v", err)
+		return
+	}
+	log.Printf("%d files skipped, see %s for details.", len(skipped), path)
+}
+
+// summaryFailures is how many files that couldn't be read are listed in the summary before referring to the report
+const summaryFailures = 10
+
+// PrintSummary writes how many files were scanned to w, how many had GPS data, and why the others were skipped,
+// listing the files that couldn't be read.
+func PrintSummary(w io.Writer, gpsData []geodata.Point, skipped []extract.Skipped) {
+	failed := extract.Failed(skipped)
+	fmt.Fprintf(w, "Scanned %d files: %d with GPS data, %d skipped.\n", len(gpsData)+len(skipped), len(gpsData), len(skipped))
+	if noGPS := len(skipped) - len(failed); noGPS > 0 {
+		fmt.Fprintf(w, "  %d without GPS coordinates\n", noGPS)
+	}
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  %d that couldn't be read:\n", len(failed))
+	for i, s := range failed {
+		if i == summaryFailures {
+			fmt.Fprintf(w, "    ... and %d more, see %s\n", len(failed)-summaryFailures, filepath.Join(Dir, "skipped.txt"))
+			break
+		}
+		fmt.Fprintf(w, "    %s: %s\n", s.Path, strings.ReplaceAll(s.Err.Error(), "\n", " "))
+	}
+}
+
+// geotagReportHeader are the columns of the geotag report
+var geotagReportHeader = []string{"path", "status", "latitude", "longitude", "gap_seconds", "span_meters", "confidence", "reason"}
+
+// GenerateGeotagReport writes how every photo considered for geotagging fared to geotag-report.csv in Dir:
+// the position, time gap to the nearest track point, interpolation span and confidence of tagged photos,
+// and the reason skipped photos weren't tagged, ordered by path.
+func GenerateGeotagReport(tagged []geotag.Tagged, skipped []extract.Skipped) {
+	rows := make([][]string, 0, len(tagged)+len(skipped))
+	for _, t := range tagged {
+		rows = append(rows, []string{
+			t.Path, "tagged",
+			fmt.Sprintf("%f", t.Match.Lat), fmt.Sprintf("%f", t.Match.Lon),
+			fmt.Sprintf("%.0f", t.Match.Gap.Seconds()), fmt.Sprintf("%.0f", t.Match.Span),
+			t.Match.Confidence(), "",
+		})
+	}
+	for _, s := range skipped {
+		rows = append(rows, []string{s.Path, "skipped", "", "", "", "", "", s.Err.Error()})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][0] < rows[j][0]
+	})
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write(geotagReportHeader)
+	_ = w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		log.Errorf("Error writing geotag report: %v", err)
+		return
+	}
+	path := filepath.Join(Dir, "geotag-report.csv")
+	if err := writeFile(path, b.Bytes()); err != nil {
+		log.Errorf("Error writing geotag report: %v", err)
+		return
+	}
+	log.Printf("Geotag report written to %s.", path)
+}
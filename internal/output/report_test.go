@@ -0,0 +1,73 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/internal/extract"
+	"github.com/toozej/photos2map/internal/geotag"
+	"github.com/toozej/photos2map/internal/track"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateSkippedReport checks that each skipped file is listed with its reason.
+func TestGenerateSkippedReport(t *testing.T) {
+	GenerateSkippedReport([]extract.Skipped{
+		{Path: "in/broken.jpg", Err: errors.New("exif: failed to find exif intro marker")},
+		{Path: "in/nogps.jpg", Err: errors.New("multi\nline")},
+	})
+	defer os.Remove("out/skipped.txt")
+
+	content, err := os.ReadFile("out/skipped.txt")
+	if err != nil {
+		t.Fatalf("Expected skipped.txt to be generated: %v", err)
+	}
+
+	expected := "in/broken.jpg\texif: failed to find exif intro marker\nin/nogps.jpg\tmulti line\n"
+	if string(content) != expected {
+		t.Errorf("Unexpected report content: got %q, expected %q", content, expected)
+	}
+}
+
+// TestPrintSummary checks that files without GPS data are counted and unreadable files are listed.
+func TestPrintSummary(t *testing.T) {
+	var b strings.Builder
+	PrintSummary(&b, []geodata.Point{{Name: "a"}}, []extract.Skipped{
+		{Path: "in/nogps.jpg", Err: fmt.Errorf("%w: zero length tag value", exif.ErrNoGPS)},
+		{Path: "in/broken.jpg", Err: errors.New("exif: failed to find exif intro marker")},
+	})
+
+	expected := "Scanned 3 files: 1 with GPS data, 2 skipped.\n" +
+		"  1 without GPS coordinates\n" +
+		"  1 that couldn't be read:\n" +
+		"    in/broken.jpg: exif: failed to find exif intro marker\n"
+	if b.String() != expected {
+		t.Errorf("Unexpected summary: got %q, expected %q", b.String(), expected)
+	}
+}
+
+// TestGenerateGeotagReport checks that tagged and skipped photos are listed in path order.
+func TestGenerateGeotagReport(t *testing.T) {
+	GenerateGeotagReport(
+		[]geotag.Tagged{{Path: "in/b.jpg", Match: track.Match{Point: track.Point{Lat: 43.5, Lon: 11.5}, Gap: 20 * time.Second, Span: 40}}},
+		[]extract.Skipped{{Path: "in/a.jpg", Err: errors.New("no capture time")}},
+	)
+	defer os.Remove("out/geotag-report.csv")
+
+	content, err := os.ReadFile("out/geotag-report.csv")
+	if err != nil {
+		t.Fatalf("Expected geotag-report.csv to be generated: %v", err)
+	}
+
+	expected := "path,status,latitude,longitude,gap_seconds,span_meters,confidence,reason\n" +
+		"in/a.jpg,skipped,,,,,,no capture time\n" +
+		"in/b.jpg,tagged,43.500000,11.500000,20,40,high,\n"
+	if string(content) != expected {
+		t.Errorf("Unexpected report content: got %q, expected %q", content, expected)
+	}
+}
@@ -0,0 +1,73 @@
+package output
+
+import (
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// filtersJS keeps the markers matching every filter in photos2map.filters, by name, shown: photos2map.refilter
+// applies them to the point data embedded in the chart option, so it works without any server. The combined
+// filter is kept in photos2map.matches for the level of detail thinning or clustering to apply to the markers it
+// shows.
+const filtersJS = `
+	(function (chart) {
+		var original = chart.getOption().series.map(function (s) {
+			return s.type === 'lines' ? null : s.data;
+		});
+		photos2map.filters = {};
+		photos2map.refilter = function () {
+			var filters = Object.keys(photos2map.filters).map(function (name) { return photos2map.filters[name]; });
+			photos2map.matches = function (d) {
+				return filters.every(function (f) { return f(d); });
+			};
+			chart.setOption({series: original.map(function (data) {
+				return data ? {data: data.filter(photos2map.matches)} : {};
+			})});
+			if (photos2map.thin) {
+				photos2map.thin(true);
+			}
+			if (photos2map.cluster) {
+				photos2map.cluster(true);
+			}
+		};
+	})(%MY_ECHARTS%);
+`
+
+// searchJS adds a search box that hides markers whose name and tooltip details don't contain every search term.
+const searchJS = `
+	(function (chart) {
+		var dom = chart.getDom();
+		dom.style.position = 'relative';
+		var input = document.createElement('input');
+		input.type = 'search';
+		input.className = 'photos2map-search';
+		input.placeholder = 'Filter by name, date, camera…';
+		input.setAttribute('aria-label', 'Filter photos');
+		input.style.cssText = 'position:absolute;top:40px;left:10px;z-index:10;width:220px;padding:4px 6px;border:1px solid #999;border-radius:4px;';
+		dom.appendChild(input);
+		function text(d) {
+			return (d.name + ' ' + String(d.value[2] || '').replace(/<[^>]*>/g, ' ')).toLowerCase();
+		}
+		photos2map.filter = function (query) {
+			var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+			photos2map.filters.search = function (d) {
+				var t = text(d);
+				return terms.every(function (term) { return t.indexOf(term) !== -1; });
+			};
+			photos2map.refilter();
+			input.value = query;
+		};
+		input.addEventListener('input', function () {
+			photos2map.filter(input.value);
+		});
+	})(%MY_ECHARTS%);
+`
+
+// addFilters adds the marker filtering the search box and legend rely on. It must be added once, before either.
+func addFilters(geo *charts.Geo) {
+	geo.AddJSFuncs(`var photos2map = photos2map || {};`, filtersJS)
+}
+
+// addSearch adds the marker search box to the map.
+func addSearch(geo *charts.Geo) {
+	geo.AddJSFuncs(`var photos2map = photos2map || {};`, searchJS)
+}
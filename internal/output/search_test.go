@@ -0,0 +1,22 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+)
+
+// TestAddSearch checks that the search box script is added.
+func TestAddSearch(t *testing.T) {
+	geo := charts.NewGeo()
+	addSearch(geo)
+
+	var js string
+	for _, fn := range geo.JSFunctions.Fns {
+		js += string(fn)
+	}
+	if !strings.Contains(js, "photos2map-search") || !strings.Contains(js, "photos2map.filter") {
+		t.Errorf("Expected the search script to be added, got %s", js)
+	}
+}
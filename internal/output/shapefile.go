@@ -0,0 +1,180 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// shapefileWGS84 is the .prj of the shapefile, declaring the coordinates as WGS 84 longitude and latitude the
+// way ArcGIS writes it
+const shapefileWGS84 = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+
+const (
+	// shpHeaderSize is the size in bytes of the header of the .shp and .shx files
+	shpHeaderSize = 100
+	// shpPointSize is the size in bytes of a point record's content: the shape type and X and Y
+	shpPointSize = 20
+	// shpPoint is the shape type of points
+	shpPoint = 1
+)
+
+// dbfField is a column of the shapefile's attribute table. dBase limits names to 10 characters.
+type dbfField struct {
+	name     string
+	kind     byte
+	length   int
+	decimals int
+	value    func(p geodata.Point) string
+}
+
+// dbfFields are the shapefile's attribute columns. Text columns hold UTF-8, as the .cpg file says, cut to whole
+// characters within the column's length in bytes.
+var dbfFields = []dbfField{
+	{"ID", 'C', geodata.MaxIDLength, 0, func(p geodata.Point) string { return p.ID }},
+	{"NAME", 'C', 100, 0, func(p geodata.Point) string { return p.Name }},
+	{"PATH", 'C', 254, 0, func(p geodata.Point) string { return p.Path }},
+	// dBase dates have no time of day, so the time is kept as text
+	{"TIME", 'C', 25, 0, func(p geodata.Point) string { return p.Time.Format(time.RFC3339) }},
+	{"ALTITUDE", 'N', 10, 1, func(p geodata.Point) string { return optionalNumber(p.Ele) }},
+	{"HEADING", 'N', 6, 1, func(p geodata.Point) string { return optionalNumber(p.Heading) }},
+	{"CAMERA", 'C', 100, 0, func(p geodata.Point) string { return p.Camera() }},
+	{"LENS", 'C', 100, 0, func(p geodata.Point) string { return p.Lens }},
+	{"CAPTION", 'C', 254, 0, func(p geodata.Point) string { return p.Caption }},
+}
+
+// GenerateShapefile creates a point shapefile for GIS tools such as ArcGIS and QGIS, saved to "output.shp" with
+// its "output.shx" index, "output.dbf" attribute table, "output.prj" coordinate system and "output.cpg" encoding.
+// The attributes are the photo's ID, name, path, time, altitude in meters, heading, camera, lens and caption.
+func GenerateShapefile(gpsData []geodata.Point) {
+	shp, shx := shapefileGeometry(gpsData)
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{outputPath("output.shp"), shp},
+		{outputPath("output.shx"), shx},
+		{outputPath("output.dbf"), shapefileAttributes(gpsData)},
+		{outputPath("output.prj"), []byte(shapefileWGS84)},
+		{outputPath("output.cpg"), []byte("UTF-8")},
+	}
+	for _, f := range files {
+		if err := writeFile(f.path, f.data); err != nil {
+			log.Fatalf("Error writing shapefile: %v", err)
+		}
+	}
+	log.Println("Shapefile generated successfully.")
+}
+
+// shapefileGeometry returns the .shp file with a point record per point, and the .shx index of the records.
+func shapefileGeometry(gpsData []geodata.Point) ([]byte, []byte) {
+	recordSize := 8 + shpPointSize
+	shp := bytes.NewBuffer(shapefileHeader(gpsData, shpHeaderSize+len(gpsData)*recordSize))
+	shx := bytes.NewBuffer(shapefileHeader(gpsData, shpHeaderSize+len(gpsData)*8))
+	for i, p := range gpsData {
+		offset := shpHeaderSize + i*recordSize
+		// record headers and the index are big-endian and count 16-bit words, the content is little-endian
+		_ = binary.Write(shx, binary.BigEndian, [2]int32{int32(offset / 2), shpPointSize / 2})
+		_ = binary.Write(shp, binary.BigEndian, [2]int32{int32(i + 1), shpPointSize / 2})
+		_ = binary.Write(shp, binary.LittleEndian, int32(shpPoint))
+		_ = binary.Write(shp, binary.LittleEndian, [2]float64{p.Lon, p.Lat})
+	}
+	return shp.Bytes(), shx.Bytes()
+}
+
+// shapefileHeader returns the header shared by the .shp and .shx files, for a file of size bytes.
+func shapefileHeader(gpsData []geodata.Point, size int) []byte {
+	header := make([]byte, shpHeaderSize)
+	binary.BigEndian.PutUint32(header[0:], 9994)
+	binary.BigEndian.PutUint32(header[24:], uint32(size/2))
+	binary.LittleEndian.PutUint32(header[28:], 1000)
+	binary.LittleEndian.PutUint32(header[32:], shpPoint)
+
+	bbox := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range gpsData {
+		bbox[0], bbox[1] = math.Min(bbox[0], p.Lon), math.Min(bbox[1], p.Lat)
+		bbox[2], bbox[3] = math.Max(bbox[2], p.Lon), math.Max(bbox[3], p.Lat)
+	}
+	if len(gpsData) == 0 {
+		bbox = [4]float64{}
+	}
+	for i, v := range bbox {
+		binary.LittleEndian.PutUint64(header[36+8*i:], math.Float64bits(v))
+	}
+	return header
+}
+
+// shapefileAttributes returns the .dbf file, a dBase III table with a row of dbfFields per point. Its last
+// update date is that of the newest photo rather than today, so the same photos give the same file.
+func shapefileAttributes(gpsData []geodata.Point) []byte {
+	recordSize := 1
+	for _, f := range dbfFields {
+		recordSize += f.length
+	}
+	updated := time.Unix(0, 0).UTC()
+	for _, p := range gpsData {
+		if p.Time.After(updated) {
+			updated = p.Time
+		}
+	}
+
+	header := make([]byte, 32)
+	header[0] = 0x03
+	header[1], header[2], header[3] = byte(updated.Year()-1900), byte(updated.Month()), byte(updated.Day())
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(gpsData)))
+	binary.LittleEndian.PutUint16(header[8:], uint16(32+32*len(dbfFields)+1))
+	binary.LittleEndian.PutUint16(header[10:], uint16(recordSize))
+	dbf := bytes.NewBuffer(header)
+	for _, f := range dbfFields {
+		descriptor := make([]byte, 32)
+		copy(descriptor, f.name)
+		descriptor[11] = f.kind
+		descriptor[16] = byte(f.length)
+		descriptor[17] = byte(f.decimals)
+		dbf.Write(descriptor)
+	}
+	dbf.WriteByte(0x0D)
+
+	for _, p := range gpsData {
+		// a space marks the record as not deleted
+		dbf.WriteByte(' ')
+		for _, f := range dbfFields {
+			value := truncateUTF8(f.value(p), f.length)
+			if f.kind == 'N' {
+				dbf.WriteString(strings.Repeat(" ", f.length-len(value)) + value)
+			} else {
+				dbf.WriteString(value + strings.Repeat(" ", f.length-len(value)))
+			}
+		}
+	}
+	dbf.WriteByte(0x1A)
+	return dbf.Bytes()
+}
+
+// optionalNumber formats a value with one decimal for a numeric column, or returns "" for no value.
+func optionalNumber(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// back up to the start of the character the cut falls in
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateShapefile checks the geometry, index and attribute table of the shapefile.
+func TestGenerateShapefile(t *testing.T) {
+	ele := 35.0
+	GenerateShapefile([]geodata.Point{
+		{ID: "3f2a9c1b7d4e", Name: "Image1", Path: "photos/Image1.jpg", Lat: 51.5074, Lon: -0.1276, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Ele: &ele, Caption: "Café"},
+		{ID: "3f2a9c1b7d4e-a81c0f", Name: "Image2", Path: "photos/Image2.jpg", Lat: 48.8566, Lon: 2.3522, Time: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)},
+	})
+	for _, ext := range []string{"shp", "shx", "dbf", "prj", "cpg"} {
+		defer os.Remove("out/output." + ext)
+	}
+
+	shp, err := os.ReadFile("out/output.shp")
+	if err != nil {
+		t.Fatalf("Expected output.shp to be generated: %v", err)
+	}
+	if len(shp) != 100+2*28 || binary.BigEndian.Uint32(shp) != 9994 || int(binary.BigEndian.Uint32(shp[24:]))*2 != len(shp) {
+		t.Fatalf("Unexpected .shp header or size %d", len(shp))
+	}
+	if xmin, ymax := math.Float64frombits(binary.LittleEndian.Uint64(shp[36:])), math.Float64frombits(binary.LittleEndian.Uint64(shp[60:])); xmin != -0.1276 || ymax != 51.5074 {
+		t.Errorf("Unexpected bounding box starting %v and ending %v", xmin, ymax)
+	}
+	second := shp[100+28:]
+	if binary.BigEndian.Uint32(second) != 2 || binary.LittleEndian.Uint32(second[8:]) != shpPoint ||
+		math.Float64frombits(binary.LittleEndian.Uint64(second[12:])) != 2.3522 || math.Float64frombits(binary.LittleEndian.Uint64(second[20:])) != 48.8566 {
+		t.Errorf("Unexpected second record % x", second)
+	}
+
+	shx, _ := os.ReadFile("out/output.shx")
+	if len(shx) != 100+2*8 || binary.BigEndian.Uint32(shx[108:]) != (100+28)/2 {
+		t.Errorf("Expected the index to point at the second record, got % x", shx[100:])
+	}
+
+	dbf, _ := os.ReadFile("out/output.dbf")
+	headerSize, recordSize := int(binary.LittleEndian.Uint16(dbf[8:])), int(binary.LittleEndian.Uint16(dbf[10:]))
+	if binary.LittleEndian.Uint32(dbf[4:]) != 2 || len(dbf) != headerSize+2*recordSize+1 || dbf[1] != 124 || dbf[3] != 2 {
+		t.Fatalf("Unexpected .dbf header % x", dbf[:32])
+	}
+	first := string(dbf[headerSize : headerSize+recordSize])
+	for _, expected := range []string{"3f2a9c1b7d4e", "Image1", "2024-05-01T10:00:00Z", "      35.0", "Café"} {
+		if !strings.Contains(first, expected) {
+			t.Errorf("Expected %q in the first record %q", expected, first)
+		}
+	}
+	// IDs with the suffix of photos sharing a capture time and camera are kept whole
+	if record := string(dbf[headerSize+recordSize : headerSize+2*recordSize]); !strings.Contains(record, "3f2a9c1b7d4e-a81c0f") {
+		t.Errorf("Expected the whole suffixed ID in the second record %q", record)
+	}
+}
+
+// TestTruncateUTF8 checks that text is cut to whole characters.
+func TestTruncateUTF8(t *testing.T) {
+	if s := truncateUTF8("Café", 4); s != "Caf" {
+		t.Errorf("Expected the é to be dropped whole, got %q", s)
+	}
+	if s := truncateUTF8("Café", 5); s != "Café" {
+		t.Errorf("Expected text that fits to be kept, got %q", s)
+	}
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// folderSidecar is the name of the GeoJSON file written into each folder of photos
+const folderSidecar = "photos2map.geojson"
+
+// GenerateFolderSidecars writes a GeoJSON file named "photos2map.geojson" into every folder holding photos,
+// with the photos directly in that folder, for archives organized by folder and for file managers that preview
+// GeoJSON on a map. The features are those of GenerateGeoJSON, with paths relative to the folder so the folder
+// can be moved. Photos read from archives or URLs, whose folder isn't a directory on disk, are left out.
+func GenerateFolderSidecars(gpsData []geodata.Point, units string) {
+	folders := make(map[string][]geodata.Point)
+	for _, p := range gpsData {
+		if p.Path != "" {
+			dir := filepath.Dir(p.Path)
+			folders[dir] = append(folders[dir], p)
+		}
+	}
+	dirs := make([]string, 0, len(folders))
+	for dir := range folders {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	count := 0
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		data, err := marshalGeoJSON(folders[dir], units, func(p geodata.Point, props map[string]interface{}) {
+			plainProperties(p, props)
+			props["path"] = filepath.Base(p.Path)
+		})
+		if err == nil {
+			err = writeFile(filepath.Join(dir, folderSidecar), data)
+		}
+		if err != nil {
+			log.Errorf("Error writing %s in %s: %v", folderSidecar, dir, err)
+			continue
+		}
+		count++
+	}
+	log.Printf("Wrote %s to %d folders.", folderSidecar, count)
+}
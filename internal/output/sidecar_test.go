@@ -0,0 +1,39 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestGenerateFolderSidecars checks that each folder gets its own photos with relative paths, and that photos
+// from archives are left out.
+func TestGenerateFolderSidecars(t *testing.T) {
+	root := t.TempDir()
+	trip := filepath.Join(root, "trip")
+	if err := os.Mkdir(trip, 0755); err != nil {
+		t.Fatal(err)
+	}
+	GenerateFolderSidecars([]geodata.Point{
+		{Name: "a", Path: filepath.Join(root, "a.jpg"), Lat: 51.5074, Lon: -0.1276},
+		{Name: "b", Path: filepath.Join(trip, "b.jpg"), Lat: 48.8566, Lon: 2.3522},
+		{Name: "c", Path: filepath.Join(root, "photos.zip", "c.jpg"), Lat: 45.764, Lon: 4.8357},
+	}, UnitsMetric)
+
+	top, err := os.ReadFile(filepath.Join(root, folderSidecar))
+	if err != nil {
+		t.Fatalf("Expected a sidecar in the top folder: %v", err)
+	}
+	if !strings.Contains(string(top), `"path": "a.jpg"`) || strings.Contains(string(top), `"b"`) {
+		t.Errorf("Expected only a.jpg with a relative path in the top folder's sidecar, got:\n%s", top)
+	}
+	if sub, err := os.ReadFile(filepath.Join(trip, folderSidecar)); err != nil || !strings.Contains(string(sub), `"path": "b.jpg"`) {
+		t.Errorf("Expected b.jpg in the trip folder's sidecar, got %s, %v", sub, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "photos.zip")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written for photos in an archive, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// previewFile is the snapshot image linked from the social meta tags, written next to the map
+const previewFile = "preview.png"
+
+// previewWidth and previewHeight are the 1.91:1 size OpenGraph and Twitter cards display best
+const (
+	previewWidth  = 1200
+	previewHeight = 630
+)
+
+// defaultDescription summarizes the photos on the map for link previews when no description is given.
+func defaultDescription(gpsData []geodata.Point) string {
+	first, last := gpsData[0].Time, gpsData[0].Time
+	for _, p := range gpsData[1:] {
+		if p.Time.Before(first) {
+			first = p.Time
+		}
+		if p.Time.After(last) {
+			last = p.Time
+		}
+	}
+
+	desc := fmt.Sprintf("Map of %d geotagged photos", len(gpsData))
+	if len(gpsData) == 1 {
+		desc = "Map of 1 geotagged photo"
+	}
+	if first.Format("2006-01-02") == last.Format("2006-01-02") {
+		return desc + " taken " + first.Format("2006-01-02")
+	}
+	return desc + " taken " + first.Format("2006-01-02") + " to " + last.Format("2006-01-02")
+}
+
+// socialMeta renders the OpenGraph and Twitter card tags for the map page named page. baseURL is where the map will be
+// published; link previews need absolute URLs, so without it the image is referenced relative to the page and
+// only shows up on services that resolve it.
+func socialMeta(title, description, baseURL, page string) string {
+	image := previewFile
+	var url string
+	if baseURL != "" {
+		url = strings.TrimSuffix(baseURL, "/") + "/"
+		image = url + previewFile
+	}
+
+	tags := [][2]string{
+		{"og:type", "website"},
+		{"og:title", title},
+		{"og:description", description},
+		{"og:image", image},
+		{"og:image:width", fmt.Sprint(previewWidth)},
+		{"og:image:height", fmt.Sprint(previewHeight)},
+	}
+	if url != "" {
+		tags = append(tags, [2]string{"og:url", url + page})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n    <meta name=\"description\" content=\"%s\">", html.EscapeString(description))
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "\n    <meta property=\"%s\" content=\"%s\">", tag[0], html.EscapeString(tag[1]))
+	}
+	for _, tag := range [][2]string{
+		{"twitter:card", "summary_large_image"},
+		{"twitter:title", title},
+		{"twitter:description", description},
+		{"twitter:image", image},
+	} {
+		fmt.Fprintf(&b, "\n    <meta name=\"%s\" content=\"%s\">", tag[0], html.EscapeString(tag[1]))
+	}
+	return b.String()
+}
+
+// addSocialMeta inserts the meta tags into the head of a rendered page.
+func addSocialMeta(page []byte, meta string) []byte {
+	head := []byte(`<meta charset="utf-8">`)
+	i := strings.Index(string(page), string(head))
+	if i < 0 {
+		return page
+	}
+	i += len(head)
+
+	out := make([]byte, 0, len(page)+len(meta))
+	out = append(out, page[:i]...)
+	out = append(out, meta...)
+	return append(out, page[i:]...)
+}
+
+// writePreview draws a snapshot of the points for link previews: a dot per photo on a plain background,
+// scaled to fit the area the photos cover with the same equirectangular projection as the map.
+func writePreview(gpsData []geodata.Point, path string) error {
+	img := image.NewRGBA(image.Rect(0, 0, previewWidth, previewHeight))
+	bg := color.RGBA{R: 0xe6, G: 0xf0, B: 0xf0, A: 0xff}
+	dot := color.RGBA{R: 0x00, G: 0x66, B: 0x66, A: 0xff}
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = bg.R, bg.G, bg.B, bg.A
+	}
+
+	minLon, maxLon, minLat, maxLat := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, p := range gpsData {
+		minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+		minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+	}
+	// keep a minimum extent so a single spot doesn't get divided by zero, and a margin around the points
+	const margin = 60
+	scale := math.Min(
+		float64(previewWidth-2*margin)/math.Max(maxLon-minLon, 0.01),
+		float64(previewHeight-2*margin)/math.Max(maxLat-minLat, 0.01),
+	)
+	cx, cy := (minLon+maxLon)/2, (minLat+maxLat)/2
+
+	// outline every dot before filling them in, so overlapping dots stay distinct
+	const r = 9
+	for _, c := range []struct {
+		radius float64
+		color  color.Color
+	}{{r + 2, color.White}, {r, dot}} {
+		for _, p := range gpsData {
+			x := int(previewWidth/2 + (p.Lon-cx)*scale)
+			y := int(previewHeight/2 - (p.Lat-cy)*scale)
+			for dy := -r - 2; dy <= r+2; dy++ {
+				for dx := -r - 2; dx <= r+2; dx++ {
+					if math.Hypot(float64(dx), float64(dy)) <= c.radius {
+						img.Set(x+dx, y+dy, c.color)
+					}
+				}
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return err
+	}
+	return writeFile(path, b.Bytes())
+}
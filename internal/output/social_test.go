@@ -0,0 +1,85 @@
+package output
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestSocialMeta checks that the tags are escaped and use absolute URLs when a base URL is given.
+func TestSocialMeta(t *testing.T) {
+	meta := socialMeta(`Trip "2024"`, "Photos <from> the trip", "https://example.com/trip/", "map.html")
+
+	for _, expected := range []string{
+		`<meta property="og:title" content="Trip &#34;2024&#34;">`,
+		`<meta name="description" content="Photos &lt;from&gt; the trip">`,
+		`<meta property="og:image" content="https://example.com/trip/preview.png">`,
+		`<meta property="og:url" content="https://example.com/trip/map.html">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+	} {
+		if !strings.Contains(meta, expected) {
+			t.Errorf("Expected meta tags to contain %s, got %s", expected, meta)
+		}
+	}
+
+	meta = socialMeta("Trip", "Photos", "", "map.html")
+	if !strings.Contains(meta, `<meta property="og:image" content="preview.png">`) || strings.Contains(meta, "og:url") {
+		t.Errorf("Expected a relative image and no page URL without a base URL, got %s", meta)
+	}
+}
+
+// TestAddSocialMeta checks that the tags are inserted into the page head.
+func TestAddSocialMeta(t *testing.T) {
+	page := addSocialMeta([]byte("<head>\n    <meta charset=\"utf-8\">\n    <title>x</title>"), "\n    <meta name=\"a\">")
+	expected := "<head>\n    <meta charset=\"utf-8\">\n    <meta name=\"a\">\n    <title>x</title>"
+	if string(page) != expected {
+		t.Errorf("Unexpected page: %q", page)
+	}
+}
+
+// TestDefaultDescription checks the summary of the photos' count and dates.
+func TestDefaultDescription(t *testing.T) {
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		points   []geodata.Point
+		expected string
+	}{
+		{[]geodata.Point{{Time: day}}, "Map of 1 geotagged photo taken 2024-05-01"},
+		{[]geodata.Point{{Time: day.Add(48 * time.Hour)}, {Time: day}}, "Map of 2 geotagged photos taken 2024-05-01 to 2024-05-03"},
+	}
+	for _, test := range tests {
+		if got := defaultDescription(test.points); got != test.expected {
+			t.Errorf("defaultDescription() = %q, expected %q", got, test.expected)
+		}
+	}
+}
+
+// TestWritePreview checks that the preview image is written at the link preview size.
+func TestWritePreview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), previewFile)
+	if err := writePreview([]geodata.Point{{Lat: 51.5074, Lon: -0.1276}}, path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected the preview to be written: %v", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("Preview is not a valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != previewWidth || b.Dy() != previewHeight {
+		t.Errorf("Unexpected preview size %v", b)
+	}
+	// a single point is drawn in the center
+	if r, g, b, _ := img.At(previewWidth/2, previewHeight/2).RGBA(); r>>8 != 0x00 || g>>8 != 0x66 || b>>8 != 0x66 {
+		t.Errorf("Expected a dot in the center of the preview")
+	}
+}
@@ -0,0 +1,38 @@
+package output
+
+import (
+	"math"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// spreadStep is the distance in degrees of latitude, about 5 m, between markers spread around a shared position
+const spreadStep = 0.00005
+
+// goldenAngle spaces consecutive markers of a spiral so they never line up, in radians
+var goldenAngle = math.Pi * (3 - math.Sqrt(5))
+
+// spreadDuplicates returns the points with those sharing the exact coordinates of an earlier point, such as a
+// tripod session, moved onto a sunflower spiral around it, so each marker can be clicked once zoomed in. The first
+// point at a position stays on it, and the layout only depends on the order of the points, so it's the same
+// every run.
+func spreadDuplicates(gpsData []geodata.Point) []geodata.Point {
+	type position struct{ lat, lon float64 }
+	seen := make(map[position]int, len(gpsData))
+	spread := make([]geodata.Point, len(gpsData))
+	for i, p := range gpsData {
+		pos := position{p.Lat, p.Lon}
+		k := seen[pos]
+		seen[pos]++
+		if k > 0 {
+			r := spreadStep * math.Sqrt(float64(k))
+			angle := float64(k) * goldenAngle
+			p.Lat += r * math.Cos(angle)
+			if cos := math.Cos(p.Lat * math.Pi / 180); cos > 1e-6 {
+				p.Lon += r * math.Sin(angle) / cos
+			}
+		}
+		spread[i] = p
+	}
+	return spread
+}
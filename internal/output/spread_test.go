@@ -0,0 +1,39 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestSpreadDuplicates checks that points sharing a position are moved apart by a few meters, the same way
+// every time, while other points stay put.
+func TestSpreadDuplicates(t *testing.T) {
+	var gpsData []geodata.Point
+	for i := 0; i < 20; i++ {
+		gpsData = append(gpsData, geodata.Point{Lat: 48.8566, Lon: 2.3522})
+	}
+	gpsData = append(gpsData, geodata.Point{Lat: 51.5074, Lon: -0.1276})
+
+	spread := spreadDuplicates(gpsData)
+	if spread[0] != gpsData[0] || spread[20] != gpsData[20] {
+		t.Errorf("Expected the first point at a position and unique points to stay put, got %+v and %+v", spread[0], spread[20])
+	}
+	for i := 1; i < 20; i++ {
+		if d := geodata.Distance(gpsData[i].Lat, gpsData[i].Lon, spread[i].Lat, spread[i].Lon); d < 1 || d > 30 {
+			t.Errorf("Expected point %d to move a few meters, moved %.1f m", i, d)
+		}
+		for j := 0; j < i; j++ {
+			if d := geodata.Distance(spread[i].Lat, spread[i].Lon, spread[j].Lat, spread[j].Lon); d < 2 {
+				t.Errorf("Expected points %d and %d to be apart, got %.1f m", i, j, d)
+			}
+		}
+	}
+
+	again := spreadDuplicates(gpsData)
+	for i := range spread {
+		if again[i] != spread[i] {
+			t.Fatalf("Expected the same layout every run, got %+v and %+v", spread[i], again[i])
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder for images without an embedded thumbnail
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+
+	"github.com/toozej/photos2map/internal/cache"
+	"github.com/toozej/photos2map/internal/exif"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// thumbsDir is the directory next to the map the thumbnails are written to
+const thumbsDir = "thumbs"
+
+// thumbSize is the longest side in pixels of thumbnails generated by resizing the photo
+const thumbSize = 240
+
+// thumbsSaveEvery is after how many new thumbnails the cache is saved, so an interrupted run over a large library
+// resumes from about where it stopped
+const thumbsSaveEvery = 500
+
+// thumbnailJS opens a popup with the photo's thumbnail when a marker is clicked. The thumbnail URL is the
+// fourth value dimension of the marker, which points without a thumbnail don't have.
+const thumbnailJS = `
+	(function (chart) {
+		var dom = chart.getDom();
+		dom.style.position = 'relative';
+		var popup = document.createElement('div');
+		popup.className = 'photos2map-popup';
+		popup.setAttribute('role', 'dialog');
+		popup.style.cssText = 'display:none;position:absolute;top:40px;left:50%;transform:translateX(-50%);z-index:20;background:#fff;border:1px solid #999;border-radius:4px;padding:8px;font:13px sans-serif;text-align:center;box-shadow:0 2px 6px rgba(0,0,0,0.3);';
+		var close = document.createElement('button');
+		close.type = 'button';
+		close.textContent = '×';
+		close.setAttribute('aria-label', 'Close photo');
+		close.style.cssText = 'position:absolute;top:2px;right:2px;border:none;background:none;font-size:16px;cursor:pointer;';
+		var img = document.createElement('img');
+		img.style.cssText = 'display:block;max-width:240px;max-height:240px;margin:16px auto 6px;';
+		var caption = document.createElement('div');
+		popup.appendChild(close);
+		popup.appendChild(img);
+		popup.appendChild(caption);
+		dom.appendChild(popup);
+		function hide() {
+			popup.style.display = 'none';
+		}
+		close.addEventListener('click', hide);
+		document.addEventListener('keydown', function (e) {
+			if (e.key === 'Escape') {
+				hide();
+			}
+		});
+		chart.on('click', function (params) {
+			if (params.seriesType === 'lines' || !params.value || !params.value[3]) {
+				return;
+			}
+			caption.innerHTML = params.name;
+			img.alt = caption.textContent;
+			img.src = params.value[3];
+			popup.style.display = 'block';
+			close.focus();
+		});
+	})(%MY_ECHARTS%);
+`
+
+// addThumbnails makes markers open their thumbnail in a popup when clicked.
+func addThumbnails(geo *charts.Geo) {
+	geo.AddJSFuncs(thumbnailJS)
+}
+
+// writeThumbnails writes a thumbnail of every photo into dir/thumbs, preferring the one embedded in its EXIF
+// data and otherwise resizing the photo. It returns the thumbnail URLs relative to dir keyed by photo path;
+// photos whose thumbnail couldn't be made are left out. With a cache, thumbnails generated by an earlier run
+// from a photo that hasn't changed since are kept, so only those of new or edited photos are made.
+func writeThumbnails(gpsData []geodata.Point, dir string, c *cache.Cache) map[string]string {
+	if err := os.MkdirAll(filepath.Join(dir, thumbsDir), 0755); err != nil { // #nosec G301
+		log.Errorf("Error creating thumbnail directory: %v", err)
+		return nil
+	}
+
+	thumbs := make(map[string]string, len(gpsData))
+	generated, kept := 0, 0
+	for _, p := range gpsData {
+		if _, ok := thumbs[p.Path]; ok || p.Path == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(p.Path))
+		url := thumbsDir + "/" + hex.EncodeToString(sum[:8]) + ".jpg"
+		path := filepath.Join(dir, url)
+
+		var info os.FileInfo
+		if c != nil {
+			var err error
+			if info, err = os.Stat(p.Path); err == nil && c.ThumbnailCurrent(p.Path, info, path) {
+				thumbs[p.Path] = url
+				kept++
+				continue
+			}
+		}
+		data, err := thumbnail(p.Path)
+		if err != nil {
+			log.Debugf("No thumbnail for %s: %v", p.Path, err)
+			continue
+		}
+		if err := replaceFile(path, data); err != nil {
+			log.Errorf("Error writing thumbnail for %s: %v", p.Path, err)
+			continue
+		}
+		thumbs[p.Path] = url
+		generated++
+		if c != nil && info != nil {
+			c.PutThumbnail(p.Path, info, path)
+			if generated%thumbsSaveEvery == 0 {
+				if err := c.Save(); err != nil {
+					log.Warnf("Error saving the thumbnail cache: %v", err)
+				}
+			}
+		}
+	}
+	if c != nil {
+		if err := c.Save(); err != nil {
+			log.Warnf("Error saving the thumbnail cache: %v", err)
+		}
+		log.Debugf("Generated %d thumbnails, kept %d unchanged ones", generated, kept)
+	}
+	return thumbs
+}
+
+// thumbnail returns a photo's embedded EXIF thumbnail, or encodes a downscaled copy of the photo when it has none.
+func thumbnail(path string) ([]byte, error) {
+	if data, err := exif.Thumbnail(path); err == nil {
+		return data, nil
+	}
+
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, shrink(img, thumbSize), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// shrink scales an image down so its longest side is at most size pixels, averaging the source pixels
+// covered by each destination pixel. Images that already fit are returned unchanged.
+func shrink(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+max((x+1)*w/dw, x*w/dw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)}) // #nosec G115
+		}
+	}
+	return dst
+}
@@ -0,0 +1,155 @@
+package output
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+
+	"github.com/toozej/photos2map/internal/cache"
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestWriteThumbnails checks that thumbnails are written for photos with and without an embedded thumbnail.
+func TestWriteThumbnails(t *testing.T) {
+	dir := t.TempDir()
+
+	// a PNG has no EXIF thumbnail, so it has to be resized
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		img.Set(x, 0, color.White)
+	}
+	pngPath := filepath.Join(dir, "plain.png")
+	file, err := os.Create(pngPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	gpsData := []geodata.Point{
+		{Name: "DSCN0010", Path: filepath.Join("..", "testdata", "DSCN0010.jpg")},
+		{Name: "plain", Path: pngPath},
+		{Name: "missing", Path: filepath.Join(dir, "missing.jpg")},
+	}
+	thumbs := writeThumbnails(gpsData, dir, nil)
+
+	if len(thumbs) != 2 {
+		t.Fatalf("Expected 2 thumbnails, got %v", thumbs)
+	}
+	for _, p := range gpsData[:2] {
+		url, ok := thumbs[p.Path]
+		if !ok || !strings.HasPrefix(url, "thumbs/") {
+			t.Fatalf("Expected a thumbnail URL for %s, got %q", p.Name, url)
+		}
+		file, err := os.Open(filepath.Join(dir, url))
+		if err != nil {
+			t.Fatalf("Expected thumbnail for %s to be written: %v", p.Name, err)
+		}
+		thumb, err := jpeg.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("Thumbnail for %s is not a valid JPEG: %v", p.Name, err)
+		}
+		if b := thumb.Bounds(); b.Dx() > thumbSize*2 || b.Dy() > thumbSize*2 {
+			t.Errorf("Thumbnail for %s is too large: %v", p.Name, b)
+		}
+	}
+}
+
+// TestWriteThumbnailsCached checks that with a cache only the thumbnails of new or edited photos, or those whose
+// thumbnail is gone, are made again.
+func TestWriteThumbnailsCached(t *testing.T) {
+	dir := t.TempDir()
+	photo, err := os.ReadFile(filepath.Join("..", "testdata", "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gpsData []geodata.Point
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, photo, 0600); err != nil {
+			t.Fatal(err)
+		}
+		gpsData = append(gpsData, geodata.Point{Name: name, Path: path})
+	}
+	c, err := cache.Open(filepath.Join(dir, "cache", "extract.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapDir := filepath.Join(dir, "map")
+	thumbs := writeThumbnails(gpsData, mapDir, c)
+
+	// mark the thumbnails so it shows which ones are made again
+	for _, url := range thumbs {
+		if err := os.WriteFile(filepath.Join(mapDir, url), []byte("kept"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(gpsData[1].Path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	c, err = cache.Open(filepath.Join(dir, "cache", "extract.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again := writeThumbnails(gpsData, mapDir, c); len(again) != 2 {
+		t.Fatalf("Expected 2 thumbnails, got %v", again)
+	}
+	if data, _ := os.ReadFile(filepath.Join(mapDir, thumbs[gpsData[0].Path])); string(data) != "kept" {
+		t.Error("Expected the thumbnail of the unchanged photo to be kept")
+	}
+	if data, _ := os.ReadFile(filepath.Join(mapDir, thumbs[gpsData[1].Path])); string(data) == "kept" {
+		t.Error("Expected the thumbnail of the edited photo to be made again")
+	}
+
+	if err := os.Remove(filepath.Join(mapDir, thumbs[gpsData[0].Path])); err != nil {
+		t.Fatal(err)
+	}
+	writeThumbnails(gpsData, mapDir, c)
+	if _, err := os.Stat(filepath.Join(mapDir, thumbs[gpsData[0].Path])); err != nil {
+		t.Errorf("Expected the missing thumbnail to be made again: %v", err)
+	}
+}
+
+// TestShrink checks that images are scaled to fit while keeping their aspect ratio.
+func TestShrink(t *testing.T) {
+	tests := []struct {
+		w, h                 int
+		expectedW, expectedH int
+	}{
+		{800, 400, 240, 120},
+		{300, 600, 120, 240},
+		{100, 50, 100, 50},
+	}
+	for _, test := range tests {
+		b := shrink(image.NewRGBA(image.Rect(0, 0, test.w, test.h)), thumbSize).Bounds()
+		if b.Dx() != test.expectedW || b.Dy() != test.expectedH {
+			t.Errorf("shrink(%dx%d) = %dx%d, expected %dx%d", test.w, test.h, b.Dx(), b.Dy(), test.expectedW, test.expectedH)
+		}
+	}
+}
+
+// TestAddThumbnails checks that the popup script is added.
+func TestAddThumbnails(t *testing.T) {
+	geo := charts.NewGeo()
+	addThumbnails(geo)
+
+	var js string
+	for _, fn := range geo.JSFunctions.Fns {
+		js += string(fn)
+	}
+	if !strings.Contains(js, "photos2map-popup") || !strings.Contains(js, "value[3]") {
+		t.Errorf("Expected the thumbnail popup script to be added, got %s", js)
+	}
+}
@@ -0,0 +1,147 @@
+package output
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// The tile providers known by name
+const (
+	// TilesOSM are the standard OpenStreetMap tiles
+	TilesOSM = "osm"
+	// TilesOpenTopo are OpenTopoMap's topographic tiles, with contour lines and hill shading for hiking maps
+	TilesOpenTopo = "opentopo"
+	// TilesCarto are CARTO's Voyager tiles, lighter than OpenStreetMap's so the markers stand out
+	TilesCarto = "carto"
+)
+
+// TileProvider is a server of raster map tiles for the Leaflet and MapLibre maps.
+type TileProvider struct {
+	// URL is the template of the tiles' URLs, with {z}, {x} and {y} and, if Subdomains is set, {s}
+	URL string
+	// Subdomains are the letters {s} in URL stands for, each a server the tiles are spread over
+	Subdomains string
+	// Attribution is the HTML crediting the tiles and their data, shown in the corner of the map
+	Attribution string
+	// MaxZoom is the deepest zoom level the server has tiles for
+	MaxZoom int
+}
+
+// osmAttribution credits the OpenStreetMap data all the providers draw
+const osmAttribution = `&copy; <a href="https://www.openstreetmap.org/copyright">OpenStreetMap</a> contributors`
+
+// TileProviders are the tile providers known by name.
+var TileProviders = map[string]TileProvider{
+	TilesOSM: {
+		URL:         "https://tile.openstreetmap.org/{z}/{x}/{y}.png",
+		Attribution: osmAttribution,
+		MaxZoom:     19,
+	},
+	TilesOpenTopo: {
+		URL:        "https://{s}.tile.opentopomap.org/{z}/{x}/{y}.png",
+		Subdomains: "abc",
+		Attribution: osmAttribution + `, <a href="http://viewfinderpanoramas.org">SRTM</a> | Map style: &copy; ` +
+			`<a href="https://opentopomap.org">OpenTopoMap</a> (<a href="https://creativecommons.org/licenses/by-sa/3.0/">CC-BY-SA</a>)`,
+		MaxZoom: 17,
+	},
+	TilesCarto: {
+		URL:         "https://{s}.basemaps.cartocdn.com/rastertiles/voyager/{z}/{x}/{y}.png",
+		Subdomains:  "abcd",
+		Attribution: osmAttribution + ` &copy; <a href="https://carto.com/attributions">CARTO</a>`,
+		MaxZoom:     20,
+	},
+}
+
+// customMaxZoom is the deepest zoom level of tile servers given by URL, that of OpenStreetMap's
+const customMaxZoom = 19
+
+// Tiles returns the tile provider called name, one of TileProviders, or the one serving tiles at the URL
+// template name, with {z}, {x} and {y}, such as a self-hosted tile server. attribution, if set, replaces the
+// provider's; tile servers given by URL have none otherwise.
+func Tiles(name, attribution string) (TileProvider, error) {
+	tiles, ok := TileProviders[name]
+	if !ok {
+		for _, placeholder := range []string{"{z}", "{x}", "{y}"} {
+			if !strings.Contains(name, placeholder) {
+				return TileProvider{}, fmt.Errorf("expected one of osm, opentopo or carto, or a URL with {z}, {x} and {y}, got %q", name)
+			}
+		}
+		tiles = TileProvider{URL: name, MaxZoom: customMaxZoom}
+	}
+	if attribution != "" {
+		tiles.Attribution = attribution
+	}
+	return tiles, nil
+}
+
+// TileSource returns the URL the map drawn with mapOpts.Engine loads its tiles from: the template of the tiles'
+// URLs for the Leaflet map and the style's URL, or the tiles', for the MapLibre map. The ECharts map loads nothing
+// but its assets, so it has none.
+func TileSource(mapOpts MapOptions) string {
+	switch {
+	case mapOpts.Engine == EngineMapLibre && mapOpts.Style != "":
+		return mapOpts.Style
+	case mapOpts.Engine == EngineMapLibre && mapOpts.Tiles.URL == "":
+		return DefaultStyle
+	case mapOpts.Engine == EngineECharts:
+		return ""
+	case mapOpts.Tiles.URL == "":
+		return TileProviders[TilesOSM].URL
+	}
+	return mapOpts.Tiles.URL
+}
+
+// IsLocalURL reports whether the map loads u without the network: a path relative to the map, a file:// URL, or a
+// URL of a server on this machine.
+func IsLocalURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "":
+		// //host/path is on another machine as much as http://host/path
+		return parsed.Host == ""
+	case "file":
+		return true
+	case "http", "https":
+		host := parsed.Hostname()
+		if ip := net.ParseIP(host); ip != nil {
+			return ip.IsLoopback()
+		}
+		return host == "localhost"
+	}
+	return false
+}
+
+// urls returns the URL templates of the tiles on each of the provider's servers, for clients that don't
+// understand {s}.
+func (t TileProvider) urls() []string {
+	if t.Subdomains == "" || !strings.Contains(t.URL, "{s}") {
+		return []string{t.URL}
+	}
+	urls := make([]string, 0, len(t.Subdomains))
+	for _, s := range t.Subdomains {
+		urls = append(urls, strings.ReplaceAll(t.URL, "{s}", string(s)))
+	}
+	return urls
+}
+
+// rasterStyle returns a MapLibre style drawing the provider's tiles.
+func rasterStyle(t TileProvider) map[string]any {
+	return map[string]any{
+		"version": 8,
+		"sources": map[string]any{
+			"tiles": map[string]any{
+				"type":        "raster",
+				"tiles":       t.urls(),
+				"tileSize":    256,
+				"maxzoom":     t.MaxZoom,
+				"attribution": t.Attribution,
+			},
+		},
+		"layers": []map[string]any{{"id": "tiles", "type": "raster", "source": "tiles"}},
+	}
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestTiles checks that providers are found by name or URL, with the attribution given replacing theirs.
+func TestTiles(t *testing.T) {
+	if tiles, err := Tiles(TilesOpenTopo, ""); err != nil || tiles.MaxZoom != 17 || !strings.Contains(tiles.Attribution, "OpenTopoMap") {
+		t.Errorf("Expected OpenTopoMap's tiles, got %+v, %v", tiles, err)
+	}
+	tiles, err := Tiles("http://tiles.local/{z}/{x}/{y}.png", "&copy; Me")
+	expected := TileProvider{URL: "http://tiles.local/{z}/{x}/{y}.png", Attribution: "&copy; Me", MaxZoom: customMaxZoom}
+	if err != nil || tiles != expected {
+		t.Errorf("Expected %+v, got %+v, %v", expected, tiles, err)
+	}
+	for _, name := range []string{"google", "http://tiles.local/{x}/{y}.png"} {
+		if _, err := Tiles(name, ""); err == nil {
+			t.Errorf("Expected an error for --tiles %s", name)
+		}
+	}
+}
+
+// TestTileSource checks where each map engine loads its tiles from, and which of those stay off the network.
+func TestTileSource(t *testing.T) {
+	local := TileProvider{URL: "http://localhost:8080/{z}/{x}/{y}.png"}
+	tests := []struct {
+		mapOpts  MapOptions
+		expected string
+		local    bool
+	}{
+		{MapOptions{Engine: EngineECharts}, "", true},
+		{MapOptions{Engine: EngineLeaflet}, TileProviders[TilesOSM].URL, false},
+		{MapOptions{Engine: EngineLeaflet, Tiles: local}, local.URL, true},
+		{MapOptions{Engine: EngineMapLibre}, DefaultStyle, false},
+		{MapOptions{Engine: EngineMapLibre, Tiles: local}, local.URL, true},
+		{MapOptions{Engine: EngineMapLibre, Style: "file:///srv/style.json"}, "file:///srv/style.json", true},
+		{MapOptions{Engine: EngineMapLibre, Style: "styles/liberty.json"}, "styles/liberty.json", true},
+		{MapOptions{Engine: EngineMapLibre, Style: "http://127.0.0.1/style.json"}, "http://127.0.0.1/style.json", true},
+		{MapOptions{Engine: EngineMapLibre, Style: "//tiles.example.com/style.json"}, "//tiles.example.com/style.json", false},
+	}
+	for _, tt := range tests {
+		source := TileSource(tt.mapOpts)
+		if source != tt.expected {
+			t.Errorf("Expected %q for %+v, got %q", tt.expected, tt.mapOpts, source)
+		}
+		if source != "" && IsLocalURL(source) != tt.local {
+			t.Errorf("Expected IsLocalURL(%q) to be %v", source, tt.local)
+		}
+	}
+}
+
+// TestTileURLs checks that {s} is expanded to each subdomain for MapLibre.
+func TestTileURLs(t *testing.T) {
+	expected := []string{
+		"https://a.basemaps.cartocdn.com/rastertiles/voyager/{z}/{x}/{y}.png",
+		"https://b.basemaps.cartocdn.com/rastertiles/voyager/{z}/{x}/{y}.png",
+		"https://c.basemaps.cartocdn.com/rastertiles/voyager/{z}/{x}/{y}.png",
+		"https://d.basemaps.cartocdn.com/rastertiles/voyager/{z}/{x}/{y}.png",
+	}
+	if urls := TileProviders[TilesCarto].urls(); !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected %v, got %v", expected, urls)
+	}
+	if urls := TileProviders[TilesOSM].urls(); !reflect.DeepEqual(urls, []string{TileProviders[TilesOSM].URL}) {
+		t.Errorf("Expected OpenStreetMap's URL alone, got %v", urls)
+	}
+}
+
+// TestGenerateMapTiles checks that the Leaflet map is drawn on the tiles given, and the MapLibre map on a raster
+// style of them.
+func TestGenerateMapTiles(t *testing.T) {
+	defer func(dir string) { Dir = dir }(Dir)
+	Dir = t.TempDir()
+	gpsData := []geodata.Point{{Name: "Rome", Lat: 41.9028, Lon: 12.4964}}
+	tiles := TileProvider{URL: "http://tiles.local/{z}/{x}/{y}.png", Attribution: "Local tiles", MaxZoom: 16}
+
+	for engine, expected := range map[string][]string{
+		EngineLeaflet:  {`L.tileLayer("http://tiles.local/{z}/{x}/{y}.png"`, `attribution: "Local tiles"`},
+		EngineMapLibre: {`"attribution":"Local tiles","maxzoom":16,"tileSize":256,"tiles":["http://tiles.local/{z}/{x}/{y}.png"],"type":"raster"`},
+	} {
+		GenerateMap(gpsData, MapOptions{Engine: engine, Tiles: tiles})
+		data, err := os.ReadFile(filepath.Join(Dir, "map.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range expected {
+			if !strings.Contains(string(data), e) {
+				t.Errorf("Expected the %s map to contain %s, got:\n%s", engine, e, data)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package output
+
+import "fmt"
+
+// The systems of units distances, altitudes and speeds are shown in
+const (
+	// UnitsMetric shows meters, kilometers and km/h
+	UnitsMetric = "metric"
+	// UnitsImperial shows feet, miles and mph
+	UnitsImperial = "imperial"
+)
+
+// Units are the valid units values, where "" is taken as UnitsMetric.
+var Units = []string{UnitsMetric, UnitsImperial}
+
+const (
+	metersPerFoot = 0.3048
+	metersPerMile = 1609.344
+)
+
+// formatAltitude shows an altitude in meters in the given units, rounded to whole meters or feet.
+func formatAltitude(meters float64, units string) string {
+	if units == UnitsImperial {
+		return fmt.Sprintf("%.0f ft", meters/metersPerFoot)
+	}
+	return fmt.Sprintf("%.0f m", meters)
+}
+
+// formatDistance shows a distance in meters in kilometers or miles, to a tenth.
+func formatDistance(meters float64, units string) string {
+	if units == UnitsImperial {
+		return fmt.Sprintf("%.1f mi", meters/metersPerMile)
+	}
+	return fmt.Sprintf("%.1f km", meters/1000)
+}
+
+// formatSpeed shows a speed in meters per second in km/h or mph, to a tenth.
+func formatSpeed(metersPerSecond float64, units string) string {
+	if units == UnitsImperial {
+		return fmt.Sprintf("%.1f mph", metersPerSecond*3600/metersPerMile)
+	}
+	return fmt.Sprintf("%.1f km/h", metersPerSecond*3.6)
+}
+
+// altitudeValue is an altitude in meters as a bare number in the given units, for data columns.
+func altitudeValue(meters float64, units string) string {
+	if units == UnitsImperial {
+		return fmt.Sprintf("%.0f", meters/metersPerFoot)
+	}
+	return fmt.Sprintf("%.0f", meters)
+}
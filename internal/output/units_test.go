@@ -0,0 +1,24 @@
+package output
+
+import "testing"
+
+// TestFormatUnits checks that altitudes, distances and speeds are shown in metric units by default and imperial
+// ones when asked.
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		got, expected string
+	}{
+		{formatAltitude(100, ""), "100 m"},
+		{formatAltitude(100, UnitsImperial), "328 ft"},
+		{formatDistance(16093.44, UnitsMetric), "16.1 km"},
+		{formatDistance(16093.44, UnitsImperial), "10.0 mi"},
+		{formatSpeed(10, UnitsMetric), "36.0 km/h"},
+		{formatSpeed(10, UnitsImperial), "22.4 mph"},
+		{altitudeValue(100, UnitsImperial), "328"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+		}
+	}
+}
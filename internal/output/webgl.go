@@ -0,0 +1,46 @@
+package output
+
+import (
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/types"
+)
+
+// The renderers the markers of the HTML map can be drawn with
+const (
+	// RendererAuto draws the markers with WebGL on maps with more than webGLAbove points, and on a canvas otherwise
+	RendererAuto = "auto"
+	// RendererCanvas draws every marker with its ripple effect on a 2D canvas
+	RendererCanvas = "canvas"
+	// RendererWebGL draws plain markers with ECharts GL, which stays smooth with hundreds of thousands of points
+	RendererWebGL = "webgl"
+)
+
+// Renderers are the valid values of MapOptions.Renderer.
+var Renderers = []string{RendererAuto, RendererCanvas, RendererWebGL}
+
+// webGLAbove is the number of points above which RendererAuto switches to WebGL, where the animated canvas
+// markers start to make panning and zooming stutter
+const webGLAbove = 5000
+
+// echartsGLAsset is the ECharts GL extension drawing the WebGL series, loaded from the same host as ECharts
+const echartsGLAsset = "echarts-gl.min.js"
+
+// useWebGL reports whether the markers of a map with n points are drawn with WebGL.
+func useWebGL(renderer string, n int) bool {
+	return renderer == RendererWebGL || (renderer == RendererAuto && n > webGLAbove)
+}
+
+// addWebGLSeries adds the markers as a "scatterGL" series in place of the animated canvas one. It's the first
+// series like the canvas one, so the search, thinning and accessibility scripts work on it unchanged.
+func addWebGLSeries(geo *charts.Geo, data []marker) {
+	geo.JSAssets.Add(echartsGLAsset)
+	geo.MultiSeries = append(geo.MultiSeries, charts.SingleSeries{
+		Name:        "geo",
+		Type:        "scatterGL",
+		CoordSystem: types.ChartGeo,
+		SymbolSize:  6,
+		ItemStyle:   &opts.ItemStyle{Color: "#c23531", Opacity: 0.8},
+		Data:        data,
+	})
+}
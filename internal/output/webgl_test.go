@@ -0,0 +1,51 @@
+package output
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestUseWebGL checks when each renderer draws the markers with WebGL.
+func TestUseWebGL(t *testing.T) {
+	tests := []struct {
+		renderer string
+		n        int
+		expected bool
+	}{
+		{"", webGLAbove + 1, false},
+		{RendererCanvas, webGLAbove + 1, false},
+		{RendererWebGL, 1, true},
+		{RendererAuto, webGLAbove, false},
+		{RendererAuto, webGLAbove + 1, true},
+	}
+	for _, tt := range tests {
+		if got := useWebGL(tt.renderer, tt.n); got != tt.expected {
+			t.Errorf("Expected WebGL %v for %q with %d points, got %v", tt.expected, tt.renderer, tt.n, got)
+		}
+	}
+}
+
+// TestGenerateMapWebGL checks that the WebGL renderer replaces the marker series and loads ECharts GL.
+func TestGenerateMapWebGL(t *testing.T) {
+	gpsData := []geodata.Point{{Name: "Image1", Lat: 48.0, Lon: 2.0}}
+	defer os.Remove("out/map.html")
+	defer os.Remove("out/" + previewFile)
+
+	for renderer, expected := range map[string]bool{RendererCanvas: false, RendererWebGL: true} {
+		GenerateMap(gpsData, MapOptions{Inline: true, Renderer: renderer})
+		page, err := os.ReadFile("out/map.html")
+		if err != nil {
+			t.Fatalf("Error reading map.html: %v", err)
+		}
+		content := string(page)
+		if strings.Contains(content, `"type":"scatterGL"`) != expected || strings.Contains(content, echartsGLAsset) != expected {
+			t.Errorf("Expected the WebGL series and ECharts GL %v with the %s renderer", expected, renderer)
+		}
+		if strings.Contains(content, `"type":"effectScatter"`) == expected {
+			t.Errorf("Expected the canvas series %v with the %s renderer", !expected, renderer)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Package progress renders a progress bar for long running scans on a terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// barWidth is the number of characters of the bar itself
+const barWidth = 30
+
+// pathWidth is the most characters of the current path shown after the counts
+const pathWidth = 40
+
+// interval is the minimum time between redraws, so fast scans aren't slowed down by output
+const interval = 100 * time.Millisecond
+
+// Bar is a single line progress bar that is redrawn in place.
+type Bar struct {
+	w     io.Writer
+	last  time.Time
+	drawn bool
+}
+
+// New returns a Bar writing to w, usually os.Stderr.
+func New(w io.Writer) *Bar {
+	return &Bar{w: w}
+}
+
+// Update redraws the bar with done of total files scanned, of which matched had GPS data, and the path of
+// the file just scanned. A negative total means it isn't known yet, and only the counts are shown.
+// Redraws are throttled, except for the final one.
+func (b *Bar) Update(done, total, matched int, path string) {
+	now := time.Now()
+	if (total < 0 || done < total) && now.Sub(b.last) < interval {
+		return
+	}
+	b.last = now
+	b.drawn = true
+	fmt.Fprint(b.w, "\r"+line(done, total, matched, path)+"\x1b[K")
+}
+
+// Finish ends the bar's line so following output starts on a new one.
+func (b *Bar) Finish() {
+	if b.drawn {
+		fmt.Fprintln(b.w)
+	}
+}
+
+// line renders the bar, the counts and the path shortened from the left to fit.
+func line(done, total, matched int, path string) string {
+	if r := []rune(path); len(r) > pathWidth {
+		path = "…" + string(r[len(r)-pathWidth+1:])
+	}
+	if total < 0 {
+		return fmt.Sprintf("%d scanned, %d matched  %s", done, matched, path)
+	}
+
+	filled := barWidth
+	if total > 0 {
+		filled = done * barWidth / total
+	}
+	percent := 100
+	if total > 0 {
+		percent = done * 100 / total
+	}
+
+	return fmt.Sprintf("[%s%s] %3d%% %d/%d scanned, %d matched  %s",
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled), percent, done, total, matched, path)
+}
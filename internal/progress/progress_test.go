@@ -0,0 +1,53 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestLine checks the bar, counts and path shortening of a progress line.
+func TestLine(t *testing.T) {
+	got := line(5, 10, 3, "photos/IMG_0001.jpg")
+	expected := "[" + strings.Repeat("=", 15) + strings.Repeat(" ", 15) + "]  50% 5/10 scanned, 3 matched  photos/IMG_0001.jpg"
+	if got != expected {
+		t.Errorf("line() = %q, expected %q", got, expected)
+	}
+
+	long := strings.Repeat("a", 100) + "/IMG_0001.jpg"
+	got = line(10, 10, 10, long)
+	if !strings.HasSuffix(got, "…"+long[len(long)-pathWidth+1:]) || !strings.Contains(got, "100%") {
+		t.Errorf("Expected a complete bar with the path shortened from the left, got %q", got)
+	}
+
+	if got := line(7, -1, 2, "IMG_0007.jpg"); got != "7 scanned, 2 matched  IMG_0007.jpg" {
+		t.Errorf("Expected only the counts for an unknown total, got %q", got)
+	}
+}
+
+// TestBar checks that redraws are throttled but the final state is always drawn.
+func TestBar(t *testing.T) {
+	var out bytes.Buffer
+	bar := New(&out)
+	bar.Update(1, 3, 1, "a.jpg")
+	bar.Update(2, 3, 1, "b.jpg")
+	bar.Update(3, 3, 2, "c.jpg")
+	bar.Finish()
+
+	s := out.String()
+	if !strings.Contains(s, "a.jpg") || strings.Contains(s, "b.jpg") || !strings.Contains(s, "3/3 scanned, 2 matched  c.jpg") {
+		t.Errorf("Unexpected output %q", s)
+	}
+	if !strings.HasSuffix(s, "\n") {
+		t.Errorf("Expected Finish to end the line, got %q", s)
+	}
+}
+
+// TestBarNothingDrawn checks that Finish doesn't leave an empty line when nothing was scanned.
+func TestBarNothingDrawn(t *testing.T) {
+	var out bytes.Buffer
+	New(&out).Finish()
+	if out.Len() != 0 {
+		t.Errorf("Expected no output, got %q", out.String())
+	}
+}
@@ -0,0 +1,103 @@
+// Package ratelimit limits the bandwidth of the remote sources, shared by all the reads in parallel.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter paces reads so that together they stay under a number of bytes per second. A nil Limiter doesn't
+// limit anything.
+type Limiter struct {
+	rate float64
+	// chunk is the most read at once, so the pace is even rather than long waits after large reads
+	chunk int
+
+	mu sync.Mutex
+	// next is when the bytes reserved so far are paid for at the rate
+	next time.Time
+}
+
+// New returns a Limiter to bytesPerSecond, or nil for no limit if it's 0.
+func New(bytesPerSecond int64) *Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &Limiter{rate: float64(bytesPerSecond), chunk: int(max(1024, min(bytesPerSecond/4, 64*1024)))}
+}
+
+// Wait takes n bytes out of the rate, waiting until they and those taken before are paid for at the rate, or
+// until ctx is done.
+func (l *Limiter) Wait(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	wait := l.next.Sub(now)
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reader returns a reader reading from r at the limiter's rate, or r itself if l is nil.
+func (l *Limiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &reader{r: r, l: l, ctx: ctx}
+}
+
+// reader is a reader paced by a Limiter
+type reader struct {
+	r   io.Reader
+	l   *Limiter
+	ctx context.Context
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > r.l.chunk {
+		p = p[:r.l.chunk]
+	}
+	n, err := r.r.Read(p)
+	if waitErr := r.l.Wait(r.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
+
+// Parse parses a rate in bytes per second, such as 500K or 2M, with an optional K, M or G suffix for KiB, MiB
+// or GiB and an optional B or /s after it.
+func Parse(s string) (int64, error) {
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "/S"), "B")
+	multiplier := 1.0
+	for i, suffix := range []string{"K", "M", "G"} {
+		if rest, ok := strings.CutSuffix(number, suffix); ok {
+			number, multiplier = rest, float64(int64(1)<<(10*(i+1)))
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("expected a number of bytes per second such as 500K or 2M, got %q", s)
+	}
+	return int64(value * multiplier), nil
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestParse checks rates with and without suffixes.
+func TestParse(t *testing.T) {
+	for input, expected := range map[string]int64{
+		"1000":   1000,
+		"500K":   500 * 1024,
+		"2M":     2 * 1024 * 1024,
+		"1.5MB":  3 * 512 * 1024,
+		"1g/s":   1 << 30,
+		"64KB/s": 64 * 1024,
+		"0":      0,
+	} {
+		if rate, err := Parse(input); err != nil || rate != expected {
+			t.Errorf("Expected %q to parse as %d, got %d, %v", input, expected, rate, err)
+		}
+	}
+	for _, input := range []string{"", "fast", "-1M", "2T"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+// TestWait checks that reads in parallel together stay under the rate.
+func TestWait(t *testing.T) {
+	l := New(1 << 20)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 2 {
+				if err := l.Wait(context.Background(), 32*1024); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// 256KiB at 1MiB/s
+	if elapsed := time.Since(start); elapsed < 240*time.Millisecond {
+		t.Errorf("Expected 256KiB to take at least 250ms at 1MiB/s, took %v", elapsed)
+	}
+
+	var none *Limiter
+	if New(0) != nil || none.Wait(context.Background(), 1<<30) != nil || none.Reader(context.Background(), nil) != nil {
+		t.Error("Expected no limit without a rate")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := New(1).Wait(ctx, 1<<20); err == nil {
+		t.Error("Expected an error once the context is done")
+	}
+}
+
+// TestReader checks that a limited reader reads everything, at the rate.
+func TestReader(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 20*1024)
+	start := time.Now()
+	read, err := io.ReadAll(New(1<<20).Reader(context.Background(), bytes.NewReader(data)))
+	if err != nil || !bytes.Equal(read, data) {
+		t.Fatalf("Expected all %d bytes, got %d, %v", len(data), len(read), err)
+	}
+	// 200KiB at 1MiB/s
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("Expected 200KiB to take at least 195ms at 1MiB/s, took %v", elapsed)
+	}
+}
@@ -0,0 +1,288 @@
+// Package s3 lists and reads objects from S3 and S3-compatible storage such as Backblaze B2 or MinIO, signing
+// requests with AWS Signature Version 4.
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/toozej/photos2map/internal/transport"
+)
+
+// emptyHash is the SHA-256 of an empty payload, as signed for GET requests
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Config holds the endpoint and credentials to use. Requests are sent unsigned when AccessKey is empty, which
+// works for public buckets.
+type Config struct {
+	// Endpoint is the base URL of an S3-compatible service, e.g. https://s3.us-west-004.backblazeb2.com.
+	// Buckets are addressed by path on custom endpoints, and by host name on AWS when it's empty.
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// ConfigFromEnv reads the configuration from the environment variables the AWS CLI uses: AWS_ENDPOINT_URL,
+// AWS_REGION or AWS_DEFAULT_REGION, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+// The region defaults to us-east-1.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Endpoint:     os.Getenv("AWS_ENDPOINT_URL"),
+		Region:       os.Getenv("AWS_REGION"),
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return cfg
+}
+
+// ParseURL splits an s3://bucket/prefix URL into its bucket and key prefix, ok is false for other URLs.
+func ParseURL(s string) (bucket, prefix string, ok bool) {
+	rest, ok := strings.CutPrefix(s, "s3://")
+	if !ok {
+		return "", "", false
+	}
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	return bucket, prefix, bucket != ""
+}
+
+// Object is an object listed in a bucket.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Client sends requests to an S3-compatible service.
+type Client struct {
+	cfg  Config
+	http *http.Client
+	// now returns the time requests are signed with, replaced in tests
+	now func() time.Time
+}
+
+// New returns a Client for cfg sending its requests with client, or with one retrying failed requests with the
+// transport package's default options if client is nil.
+func New(cfg Config, client *http.Client) *Client {
+	if client == nil {
+		client = transport.NewClient(transport.DefaultOptions)
+	}
+	return &Client{cfg: cfg, http: client, now: time.Now}
+}
+
+// listResult is the part of a ListObjectsV2 response that is read
+type listResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List returns all objects in bucket whose key starts with prefix, following continuation tokens across pages.
+func (c *Client) List(ctx context.Context, bucket, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := c.do(ctx, bucket, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing bucket listing: %w", err)
+		}
+
+		for _, o := range result.Contents {
+			objects = append(objects, Object{Key: o.Key, Size: o.Size, LastModified: o.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// ReadRange returns up to the first n bytes of an object, fetched with a ranged GET.
+func (c *Client) ReadRange(ctx context.Context, bucket, key string, n int64) ([]byte, error) {
+	resp, err := c.do(ctx, bucket, key, nil, http.Header{"Range": {fmt.Sprintf("bytes=0-%d", n-1)}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// servers ignoring the range send the whole object, so only n bytes are read either way
+	return io.ReadAll(io.LimitReader(resp.Body, n))
+}
+
+// do sends a signed GET request for key in bucket, or for the bucket itself if key is empty, returning an error
+// for responses other than 200 and 206.
+func (c *Client) do(ctx context.Context, bucket, key string, query url.Values, header http.Header) (*http.Response, error) {
+	u, err := c.objectURL(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if c.cfg.AccessKey != "" {
+		c.sign(req, c.now())
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s %s", u.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// objectURL returns the URL of key in bucket, in path style on custom endpoints and virtual-hosted style on AWS.
+func (c *Client) objectURL(bucket, key string) (*url.URL, error) {
+	if c.cfg.Endpoint == "" {
+		return &url.URL{
+			Scheme:  "https",
+			Host:    fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, c.cfg.Region),
+			Path:    "/" + key,
+			RawPath: "/" + uriEncode(key, false),
+		}, nil
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(c.cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint: %w", err)
+	}
+	u.RawPath = u.EscapedPath() + "/" + uriEncode(bucket, false) + "/" + uriEncode(key, false)
+	u.Path += "/" + bucket + "/" + key
+	return u, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req, signing the host, range and x-amz-* headers.
+func (c *Client) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyHash)
+	if c.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.cfg.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyHash,
+	}, "\n")
+	scope := date + "/" + c.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretKey), date)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes a query string the way Signature Version 4 expects: sorted by key, with spaces as %20.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and slashes unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// hexSHA256 returns the hex encoded SHA-256 of s.
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,54 @@
+// Package segment splits the path between photos into moving and stationary segments.
+package segment
+
+import (
+	"sort"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// Segment is the leg of the path between two consecutive photos.
+type Segment struct {
+	From geodata.Point
+	To   geodata.Point
+	// Distance is the great-circle distance between From and To in meters
+	Distance float64
+	Duration time.Duration
+	// Speed is the average speed over the segment in meters per second, or 0 if the photos share a timestamp
+	Speed float64
+	// Stationary is true when both photos were taken at roughly the same spot
+	Stationary bool
+}
+
+// Split orders the points by capture time and returns the segments connecting them.
+// Consecutive points no more than stopRadius meters apart are classified as stationary.
+func Split(points []geodata.Point, stopRadius float64) []Segment {
+	if len(points) < 2 {
+		return nil
+	}
+
+	sorted := make([]geodata.Point, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	segments := make([]Segment, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		from, to := sorted[i-1], sorted[i]
+		s := Segment{
+			From:     from,
+			To:       to,
+			Distance: geodata.Distance(from.Lat, from.Lon, to.Lat, to.Lon),
+			Duration: to.Time.Sub(from.Time),
+		}
+		if s.Duration > 0 {
+			s.Speed = s.Distance / s.Duration.Seconds()
+		}
+		s.Stationary = s.Distance <= stopRadius
+		segments = append(segments, s)
+	}
+
+	return segments
+}
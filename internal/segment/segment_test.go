@@ -0,0 +1,50 @@
+package segment
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// TestSplit checks that segments are ordered by time and classified as moving or stationary.
+func TestSplit(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	points := []geodata.Point{
+		// deliberately out of order to ensure Split sorts by time
+		{Name: "Paris", Lat: 48.8566, Lon: 2.3522, Time: start.Add(2 * time.Hour)},
+		{Name: "London1", Lat: 51.5074, Lon: -0.1276, Time: start},
+		{Name: "London2", Lat: 51.5075, Lon: -0.1276, Time: start.Add(5 * time.Minute)},
+	}
+
+	segments := Split(points, 50)
+	if len(segments) != 2 {
+		t.Fatalf("Expected 2 segments, got %d", len(segments))
+	}
+
+	if segments[0].From.Name != "London1" || segments[0].To.Name != "London2" {
+		t.Errorf("Expected first segment London1 -> London2, got %s -> %s", segments[0].From.Name, segments[0].To.Name)
+	}
+	if !segments[0].Stationary {
+		t.Errorf("Expected London1 -> London2 to be stationary, got %+v", segments[0])
+	}
+
+	if segments[1].Stationary {
+		t.Errorf("Expected London2 -> Paris to be moving, got %+v", segments[1])
+	}
+	// London to Paris is roughly 343km
+	if math.Abs(segments[1].Distance-343000) > 5000 {
+		t.Errorf("Unexpected London -> Paris distance: %f", segments[1].Distance)
+	}
+	if segments[1].Speed <= 0 {
+		t.Errorf("Expected a positive speed for London2 -> Paris, got %f", segments[1].Speed)
+	}
+}
+
+// TestSplitTooFewPoints checks that no segments are produced for a single point.
+func TestSplitTooFewPoints(t *testing.T) {
+	if segments := Split([]geodata.Point{{Name: "Lonely"}}, 50); segments != nil {
+		t.Errorf("Expected no segments, got %+v", segments)
+	}
+}
@@ -0,0 +1,541 @@
+// Package sqlite writes SQLite database files from tables held in memory, for formats built on SQLite such as
+// GeoPackage. It only writes whole new files: there is no SQL engine, so tables, rows and the automatic indexes
+// their constraints need are given as they should be stored.
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+const (
+	// pageSize is the size in bytes of every page of the file
+	pageSize = 4096
+	// headerSize is the size of the database header at the start of the first page
+	headerSize = 100
+
+	// the page types of table and index b-trees
+	interiorTable = 0x05
+	leafTable     = 0x0d
+	interiorIndex = 0x02
+	leafIndex     = 0x0a
+
+	// maxLocal is the largest payload of a table row stored whole in its page, and minLocal the part of a larger
+	// payload kept in the page with the rest moved to overflow pages
+	maxLocal = pageSize - 35
+	minLocal = (pageSize-12)*32/255 - 23
+	// maxIndexLocal is the largest payload of an index entry stored whole in its page, small enough for at least
+	// four entries to fit any index page
+	maxIndexLocal = (pageSize-12)*64/255 - 23
+
+	// maxPages is the most pages a database file can have
+	maxPages int64 = math.MaxUint32 - 1
+)
+
+// Database is the content of a database file.
+type Database struct {
+	// ApplicationID and UserVersion are the values of PRAGMA application_id and PRAGMA user_version, which file
+	// formats built on SQLite use to identify themselves
+	ApplicationID uint32
+	UserVersion   uint32
+	Tables        []Table
+}
+
+// Table is a table and its rows. A value is nil for NULL, an int64, a float64, a string or a []byte. An INTEGER
+// PRIMARY KEY column is an alias of the rowid, so it's given in RowIDs and must be nil in the rows.
+type Table struct {
+	Name string
+	// SQL is the CREATE TABLE statement, as it's stored in the schema
+	SQL  string
+	Rows [][]any
+	// RowIDs are the rowids of the rows in ascending order, or nil to number the rows from 1
+	RowIDs  []int64
+	Indexes []Index
+}
+
+// Index is an automatic index SQLite keeps for a PRIMARY KEY or UNIQUE constraint of a table other than an
+// INTEGER PRIMARY KEY, named sqlite_autoindex_<table>_<n> with n counting the constraints from 1 in the order of
+// the CREATE TABLE statement. Like the constraint, it refuses two rows with the same key, unless a value of the
+// key is NULL.
+type Index struct {
+	// Columns are the positions of the indexed columns in the table's rows
+	Columns []int
+}
+
+// Bytes returns the database file, or an error for tables that can't be stored as given: with rowids out of
+// order, values of other types than listed for Table, indexes of columns the rows don't have or with duplicate
+// keys, or too large for a database file.
+func (db Database) Bytes() ([]byte, error) {
+	w := &writer{}
+	// the schema table's root is always the first page
+	w.allocate()
+
+	var schema [][]any
+	for _, t := range db.Tables {
+		rowids := t.RowIDs
+		if rowids == nil {
+			rowids = numbered(len(t.Rows))
+		}
+		if err := t.check(rowids); err != nil {
+			return nil, fmt.Errorf("table %s: %w", t.Name, err)
+		}
+		root := w.table(t.Rows, rowids)
+		schema = append(schema, []any{"table", t.Name, t.Name, int64(root), t.SQL})
+		for i, index := range t.Indexes {
+			root, err := w.index(t.Rows, rowids, index.Columns)
+			if err != nil {
+				return nil, fmt.Errorf("index %d of %s: %w", i+1, t.Name, err)
+			}
+			name := fmt.Sprintf("sqlite_autoindex_%s_%d", t.Name, i+1)
+			schema = append(schema, []any{"index", name, t.Name, int64(root), nil})
+		}
+	}
+	w.tableAt(1, schema, numbered(len(schema)))
+	if int64(len(w.pages)) > maxPages {
+		return nil, fmt.Errorf("%d pages are more than a database file can have", len(w.pages))
+	}
+
+	header := w.pages[0][:headerSize]
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], pageSize)
+	// file format versions 1 for the legacy rollback journal, and no bytes reserved at the end of each page
+	header[18], header[19], header[20] = 1, 1, 0
+	// the fixed payload fractions
+	header[21], header[22], header[23] = 64, 32, 32
+	// the file change counter, which the version at 92 must match for the page count at 28 to be trusted
+	binary.BigEndian.PutUint32(header[24:], 1)
+	binary.BigEndian.PutUint32(header[28:], uint32(len(w.pages)))
+	// the schema cookie and the schema format 4, which has the serial types 8 and 9 for the integers 0 and 1
+	binary.BigEndian.PutUint32(header[40:], 1)
+	binary.BigEndian.PutUint32(header[44:], 4)
+	// UTF-8 text
+	binary.BigEndian.PutUint32(header[56:], 1)
+	binary.BigEndian.PutUint32(header[60:], db.UserVersion)
+	binary.BigEndian.PutUint32(header[68:], db.ApplicationID)
+	binary.BigEndian.PutUint32(header[92:], 1)
+	// the SQLITE_VERSION_NUMBER of the library that last wrote the file, claiming the version this layout follows
+	binary.BigEndian.PutUint32(header[96:], 3045000)
+
+	return bytes.Join(w.pages, nil), nil
+}
+
+// check returns an error if the table can't be stored with the given rowids.
+func (t Table) check(rowids []int64) error {
+	if len(rowids) != len(t.Rows) {
+		return fmt.Errorf("%d rowids for %d rows", len(rowids), len(t.Rows))
+	}
+	for i, row := range t.Rows {
+		if i > 0 && rowids[i] <= rowids[i-1] {
+			return fmt.Errorf("rowid %d of row %d not after %d", rowids[i], i+1, rowids[i-1])
+		}
+		for _, v := range row {
+			switch v.(type) {
+			case nil, int64, float64, string, []byte:
+			default:
+				return fmt.Errorf("unsupported value of type %T in row %d", v, i+1)
+			}
+		}
+		for n, index := range t.Indexes {
+			for _, c := range index.Columns {
+				if c < 0 || c >= len(row) {
+					return fmt.Errorf("index %d: no column %d in row %d of %d columns", n+1, c+1, i+1, len(row))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writer lays out the pages of the file, numbered from 1.
+type writer struct {
+	pages [][]byte
+}
+
+// allocate adds an empty page and returns its number.
+func (w *writer) allocate() int {
+	w.pages = append(w.pages, make([]byte, pageSize))
+	return len(w.pages)
+}
+
+// table writes a table b-tree holding rows with the given rowids and returns the number of its root page.
+func (w *writer) table(rows [][]any, rowids []int64) int {
+	root := w.allocate()
+	w.tableAt(root, rows, rowids)
+	return root
+}
+
+// tableAt writes a table b-tree holding rows with the given rowids with its root on the given page, which is
+// already allocated.
+func (w *writer) tableAt(root int, rows [][]any, rowids []int64) {
+	cells := make([][]byte, len(rows))
+	for i, row := range rows {
+		cells[i] = w.tableCell(rowids[i], record(row))
+	}
+	leaves := split(cells, offset(root)+8)
+	if len(leaves) == 1 {
+		w.writePage(root, leafTable, leaves[0], 0)
+		return
+	}
+
+	// children are the pages of the level below, each with the largest rowid under it
+	type child struct {
+		page  int
+		rowid int64
+	}
+	var children []child
+	last := 0
+	for _, leaf := range leaves {
+		page := w.allocate()
+		w.writePage(page, leafTable, leaf, 0)
+		last += len(leaf)
+		children = append(children, child{page, rowids[last-1]})
+	}
+	for {
+		// all but the last child are cells keyed by their largest rowid, the last is the right-most pointer
+		var level [][]child
+		used := 0
+		for _, c := range children {
+			size := 4 + len(varint(uint64(c.rowid))) + 2
+			if len(level) == 0 || used+size > pageSize-offset(root)-12 {
+				level = append(level, nil)
+				used = 0
+			}
+			level[len(level)-1] = append(level[len(level)-1], c)
+			used += size
+		}
+		// a page with only its right-most pointer would have no cells, so the last one takes a child from the
+		// page before it
+		if n := len(level); n > 1 && len(level[n-1]) == 1 {
+			prev := level[n-2]
+			level[n-2], level[n-1] = prev[:len(prev)-1], append([]child{prev[len(prev)-1]}, level[n-1]...)
+		}
+		var parents []child
+		for _, group := range level {
+			page := root
+			if len(level) > 1 {
+				page = w.allocate()
+			}
+			var cells [][]byte
+			for _, c := range group[:len(group)-1] {
+				cell := binary.BigEndian.AppendUint32(nil, uint32(c.page))
+				cells = append(cells, append(cell, varint(uint64(c.rowid))...))
+			}
+			last := group[len(group)-1]
+			w.writePage(page, interiorTable, cells, last.page)
+			parents = append(parents, child{page, last.rowid})
+		}
+		if len(parents) == 1 {
+			return
+		}
+		children = parents
+	}
+}
+
+// tableCell returns the cell of a table leaf page holding the row with the given rowid.
+func (w *writer) tableCell(rowid int64, payload []byte) []byte {
+	cell := append(varint(uint64(len(payload))), varint(uint64(rowid))...)
+	return append(cell, w.spill(payload, maxLocal)...)
+}
+
+// spill returns the part of payload stored in its cell: all of it if it's at most the most bytes a cell of the
+// page holds, and otherwise its start followed by the number of the first of the overflow pages the rest is
+// moved to.
+func (w *writer) spill(payload []byte, most int) []byte {
+	if len(payload) <= most {
+		return payload
+	}
+	local := minLocal + (len(payload)-minLocal)%(pageSize-4)
+	if local > most {
+		local = minLocal
+	}
+
+	// each overflow page starts with the number of the next one, or 0 on the last
+	rest := payload[local:]
+	first := w.allocate()
+	for page := first; ; {
+		n := copy(w.pages[page-1][4:], rest)
+		rest = rest[n:]
+		if len(rest) == 0 {
+			break
+		}
+		next := w.allocate()
+		binary.BigEndian.PutUint32(w.pages[page-1], uint32(next)) // #nosec G115 -- Bytes checks the page count
+		page = next
+	}
+	return binary.BigEndian.AppendUint32(payload[:local:local], uint32(first)) // #nosec G115
+}
+
+// index writes an index b-tree of the given columns of rows and returns the number of its root page. Unlike
+// table b-trees, whose interior pages repeat the largest rowid of each child, every entry of an index is stored
+// once: the entries dividing the pages of a level are moved up to the level above.
+func (w *writer) index(rows [][]any, rowids []int64, columns []int) (int, error) {
+	keys := make([][]any, len(rows))
+	for i, row := range rows {
+		for _, c := range columns {
+			keys[i] = append(keys[i], row[c])
+		}
+		keys[i] = append(keys[i], rowids[i])
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compareKeys(keys[i], keys[j]) < 0
+	})
+	for i := 1; i < len(keys); i++ {
+		key := keys[i][:len(columns)]
+		if !slices.Contains(key, nil) && compareKeys(keys[i-1][:len(columns)], key) == 0 {
+			return 0, fmt.Errorf("duplicate key %v", key)
+		}
+	}
+
+	root := w.allocate()
+	level := make([][]byte, len(keys))
+	for i, key := range keys {
+		payload := record(key)
+		level[i] = append(varint(uint64(len(payload))), w.spill(payload, maxIndexLocal)...)
+	}
+	// children are the pages of the level below, one more than the entries of this level, or nil for the leaves
+	var children []int
+	for {
+		kind, header, extra := byte(leafIndex), 8, 0
+		if children != nil {
+			kind, header, extra = interiorIndex, 12, 4
+		}
+		ranges := pack(level, pageSize-header, extra)
+		var pages []int
+		var dividers [][]byte
+		for r, rg := range ranges {
+			page := root
+			if len(ranges) > 1 {
+				page = w.allocate()
+			}
+			cells := level[rg[0]:rg[1]]
+			rightMost := 0
+			if children != nil {
+				// each cell of an interior page starts with the child before its entry
+				cells = make([][]byte, 0, rg[1]-rg[0])
+				for i := rg[0]; i < rg[1]; i++ {
+					cells = append(cells, append(binary.BigEndian.AppendUint32(nil, uint32(children[i])), level[i]...)) // #nosec G115
+				}
+				rightMost = children[rg[1]]
+			}
+			w.writePage(page, kind, cells, rightMost)
+			pages = append(pages, page)
+			if r < len(ranges)-1 {
+				dividers = append(dividers, level[rg[1]])
+			}
+		}
+		if len(ranges) == 1 {
+			return root, nil
+		}
+		level, children = dividers, pages
+	}
+}
+
+// pack splits the cells of a level of an index b-tree into the ranges of cells of its pages, as many as fit in
+// space bytes with their 2 byte pointers and extra bytes each, keeping the cell after each range but the last
+// to divide it from the next one. Every range has at least one cell.
+func pack(cells [][]byte, space, extra int) [][2]int {
+	var ranges [][2]int
+	for start := 0; ; {
+		end, used := start, 0
+		for end < len(cells) && (end == start || used+len(cells[end])+extra+2 <= space) {
+			used += len(cells[end]) + extra + 2
+			end++
+		}
+		switch {
+		case end >= len(cells):
+			return append(ranges, [2]int{start, end})
+		case end == len(cells)-1:
+			// the last cell can't divide this range from an empty one, so the range gives up its own last cell
+			// as the divider; with at least four cells to a page, it keeps some
+			return append(ranges, [2]int{start, end - 1}, [2]int{end, end + 1})
+		}
+		ranges = append(ranges, [2]int{start, end})
+		start = end + 1
+	}
+}
+
+// writePage writes a b-tree page of the given type holding cells, with its cell pointers after the page header
+// and the cells packed at the end of the page. rightMost is the right-most child of an interior page.
+func (w *writer) writePage(n int, kind byte, cells [][]byte, rightMost int) {
+	page := w.pages[n-1]
+	start := offset(n)
+	pointers := start + 8
+	if kind == interiorTable || kind == interiorIndex {
+		binary.BigEndian.PutUint32(page[start+8:], uint32(rightMost))
+		pointers += 4
+	}
+	content := pageSize
+	for i, cell := range cells {
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[pointers+2*i:], uint16(content))
+	}
+	page[start] = kind
+	binary.BigEndian.PutUint16(page[start+3:], uint16(len(cells)))
+	binary.BigEndian.PutUint16(page[start+5:], uint16(content))
+}
+
+// numbered returns the rowids 1 to n.
+func numbered(n int) []int64 {
+	rowids := make([]int64, n)
+	for i := range rowids {
+		rowids[i] = int64(i + 1)
+	}
+	return rowids
+}
+
+// offset is where the b-tree page header of page n starts, after the database header on the first page.
+func offset(n int) int {
+	if n == 1 {
+		return headerSize
+	}
+	return 0
+}
+
+// split groups cells into leaf pages whose header starts at the given offset.
+func split(cells [][]byte, header int) [][][]byte {
+	leaves := [][][]byte{nil}
+	used := header
+	for _, cell := range cells {
+		if used+len(cell)+2 > pageSize && len(leaves[len(leaves)-1]) > 0 {
+			leaves = append(leaves, nil)
+			// only the first page has the database header, and only the root can be the first page
+			used = 8
+		}
+		leaves[len(leaves)-1] = append(leaves[len(leaves)-1], cell)
+		used += len(cell) + 2
+	}
+	return leaves
+}
+
+// record encodes values in the record format of table rows and index entries: a header of the values' serial
+// types followed by their content.
+func record(values []any) []byte {
+	var types, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = append(types, varint(0)...)
+		case int64:
+			t, content := integer(v)
+			types = append(types, varint(t)...)
+			body = append(body, content...)
+		case float64:
+			types = append(types, varint(7)...)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			types = append(types, varint(uint64(13+2*len(v)))...)
+			body = append(body, v...)
+		case []byte:
+			types = append(types, varint(uint64(12+2*len(v)))...)
+			body = append(body, v...)
+		default:
+			panic(fmt.Sprintf("sqlite: unsupported value %T", v))
+		}
+	}
+	// the header's size counts itself, so its own varint may need to grow by a byte
+	size := len(types) + 1
+	if len(varint(uint64(size))) > 1 {
+		size = len(types) + len(varint(uint64(size+1)))
+	}
+	out := append(varint(uint64(size)), types...)
+	return append(out, body...)
+}
+
+// integer returns the serial type of the smallest encoding of v and its content.
+func integer(v int64) (uint64, []byte) {
+	switch {
+	case v == 0:
+		return 8, nil
+	case v == 1:
+		return 9, nil
+	}
+	sizes := []struct {
+		kind  uint64
+		bytes int
+	}{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 6}, {6, 8}}
+	for _, s := range sizes {
+		if bits := uint(8*s.bytes - 1); s.bytes == 8 || (v >= -1<<bits && v < 1<<bits) {
+			content := binary.BigEndian.AppendUint64(nil, uint64(v))
+			return s.kind, content[8-s.bytes:]
+		}
+	}
+	return 0, nil
+}
+
+// varint encodes v as a SQLite variable-length integer: big-endian groups of 7 bits with the high bit set on all
+// but the last, and all 8 bits of a ninth byte.
+func varint(v uint64) []byte {
+	if v > 1<<56-1 {
+		out := make([]byte, 9)
+		out[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			out[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return out
+	}
+	out := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		out = append([]byte{byte(v&0x7f) | 0x80}, out...)
+	}
+	return out
+}
+
+// compareKeys orders index entries the way SQLite's BINARY collation does, value by value: NULL first, then
+// numbers, text and blobs.
+func compareKeys(a, b []any) int {
+	for i := range a {
+		if c := compareValues(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues orders two values of an index entry, see compareKeys.
+func compareValues(a, b any) int {
+	rank := func(v any) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case int64, float64:
+			return 1
+		case string:
+			return 2
+		}
+		return 3
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case int64, float64:
+		fa, fb := number(a), number(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case string:
+		return bytes.Compare([]byte(a), []byte(b.(string)))
+	case []byte:
+		return bytes.Compare(a, b.([]byte))
+	}
+	return 0
+}
+
+// number returns an integer or float value as a float64.
+func number(v any) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestVarint checks variable-length integers against examples of the SQLite file format.
+func TestVarint(t *testing.T) {
+	for v, expected := range map[uint64][]byte{
+		0:          {0x00},
+		127:        {0x7f},
+		128:        {0x81, 0x00},
+		16383:      {0xff, 0x7f},
+		1<<64 - 1:  bytes.Repeat([]byte{0xff}, 9),
+		1<<56 - 1:  {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+		0x12345678: {0x81, 0x91, 0xd1, 0xac, 0x78},
+	} {
+		if got := varint(v); !bytes.Equal(got, expected) {
+			t.Errorf("Expected % x for %d, got % x", expected, v, got)
+		}
+	}
+}
+
+// TestRecord checks that values get the smallest serial type and the header counts its own size.
+func TestRecord(t *testing.T) {
+	got := record([]any{nil, int64(0), int64(1), int64(-2), int64(300), 1.5, "hi", []byte{0xab}})
+	expected := []byte{
+		9, 0, 8, 9, 1, 2, 7, 17, 14,
+		0xfe, 0x01, 0x2c, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0, 'h', 'i', 0xab,
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("Expected record % x, got % x", expected, got)
+	}
+
+	long := record([]any{strings.Repeat("x", 200)})
+	if !bytes.Equal(long[:4], []byte{0x03, 0x83, 0x1d, 'x'}) {
+		t.Errorf("Expected a 3 byte header with a 2 byte serial type, got % x", long[:4])
+	}
+}
+
+// TestDatabaseBytes checks the header and the schema of a database whose table spans several pages.
+func TestDatabaseBytes(t *testing.T) {
+	var rows [][]any
+	for i := 0; i < 1000; i++ {
+		rows = append(rows, []any{strings.Repeat("row", 10)})
+	}
+	db := Database{ApplicationID: 0x47504B47, UserVersion: 10300, Tables: []Table{
+		{Name: "t", SQL: "CREATE TABLE t (name TEXT UNIQUE)", Rows: rows[:1], Indexes: []Index{{Columns: []int{0}}}},
+		{Name: "big", SQL: "CREATE TABLE big (name TEXT)", Rows: rows},
+	}}
+	file, err := db.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(file, []byte("SQLite format 3\x00")) || len(file)%pageSize != 0 {
+		t.Fatalf("Expected a SQLite file of whole pages, got %d bytes starting %q", len(file), file[:16])
+	}
+	if pages := int(binary.BigEndian.Uint32(file[28:])); pages != len(file)/pageSize || pages < 10 {
+		t.Errorf("Expected the page count of the file in the header, got %d", pages)
+	}
+	if binary.BigEndian.Uint32(file[60:]) != 10300 || binary.BigEndian.Uint32(file[68:]) != 0x47504B47 {
+		t.Errorf("Unexpected user version or application ID % x", file[60:72])
+	}
+
+	// the schema on the first page lists the two tables and the index, in that order
+	if file[100] != leafTable || binary.BigEndian.Uint16(file[103:]) != 3 {
+		t.Fatalf("Expected a schema leaf page with 3 entries, got % x", file[100:108])
+	}
+	for _, name := range []string{"sqlite_autoindex_t_1", "CREATE TABLE big"} {
+		if !bytes.Contains(file[:pageSize], []byte(name)) {
+			t.Errorf("Expected %q in the schema", name)
+		}
+	}
+	// the big table's root, after the first table and its index, is an interior page
+	if file[3*pageSize] != interiorTable {
+		t.Errorf("Expected the root of the big table to be an interior page, got type %x", file[3*pageSize])
+	}
+}
+
+// TestInvalid checks that tables that can't be stored as given are refused rather than written corrupt.
+func TestInvalid(t *testing.T) {
+	unique := []Index{{Columns: []int{0}}}
+	tests := []struct {
+		name  string
+		table Table
+	}{
+		{"rowids out of order", Table{Rows: [][]any{{"a"}, {"b"}}, RowIDs: []int64{2, 1}}},
+		{"duplicate rowids", Table{Rows: [][]any{{"a"}, {"b"}}, RowIDs: []int64{1, 1}}},
+		{"missing rowids", Table{Rows: [][]any{{"a"}, {"b"}}, RowIDs: []int64{1}}},
+		{"unsupported value", Table{Rows: [][]any{{int32(1)}}}},
+		{"index column out of range", Table{Rows: [][]any{{"a"}}, Indexes: []Index{{Columns: []int{1}}}}},
+		{"duplicate key", Table{Rows: [][]any{{"a"}, {"b"}, {"a"}}, Indexes: unique}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.table.Name, tt.table.SQL = "t", "CREATE TABLE t (name TEXT UNIQUE)"
+			if _, err := (Database{Tables: []Table{tt.table}}).Bytes(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	nulls := Table{Name: "t", SQL: "CREATE TABLE t (name TEXT UNIQUE)", Rows: [][]any{{nil}, {nil}}, Indexes: unique}
+	if _, err := (Database{Tables: []Table{nulls}}).Bytes(); err != nil {
+		t.Errorf("Expected NULLs not to be duplicates, got %v", err)
+	}
+}
+
+// TestIntegrity checks with the sqlite3 shell that tables and indexes spanning several levels of pages, with
+// entries too large for their pages, are read back whole and pass SQLite's own integrity check.
+func TestIntegrity(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	tests := []struct {
+		name string
+		rows int
+		// value returns the values of the row i, which are all different
+		value func(i int) []any
+	}{
+		{"empty", 0, nil},
+		{"single page", 10, func(i int) []any { return []any{fmt.Sprintf("photo%d", i), int64(i)} }},
+		{"three levels", 40000, func(i int) []any { return []any{fmt.Sprintf("photo%06d", (i*7919)%40000), float64(i) / 3} }},
+		{"overflow", 300, func(i int) []any {
+			return []any{fmt.Sprintf("%d%s", i, strings.Repeat("x", i*37)), []byte(strings.Repeat("y", i*53))}
+		}},
+		{"mixed types", 3000, func(i int) []any {
+			switch i % 4 {
+			case 0:
+				return []any{int64(i), nil}
+			case 1:
+				return []any{float64(i) + 0.5, int64(i)}
+			case 2:
+				return []any{fmt.Sprint(i), "text"}
+			}
+			return []any{[]byte(fmt.Sprint(i)), nil}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows [][]any
+			var rowids []int64
+			for i := range tt.rows {
+				rows = append(rows, tt.value(i))
+				rowids = append(rowids, int64(i*3-1000))
+			}
+			db := Database{Tables: []Table{
+				{Name: "t", SQL: "CREATE TABLE t (a UNIQUE, b, PRIMARY KEY (b, a))", Rows: rows, RowIDs: rowids,
+					Indexes: []Index{{Columns: []int{0}}, {Columns: []int{1, 0}}}},
+				{Name: "copy", SQL: "CREATE TABLE copy (a, b)", Rows: rows},
+			}}
+			file, err := db.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "test.db")
+			if err := os.WriteFile(path, file, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			query := "PRAGMA integrity_check; SELECT (SELECT count(*) FROM t), (SELECT count(DISTINCT a) FROM t INDEXED BY sqlite_autoindex_t_1), (SELECT count(*) FROM copy);"
+			expected := fmt.Sprintf("ok\n%d|%d|%d", tt.rows, tt.rows, tt.rows)
+			if tt.rows > 0 {
+				// a lookup through each index finds the row with the largest rowid
+				a, b := literal(rows[tt.rows-1][0]), literal(rows[tt.rows-1][1])
+				query += fmt.Sprintf(" SELECT rowid FROM t INDEXED BY sqlite_autoindex_t_1 WHERE a = %s;", a)
+				query += fmt.Sprintf(" SELECT rowid FROM t INDEXED BY sqlite_autoindex_t_2 WHERE b IS %s AND a = %s;", b, a)
+				expected += fmt.Sprintf("\n%d\n%d", rowids[tt.rows-1], rowids[tt.rows-1])
+			}
+			out, err := exec.Command("sqlite3", path, query).CombinedOutput() // #nosec G204 -- test query
+			if err != nil || strings.TrimSpace(string(out)) != expected {
+				t.Errorf("Expected %q, got %q, %v", expected, out, err)
+			}
+		})
+	}
+}
+
+// literal returns v as an SQL literal.
+func literal(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + v + "'"
+	case []byte:
+		return fmt.Sprintf("x'%x'", v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
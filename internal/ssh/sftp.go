@@ -0,0 +1,468 @@
+package ssh
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/toozej/photos2map/internal/ratelimit"
+)
+
+// The SFTP version 3 packet types used, from draft-ietf-secsh-filexfer-02, the version OpenSSH implements
+const (
+	fxpInit     = 1
+	fxpVersion  = 2
+	fxpOpen     = 3
+	fxpClose    = 4
+	fxpRead     = 5
+	fxpOpendir  = 11
+	fxpReaddir  = 12
+	fxpRealpath = 16
+	fxpStatus   = 101
+	fxpHandle   = 102
+	fxpData     = 103
+	fxpName     = 104
+)
+
+// The attribute flags saying which fields an ATTRS structure has
+const (
+	attrSize        = 0x1
+	attrUIDGID      = 0x2
+	attrPermissions = 0x4
+	attrACModTime   = 0x8
+	attrExtended    = 0x80000000
+)
+
+// statusEOF is the status code of the end of a file or directory listing
+const statusEOF = 1
+
+// openRead is the pflags of OPEN for reading
+const openRead = 0x1
+
+// The file types in the permissions of ATTRS, as in st_mode
+const (
+	modeType    = 0170000
+	modeDir     = 0040000
+	modeRegular = 0100000
+)
+
+// maxRead is the largest READ requested, the length every server must support
+const maxRead = 32 * 1024
+
+// maxPacket is the largest packet accepted, well above the largest response to maxRead
+const maxPacket = 256 * 1024
+
+// StatusError is an error returned by the server for a request, such as a missing file or a denied permission.
+type StatusError struct {
+	Code    uint32
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("SFTP status %d", e.Code)
+}
+
+// attrs are the parts of a file's ATTRS that are read
+type attrs struct {
+	size    int64
+	mode    uint32
+	modTime time.Time
+}
+
+// entry is a name listed by READDIR, with its attributes as lstat returns them
+type entry struct {
+	name string
+	attrs
+}
+
+// response is a packet received for a request, with its type and the data after the request ID
+type response struct {
+	typ  byte
+	data []byte
+}
+
+// conn is an SFTP session over a pair of pipes. Requests can be sent from several goroutines, each waiting for
+// its own response while a single goroutine reads them all, so they're pipelined over the connection.
+type conn struct {
+	w io.Writer
+	// writeMu keeps packets from interleaving
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]chan response
+	// err is why the connection was lost, once it is
+	err error
+
+	// limiter, if set, paces the reads of files
+	limiter *ratelimit.Limiter
+}
+
+// newConn starts an SFTP version 3 session over r and w, such as the output and input of the sftp subsystem.
+func newConn(r io.Reader, w io.Writer) (*conn, error) {
+	c := &conn{w: w, pending: map[uint32]chan response{}}
+	// INIT has no request ID, just the version
+	if err := c.write(fxpInit, 3, nil); err != nil {
+		return nil, err
+	}
+	typ, _, err := readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if typ != fxpVersion {
+		return nil, fmt.Errorf("expected the server's SFTP version, got packet type %d", typ)
+	}
+	go c.readLoop(r)
+	return c, nil
+}
+
+// readPacket reads a packet, returning its type and everything after it.
+func readPacket(r io.Reader) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("reading from the SFTP server: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > maxPacket {
+		// commonly the start of a message printed by the login shell, which SFTP can't work with
+		return 0, nil, fmt.Errorf("unexpected SFTP packet length %d, does the server's shell print something at login?", length)
+	}
+	data := make([]byte, length-1)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, fmt.Errorf("reading from the SFTP server: %w", err)
+	}
+	return header[4], data, nil
+}
+
+// readLoop hands the responses to the requests waiting for them until the connection is lost, then fails the
+// requests still waiting.
+func (c *conn) readLoop(r io.Reader) {
+	for {
+		typ, data, err := readPacket(r)
+		if err == nil && len(data) < 4 {
+			err = fmt.Errorf("SFTP packet type %d too short for a request ID", typ)
+		}
+		c.mu.Lock()
+		if err != nil {
+			c.err = err
+			for id, ch := range c.pending {
+				close(ch)
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+		id := binary.BigEndian.Uint32(data)
+		ch, ok := c.pending[id]
+		delete(c.pending, id)
+		c.mu.Unlock()
+		if ok {
+			ch <- response{typ, data[4:]}
+		}
+	}
+}
+
+// write sends a packet of type typ starting with the uint32 id, the request ID or INIT's version.
+func (c *conn) write(typ byte, id uint32, payload []byte) error {
+	packet := make([]byte, 9, 9+len(payload))
+	binary.BigEndian.PutUint32(packet, uint32(5+len(payload)))
+	packet[4] = typ
+	binary.BigEndian.PutUint32(packet[5:], id)
+	packet = append(packet, payload...)
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.w.Write(packet)
+	return err
+}
+
+// send sends a request, returning the channel its response is delivered on, which is closed instead if the
+// connection is lost.
+func (c *conn) send(typ byte, payload []byte) (chan response, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	id := c.nextID
+	c.nextID++
+	ch := make(chan response, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+	if err := c.write(typ, id, payload); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, err
+	}
+	return ch, nil
+}
+
+// wait returns the response delivered on ch, or an error if the connection was lost or ctx is done first.
+func (c *conn) wait(ctx context.Context, ch chan response) (response, error) {
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return response{}, c.err
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return response{}, ctx.Err()
+	}
+}
+
+// request sends a request and waits for its response.
+func (c *conn) request(ctx context.Context, typ byte, payload []byte) (response, error) {
+	ch, err := c.send(typ, payload)
+	if err != nil {
+		return response{}, err
+	}
+	return c.wait(ctx, ch)
+}
+
+// status returns nil for a response of the expected type, the error of a STATUS response, or an error for a
+// response of another type.
+func status(resp response, expected byte) error {
+	if resp.typ == fxpStatus {
+		d := decoder{data: resp.data}
+		code := d.uint32()
+		msg := d.string()
+		if d.err != nil {
+			return d.err
+		}
+		return &StatusError{Code: code, Message: msg}
+	}
+	if resp.typ != expected {
+		return fmt.Errorf("unexpected SFTP response type %d", resp.typ)
+	}
+	return nil
+}
+
+// realpath returns the absolute path of p on the server, relative paths being relative to the home directory.
+func (c *conn) realpath(ctx context.Context, p string) (string, error) {
+	resp, err := c.request(ctx, fxpRealpath, encodeString(nil, p))
+	if err != nil {
+		return "", err
+	}
+	if err := status(resp, fxpName); err != nil {
+		return "", err
+	}
+	entries, err := decodeNames(resp.data)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) != 1 {
+		return "", fmt.Errorf("expected a single name resolving %s, got %d", p, len(entries))
+	}
+	return entries[0].name, nil
+}
+
+// handle opens a file or directory with OPEN or OPENDIR, returning its handle.
+func (c *conn) handle(ctx context.Context, typ byte, payload []byte) (string, error) {
+	resp, err := c.request(ctx, typ, payload)
+	if err != nil {
+		return "", err
+	}
+	if err := status(resp, fxpHandle); err != nil {
+		return "", err
+	}
+	d := decoder{data: resp.data}
+	handle := d.string()
+	return handle, d.err
+}
+
+// close closes a handle, without waiting for the response: the server releases it either way.
+func (c *conn) close(handle string) {
+	_, _ = c.send(fxpClose, encodeString(nil, handle))
+}
+
+// readDir returns the entries of a directory, other than . and ..
+func (c *conn) readDir(ctx context.Context, dir string) ([]entry, error) {
+	handle, err := c.handle(ctx, fxpOpendir, encodeString(nil, dir))
+	if err != nil {
+		return nil, err
+	}
+	defer c.close(handle)
+	var entries []entry
+	for {
+		resp, err := c.request(ctx, fxpReaddir, encodeString(nil, handle))
+		if err != nil {
+			return nil, err
+		}
+		if err := status(resp, fxpName); err != nil {
+			if se := (*StatusError)(nil); errors.As(err, &se) && se.Code == statusEOF {
+				return entries, nil
+			}
+			return nil, err
+		}
+		names, err := decodeNames(resp.data)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range names {
+			if e.name != "." && e.name != ".." {
+				entries = append(entries, e)
+			}
+		}
+	}
+}
+
+// readFile returns up to the first n bytes of a file. The reads of all of it are sent at once rather than one
+// after the other, so a file takes a single round trip, unless the limiter holds them back; a short read, which
+// servers may return, is followed by another batch from where it ended.
+func (c *conn) readFile(ctx context.Context, p string, n int64) ([]byte, error) {
+	handle, err := c.handle(ctx, fxpOpen, encodeAttrs(encodeUint32(encodeString(nil, p), openRead)))
+	if err != nil {
+		return nil, err
+	}
+	defer c.close(handle)
+	data := make([]byte, 0, n)
+	for int64(len(data)) < n {
+		var chs []chan response
+		for offset := int64(len(data)); offset < n; offset += maxRead {
+			size := min(maxRead, n-offset)
+			if err := c.limiter.Wait(ctx, int(size)); err != nil {
+				return nil, err
+			}
+			payload := encodeUint64(encodeString(nil, handle), uint64(offset)) // #nosec G115 -- offsets aren't negative
+			ch, err := c.send(fxpRead, encodeUint32(payload, uint32(size)))
+			if err != nil {
+				return nil, err
+			}
+			chs = append(chs, ch)
+		}
+		read, short, eof := len(data), false, false
+		for i, ch := range chs {
+			resp, err := c.wait(ctx, ch)
+			if err != nil {
+				return nil, err
+			}
+			if short || eof {
+				continue
+			}
+			if err := status(resp, fxpData); err != nil {
+				if se := (*StatusError)(nil); errors.As(err, &se) && se.Code == statusEOF {
+					eof = true
+					continue
+				}
+				return nil, err
+			}
+			d := decoder{data: resp.data}
+			chunk := d.string()
+			if d.err != nil {
+				return nil, d.err
+			}
+			data = append(data, chunk...)
+			short = len(chunk) < maxRead && i < len(chs)-1
+		}
+		if eof || len(data) == read {
+			break
+		}
+	}
+	return data, nil
+}
+
+// encodeUint32 appends a uint32 to b.
+func encodeUint32(b []byte, v uint32) []byte {
+	return binary.BigEndian.AppendUint32(b, v)
+}
+
+// encodeUint64 appends a uint64 to b.
+func encodeUint64(b []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(b, v)
+}
+
+// encodeString appends a string, preceded by its length, to b.
+func encodeString(b []byte, s string) []byte {
+	return append(encodeUint32(b, uint32(len(s))), s...) // #nosec G115 -- paths and handles are short
+}
+
+// encodeAttrs appends empty attributes to b, as OPEN takes.
+func encodeAttrs(b []byte) []byte {
+	return encodeUint32(b, 0)
+}
+
+// decoder reads the fields of a packet, keeping the first error so they can be checked once.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uint32() uint32 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) < 4 {
+		d.err = errors.New("SFTP packet too short")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(d.data)
+	d.data = d.data[4:]
+	return v
+}
+
+func (d *decoder) uint64() uint64 {
+	return uint64(d.uint32())<<32 | uint64(d.uint32())
+}
+
+func (d *decoder) string() string {
+	n := d.uint32()
+	if d.err != nil {
+		return ""
+	}
+	if uint32(len(d.data)) < n { // #nosec G115 -- packets are at most maxPacket long
+		d.err = errors.New("SFTP packet too short")
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+// attrs reads an ATTRS structure.
+func (d *decoder) attrs() attrs {
+	var a attrs
+	flags := d.uint32()
+	if flags&attrSize != 0 {
+		a.size = int64(d.uint64()) // #nosec G115 -- sizes fit
+	}
+	if flags&attrUIDGID != 0 {
+		d.uint32()
+		d.uint32()
+	}
+	if flags&attrPermissions != 0 {
+		a.mode = d.uint32()
+	}
+	if flags&attrACModTime != 0 {
+		d.uint32()
+		a.modTime = time.Unix(int64(d.uint32()), 0)
+	}
+	if flags&attrExtended != 0 {
+		for n := d.uint32(); n > 0 && d.err == nil; n-- {
+			d.string()
+			d.string()
+		}
+	}
+	return a
+}
+
+// decodeNames decodes the entries of a NAME response.
+func decodeNames(data []byte) ([]entry, error) {
+	d := decoder{data: data}
+	n := d.uint32()
+	var entries []entry
+	for i := uint32(0); i < n && d.err == nil; i++ {
+		name := d.string()
+		d.string() // the long name, as ls -l prints it
+		entries = append(entries, entry{name: name, attrs: d.attrs()})
+	}
+	return entries, d.err
+}
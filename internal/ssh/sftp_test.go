@@ -0,0 +1,237 @@
+package ssh
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/internal/ratelimit"
+)
+
+// fakeServer serves the SFTP requests the client sends from the local file system, relative paths being
+// relative to home. Reads return at most maxChunk bytes, so short reads can be tested.
+type fakeServer struct {
+	home     string
+	maxChunk int
+	files    map[string]*os.File
+	dirs     map[string]string
+	listed   map[string]bool
+	handles  int
+}
+
+// serve answers requests read from r on w until r is closed.
+func (s *fakeServer) serve(r io.Reader, w io.Writer) {
+	s.files, s.dirs, s.listed = map[string]*os.File{}, map[string]string{}, map[string]bool{}
+	if typ, _, err := readPacket(r); err != nil || typ != fxpInit {
+		return
+	}
+	writePacket(w, fxpVersion, encodeUint32(nil, 3))
+	for {
+		typ, data, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		d := decoder{data: data}
+		id := d.uint32()
+		reply := encodeUint32(nil, id)
+		respond := func(typ byte, payload []byte) { writePacket(w, typ, append(reply, payload...)) }
+		fail := func(code uint32, msg string) {
+			respond(fxpStatus, encodeString(encodeString(encodeUint32(nil, code), msg), ""))
+		}
+		switch typ {
+		case fxpRealpath:
+			respond(fxpName, encodeAttrs(encodeString(encodeString(encodeUint32(nil, 1), s.path(d.string())), "")))
+		case fxpOpendir:
+			p := s.path(d.string())
+			if _, err := os.ReadDir(p); err != nil {
+				fail(2, "No such file")
+				continue
+			}
+			handle := s.newHandle()
+			s.dirs[handle] = p
+			respond(fxpHandle, encodeString(nil, handle))
+		case fxpReaddir:
+			handle := d.string()
+			if s.listed[handle] {
+				fail(statusEOF, "End of file")
+				continue
+			}
+			s.listed[handle] = true
+			p := s.dirs[handle]
+			entries, _ := os.ReadDir(p)
+			names := encodeUint32(nil, uint32(len(entries)+2))
+			for _, name := range []string{".", ".."} {
+				names = appendEntry(names, name, p)
+			}
+			for _, e := range entries {
+				names = appendEntry(names, e.Name(), filepath.Join(p, e.Name()))
+			}
+			respond(fxpName, names)
+		case fxpOpen:
+			p := s.path(d.string())
+			f, err := os.Open(p) // #nosec G304 -- test files
+			if err != nil {
+				fail(2, "No such file")
+				continue
+			}
+			handle := s.newHandle()
+			s.files[handle] = f
+			respond(fxpHandle, encodeString(nil, handle))
+		case fxpRead:
+			f := s.files[d.string()]
+			offset, length := d.uint64(), d.uint32()
+			buf := make([]byte, min(int(length), s.maxChunk))
+			n, err := f.ReadAt(buf, int64(offset)) // #nosec G115
+			if n == 0 && err != nil {
+				fail(statusEOF, "End of file")
+				continue
+			}
+			respond(fxpData, encodeString(nil, string(buf[:n])))
+		case fxpClose:
+			if f := s.files[d.string()]; f != nil {
+				f.Close()
+			}
+			fail(0, "Success")
+		default:
+			fail(8, "Operation unsupported")
+		}
+	}
+}
+
+// newHandle returns a handle for a file or directory being opened, different from all others.
+func (s *fakeServer) newHandle() string {
+	s.handles++
+	return strconv.Itoa(s.handles)
+}
+
+// path returns the local path of p, relative to home unless it's absolute.
+func (s *fakeServer) path(p string) string {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(s.home, p)
+	}
+	return filepath.Clean(p)
+}
+
+// appendEntry appends a NAME entry for the file at p to b, with the attributes OpenSSH sends.
+func appendEntry(b []byte, name, p string) []byte {
+	b = encodeString(encodeString(b, name), "-rw-r--r-- 1 me me "+name)
+	info, err := os.Lstat(p)
+	if err != nil {
+		return encodeUint32(b, 0)
+	}
+	mode := uint32(info.Mode().Perm())
+	switch {
+	case info.Mode().IsRegular():
+		mode |= modeRegular
+	case info.IsDir():
+		mode |= modeDir
+	default:
+		mode |= 0120000
+	}
+	b = encodeUint32(b, attrSize|attrUIDGID|attrPermissions|attrACModTime)
+	b = encodeUint64(b, uint64(info.Size()))                               // #nosec G115
+	b = encodeUint32(encodeUint32(b, 1000), 1000)                          // uid and gid
+	b = encodeUint32(b, mode)                                              // permissions
+	return encodeUint32(encodeUint32(b, 0), uint32(info.ModTime().Unix())) // #nosec G115
+}
+
+// writePacket writes a packet as the server does, with no request ID of its own.
+func writePacket(w io.Writer, typ byte, payload []byte) {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(1+len(payload))) // #nosec G115
+	_, _ = w.Write(append(append(packet, typ), payload...))
+}
+
+// pipeConn returns a conn to a fakeServer serving home.
+func pipeConn(t *testing.T, home string, maxChunk int) *conn {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	server := &fakeServer{home: home, maxChunk: maxChunk}
+	go func() {
+		server.serve(serverR, serverW)
+		serverW.Close()
+	}()
+	t.Cleanup(func() { clientW.Close() })
+	c, err := newConn(clientR, clientW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// TestReadFile checks that only the start of a file is read, across several reads and servers' short reads.
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	content := strings.Repeat("0123456789", 10000)
+	if err := os.WriteFile(filepath.Join(dir, "a.jpg"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, maxChunk := range []int{maxRead, 5000} {
+		c := pipeConn(t, dir, maxChunk)
+		for _, n := range []int64{10, maxRead, 70000, 200000} {
+			data, err := c.readFile(context.Background(), filepath.Join(dir, "a.jpg"), n)
+			expected := content[:min(n, int64(len(content)))]
+			if err != nil || string(data) != expected {
+				t.Errorf("Expected the first %d bytes with reads of at most %d, got %d bytes, %v", n, maxChunk, len(data), err)
+			}
+		}
+		_, err := c.readFile(context.Background(), "missing.jpg", 10)
+		if se := (*StatusError)(nil); !errors.As(err, &se) || se.Code != 2 {
+			t.Errorf("Expected a no such file status, got %v", err)
+		}
+	}
+}
+
+// TestReadFileLimited checks that reads are held back to the limiter's rate.
+func TestReadFileLimited(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.jpg"), make([]byte, 200000), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := pipeConn(t, dir, maxRead)
+	c.limiter = ratelimit.New(256 * 1024)
+	start := time.Now()
+	if data, err := c.readFile(context.Background(), "a.jpg", 128*1024); err != nil || len(data) != 128*1024 {
+		t.Fatalf("Expected 128KiB, got %d bytes, %v", len(data), err)
+	}
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("Expected 128KiB to take at least 500ms at 256KiB/s, took %v", elapsed)
+	}
+}
+
+// TestReadPacket checks that a message printed by the login shell is reported rather than read as a packet.
+func TestReadPacket(t *testing.T) {
+	if _, _, err := readPacket(strings.NewReader("Welcome to the server\n")); err == nil || !strings.Contains(err.Error(), "print something at login") {
+		t.Errorf("Expected an error about the login message, got %v", err)
+	}
+}
+
+// TestConnLost checks that requests waiting for a response fail when the connection is lost.
+func TestConnLost(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go func() {
+		_, _, _ = readPacket(serverR)
+		writePacket(serverW, fxpVersion, encodeUint32(nil, 3))
+		_, _, _ = readPacket(serverR)
+		serverW.Close()
+	}()
+	c, err := newConn(clientR, clientW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.realpath(context.Background(), "."); err == nil {
+		t.Error("Expected an error when the connection is lost")
+	}
+	if _, err := c.send(fxpRealpath, encodeString(nil, ".")); err == nil {
+		t.Error("Expected requests to fail once the connection is lost")
+	}
+}
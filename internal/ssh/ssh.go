@@ -0,0 +1,196 @@
+// Package ssh lists and reads files on a remote server over SFTP, for sftp:// URLs. It speaks the SFTP protocol
+// to the server's sftp subsystem through the system's OpenSSH client, so it works with SFTP-only and chrooted
+// accounts and doesn't depend on the server's shell or tools. Authentication is left to ssh and its
+// configuration, including ~/.ssh/config and ssh-agent.
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/toozej/photos2map/internal/ratelimit"
+)
+
+// Target is a directory on a remote server.
+type Target struct {
+	// Host is the server's host name, or user@host
+	Host string
+	// Port is empty for ssh's default
+	Port string
+	// Dir is an absolute path, or relative to the user's home directory when it starts with ~
+	Dir string
+}
+
+// ParseURL splits an sftp://[user@]host[:port]/path URL into its Target. Paths are absolute, except for
+// sftp://host/~/path which is relative to the home directory; the home directory itself is scanned when the URL
+// has no path. ok is false for other URLs, and for hosts starting with - or ports that aren't numbers, which ssh
+// would take for options.
+func ParseURL(s string) (t Target, ok bool) {
+	rest, ok := strings.CutPrefix(s, "sftp://")
+	if !ok {
+		return Target{}, false
+	}
+	authority, dir, _ := strings.Cut(rest, "/")
+	t.Host = authority
+	if at := strings.LastIndex(authority, "@"); strings.LastIndex(authority, ":") > at {
+		i := strings.LastIndex(authority, ":")
+		t.Host, t.Port = authority[:i], authority[i+1:]
+	}
+	switch {
+	case dir == "":
+		t.Dir = "~"
+	case dir == "~" || strings.HasPrefix(dir, "~/"):
+		t.Dir = dir
+	default:
+		t.Dir = "/" + dir
+	}
+	return t, t.Host != "" && !strings.HasSuffix(t.Host, "@") && !strings.HasPrefix(t.Host, "-") && numeric(t.Port)
+}
+
+// numeric reports whether s is empty or all digits.
+func numeric(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// URL returns the sftp:// URL of a path on the target's server, without the user name.
+func (t Target) URL(path string) string {
+	host := t.Host
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	if t.Port != "" {
+		host += ":" + t.Port
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "sftp://" + host + path
+}
+
+// Available reports whether the OpenSSH client is installed.
+func Available() bool {
+	_, err := exec.LookPath("ssh")
+	return err == nil
+}
+
+// File is a file listed on the server.
+type File struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Client is an SFTP session with a server over a single SSH connection. Its requests are pipelined, so files can
+// be read in parallel without a round trip each.
+type Client struct {
+	target Target
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *conn
+}
+
+// Dial connects to the target's server and starts its sftp subsystem, prompting for a password or passphrase on
+// the terminal if ssh needs one. Files are read at the rate of limiter, if set. The session is kept open until
+// Close.
+func Dial(ctx context.Context, t Target, limiter *ratelimit.Limiter) (*Client, error) {
+	// #nosec G204 -- the host and port are the user's own, and -- keeps the host from being taken as an option
+	cmd := exec.CommandContext(ctx, "ssh", args(t)...)
+	// stdout is the SFTP session, so ssh's own messages go to stderr; prompts are read from the terminal
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("running ssh: %w", err)
+	}
+	conn, err := newConn(stdout, stdin)
+	if err != nil {
+		stdin.Close()
+		if waitErr := cmd.Wait(); waitErr != nil {
+			err = waitErr
+		}
+		return nil, fmt.Errorf("connecting to %s: %w", t.Host, err)
+	}
+	conn.limiter = limiter
+	return &Client{target: t, cmd: cmd, stdin: stdin, conn: conn}, nil
+}
+
+// Close ends the session and the connection.
+func (c *Client) Close() error {
+	c.stdin.Close()
+	return c.cmd.Wait()
+}
+
+// List returns the regular files in the target directory and its subdirectories, down to maxDepth levels
+// counting the directory itself, or all of them if maxDepth is 0. Symbolic links aren't followed. Paths are
+// absolute, starting with the directory with ~ resolved.
+func (c *Client) List(ctx context.Context, maxDepth int) ([]File, error) {
+	dir, err := c.conn.realpath(ctx, remoteDir(c.target.Dir))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", c.target.Dir, err)
+	}
+	var files []File
+	var walk func(dir string, depth int) error
+	walk = func(dir string, depth int) error {
+		entries, err := c.conn.readDir(ctx, dir)
+		if err != nil {
+			return fmt.Errorf("listing %s: %w", dir, err)
+		}
+		for _, e := range entries {
+			p := path.Join(dir, e.name)
+			switch e.mode & modeType {
+			case modeRegular:
+				files = append(files, File{Path: p, Size: e.size, ModTime: e.modTime})
+			case modeDir:
+				if maxDepth == 0 || depth < maxDepth {
+					if err := walk(p, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+	return files, walk(dir, 1)
+}
+
+// ReadRange returns up to the first n bytes of a file.
+func (c *Client) ReadRange(ctx context.Context, path string, n int64) ([]byte, error) {
+	data, err := c.conn.readFile(ctx, path, n)
+	if se := (*StatusError)(nil); errors.As(err, &se) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, err
+}
+
+// args returns the ssh arguments starting the sftp subsystem on the target's server.
+func args(t Target) []string {
+	var args []string
+	if t.Port != "" {
+		args = append(args, "-p", t.Port)
+	}
+	return append(args, "-s", "--", t.Host, "sftp")
+}
+
+// remoteDir returns the path of dir in SFTP, where paths not starting with / are relative to the home directory.
+func remoteDir(dir string) string {
+	if dir == "~" {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		return rest
+	}
+	return dir
+}
@@ -0,0 +1,116 @@
+package ssh
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// fakeSSH is an ssh stand-in running the test binary as the sftp subsystem, checking the arguments it's given
+const fakeSSH = `#!/bin/sh
+[ "$*" = "-s -- localhost sftp" ] || { echo "unexpected arguments $*" >&2; exit 255; }
+PHOTOS2MAP_TEST_SFTP_SERVE=1 exec "$PHOTOS2MAP_TEST_BIN"
+`
+
+// TestParseURL checks that sftp:// URLs are split into host, port and directory.
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Target
+		ok       bool
+	}{
+		{"sftp://nas.local/volume1/photos", Target{Host: "nas.local", Dir: "/volume1/photos"}, true},
+		{"sftp://me@example.com:2222/~/Pictures", Target{Host: "me@example.com", Port: "2222", Dir: "~/Pictures"}, true},
+		{"sftp://me@example.com", Target{Host: "me@example.com", Dir: "~"}, true},
+		{"sftp://me@/photos", Target{}, false},
+		{"sftp://-oProxyCommand=touch%20x/photos", Target{}, false},
+		{"sftp://example.com:-1/photos", Target{}, false},
+		{"s3://bucket/photos", Target{}, false},
+	}
+	for _, tt := range tests {
+		target, ok := ParseURL(tt.input)
+		if ok != tt.ok || (ok && target != tt.expected) {
+			t.Errorf("ParseURL(%q) = %+v, %v, expected %+v, %v", tt.input, target, ok, tt.expected, tt.ok)
+		}
+	}
+
+	if u := (Target{Host: "me@example.com", Port: "2222"}).URL("/home/me/a.jpg"); u != "sftp://example.com:2222/home/me/a.jpg" {
+		t.Errorf("Unexpected URL %s", u)
+	}
+}
+
+// TestArgs checks that the host is passed after --, so ssh can't take it for an option.
+func TestArgs(t *testing.T) {
+	expected := []string{"-p", "2222", "-s", "--", "me@example.com", "sftp"}
+	if a := args(Target{Host: "me@example.com", Port: "2222", Dir: "/photos"}); !reflect.DeepEqual(a, expected) {
+		t.Errorf("Expected %q, got %q", expected, a)
+	}
+}
+
+// TestClient checks listing and reading files through a local stand-in for ssh, which runs the test binary as
+// the server's sftp subsystem.
+func TestClient(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(fakeSSH), 0700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("PHOTOS2MAP_TEST_BIN", exe)
+
+	dir := t.TempDir()
+	t.Setenv("PHOTOS2MAP_TEST_SFTP_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "Pictures", "it's here"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"top.jpg", filepath.Join("it's here", "deep.jpg")} {
+		if err := os.WriteFile(filepath.Join(dir, "Pictures", name), []byte("0123456789"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "Pictures", "top.jpg"), filepath.Join(dir, "Pictures", "link.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Dial(context.Background(), Target{Host: "localhost", Dir: "~/Pictures"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	files, err := c.List(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	if len(files) != 2 || files[0].Path != filepath.Join(dir, "Pictures", "it's here", "deep.jpg") || files[0].Size != 10 ||
+		files[0].ModTime.IsZero() {
+		t.Fatalf("Unexpected files %+v", files)
+	}
+	if files, err := c.List(context.Background(), 1); err != nil || len(files) != 1 {
+		t.Errorf("Expected only the top level file with a maximum depth of 1, got %+v, %v", files, err)
+	}
+
+	data, err := c.ReadRange(context.Background(), files[0].Path, 4)
+	if err != nil || string(data) != "0123" {
+		t.Errorf("Expected the first 4 bytes, got %q, %v", data, err)
+	}
+	if _, err := c.ReadRange(context.Background(), filepath.Join(dir, "missing.jpg"), 4); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestMain serves SFTP on stdin and stdout instead of running the tests when run by fakeSSH.
+func TestMain(m *testing.M) {
+	if home := os.Getenv("PHOTOS2MAP_TEST_SFTP_HOME"); home != "" && os.Getenv("PHOTOS2MAP_TEST_SFTP_SERVE") != "" {
+		(&fakeServer{home: home, maxChunk: maxRead}).serve(os.Stdin, os.Stdout)
+		return
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,206 @@
+package track
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// FIT global message and field numbers of the GPS records in activity files
+const (
+	fitRecordMessage        = 20
+	fitFieldLat             = 0
+	fitFieldLon             = 1
+	fitFieldAltitude        = 2
+	fitFieldEnhancedAlt     = 78
+	fitFieldTimestamp       = 253
+	fitSemicirclesToDegrees = 180.0 / (1 << 31)
+)
+
+// fitEpoch is the start of FIT timestamps, 1989-12-31 00:00:00 UTC
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// fitField is a field of a FIT definition message
+type fitField struct {
+	num  byte
+	size byte
+}
+
+// fitDefinition describes the layout of the data messages of a local message type
+type fitDefinition struct {
+	global    uint16
+	order     binary.ByteOrder
+	fields    []fitField
+	devFields int
+}
+
+// ReadFIT reads the GPS positions of the record messages in a FIT activity file, as written by Garmin, Wahoo
+// and other devices, ordered by time. Records without a position are left out.
+func ReadFIT(path string) (Track, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	t, err := decodeFIT(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("reading FIT file %s: %w", path, err)
+	}
+	sort.SliceStable(t, func(i, j int) bool {
+		return t[i].Time.Before(t[j].Time)
+	})
+	return t, nil
+}
+
+// decodeFIT decodes the messages of a FIT file, keeping only the positions of record messages.
+func decodeFIT(r io.Reader) (Track, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[8:12]) != ".FIT" || header[0] < 12 {
+		return nil, errors.New("not a FIT file")
+	}
+	// skip the rest of a longer header, which holds its CRC
+	if _, err := io.CopyN(io.Discard, r, int64(header[0])-12); err != nil {
+		return nil, err
+	}
+	data := io.LimitReader(r, int64(binary.LittleEndian.Uint32(header[4:8])))
+
+	var t Track
+	var definitions [16]*fitDefinition
+	var last uint32
+	for {
+		var h [1]byte
+		if _, err := io.ReadFull(data, h[:]); err == io.EOF {
+			return t, nil
+		} else if err != nil {
+			return t, err
+		}
+
+		local := h[0] & 0x0f
+		var timestamp *uint32
+		switch {
+		case h[0]&0x80 != 0:
+			// compressed timestamp header: a 5 bit offset from the last full timestamp
+			local = (h[0] >> 5) & 0x03
+			offset := uint32(h[0] & 0x1f)
+			ts := last&^0x1f + offset
+			if offset < last&0x1f {
+				ts += 0x20
+			}
+			timestamp = &ts
+		case h[0]&0x40 != 0:
+			def, err := readFITDefinition(data, h[0]&0x20 != 0)
+			if err != nil {
+				return t, err
+			}
+			definitions[local] = def
+			continue
+		}
+
+		def := definitions[local]
+		if def == nil {
+			return t, fmt.Errorf("data message for undefined local message type %d", local)
+		}
+		values, err := readFITData(data, def)
+		if err != nil {
+			return t, err
+		}
+		if v, ok := values[fitFieldTimestamp]; ok && v != 0xffffffff {
+			ts := uint32(v)
+			timestamp = &ts
+		}
+		if timestamp != nil {
+			last = *timestamp
+		}
+
+		if def.global != fitRecordMessage || timestamp == nil {
+			continue
+		}
+		lat, okLat := values[fitFieldLat]
+		lon, okLon := values[fitFieldLon]
+		if !okLat || !okLon || int32(lat) == 0x7fffffff || int32(lon) == 0x7fffffff { // #nosec G115
+			continue
+		}
+		p := Point{
+			Lat:  float64(int32(lat)) * fitSemicirclesToDegrees, // #nosec G115
+			Lon:  float64(int32(lon)) * fitSemicirclesToDegrees, // #nosec G115
+			Time: fitEpoch.Add(time.Duration(*timestamp) * time.Second),
+		}
+		if alt, ok := values[fitFieldEnhancedAlt]; ok && alt != 0xffffffff {
+			ele := float64(alt)/5 - 500
+			p.Ele = &ele
+		} else if alt, ok := values[fitFieldAltitude]; ok && alt != 0xffff {
+			ele := float64(alt)/5 - 500
+			p.Ele = &ele
+		}
+		t = append(t, p)
+	}
+}
+
+// readFITDefinition reads a definition message following its header.
+func readFITDefinition(r io.Reader, developer bool) (*fitDefinition, error) {
+	var fixed [5]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, err
+	}
+	def := &fitDefinition{order: binary.LittleEndian}
+	if fixed[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(fixed[2:4])
+
+	fields := make([]byte, 3*int(fixed[4]))
+	if _, err := io.ReadFull(r, fields); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fitField{num: fields[i], size: fields[i+1]})
+	}
+
+	if developer {
+		var n [1]byte
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return nil, err
+		}
+		devFields := make([]byte, 3*int(n[0]))
+		if _, err := io.ReadFull(r, devFields); err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devFields += int(devFields[i+1])
+		}
+	}
+	return def, nil
+}
+
+// readFITData reads a data message laid out by def, returning its integer fields of up to 4 bytes by field number.
+// Other fields, such as strings and arrays, are skipped.
+func readFITData(r io.Reader, def *fitDefinition) (map[byte]uint32, error) {
+	values := make(map[byte]uint32, len(def.fields))
+	for _, f := range def.fields {
+		buf := make([]byte, f.size)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		switch f.size {
+		case 1:
+			values[f.num] = uint32(buf[0])
+		case 2:
+			values[f.num] = uint32(def.order.Uint16(buf))
+		case 4:
+			values[f.num] = def.order.Uint32(buf)
+		}
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(def.devFields)); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
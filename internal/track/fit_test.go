@@ -0,0 +1,110 @@
+package track
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fitTime converts a time into a FIT timestamp.
+func fitTime(t time.Time) uint32 {
+	return uint32(t.Sub(fitEpoch) / time.Second) // #nosec G115
+}
+
+// semicircles converts degrees into FIT semicircles.
+func semicircles(deg float64) uint32 {
+	return uint32(int32(math.Round(deg / fitSemicirclesToDegrees))) // #nosec G115
+}
+
+// testFIT builds a FIT activity with a file_id message, three positioned records (one using a compressed
+// timestamp header and developer fields) and a record without a position.
+func testFIT(start time.Time) []byte {
+	var data bytes.Buffer
+	le := binary.LittleEndian
+
+	// file_id definition (global 0) on local type 1 and its data, which has to be skipped
+	data.Write([]byte{0x41, 0, 0, 0, 0, 1, 0, 1, 0})
+	data.Write([]byte{0x01, 4})
+
+	// record definition on local type 0: timestamp, lat, lon, altitude
+	data.Write([]byte{0x40, 0, 0})
+	_ = binary.Write(&data, le, uint16(fitRecordMessage))
+	data.Write([]byte{4, fitFieldTimestamp, 4, 0x86, fitFieldLat, 4, 0x85, fitFieldLon, 4, 0x85, fitFieldAltitude, 2, 0x84})
+	record := func(ts uint32, lat, lon uint32, alt uint16) {
+		data.WriteByte(0x00)
+		_ = binary.Write(&data, le, []uint32{ts, lat, lon})
+		_ = binary.Write(&data, le, alt)
+	}
+	record(fitTime(start), semicircles(43), semicircles(11), (100+500)*5)
+	record(fitTime(start.Add(10*time.Second)), 0x7fffffff, 0x7fffffff, 0xffff)
+
+	// record definition with developer fields on local type 2: lat, lon and a 2 byte developer field
+	data.Write([]byte{0x62, 0, 0})
+	_ = binary.Write(&data, le, uint16(fitRecordMessage))
+	data.Write([]byte{2, fitFieldLat, 4, 0x85, fitFieldLon, 4, 0x85, 1, 0, 2, 0})
+	// compressed timestamp header for local type 2, 20s after the last timestamp
+	offset := (fitTime(start.Add(10*time.Second)) + 20) & 0x1f
+	data.WriteByte(0x80 | 2<<5 | byte(offset))
+	_ = binary.Write(&data, le, []uint32{semicircles(44), semicircles(-12)})
+	data.Write([]byte{0xaa, 0xbb})
+
+	record(fitTime(start.Add(time.Minute)), semicircles(45), semicircles(13), 0xffff)
+
+	var file bytes.Buffer
+	file.Write([]byte{14, 0x20})
+	_ = binary.Write(&file, le, uint16(2132))
+	_ = binary.Write(&file, le, uint32(data.Len())) // #nosec G115
+	file.WriteString(".FIT")
+	file.Write([]byte{0, 0})
+	file.Write(data.Bytes())
+	file.Write([]byte{0, 0})
+	return file.Bytes()
+}
+
+// TestReadFIT checks that positioned records are read with their times and altitudes.
+func TestReadFIT(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "activity.fit")
+	if err := os.WriteFile(path, testFIT(start), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	trk, err := Read(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(trk) != 3 {
+		t.Fatalf("Expected 3 positioned records, got %+v", trk)
+	}
+
+	if math.Abs(trk[0].Lat-43) > 1e-6 || math.Abs(trk[0].Lon-11) > 1e-6 || !trk[0].Time.Equal(start) {
+		t.Errorf("Unexpected first record %+v", trk[0])
+	}
+	if trk[0].Ele == nil || math.Abs(*trk[0].Ele-100) > 1e-9 {
+		t.Errorf("Expected an altitude of 100, got %v", trk[0].Ele)
+	}
+	if math.Abs(trk[1].Lon+12) > 1e-6 || !trk[1].Time.Equal(start.Add(30*time.Second)) {
+		t.Errorf("Expected the compressed timestamp record at +30s, got %+v", trk[1])
+	}
+	if trk[2].Ele != nil || !trk[2].Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("Unexpected last record %+v", trk[2])
+	}
+}
+
+// TestReadUnsupported checks that tracks of unknown formats are rejected.
+func TestReadUnsupported(t *testing.T) {
+	if _, err := Read("track.kml"); err == nil {
+		t.Error("Expected an error for an unsupported track format, got none")
+	}
+	path := filepath.Join(t.TempDir(), "bad.fit")
+	if err := os.WriteFile(path, []byte("definitely not a FIT file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Expected an error for an invalid FIT file, got none")
+	}
+}
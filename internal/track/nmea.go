@@ -0,0 +1,130 @@
+package track
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadNMEA reads the positions of an NMEA 0183 log, as written by dedicated GPS loggers, ordered by time.
+// Positions come from RMC sentences, which carry the date; altitudes are taken from GGA sentences with the
+// same time. Sentences with a bad checksum or without a valid fix are ignored.
+func ReadNMEA(path string) (Track, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var t Track
+	// altitudes by time of day, as GGA sentences have no date
+	altitudes := make(map[time.Duration]float64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields, ok := nmeaFields(scanner.Text())
+		if !ok || len(fields[0]) < 5 {
+			continue
+		}
+		// the first two letters are the talker, e.g. GP for GPS or GN for combined constellations
+		switch fields[0][2:] {
+		case "RMC":
+			if p, ok := parseRMC(fields); ok {
+				t = append(t, p)
+			}
+		case "GGA":
+			// fields: time, lat, N/S, lon, E/W, fix quality, satellites, HDOP, altitude, M, ...
+			if len(fields) <= 9 || fields[6] == "" || fields[6] == "0" {
+				continue
+			}
+			clock, okClock := nmeaClock(fields[1])
+			if alt, err := strconv.ParseFloat(fields[9], 64); err == nil && okClock {
+				altitudes[clock] = alt
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading NMEA log %s: %w", path, err)
+	}
+
+	for i := range t {
+		day := t[i].Time.Truncate(24 * time.Hour)
+		if alt, ok := altitudes[t[i].Time.Sub(day)]; ok {
+			t[i].Ele = &alt
+		}
+	}
+	sort.SliceStable(t, func(i, j int) bool {
+		return t[i].Time.Before(t[j].Time)
+	})
+	return t, nil
+}
+
+// nmeaFields splits a sentence into its comma separated fields without the leading $, after verifying its
+// checksum if it has one.
+func nmeaFields(line string) ([]string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "$") {
+		return nil, false
+	}
+	body := line[1:]
+	if i := strings.LastIndexByte(body, '*'); i >= 0 {
+		want, err := strconv.ParseUint(body[i+1:], 16, 8)
+		if err != nil {
+			return nil, false
+		}
+		var sum byte
+		for _, c := range []byte(body[:i]) {
+			sum ^= c
+		}
+		if uint64(sum) != want {
+			return nil, false
+		}
+		body = body[:i]
+	}
+	return strings.Split(body, ","), true
+}
+
+// parseRMC reads the time and position of an RMC sentence, if it has a valid fix.
+// Fields: time, status, lat, N/S, lon, E/W, speed, course, date, ...
+func parseRMC(fields []string) (Point, bool) {
+	if len(fields) < 10 || fields[2] != "A" {
+		return Point{}, false
+	}
+	lat, okLat := nmeaDegrees(fields[3], fields[4], "S")
+	lon, okLon := nmeaDegrees(fields[5], fields[6], "W")
+	if !okLat || !okLon {
+		return Point{}, false
+	}
+
+	clock, okClock := nmeaClock(fields[1])
+	date, err := time.Parse("020106", fields[9])
+	if err != nil || !okClock {
+		return Point{}, false
+	}
+	return Point{Lat: lat, Lon: lon, Time: date.Add(clock)}, true
+}
+
+// nmeaClock parses an NMEA hhmmss(.sss) UTC time into the time since midnight.
+func nmeaClock(value string) (time.Duration, bool) {
+	t, err := time.Parse("150405", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Sub(t.Truncate(24 * time.Hour)), true
+}
+
+// nmeaDegrees converts an NMEA (d)ddmm.mmmm coordinate into signed decimal degrees.
+func nmeaDegrees(value, hemisphere, negative string) (float64, bool) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	deg := float64(int(v/100)) + (v-float64(int(v/100))*100)/60
+	if hemisphere == negative {
+		deg = -deg
+	}
+	return deg, true
+}
@@ -0,0 +1,58 @@
+package track
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testNMEA = `$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47
+$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A
+$GPGSV,2,1,08,01,40,083,46,02,17,308,41,12,07,344,39,14,22,228,45*75
+$GNRMC,123529.00,A,4807.538,N,01131.500,E,022.4,084.4,230394,,,A*4F
+$GPRMC,123539,V,4807.538,N,01131.500,E,,,230394,,*08
+$GPRMC,123549,A,4807.538,N,01131.500,E,022.4,084.4,230394,003.1,W*00
+garbage
+`
+
+// TestReadNMEA checks that valid RMC fixes are read with altitudes from matching GGA sentences.
+func TestReadNMEA(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.nmea")
+	if err := os.WriteFile(path, []byte(testNMEA), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	trk, err := Read(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the void fix and the sentence with a bad checksum are ignored
+	if len(trk) != 2 {
+		t.Fatalf("Expected 2 fixes, got %+v", trk)
+	}
+
+	if !trk[0].Time.Equal(time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC)) {
+		t.Errorf("Unexpected time %v", trk[0].Time)
+	}
+	if math.Abs(trk[0].Lat-(48+7.038/60)) > 1e-9 || math.Abs(trk[0].Lon-(11+31.0/60)) > 1e-9 {
+		t.Errorf("Unexpected position %v, %v", trk[0].Lat, trk[0].Lon)
+	}
+	if trk[0].Ele == nil || *trk[0].Ele != 545.4 {
+		t.Errorf("Expected an altitude of 545.4, got %v", trk[0].Ele)
+	}
+	if trk[1].Ele != nil || !trk[1].Time.Equal(time.Date(1994, 3, 23, 12, 35, 29, 0, time.UTC)) {
+		t.Errorf("Unexpected second fix %+v", trk[1])
+	}
+}
+
+// TestNMEADegrees checks the conversion of NMEA coordinates.
+func TestNMEADegrees(t *testing.T) {
+	if deg, ok := nmeaDegrees("01131.500", "W", "W"); !ok || math.Abs(deg+11.525) > 1e-9 {
+		t.Errorf("Expected -11.525, got %v", deg)
+	}
+	if _, ok := nmeaDegrees("", "N", "S"); ok {
+		t.Error("Expected an empty coordinate to be rejected")
+	}
+}
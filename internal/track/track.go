@@ -0,0 +1,158 @@
+// Package track reads reference GPS tracks and looks up positions along them by time.
+package track
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/twpayne/go-gpx"
+
+	"github.com/toozej/photos2map/pkg/geodata"
+)
+
+// Point is a timestamped position on a track.
+type Point struct {
+	Lat  float64
+	Lon  float64
+	Time time.Time
+	// Ele is the altitude in meters above sea level, or nil if the track has none
+	Ele *float64
+}
+
+// Track is a series of positions ordered by time.
+type Track []Point
+
+// Read reads a GPX, FIT or NMEA track, chosen by the file's extension.
+func Read(path string) (Track, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gpx":
+		return ReadGPX(path)
+	case ".fit":
+		return ReadFIT(path)
+	case ".nmea", ".nma":
+		return ReadNMEA(path)
+	default:
+		return nil, fmt.Errorf("unsupported track format %q, expected .gpx, .fit or .nmea", filepath.Ext(path))
+	}
+}
+
+// ReadGPX reads the points of every track in a GPX file, ordered by time. Points without a timestamp
+// can't be matched to photos and are left out.
+func ReadGPX(path string) (Track, error) {
+	file, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	g, err := gpx.Read(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var t Track
+	for _, trk := range g.Trk {
+		for _, seg := range trk.TrkSeg {
+			for _, wpt := range seg.TrkPt {
+				if wpt.Time.IsZero() {
+					continue
+				}
+				p := Point{Lat: wpt.Lat, Lon: wpt.Lon, Time: wpt.Time}
+				// go-gpx can't tell a missing <ele> from 0, so 0 is treated as unknown
+				if wpt.Ele != 0 {
+					ele := wpt.Ele
+					p.Ele = &ele
+				}
+				t = append(t, p)
+			}
+		}
+	}
+
+	sort.SliceStable(t, func(i, j int) bool {
+		return t[i].Time.Before(t[j].Time)
+	})
+	return t, nil
+}
+
+// Merge combines several tracks of the same trip, such as from a watch and a phone, into one timeline.
+// Where tracks have points in the same second only the one from the earliest track in the arguments is kept,
+// so the most accurate device should be passed first.
+func Merge(tracks ...Track) Track {
+	var merged Track
+	for _, t := range tracks {
+		merged = append(merged, t...)
+	}
+	// a stable sort keeps points with equal times in argument order
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.Before(merged[j].Time)
+	})
+
+	deduped := merged[:0]
+	for _, p := range merged {
+		if n := len(deduped); n > 0 && p.Time.Truncate(time.Second).Equal(deduped[n-1].Time.Truncate(time.Second)) {
+			continue
+		}
+		deduped = append(deduped, p)
+	}
+	return deduped
+}
+
+// Match is a position found on a track, along with how far it is from the recorded track points.
+type Match struct {
+	Point
+	// Gap is the time between the matched time and the nearest track point
+	Gap time.Duration
+	// Span is the distance in meters between the two track points the position was interpolated between,
+	// or 0 when the time matched a track point exactly
+	Span float64
+}
+
+// Confidence rates a match as "high", "medium" or "low". Positions close in time to a recorded point are
+// reliable, while long gaps may have been spent anywhere between the surrounding points.
+func (m Match) Confidence() string {
+	switch {
+	case m.Gap <= 30*time.Second && m.Span <= 100:
+		return "high"
+	case m.Gap <= 5*time.Minute && m.Span <= 1000:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// Locate returns the position at the given time, interpolated linearly between the track points around it.
+// ok is false when the time is before the start or after the end of the track.
+func (t Track) Locate(when time.Time) (m Match, ok bool) {
+	if len(t) == 0 || when.Before(t[0].Time) || when.After(t[len(t)-1].Time) {
+		return Match{}, false
+	}
+
+	// index of the first point at or after when
+	i := sort.Search(len(t), func(i int) bool {
+		return !t[i].Time.Before(when)
+	})
+	if t[i].Time.Equal(when) {
+		return Match{Point: t[i]}, true
+	}
+
+	prev, next := t[i-1], t[i]
+	f := float64(when.Sub(prev.Time)) / float64(next.Time.Sub(prev.Time))
+	m = Match{
+		Point: Point{
+			Lat:  prev.Lat + (next.Lat-prev.Lat)*f,
+			Lon:  prev.Lon + (next.Lon-prev.Lon)*f,
+			Time: when,
+		},
+		Gap:  min(when.Sub(prev.Time), next.Time.Sub(when)),
+		Span: geodata.Distance(prev.Lat, prev.Lon, next.Lat, next.Lon),
+	}
+	if prev.Ele != nil && next.Ele != nil {
+		ele := *prev.Ele + (*next.Ele-*prev.Ele)*f
+		m.Ele = &ele
+	}
+	return m, true
+}
@@ -0,0 +1,132 @@
+package track
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <trkseg>
+      <trkpt lat="43.0" lon="11.0"><ele>100</ele><time>2024-05-01T10:00:00Z</time></trkpt>
+      <trkpt lat="44.0" lon="12.0"><ele>200</ele><time>2024-05-01T10:10:00Z</time></trkpt>
+      <trkpt lat="45.0" lon="13.0"></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="42.0" lon="10.0"><time>2024-05-01T09:50:00Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+// writeGPX writes the test track to a temporary file and returns its path.
+func writeGPX(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "track.gpx")
+	if err := os.WriteFile(path, []byte(testGPX), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestReadGPX checks that timed track points from every segment are read in time order.
+func TestReadGPX(t *testing.T) {
+	trk, err := ReadGPX(writeGPX(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(trk) != 3 {
+		t.Fatalf("Expected 3 timed points, got %d", len(trk))
+	}
+	if trk[0].Lat != 42 || trk[2].Lat != 44 {
+		t.Errorf("Expected points ordered by time, got %+v", trk)
+	}
+	if trk[0].Ele != nil || trk[1].Ele == nil || *trk[1].Ele != 100 {
+		t.Errorf("Unexpected elevations: %v, %v", trk[0].Ele, trk[1].Ele)
+	}
+}
+
+// TestLocate checks interpolation between track points and the bounds of the track.
+func TestLocate(t *testing.T) {
+	trk, err := ReadGPX(writeGPX(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p, ok := trk.Locate(time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("Expected a position within the track")
+	}
+	if math.Abs(p.Lat-43.5) > 1e-9 || math.Abs(p.Lon-11.5) > 1e-9 {
+		t.Errorf("Expected the midpoint, got %v, %v", p.Lat, p.Lon)
+	}
+	if p.Ele == nil || math.Abs(*p.Ele-150) > 1e-9 {
+		t.Errorf("Expected an interpolated elevation of 150, got %v", p.Ele)
+	}
+	if p.Gap != 5*time.Minute || p.Span < 100_000 || p.Confidence() != "low" {
+		t.Errorf("Expected a 5 minute gap over a long span, got %v, %v m", p.Gap, p.Span)
+	}
+
+	// no elevation is made up when one side has none
+	if p, ok := trk.Locate(time.Date(2024, 5, 1, 9, 55, 0, 0, time.UTC)); !ok || p.Ele != nil {
+		t.Errorf("Expected a position without elevation, got %+v", p)
+	}
+
+	if p, ok := trk.Locate(time.Date(2024, 5, 1, 10, 10, 0, 0, time.UTC)); !ok || p.Lat != 44 || p.Gap != 0 || p.Confidence() != "high" {
+		t.Errorf("Expected an exact match of the last point, got %+v", p)
+	}
+
+	for _, when := range []time.Time{
+		time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+	} {
+		if _, ok := trk.Locate(when); ok {
+			t.Errorf("Expected no position outside the track at %v", when)
+		}
+	}
+}
+
+// TestConfidence checks the confidence levels of matches.
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		match    Match
+		expected string
+	}{
+		{Match{Gap: 10 * time.Second, Span: 50}, "high"},
+		{Match{Gap: 10 * time.Second, Span: 500}, "medium"},
+		{Match{Gap: 2 * time.Minute, Span: 50}, "medium"},
+		{Match{Gap: 10 * time.Minute, Span: 50}, "low"},
+		{Match{Gap: time.Second, Span: 5000}, "low"},
+	}
+	for _, test := range tests {
+		if got := test.match.Confidence(); got != test.expected {
+			t.Errorf("Confidence() for %+v = %q, expected %q", test.match, got, test.expected)
+		}
+	}
+}
+
+// TestMerge checks that tracks are interleaved by time with points in the same second deduplicated.
+func TestMerge(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	watch := Track{
+		{Lat: 1, Time: start},
+		{Lat: 3, Time: start.Add(2 * time.Minute)},
+	}
+	phone := Track{
+		{Lat: 10, Time: start.Add(500 * time.Millisecond)},
+		{Lat: 2, Time: start.Add(time.Minute)},
+		{Lat: 30, Time: start.Add(2 * time.Minute)},
+	}
+
+	merged := Merge(watch, phone)
+	if len(merged) != 3 || merged[0].Lat != 1 || merged[1].Lat != 2 || merged[2].Lat != 3 {
+		t.Errorf("Expected points 1, 2, 3 with the watch preferred, got %+v", merged)
+	}
+	if len(Merge()) != 0 {
+		t.Error("Expected an empty track from no tracks")
+	}
+}
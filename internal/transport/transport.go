@@ -0,0 +1,272 @@
+// Package transport is the HTTP transport shared by the remote sources. It retries the requests that failed in a
+// way that may pass, such as a dropped connection, a server that's briefly overloaded or one throttling the
+// requests, waiting longer before each attempt, and gives up on servers that stop answering.
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/toozej/photos2map/internal/ratelimit"
+)
+
+// DefaultRetries is how many times a failed request is retried by default
+const DefaultRetries = 3
+
+// DefaultTimeout is how long a request waits for the server by default
+const DefaultTimeout = time.Minute
+
+// minBackoff is the wait before the first retry, doubling with each one up to maxBackoff
+const minBackoff = 500 * time.Millisecond
+
+// maxBackoff is the longest wait between attempts, unless the server asks for longer with Retry-After
+const maxBackoff = 30 * time.Second
+
+// maxRetryAfter is the longest Retry-After waited for; responses asking for longer aren't retried
+const maxRetryAfter = 2 * time.Minute
+
+// Options configures a Transport.
+type Options struct {
+	// Retries is how many times a failed request is retried, 0 for none
+	Retries int
+	// Timeout is how long a request waits for the server, for its response or the next part of the body, before
+	// it fails and may be retried; 0 for no limit
+	Timeout time.Duration
+	// Limiter, if set, limits the rate response bodies are read at
+	Limiter *ratelimit.Limiter
+	// TLS, if set, replaces how the servers' certificates are verified, see TLSConfig
+	TLS *tls.Config
+}
+
+// DefaultOptions are the Options of the remote sources unless the user says otherwise.
+var DefaultOptions = Options{Retries: DefaultRetries, Timeout: DefaultTimeout}
+
+// New returns a Transport sending its requests with http.DefaultTransport, or a clone of it using opts.TLS if set.
+func New(opts Options) *Transport {
+	base := http.DefaultTransport
+	if opts.TLS != nil {
+		clone := http.DefaultTransport.(*http.Transport).Clone()
+		clone.TLSClientConfig = opts.TLS
+		base = clone
+	}
+	return &Transport{Base: base, Options: opts}
+}
+
+// TLSConfig returns the TLS configuration trusting the certificates in the PEM file caCert as well as the
+// system's, for servers with a certificate of a private CA, or skipping the verification of the servers'
+// certificates altogether if insecure is set. It returns nil if neither is given.
+func TLSConfig(caCert string, insecure bool) (*tls.Config, error) {
+	if caCert == "" && !insecure {
+		return nil, nil
+	}
+	// #nosec G402 -- skipping the verification is what the user asked for
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecure}
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert) // #nosec G304 -- the file the user gave
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates in %s", caCert)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// NewClient returns an HTTP client sending its requests through a Transport configured with opts.
+func NewClient(opts Options) *http.Client {
+	return New(opts).Client()
+}
+
+// Transport is an http.RoundTripper retrying the requests Base fails, with exponential backoff and jitter, when
+// it's safe and may help: for GET, HEAD and PROPFIND requests failing with a network error or timeout, or a 429
+// or 5xx response other than 501 and 505. A Retry-After header is honored.
+type Transport struct {
+	Base http.RoundTripper
+	Options
+	// sleep waits between attempts, replaced in tests
+	sleep   func(ctx context.Context, d time.Duration) error
+	retried atomic.Int64
+}
+
+// Client returns an HTTP client sending its requests through t.
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Retried returns how many times requests were retried, for the summary of the scan.
+func (t *Transport) Retried() int64 {
+	return t.retried.Load()
+}
+
+// RoundTrip sends req, retrying it as needed.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r := req
+		// an empty body, nil or http.NoBody, needs no rewinding, and one that can't be rewound isn't retryable
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		resp, err := t.try(r)
+		wait, retry := t.backoff(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		t.retried.Add(1)
+		log.Debugf("Retrying %s %s in %v after %s, retry %d of %d", req.Method, req.URL.Redacted(), wait.Round(time.Millisecond), reason, attempt+1, t.Retries)
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// try sends a single attempt of req, failing it if the server is silent for longer than t.Timeout, and paces
+// the reading of the response's body with t.Limiter.
+func (t *Transport) try(req *http.Request) (*http.Response, error) {
+	resp, err := t.timed(req)
+	if err != nil || t.Limiter == nil {
+		return resp, err
+	}
+	resp.Body = &limitedBody{Reader: t.Limiter.Reader(req.Context(), resp.Body), Closer: resp.Body}
+	return resp, nil
+}
+
+// timed sends req, failing it if the server is silent for longer than t.Timeout.
+func (t *Transport) timed(req *http.Request) (*http.Response, error) {
+	if t.Timeout <= 0 {
+		return t.Base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.Timeout, cancel)
+	resp, err := t.Base.RoundTrip(req.WithContext(ctx))
+	// from here on only the reads of the body are timed, each by idleBody
+	timer.Stop()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &idleBody{ReadCloser: resp.Body, timer: timer, timeout: t.Timeout, cancel: cancel}
+	return resp, nil
+}
+
+// backoff returns how long to wait before retrying a request that got resp or err on the given attempt,
+// counting from 0, and whether to retry it at all.
+func (t *Transport) backoff(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= t.Retries || req.Context().Err() != nil || !retryable(req) {
+		return 0, false
+	}
+	if err == nil && resp.StatusCode != http.StatusTooManyRequests &&
+		(resp.StatusCode < 500 || resp.StatusCode == http.StatusNotImplemented || resp.StatusCode == http.StatusHTTPVersionNotSupported) {
+		return 0, false
+	}
+
+	// equal jitter: between half and all of the exponential backoff, so clients that failed together spread out
+	backoff := min(maxBackoff, minBackoff<<min(attempt, 16))
+	wait := backoff/2 + rand.N(backoff/2+1) // #nosec G404 -- jitter needs no cryptographic randomness
+	if err == nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if after > maxRetryAfter {
+				return 0, false
+			}
+			wait = max(wait, after)
+		}
+	}
+	return wait, true
+}
+
+// retryable reports whether req can be sent again without side effects: its method is safe, and its body, if
+// any, can be read again.
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, "PROPFIND":
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, either a number of seconds or a date, into the wait it asks for.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(0, date.Sub(now)), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// idleBody is a response body failing the request if a read waits longer than timeout for the server. The time
+// between reads, such as waits for a Limiter, doesn't count.
+type idleBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelFunc
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	n, err := b.ReadCloser.Read(p)
+	b.timer.Stop()
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// limitedBody is a response body read at a Limiter's rate
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
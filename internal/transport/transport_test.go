@@ -0,0 +1,288 @@
+package transport
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/toozej/photos2map/internal/ratelimit"
+)
+
+// newTestClient returns a client sending its requests through a Transport with opts, recording the waits
+// between attempts in waits instead of sleeping.
+func newTestClient(opts Options, waits *[]time.Duration) *http.Client {
+	return &http.Client{Transport: &Transport{Base: http.DefaultTransport, Options: opts, sleep: func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return ctx.Err()
+	}}}
+}
+
+// TestRetry checks which failures are retried, how many times and how long the waits between attempts are.
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		header   string
+		// expected is the status the client ends up with, and attempts the number of requests it sent
+		expected, attempts int
+	}{
+		{"success", http.MethodGet, []int{200}, "", 200, 1},
+		{"unavailable then success", http.MethodGet, []int{503, 502, 206}, "", 206, 3},
+		{"throttled", http.MethodGet, []int{429, 200}, "", 200, 2},
+		{"retries exhausted", http.MethodGet, []int{500, 500, 500, 500, 200}, "", 500, 4},
+		{"not found", http.MethodGet, []int{404, 200}, "", 404, 1},
+		{"not implemented", "PROPFIND", []int{501, 207}, "", 501, 1},
+		{"listing unavailable", "PROPFIND", []int{503, 207}, "", 207, 2},
+		{"post", http.MethodPost, []int{503, 200}, "", 503, 1},
+		{"retry after too long", http.MethodGet, []int{503, 200}, "3600", 503, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if body, _ := io.ReadAll(r.Body); r.Method == "PROPFIND" && string(body) != "<propfind/>" {
+					t.Errorf("Expected the body to be sent on every attempt, got %q", body)
+				}
+				if tt.header != "" {
+					w.Header().Set("Retry-After", tt.header)
+				}
+				w.WriteHeader(tt.statuses[attempts.Add(1)-1])
+			}))
+			defer srv.Close()
+
+			var waits []time.Duration
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("<propfind/>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := newTestClient(Options{Retries: DefaultRetries}, &waits)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expected || int(attempts.Load()) != tt.attempts {
+				t.Errorf("Expected status %d after %d attempts, got %d after %d", tt.expected, tt.attempts, resp.StatusCode, attempts.Load())
+			}
+			if retried := client.Transport.(*Transport).Retried(); retried != int64(tt.attempts-1) {
+				t.Errorf("Expected %d retries to be counted, got %d", tt.attempts-1, retried)
+			}
+			for i, wait := range waits {
+				if backoff := minBackoff << i; wait < backoff/2 || wait > backoff {
+					t.Errorf("Expected retry %d to wait between %v and %v, got %v", i+1, backoff/2, backoff, wait)
+				}
+			}
+		})
+	}
+}
+
+// TestRetryEmptyBody checks that a request with an empty body and no GetBody is retried as it is.
+func TestRetryEmptyBody(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.GetBody = nil
+	var waits []time.Duration
+	resp, err := newTestClient(Options{Retries: 1}, &waits).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || attempts.Load() != 2 {
+		t.Errorf("Expected success on the second attempt, got %s after %d", resp.Status, attempts.Load())
+	}
+}
+
+// TestRetryAfter checks that a server asking to wait longer than the backoff is waited for.
+func TestRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "20")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	resp, err := newTestClient(Options{Retries: 1}, &waits).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(waits) != 1 || waits[0] != 20*time.Second {
+		t.Errorf("Expected a single wait of 20s before succeeding, got %v and %s", waits, resp.Status)
+	}
+
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	for header, expected := range map[string]time.Duration{
+		"5":                             5 * time.Second,
+		"Sat, 01 Jun 2024 10:01:00 GMT": time.Minute,
+		"Sat, 01 Jun 2024 09:00:00 GMT": 0,
+	} {
+		if wait, ok := retryAfter(header, now); !ok || wait != expected {
+			t.Errorf("Expected Retry-After %q to ask for %v, got %v, %v", header, expected, wait, ok)
+		}
+	}
+	if _, ok := retryAfter("soon", now); ok {
+		t.Error("Expected an invalid Retry-After to be ignored")
+	}
+}
+
+// TestTimeout checks that a server that stops answering fails the attempt, which is retried.
+func TestTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	resp, err := newTestClient(Options{Retries: 1, Timeout: 100 * time.Millisecond}, &waits).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, err := io.ReadAll(resp.Body); err != nil || string(body) != "ok" || attempts.Load() != 2 {
+		t.Errorf("Expected the second attempt to succeed, got %q, %v after %d attempts", body, err, attempts.Load())
+	}
+
+	waits = nil
+	if _, err := newTestClient(Options{Timeout: 100 * time.Millisecond}, &waits).Get(srv.URL + "/?again"); err != nil {
+		t.Errorf("Expected no timeout for a server answering in time, got %v", err)
+	}
+}
+
+// TestTimeoutBeforeReading checks that the time before the body is first read doesn't count towards the timeout.
+func TestTimeoutBeforeReading(t *testing.T) {
+	data := strings.Repeat("0123456789", 100000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(data))
+	}))
+	defer srv.Close()
+
+	resp, err := New(Options{Timeout: 50 * time.Millisecond}).Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	time.Sleep(150 * time.Millisecond)
+	if body, err := io.ReadAll(resp.Body); err != nil || string(body) != data {
+		t.Errorf("Expected the whole body read after waiting longer than the timeout, got %d bytes, %v", len(body), err)
+	}
+}
+
+// TestCanceled checks that a canceled request isn't retried.
+func TestCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var waits []time.Duration
+	client := &http.Client{Transport: &Transport{Base: http.DefaultTransport, Options: Options{Retries: DefaultRetries}, sleep: func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		cancel()
+		return ctx.Err()
+	}}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); err == nil || len(waits) != 1 {
+		t.Errorf("Expected the request to stop once canceled, got %v after %d waits", err, len(waits))
+	}
+}
+
+// TestLimiter checks that response bodies are read at the limiter's rate, and that waiting for it doesn't count
+// towards the timeout.
+func TestLimiter(t *testing.T) {
+	data := strings.Repeat("0123456789", 6400)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(data))
+	}))
+	defer srv.Close()
+
+	client := New(Options{Timeout: 50 * time.Millisecond, Limiter: ratelimit.New(128 * 1024)}).Client()
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != data {
+		t.Fatalf("Expected the whole body, got %d bytes, %v", len(body), err)
+	}
+	// 62.5KiB at 128KiB/s
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("Expected the body to take at least 480ms at 128KiB/s, took %v", elapsed)
+	}
+}
+
+// TestTLSConfig checks that a server with a certificate of a private CA is trusted only with its certificate in
+// the CA file, or with the verification skipped.
+func TestTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		caCert   string
+		insecure bool
+		trusted  bool
+	}{
+		{"system CAs", "", false, false},
+		{"CA file", caCert, false, true},
+		{"skipped verification", "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := TLSConfig(tt.caCert, tt.insecure)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := New(Options{TLS: cfg}).Client().Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if trusted := err == nil; trusted != tt.trusted {
+				t.Errorf("Expected the server to be trusted: %v, got %v", tt.trusted, err)
+			}
+		})
+	}
+
+	if _, err := TLSConfig(filepath.Join(t.TempDir(), "missing.pem"), false); err == nil {
+		t.Error("Expected an error for a missing CA file")
+	}
+	if _, err := TLSConfig(srv.URL, false); err == nil {
+		t.Error("Expected an error for a CA file that can't be read")
+	}
+}
@@ -0,0 +1,207 @@
+// Package webdav lists and reads files on WebDAV servers such as Nextcloud, ownCloud or a NAS.
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toozej/photos2map/internal/transport"
+)
+
+// propfindBody asks for the properties List reads
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+// Config holds the credentials to use. Requests are sent without authentication when User is empty.
+type Config struct {
+	User     string
+	Password string
+}
+
+// ConfigFromEnv reads the credentials from PHOTOS2MAP_WEBDAV_USER and PHOTOS2MAP_WEBDAV_PASSWORD. With Nextcloud,
+// use an app password rather than the account's own.
+func ConfigFromEnv() Config {
+	return Config{User: os.Getenv("PHOTOS2MAP_WEBDAV_USER"), Password: os.Getenv("PHOTOS2MAP_WEBDAV_PASSWORD")}
+}
+
+// ParseURL turns a webdav://host/path URL into the https:// URL of the collection, or webdav+http://host/path
+// into a plain http:// one for servers on the local network. A user name in the URL, as in
+// webdav://user@host/path, is returned separately and left out of the collection's URL. ok is false for other
+// URLs.
+func ParseURL(s string) (collection *url.URL, user string, ok bool) {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil, "", false
+	}
+	switch u.Scheme {
+	case "webdav":
+		u.Scheme = "https"
+	case "webdav+http":
+		u.Scheme = "http"
+	default:
+		return nil, "", false
+	}
+	if u.User != nil {
+		user = u.User.Username()
+		u.User = nil
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+		u.RawPath = ""
+	}
+	return u, user, true
+}
+
+// File is a file listed on the server.
+type File struct {
+	// URL is the file's absolute http(s) URL
+	URL          *url.URL
+	Size         int64
+	LastModified time.Time
+}
+
+// Client sends requests to a WebDAV server.
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+// New returns a Client for cfg sending its requests with client, or with one retrying failed requests with the
+// transport package's default options if client is nil.
+func New(cfg Config, client *http.Client) *Client {
+	if client == nil {
+		client = transport.NewClient(transport.DefaultOptions)
+	}
+	return &Client{cfg: cfg, http: client}
+}
+
+// multistatus is the part of a PROPFIND response that is read
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ContentLength string `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+			} `xml:"prop"`
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// List returns the files in the collection and its subcollections, down to maxDepth levels counting the
+// collection itself, or all of them if maxDepth is 0. Collections are listed one level at a time, since many
+// servers refuse "Depth: infinity" requests.
+func (c *Client) List(ctx context.Context, collection *url.URL, maxDepth int) ([]File, error) {
+	var files []File
+	pending := []*url.URL{collection}
+	for depth := 1; len(pending) > 0 && (maxDepth <= 0 || depth <= maxDepth); depth++ {
+		var next []*url.URL
+		for _, dir := range pending {
+			listed, subdirs, err := c.propfind(ctx, dir)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, listed...)
+			next = append(next, subdirs...)
+		}
+		pending = next
+	}
+	return files, nil
+}
+
+// propfind lists the direct members of a collection, split into files and subcollections.
+func (c *Client) propfind(ctx context.Context, dir *url.URL) ([]File, []*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", dir.String(), strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := c.do(req, http.StatusMultiStatus)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, nil, fmt.Errorf("parsing listing of %s: %w", dir.Redacted(), err)
+	}
+
+	var files []File
+	var subdirs []*url.URL
+	for _, r := range ms.Responses {
+		// hrefs are usually absolute paths, but may be full URLs
+		u, err := dir.Parse(r.Href)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %q in listing of %s: %w", r.Href, dir.Redacted(), err)
+		}
+		if path.Clean(u.Path) == path.Clean(dir.Path) {
+			continue // the collection itself
+		}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			if ps.Prop.ResourceType.Collection != nil {
+				subdirs = append(subdirs, u)
+				break
+			}
+			f := File{URL: u}
+			f.Size, _ = strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+			f.LastModified, _ = http.ParseTime(ps.Prop.LastModified)
+			files = append(files, f)
+			break
+		}
+	}
+	return files, subdirs, nil
+}
+
+// ReadRange returns up to the first n bytes of a file, fetched with a ranged GET.
+func (c *Client) ReadRange(ctx context.Context, file *url.URL, n int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", n-1))
+	resp, err := c.do(req, http.StatusOK, http.StatusPartialContent)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// servers ignoring the range send the whole file, so only n bytes are read either way
+	return io.ReadAll(io.LimitReader(resp.Body, n))
+}
+
+// do sends req with the configured credentials, returning an error for responses with other statuses than
+// expected.
+func (c *Client) do(req *http.Request, expected ...int) (*http.Response, error) {
+	if c.cfg.User != "" {
+		req.SetBasicAuth(c.cfg.User, c.cfg.Password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	resp.Body.Close()
+	return nil, fmt.Errorf("%s %s: %s %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,94 @@
+package webdav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// multistatusResponse is a PROPFIND response for /dav/photos/ with a photo and a subcollection
+const multistatusResponse = `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:">
+  <d:response><d:href>/dav/photos/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+  <d:response><d:href>/dav/photos/a%20b.jpg</d:href><d:propstat><d:prop><d:resourcetype/><d:getcontentlength>1234</d:getcontentlength><d:getlastmodified>Sat, 01 Jun 2024 10:00:00 GMT</d:getlastmodified></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+  <d:response><d:href>/dav/photos/trip/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+</d:multistatus>`
+
+// subMultistatusResponse is a PROPFIND response for /dav/photos/trip/ with one photo, given as a full URL
+const subMultistatusResponse = `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:">
+  <d:response><d:href>/dav/photos/trip/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+  <d:response><d:href>HOST/dav/photos/trip/c.jpg</d:href><d:propstat><d:prop><d:resourcetype/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+</d:multistatus>`
+
+// TestParseURL checks that webdav:// URLs are turned into the collection's URL and user.
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		input, collection, user string
+		ok                      bool
+	}{
+		{"webdav://cloud.example.com/remote.php/dav/files/me/Photos", "https://cloud.example.com/remote.php/dav/files/me/Photos/", "", true},
+		{"webdav://me@cloud.example.com:8443/dav/", "https://cloud.example.com:8443/dav/", "me", true},
+		{"webdav+http://nas.local/photos", "http://nas.local/photos/", "", true},
+		{"https://cloud.example.com/dav", "", "", false},
+		{"webdav:///photos", "", "", false},
+	}
+	for _, tt := range tests {
+		u, user, ok := ParseURL(tt.input)
+		if ok != tt.ok || (ok && (u.String() != tt.collection || user != tt.user)) {
+			t.Errorf("ParseURL(%q) = %v, %q, %v, expected %s, %q, %v", tt.input, u, user, ok, tt.collection, tt.user, tt.ok)
+		}
+	}
+}
+
+// TestList checks that collections are listed level by level with the file properties and credentials.
+func TestList(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "me" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != "PROPFIND" || r.Header.Get("Depth") != "1" {
+			t.Errorf("Unexpected %s request with depth %q", r.Method, r.Header.Get("Depth"))
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		switch r.URL.Path {
+		case "/dav/photos/":
+			_, _ = w.Write([]byte(multistatusResponse))
+		case "/dav/photos/trip/":
+			_, _ = w.Write([]byte(strings.ReplaceAll(subMultistatusResponse, "HOST", srv.URL)))
+		}
+	}))
+	defer srv.Close()
+	collection, _ := url.Parse(srv.URL + "/dav/photos/")
+
+	client := New(Config{User: "me", Password: "secret"}, nil)
+	files, err := client.List(context.Background(), collection, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.URL.Path)
+	}
+	sort.Strings(paths)
+	if len(paths) != 2 || paths[0] != "/dav/photos/a b.jpg" || paths[1] != "/dav/photos/trip/c.jpg" {
+		t.Fatalf("Unexpected files %v", paths)
+	}
+	if files[0].Size != 1234 || !files[0].LastModified.Equal(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected properties %+v", files[0])
+	}
+
+	if files, err := client.List(context.Background(), collection, 1); err != nil || len(files) != 1 {
+		t.Errorf("Expected only the top level file with a maximum depth of 1, got %d, %v", len(files), err)
+	}
+	if _, err := New(Config{}, nil).List(context.Background(), collection, 0); err == nil {
+		t.Error("Expected an error without credentials")
+	}
+}
@@ -0,0 +1,62 @@
+package geodata
+
+// Continents are the names Continent returns, in the order maps split by continent list them.
+var Continents = []string{"North America", "South America", "Europe", "Africa", "Asia", "Oceania", "Antarctica"}
+
+// Continent returns the continent a coordinate is on or off the coast of. It's a coarse classification by
+// latitude and longitude, good for grouping photos but not for telling neighboring countries apart: places near
+// the boundaries, such as Istanbul, the Caucasus or Panama, may be put on either side.
+func Continent(lat, lon float64) string {
+	switch {
+	case lat < -60:
+		return "Antarctica"
+	case lon < -32 || (lat > 67 && lon < -10):
+		return americas(lat, lon)
+	case inEurope(lat, lon):
+		return "Europe"
+	case inAfrica(lat, lon):
+		return "Africa"
+	case lat < -10 && lon > 110, lat < 20 && lon > 130, lat < 30 && lon > 160:
+		return "Oceania"
+	default:
+		return "Asia"
+	}
+}
+
+// americas tells North from South America, leaving the Pacific islands on their side of the ocean to Oceania.
+// Greenland counts as North America, and Central America up to Panama as North America.
+func americas(lat, lon float64) string {
+	switch {
+	case (lat < 8 && lon < -100) || (lat < 30 && lon < -140):
+		return "Oceania"
+	case lat < 8 || (lat < 13 && lon > -78):
+		return "South America"
+	default:
+		return "North America"
+	}
+}
+
+// inEurope reports whether a coordinate is north of the Mediterranean and west of the Caucasus and Urals,
+// leaving Anatolia and Cyprus to Asia.
+func inEurope(lat, lon float64) bool {
+	switch {
+	case lon < 0:
+		return lat > 35.9 // between Tarifa and Tangier
+	case lon < 12:
+		return lat > 37.5 // north of Algiers and Tunis
+	case lon < 26:
+		return lat > 34 // including Malta and Crete
+	case lon < 45:
+		return lat > 42 // north of Anatolia and the Caucasus
+	case lon < 60:
+		return lat > 50 // west of the Urals
+	default:
+		return false
+	}
+}
+
+// inAfrica reports whether a coordinate south of Europe is in Africa rather than the Middle East, with Sinai
+// counted as Africa.
+func inAfrica(lat, lon float64) bool {
+	return lat <= 37.5 && lon < 52 && !(lon > 35 && lat > 12) && !(lon > 34 && lat > 29.5)
+}
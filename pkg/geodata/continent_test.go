@@ -0,0 +1,57 @@
+package geodata
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestContinent checks the continent of places across the world, including some close to the boundaries.
+func TestContinent(t *testing.T) {
+	tests := []struct {
+		place    string
+		lat, lon float64
+		expected string
+	}{
+		{"New York", 40.7128, -74.0060, "North America"},
+		{"Anchorage", 61.2181, -149.9003, "North America"},
+		{"Nuuk", 64.1814, -51.6941, "North America"},
+		{"San José, Costa Rica", 9.9281, -84.0907, "North America"},
+		{"Havana", 23.1136, -82.3666, "North America"},
+		{"Bogotá", 4.7110, -74.0721, "South America"},
+		{"Caracas", 10.4806, -66.9036, "South America"},
+		{"Ushuaia", -54.8019, -68.3030, "South America"},
+		{"Reykjavík", 64.1466, -21.9426, "Europe"},
+		{"Ponta Delgada, Azores", 37.7412, -25.6756, "Europe"},
+		{"Tarifa", 36.0143, -5.6044, "Europe"},
+		{"Palermo", 38.1157, 13.3615, "Europe"},
+		{"Heraklion", 35.3387, 25.1442, "Europe"},
+		{"Moscow", 55.7558, 37.6173, "Europe"},
+		{"Tangier", 35.7595, -5.8340, "Africa"},
+		{"Tunis", 36.8065, 10.1815, "Africa"},
+		{"Cairo", 30.0444, 31.2357, "Africa"},
+		{"Las Palmas", 28.1235, -15.4363, "Africa"},
+		{"Antananarivo", -18.8792, 47.5079, "Africa"},
+		{"Cape Town", -33.9249, 18.4241, "Africa"},
+		{"Ankara", 39.9334, 32.8597, "Asia"},
+		{"Jerusalem", 31.7683, 35.2137, "Asia"},
+		{"Dubai", 25.2048, 55.2708, "Asia"},
+		{"Tokyo", 35.6762, 139.6503, "Asia"},
+		{"Jakarta", -6.2088, 106.8456, "Asia"},
+		{"Sydney", -33.8688, 151.2093, "Oceania"},
+		{"Auckland", -36.8485, 174.7633, "Oceania"},
+		{"Guam", 13.4443, 144.7937, "Oceania"},
+		{"Honolulu", 21.3069, -157.8583, "Oceania"},
+		{"Apia, Samoa", -13.8507, -171.7514, "Oceania"},
+		{"Papeete", -17.5516, -149.5585, "Oceania"},
+		{"McMurdo Station", -77.8419, 166.6863, "Antarctica"},
+	}
+	for _, tt := range tests {
+		got := Continent(tt.lat, tt.lon)
+		if got != tt.expected {
+			t.Errorf("Expected %s to be in %s, got %s", tt.place, tt.expected, got)
+		}
+		if !slices.Contains(Continents, got) {
+			t.Errorf("%s isn't one of the listed continents", got)
+		}
+	}
+}
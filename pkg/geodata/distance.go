@@ -0,0 +1,49 @@
+package geodata
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// earthRadius is the mean radius of the Earth in meters
+const earthRadius = 6371000
+
+// Distance returns the great-circle distance in meters between two coordinates given in degrees.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// ParseDistance parses a distance in meters or, suffixed with km, in kilometers, such as "50", "50m" or "1.5km".
+func ParseDistance(s string) (float64, error) {
+	v := strings.TrimSpace(s)
+	unit := 1.0
+	if km, ok := strings.CutSuffix(v, "km"); ok {
+		v, unit = km, 1000
+	} else {
+		v = strings.TrimSuffix(v, "m")
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || math.IsNaN(d) || math.IsInf(d, 0) {
+		return 0, fmt.Errorf("invalid distance %q, expected meters or kilometers such as 50m or 1.5km", s)
+	}
+	return d * unit, nil
+}
+
+// Thin returns the points without those closer than minDistance meters to the last point kept before them, so a
+// photo is kept every minDistance along the way. The points are expected in capture order.
+func Thin(points []Point, minDistance float64) []Point {
+	var kept []Point
+	for _, p := range points {
+		if n := len(kept); n > 0 && Distance(kept[n-1].Lat, kept[n-1].Lon, p.Lat, p.Lon) < minDistance {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
@@ -0,0 +1,53 @@
+package geodata
+
+import (
+	"math"
+	"testing"
+)
+
+// TestDistance checks the great-circle distance between known coordinates.
+func TestDistance(t *testing.T) {
+	// London to Paris is about 344 km
+	if d := Distance(51.5074, -0.1276, 48.8566, 2.3522); math.Abs(d-343_500) > 1_000 {
+		t.Errorf("Expected about 343.5 km, got %.0f m", d)
+	}
+	if d := Distance(43.0, 11.0, 43.0, 11.0); d != 0 {
+		t.Errorf("Expected 0 for the same coordinates, got %v", d)
+	}
+}
+
+// TestParseDistance checks that distances are read in meters or kilometers.
+func TestParseDistance(t *testing.T) {
+	tests := map[string]float64{"50": 50, "50m": 50, " 1.5km": 1500, "0": 0}
+	for s, expected := range tests {
+		if d, err := ParseDistance(s); err != nil || d != expected {
+			t.Errorf("ParseDistance(%q) = %v, %v, expected %v", s, d, err, expected)
+		}
+	}
+	for _, s := range []string{"", "km", "5mi", "NaN"} {
+		if _, err := ParseDistance(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+// TestThin checks that points closer than the minimum distance to the last point kept are dropped.
+func TestThin(t *testing.T) {
+	// each step is about 11 m north
+	var points []Point
+	for i := 0; i < 10; i++ {
+		points = append(points, Point{Name: string(rune('a' + i)), Lat: 48.0 + float64(i)*0.0001, Lon: 2.0})
+	}
+	points = append(points, Point{Name: "back", Lat: 48.0, Lon: 2.0})
+
+	var names string
+	for _, p := range Thin(points, 30) {
+		names += p.Name + " "
+	}
+	if names != "a d g j back " {
+		t.Errorf("Expected a photo about every 30 m and the return to the start, got %q", names)
+	}
+	if kept := Thin(points, 0); len(kept) != len(points) {
+		t.Errorf("Expected every point with no minimum distance, got %d", len(kept))
+	}
+}
@@ -0,0 +1,48 @@
+package geodata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// idLength is the number of hex digits of the hash kept in an ID, enough to tell apart the photos of a lifetime
+const idLength = 12
+
+// suffixLength is the number of hex digits of the hash of the path added to the IDs of photos sharing a capture
+// time and camera
+const suffixLength = 6
+
+// MaxIDLength is the length of the longest ID AssignIDs sets, one with a suffix, for formats with fixed-width fields
+const MaxIDLength = idLength + 1 + suffixLength
+
+// AssignIDs sets the ID of each point to a hash of its capture time, to the fraction of a second the camera
+// recorded, and camera, which stays the same across runs when the photo is renamed or moved. The coordinates and
+// path are left out so the ID reveals neither, even when the published coordinates are rounded or fuzzed.
+// Points that still share a hash, such as the RAW and JPEG of one shot or bursts from cameras without fractions
+// of a second, get a suffix hashed from their path relative to root instead, so their IDs don't depend on the
+// order the photos were scanned in.
+func AssignIDs(points []Point, root string) {
+	ids := make([]string, len(points))
+	counts := make(map[string]int, len(points))
+	for i, p := range points {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s", p.Time.UTC().Format(time.RFC3339Nano), p.Make, p.Model)))
+		ids[i] = hex.EncodeToString(sum[:])[:idLength]
+		counts[ids[i]]++
+	}
+	for i, p := range points {
+		id := ids[i]
+		if counts[id] > 1 {
+			path := p.Path
+			if rel, err := filepath.Rel(root, path); root != "" && err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+			sum := sha256.Sum256([]byte(filepath.ToSlash(path)))
+			id += "-" + hex.EncodeToString(sum[:])[:suffixLength]
+		}
+		points[i].ID = id
+	}
+}
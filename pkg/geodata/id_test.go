@@ -0,0 +1,45 @@
+package geodata
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAssignIDs checks that IDs don't depend on the path or coordinates, and that points sharing a capture time
+// and camera still get distinct IDs, whatever order they come in.
+func TestAssignIDs(t *testing.T) {
+	taken := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Path: "/photos/a.jpg", Lat: 1, Lon: 2, Time: taken, Model: "Pixel 8"},
+		{Path: "/photos/a.dng", Lat: 1, Lon: 2, Time: taken, Model: "Pixel 8"},
+		{Path: "/photos/b.jpg", Lat: 1, Lon: 2, Time: taken.Add(40 * time.Millisecond), Model: "Pixel 8"},
+		{Path: "/photos/other.jpg", Lat: 1, Lon: 2, Time: taken, Model: "iPhone 15"},
+	}
+	AssignIDs(points, "/photos")
+	base := points[0].ID[:idLength]
+	if len(points[0].ID) != MaxIDLength || !strings.HasPrefix(points[1].ID, base+"-") || points[0].ID == points[1].ID {
+		t.Errorf("Expected the RAW and JPEG of a shot to share a hash with distinct suffixes, got %q, %q", points[0].ID, points[1].ID)
+	}
+	if len(points[2].ID) != idLength || points[2].ID == base || strings.HasPrefix(points[3].ID, base) {
+		t.Errorf("Expected a later shot of the burst and another camera to get IDs of their own, got %q, %q", points[2].ID, points[3].ID)
+	}
+
+	// scanned in another order, from the same library mounted elsewhere
+	reordered := []Point{points[3], points[2], points[1], points[0]}
+	for i := range reordered {
+		reordered[i].Path = strings.Replace(reordered[i].Path, "/photos", "/mnt/backup", 1)
+	}
+	AssignIDs(reordered, "/mnt/backup")
+	for i, p := range reordered {
+		if expected := points[len(points)-1-i].ID; p.ID != expected {
+			t.Errorf("Expected %s to keep its ID %q in another order, got %q", p.Path, expected, p.ID)
+		}
+	}
+
+	renamed := []Point{{Path: "/photos/moved/renamed.jpg", Lat: 1.5, Lon: 2.5, Time: points[2].Time.In(time.Local), Model: "Pixel 8"}}
+	AssignIDs(renamed, "/photos")
+	if renamed[0].ID != points[2].ID {
+		t.Errorf("Expected a renamed and fuzzed photo to keep its ID %q, got %q", points[2].ID, renamed[0].ID)
+	}
+}
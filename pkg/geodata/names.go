@@ -0,0 +1,128 @@
+package geodata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// The ways Disambiguate can rename photos sharing a name
+const (
+	// NamesBase keeps the file name without its extension, even when photos share it
+	NamesBase = "base"
+	// NamesPath uses the path relative to the scanned directory, e.g. 2024/rome/IMG_0001
+	NamesPath = "path"
+	// NamesFolder prefixes the name with the folder the photo is in, e.g. rome/IMG_0001
+	NamesFolder = "folder"
+	// NamesHash suffixes the name with a short hash of the photo's path, e.g. IMG_0001-3f2a9c
+	NamesHash = "hash"
+)
+
+// NameStrategies are the valid strategies of Disambiguate.
+var NameStrategies = []string{NamesBase, NamesPath, NamesFolder, NamesHash}
+
+// Disambiguate renames the points whose name is shared with another point, such as IMG_0001 from two cameras'
+// folders, as strategy says, so every output tells them apart by the same names. Names only one point has are
+// kept. Paths are made relative to root for NamesPath.
+func Disambiguate(points []Point, strategy, root string) {
+	if strategy == NamesBase {
+		return
+	}
+	counts := make(map[string]int, len(points))
+	for _, p := range points {
+		counts[p.Name]++
+	}
+	for i, p := range points {
+		if counts[p.Name] < 2 {
+			continue
+		}
+		switch strategy {
+		case NamesPath:
+			path := p.Path
+			if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+			points[i].Name = filepath.ToSlash(strings.TrimSuffix(path, filepath.Ext(path)))
+		case NamesFolder:
+			points[i].Name = filepath.Base(filepath.Dir(p.Path)) + "/" + p.Name
+		case NamesHash:
+			sum := sha256.Sum256([]byte(p.Path))
+			points[i].Name = p.Name + "-" + hex.EncodeToString(sum[:3])
+		}
+	}
+}
+
+// NameFields are the fields a name template composes a photo's name from, see ParseNameTemplate.
+type NameFields struct {
+	// Name is the name the photo would have otherwise, its file name without extension as Disambiguate left it
+	Name string
+	// Filename is the file name with its extension, Folder the name of the folder it's in, and Path the
+	// slash-separated path relative to the scanned directory
+	Filename string
+	Folder   string
+	Path     string
+	// Date and Time are the capture date and time, e.g. 2024-05-01 and 10:00:00, empty when unknown
+	Date string
+	Time string
+	// Place is the continent the photo was taken on, see Continent; place names aren't looked up
+	Place string
+	// Camera, Lens and Caption are empty when not recorded, see Point
+	Camera  string
+	Lens    string
+	Caption string
+	// Number is the photo's number when numbered, see NumberByTime, and 0 otherwise
+	Number int
+	Lat    float64
+	Lon    float64
+}
+
+// ParseNameTemplate parses a text/template naming photos from their NameFields, such as
+// "{{.Date}} {{.Place}} ({{.Filename}})", and checks that it only refers to fields there are.
+func ParseNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("name").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(&strings.Builder{}, NameFields{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Rename names the points with tmpl, see ParseNameTemplate, with paths relative to root. Points whose name comes
+// out empty, such as from a template of fields they don't have, keep their name.
+func Rename(points []Point, tmpl *template.Template, root string) error {
+	for i, p := range points {
+		path := p.Path
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		fields := NameFields{
+			Name:     p.Name,
+			Filename: filepath.Base(p.Path),
+			Folder:   filepath.Base(filepath.Dir(p.Path)),
+			Path:     filepath.ToSlash(path),
+			Place:    Continent(p.Lat, p.Lon),
+			Camera:   p.Camera(),
+			Lens:     p.Lens,
+			Caption:  p.Caption,
+			Number:   p.Number,
+			Lat:      p.Lat,
+			Lon:      p.Lon,
+		}
+		if !p.Time.IsZero() {
+			fields.Date, fields.Time = p.Time.Format("2006-01-02"), p.Time.Format("15:04:05")
+		}
+		var name strings.Builder
+		if err := tmpl.Execute(&name, fields); err != nil {
+			return fmt.Errorf("naming %s: %w", p.Path, err)
+		}
+		if s := strings.TrimSpace(name.String()); s != "" {
+			points[i].Name = s
+		}
+	}
+	return nil
+}
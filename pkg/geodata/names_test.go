@@ -0,0 +1,78 @@
+package geodata
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDisambiguate checks each strategy on two photos sharing a name, and that a unique name is kept.
+func TestDisambiguate(t *testing.T) {
+	root := "photos"
+	points := func() []Point {
+		return []Point{
+			{Name: "IMG_0001", Path: filepath.Join(root, "2024", "rome", "IMG_0001.jpg")},
+			{Name: "IMG_0001", Path: filepath.Join(root, "2023", "paris", "IMG_0001.JPG")},
+			{Name: "IMG_0002", Path: filepath.Join(root, "2024", "rome", "IMG_0002.jpg")},
+		}
+	}
+	for strategy, expected := range map[string][]string{
+		NamesBase:   {"IMG_0001", "IMG_0001"},
+		NamesPath:   {"2024/rome/IMG_0001", "2023/paris/IMG_0001"},
+		NamesFolder: {"rome/IMG_0001", "paris/IMG_0001"},
+	} {
+		p := points()
+		Disambiguate(p, strategy, root)
+		if p[0].Name != expected[0] || p[1].Name != expected[1] || p[2].Name != "IMG_0002" {
+			t.Errorf("Unexpected names with %s: %q, %q, %q", strategy, p[0].Name, p[1].Name, p[2].Name)
+		}
+	}
+
+	p := points()
+	Disambiguate(p, NamesHash, root)
+	if len(p[0].Name) != len("IMG_0001-")+6 || p[0].Name == p[1].Name || p[2].Name != "IMG_0002" {
+		t.Errorf("Expected distinct hash suffixes on the shared names only, got %q, %q, %q", p[0].Name, p[1].Name, p[2].Name)
+	}
+}
+
+// TestRename checks that the template composes names from the photos' fields, and that empty names are
+// left as they were.
+func TestRename(t *testing.T) {
+	tmpl, err := ParseNameTemplate("{{.Date}} {{.Place}} ({{.Filename}})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := []Point{
+		{Name: "IMG_0001", Path: filepath.Join("photos", "rome", "IMG_0001.jpg"), Lat: 41.9, Lon: 12.5, Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "IMG_0002", Path: filepath.Join("photos", "IMG_0002.jpg"), Lat: -33.9, Lon: 151.2},
+	}
+	if err := Rename(points, tmpl, "photos"); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"2024-05-01 Europe (IMG_0001.jpg)", "Oceania (IMG_0002.jpg)"} {
+		if points[i].Name != expected {
+			t.Errorf("Expected the name %q, got %q", expected, points[i].Name)
+		}
+	}
+
+	tmpl, err = ParseNameTemplate("{{.Path}}{{.Caption}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	points = []Point{{Name: "a", Path: filepath.Join("photos", "rome", "a.jpg")}, {Name: "b", Path: "https://example.com/b.jpg", Caption: " "}}
+	if err := Rename(points, tmpl, "photos"); err != nil {
+		t.Fatal(err)
+	}
+	if points[0].Name != "rome/a.jpg" || points[1].Name != "https://example.com/b.jpg" {
+		t.Errorf("Expected names from the paths, got %q and %q", points[0].Name, points[1].Name)
+	}
+}
+
+// TestParseNameTemplate checks that templates with syntax errors or unknown fields are rejected.
+func TestParseNameTemplate(t *testing.T) {
+	for _, text := range []string{"{{.Date}", "{{.Town}}", "{{.Date.Year}}"} {
+		if _, err := ParseNameTemplate(text); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package geodata
+
+import (
+	"fmt"
+	"time"
+)
+
+// nullIslandRadius is the distance in meters from 0,0 within which a position is taken for a failed GPS fix
+// recorded as zeros rather than a real place, which would be in the Gulf of Guinea
+const nullIslandRadius = 1000
+
+// minSpeedInterval is the shortest time speeds between points are measured over, so photos taken in the same
+// second with a few meters of GPS jitter don't count as moving impossibly fast
+const minSpeedInterval = time.Minute
+
+// Outlier is a point left out by RemoveOutliers, with why.
+type Outlier struct {
+	Point  Point
+	Reason string
+}
+
+// RemoveOutliers returns the points without those whose coordinates are evidently wrong: positions at 0,0, and
+// isolated jumps, points reached at more than maxSpeed meters per second from the photos on either side of them
+// while those photos are close enough to each other. The points are expected in capture order.
+func RemoveOutliers(points []Point, maxSpeed float64) ([]Point, []Outlier) {
+	var outliers []Outlier
+	candidates := make([]Point, 0, len(points))
+	for _, p := range points {
+		if Distance(0, 0, p.Lat, p.Lon) < nullIslandRadius {
+			outliers = append(outliers, Outlier{Point: p, Reason: "at 0,0, where a failed GPS fix is recorded"})
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+
+	kept := make([]Point, 0, len(candidates))
+	for i, p := range candidates {
+		// the two photos closest in time other than p, preferring one on each side
+		a, b := i-1, i+1
+		switch {
+		case a < 0:
+			a, b = i+1, i+2
+		case b >= len(candidates):
+			a, b = i-2, i-1
+		}
+		if a < 0 || b >= len(candidates) {
+			kept = append(kept, p)
+			continue
+		}
+		if sa, sb := speed(candidates[a], p), speed(p, candidates[b]); sa > maxSpeed && sb > maxSpeed &&
+			speed(candidates[a], candidates[b]) <= maxSpeed {
+			outliers = append(outliers, Outlier{Point: p, Reason: fmt.Sprintf("reached at %.0f km/h from the photos around it", min(sa, sb)*3.6)})
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, outliers
+}
+
+// speed returns the speed in meters per second needed to travel between two points in the time between them.
+func speed(from, to Point) float64 {
+	interval := to.Time.Sub(from.Time)
+	if interval < 0 {
+		interval = -interval
+	}
+	return Distance(from.Lat, from.Lon, to.Lat, to.Lon) / max(interval, minSpeedInterval).Seconds()
+}
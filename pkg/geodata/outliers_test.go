@@ -0,0 +1,53 @@
+package geodata
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRemoveOutliers checks that positions at 0,0 and isolated jumps are left out, while real travel is kept.
+func TestRemoveOutliers(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(name string, minutes int, lat, lon float64) Point {
+		return Point{Name: name, Time: start.Add(time.Duration(minutes) * time.Minute), Lat: lat, Lon: lon}
+	}
+	points := []Point{
+		// a wrong first fix, 500 km from the walk that follows
+		at("wrong-start", 0, 52.0, 6.0),
+		at("paris-1", 5, 48.8566, 2.3522),
+		at("paris-2", 10, 48.8600, 2.3400),
+		at("null-island", 12, 0, 0),
+		// a jump to Lyon and back within minutes
+		at("jump", 15, 45.7640, 4.8357),
+		at("paris-3", 20, 48.8530, 2.3499),
+		// a flight to Nice, slower than maxSpeed
+		at("nice-1", 110, 43.7102, 7.2620),
+		at("nice-2", 115, 43.7000, 7.2700),
+		// burst shots in the same second a few meters apart
+		at("nice-3", 120, 43.70001, 7.27),
+		at("nice-4", 120, 43.70004, 7.27),
+	}
+
+	kept, outliers := RemoveOutliers(points, 1000/3.6)
+	var names string
+	for _, p := range kept {
+		names += p.Name + " "
+	}
+	if names != "paris-1 paris-2 paris-3 nice-1 nice-2 nice-3 nice-4 " {
+		t.Errorf("Unexpected points kept: %s", names)
+	}
+	names = ""
+	for _, o := range outliers {
+		if o.Reason == "" {
+			t.Errorf("Expected a reason for leaving out %s", o.Point.Name)
+		}
+		names += o.Point.Name + " "
+	}
+	if names != "null-island wrong-start jump " {
+		t.Errorf("Unexpected outliers: %s", names)
+	}
+
+	if kept, _ := RemoveOutliers(points[:2], 1000/3.6); len(kept) != 2 {
+		t.Errorf("Expected both of 2 points to be kept without a third to tell which is wrong, got %+v", kept)
+	}
+}
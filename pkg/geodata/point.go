@@ -0,0 +1,77 @@
+// Package geodata defines the geotagged points photos2map reads from photos and writes to its outputs.
+package geodata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Point is a single geotagged image along with when it was taken.
+type Point struct {
+	// ID identifies the photo across runs, see AssignIDs
+	ID   string
+	Name string
+	// Path is the image file the point was read from
+	Path string
+	Lat  float64
+	Lon  float64
+	// Time is the EXIF capture time, or the file's modification time if the image has none.
+	Time time.Time
+	// Ele is the altitude in meters above sea level, or nil if unknown
+	Ele *float64
+	// Heading is the camera direction in degrees clockwise from north, or nil if unknown
+	Heading *float64
+	// Make, Model and Lens describe the camera and lens, empty when not recorded
+	Make  string
+	Model string
+	Lens  string
+	// Caption is the photo's description, empty when it has none
+	Caption string
+	// Number is the photo's position in capture order counting from 1, see NumberByTime, or 0 if not numbered
+	Number int
+	// NotBackedUp is set for photos found missing from a backup, when checked against one
+	NotBackedUp bool
+}
+
+// Camera returns the camera's make and model, omitting the make when the model already starts with it.
+func (p Point) Camera() string {
+	if p.Make == "" || strings.HasPrefix(strings.ToLower(p.Model), strings.ToLower(p.Make)) {
+		return p.Model
+	}
+	if p.Model == "" {
+		return p.Make
+	}
+	return p.Make + " " + p.Model
+}
+
+// SortByTime orders points by capture time in place, keeping points taken at the same time in their current order.
+func SortByTime(points []Point) {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+}
+
+// NumberByTime numbers points 1 to len(points) in capture order, without reordering them. Points taken at the
+// same time are numbered in their current order.
+func NumberByTime(points []Point) {
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return points[order[i]].Time.Before(points[order[j]].Time)
+	})
+	for n, i := range order {
+		points[i].Number = n + 1
+	}
+}
+
+// Label is the point's name, preceded by its number if it has one.
+func (p Point) Label() string {
+	if p.Number == 0 {
+		return p.Name
+	}
+	return fmt.Sprintf("%d. %s", p.Number, p.Name)
+}
@@ -0,0 +1,65 @@
+package geodata
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPointCamera checks that make and model are combined without repeating the make.
+func TestPointCamera(t *testing.T) {
+	tests := []struct {
+		point    Point
+		expected string
+	}{
+		{Point{Make: "NIKON", Model: "COOLPIX P6000"}, "NIKON COOLPIX P6000"},
+		{Point{Make: "Canon", Model: "Canon EOS 5D"}, "Canon EOS 5D"},
+		{Point{Make: "Apple"}, "Apple"},
+		{Point{Model: "Pixel 8"}, "Pixel 8"},
+		{Point{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.point.Camera(); got != tt.expected {
+			t.Errorf("Camera() for %+v = %q, expected %q", tt.point, got, tt.expected)
+		}
+	}
+}
+
+// TestSortByTime checks that points are ordered by capture time, with ties kept in their original order.
+func TestSortByTime(t *testing.T) {
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Name: "c", Time: noon.Add(time.Hour)},
+		{Name: "a", Time: noon},
+		// the same instant as a, given in another time zone
+		{Name: "b", Time: noon.In(time.FixedZone("CEST", 2*60*60))},
+		{Name: "first", Time: noon.Add(-time.Minute)},
+	}
+	SortByTime(points)
+
+	expected := []string{"first", "a", "b", "c"}
+	for i, p := range points {
+		if p.Name != expected[i] {
+			t.Fatalf("Expected the order %v, got %+v", expected, points)
+		}
+	}
+}
+
+// TestNumberByTime checks that points are numbered in capture order without being moved, and labelled by number.
+func TestNumberByTime(t *testing.T) {
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Name: "c", Time: noon.Add(time.Hour)},
+		{Name: "a", Time: noon},
+		{Name: "b", Time: noon},
+	}
+	if label := points[0].Label(); label != "c" {
+		t.Errorf("Expected an unnumbered point to be labelled by its name, got %q", label)
+	}
+	NumberByTime(points)
+
+	for i, expected := range []string{"3. c", "1. a", "2. b"} {
+		if label := points[i].Label(); label != expected {
+			t.Errorf("Expected %q for point %d, got %q", expected, i, label)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package geodata
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// metersPerDegree is the length of a degree of latitude, and of longitude at the equator
+const metersPerDegree = 2 * math.Pi * earthRadius / 360
+
+// Round returns the points with their coordinates rounded to the given number of decimal places: 3 places are
+// about 100 m, 2 about 1 km.
+func Round(points []Point, decimals int) []Point {
+	scale := math.Pow(10, float64(decimals))
+	rounded := make([]Point, len(points))
+	for i, p := range points {
+		p.Lat = math.Round(p.Lat*scale) / scale
+		p.Lon = math.Round(p.Lon*scale) / scale
+		rounded[i] = p
+	}
+	return rounded
+}
+
+// Fuzz returns the points each moved in a direction and by a distance of up to radius meters derived from key and
+// the cell, radius meters wide, of a grid over the globe the point is in, spread uniformly over the disk around
+// it. Every point in a cell moves the same way, run after run: offsets drawn for each photo would average out over
+// the many photos taken at one place, such as home, and offsets changing from run to run would average out over
+// the maps published over time, either way giving the exact location away. key must stay the same, and secret,
+// since anyone with it can undo the offsets.
+func Fuzz(points []Point, radius float64, key []byte) []Point {
+	fuzzed := make([]Point, len(points))
+	for i, p := range points {
+		sum := cellHash(p.Lat, p.Lon, radius, key)
+		// the square root spreads the points evenly over the disk rather than bunching them at its center
+		dist := radius * math.Sqrt(unitFloat(sum[:8]))
+		angle := 2 * math.Pi * unitFloat(sum[8:16])
+		p.Lat += dist * math.Cos(angle) / metersPerDegree
+		if cos := math.Cos(p.Lat * math.Pi / 180); cos > 1e-6 {
+			p.Lon += dist * math.Sin(angle) / (metersPerDegree * cos)
+		}
+		p.Lat = math.Max(-90, math.Min(90, p.Lat))
+		p.Lon = math.Remainder(p.Lon, 360)
+		fuzzed[i] = p
+	}
+	return fuzzed
+}
+
+// cellHash returns the HMAC-SHA256 with key of the cell of the grid of size meters the coordinates are in. The
+// grid's rows are size meters high, and its cells size meters wide at the middle of their row.
+func cellHash(lat, lon, size float64, key []byte) []byte {
+	row := math.Floor(lat * metersPerDegree / size)
+	col := 0.0
+	if cos := math.Cos((row + 0.5) * size / metersPerDegree * math.Pi / 180); cos > 1e-6 {
+		col = math.Floor(lon * metersPerDegree * cos / size)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(fmt.Appendf(nil, "%d,%d", int64(row), int64(col)))
+	return mac.Sum(nil)
+}
+
+// unitFloat returns a number in [0, 1) from the first 8 bytes of b.
+func unitFloat(b []byte) float64 {
+	return float64(binary.BigEndian.Uint64(b)>>11) / (1 << 53)
+}
@@ -0,0 +1,61 @@
+package geodata
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// TestRound checks that coordinates are rounded to the given decimal places and the original points are kept.
+func TestRound(t *testing.T) {
+	points := []Point{{Name: "a", Lat: 48.85661, Lon: -2.35229}}
+	rounded := Round(points, 2)
+	if rounded[0].Lat != 48.86 || rounded[0].Lon != -2.35 || rounded[0].Name != "a" {
+		t.Errorf("Unexpected rounded point %+v", rounded[0])
+	}
+	if points[0].Lat != 48.85661 {
+		t.Error("Expected the original points to be left unchanged")
+	}
+	if r := Round(points, 0); r[0].Lat != 49 || r[0].Lon != -2 {
+		t.Errorf("Unexpected point rounded to whole degrees %+v", r[0])
+	}
+}
+
+// TestFuzz checks that points move by up to the radius, that the points in a cell all move the same way whatever
+// their order, so many photos at one place don't average back to it, and that the key decides the offsets.
+func TestFuzz(t *testing.T) {
+	var points []Point
+	for i := 0; i < 100; i++ {
+		points = append(points, Point{ID: fmt.Sprint("paris", i), Lat: 48.8566, Lon: 2.3522}, Point{ID: fmt.Sprint("auckland", i), Lat: -36.8485, Lon: 179.999})
+	}
+	key := []byte("a secret of 32 bytes or so, kept")
+	fuzzed := Fuzz(points, 500, key)
+
+	for i, p := range fuzzed {
+		d := Distance(points[i].Lat, points[i].Lon, p.Lat, p.Lon)
+		if d > 501 {
+			t.Errorf("Expected %+v to move at most 500 m, moved %.0f m to %+v", points[i], d, p)
+		}
+		if p.Lon <= -180 || p.Lon > 180 {
+			t.Errorf("Expected the longitude to stay in range, got %v", p.Lon)
+		}
+		if same := fuzzed[i%2]; p.Lat != same.Lat || p.Lon != same.Lon {
+			t.Fatalf("Expected the photos taken at one place to move the same way, got %+v and %+v", p, same)
+		}
+	}
+	if fuzzed[0].Lat == points[0].Lat || fuzzed[0].Lat-points[0].Lat == fuzzed[1].Lat-points[1].Lat {
+		t.Errorf("Expected the places to move, each its own way, got %+v and %+v", fuzzed[0], fuzzed[1])
+	}
+
+	reversed := slices.Clone(points)
+	slices.Reverse(reversed)
+	again := Fuzz(reversed, 500, key)
+	for i := range fuzzed {
+		if fuzzed[i] != again[len(again)-1-i] {
+			t.Fatalf("Expected the same positions with the same key in another order, got %+v and %+v", fuzzed[i], again[len(again)-1-i])
+		}
+	}
+	if other := Fuzz(points[:1], 500, []byte("another secret of 32 bytes or so")); other[0] == fuzzed[0] {
+		t.Error("Expected another key to move the point elsewhere")
+	}
+}
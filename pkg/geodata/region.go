@@ -0,0 +1,138 @@
+package geodata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Region is an area points can be restricted to.
+type Region interface {
+	Contains(lat, lon float64) bool
+}
+
+// BBox is a bounding box in degrees. A box with MinLon greater than MaxLon crosses the antimeridian.
+type BBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+// Contains reports whether a coordinate is inside the box, including its edges.
+func (b BBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// Bounds returns the smallest box holding the points, which mustn't be empty. It never crosses the antimeridian.
+func Bounds(points []Point) BBox {
+	b := BBox{MinLon: points[0].Lon, MinLat: points[0].Lat, MaxLon: points[0].Lon, MaxLat: points[0].Lat}
+	for _, p := range points[1:] {
+		b.MinLon, b.MaxLon = min(b.MinLon, p.Lon), max(b.MaxLon, p.Lon)
+		b.MinLat, b.MaxLat = min(b.MinLat, p.Lat), max(b.MaxLat, p.Lat)
+	}
+	return b
+}
+
+// Circle is the area within Radius meters of a coordinate.
+type Circle struct {
+	Lat, Lon, Radius float64
+}
+
+// Contains reports whether a coordinate is within the circle's radius.
+func (c Circle) Contains(lat, lon float64) bool {
+	return Distance(c.Lat, c.Lon, lat, lon) <= c.Radius
+}
+
+// ParseBBox parses a bounding box given as "minLon,minLat,maxLon,maxLat", the order GeoJSON and most tools use.
+func ParseBBox(s string) (BBox, error) {
+	v, err := parseFloats(s, 4)
+	if err != nil {
+		return BBox{}, fmt.Errorf("invalid bounding box %q, expected minLon,minLat,maxLon,maxLat: %w", s, err)
+	}
+	b := BBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+	if err := checkCoordinate(b.MinLat, b.MinLon); err != nil {
+		return BBox{}, fmt.Errorf("invalid bounding box %q: %w", s, err)
+	}
+	if err := checkCoordinate(b.MaxLat, b.MaxLon); err != nil {
+		return BBox{}, fmt.Errorf("invalid bounding box %q: %w", s, err)
+	}
+	if b.MinLat > b.MaxLat {
+		return BBox{}, fmt.Errorf("invalid bounding box %q: minimum latitude is above the maximum", s)
+	}
+	return b, nil
+}
+
+// ParseCircle parses a circle given as "lat,lon,radius", with the radius in meters or, suffixed with km, in
+// kilometers.
+func ParseCircle(s string) (Circle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return Circle{}, fmt.Errorf("invalid area %q, expected lat,lon,radius", s)
+	}
+	v, err := parseFloats(strings.Join(parts[:2], ","), 2)
+	if err != nil {
+		return Circle{}, fmt.Errorf("invalid area %q, expected lat,lon,radius: %w", s, err)
+	}
+	radius, err := ParseDistance(parts[2])
+	if err != nil {
+		return Circle{}, fmt.Errorf("invalid area %q: %w", s, err)
+	}
+	c := Circle{Lat: v[0], Lon: v[1], Radius: radius}
+	if err := checkCoordinate(c.Lat, c.Lon); err != nil {
+		return Circle{}, fmt.Errorf("invalid area %q: %w", s, err)
+	}
+	if c.Radius <= 0 {
+		return Circle{}, fmt.Errorf("invalid area %q: the radius must be positive", s)
+	}
+	return c, nil
+}
+
+// Filter returns the points inside all of the regions, in their original order.
+func Filter(points []Point, regions ...Region) []Point {
+	var kept []Point
+	for _, p := range points {
+		inside := true
+		for _, r := range regions {
+			if !r.Contains(p.Lat, p.Lon) {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// parseFloats parses n comma-separated numbers.
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d numbers, got %d", n, len(parts))
+	}
+	v := make([]float64, n)
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a number", strings.TrimSpace(part))
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+// checkCoordinate returns an error if a coordinate is out of range.
+func checkCoordinate(lat, lon float64) error {
+	if lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude %g is out of range", lat)
+	}
+	if lon < -180 || lon > 180 {
+		return fmt.Errorf("longitude %g is out of range", lon)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package geodata
+
+import (
+	"testing"
+)
+
+// TestParseBBox checks that bounding boxes are parsed in minLon,minLat,maxLon,maxLat order and validated.
+func TestParseBBox(t *testing.T) {
+	b, err := ParseBBox("2.22, 48.81, 2.47, 48.91")
+	if err != nil || b != (BBox{MinLon: 2.22, MinLat: 48.81, MaxLon: 2.47, MaxLat: 48.91}) {
+		t.Errorf("Unexpected bounding box %+v, %v", b, err)
+	}
+	for _, s := range []string{"1,2,3", "a,1,2,3", "0,91,1,92", "0,10,1,5", "-181,0,0,1"} {
+		if _, err := ParseBBox(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+// TestBounds checks that the box holds all the points.
+func TestBounds(t *testing.T) {
+	points := []Point{{Lat: 41.9, Lon: 12.5}, {Lat: -33.9, Lon: 151.2}, {Lat: 48.9, Lon: 2.4}}
+	if b := Bounds(points); b != (BBox{MinLon: 2.4, MinLat: -33.9, MaxLon: 151.2, MaxLat: 48.9}) {
+		t.Errorf("Unexpected bounds %+v", b)
+	}
+}
+
+// TestParseCircle checks that radiuses are read in meters or kilometers and validated.
+func TestParseCircle(t *testing.T) {
+	tests := map[string]Circle{
+		"48.8566,2.3522,500":   {Lat: 48.8566, Lon: 2.3522, Radius: 500},
+		"48.8566,2.3522,500m":  {Lat: 48.8566, Lon: 2.3522, Radius: 500},
+		"48.8566, 2.3522, 5km": {Lat: 48.8566, Lon: 2.3522, Radius: 5000},
+	}
+	for s, expected := range tests {
+		if c, err := ParseCircle(s); err != nil || c != expected {
+			t.Errorf("ParseCircle(%q) = %+v, %v, expected %+v", s, c, err, expected)
+		}
+	}
+	for _, s := range []string{"48,2", "48,2,0", "48,2,-1km", "100,2,5km", "48,2,5mi"} {
+		if _, err := ParseCircle(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+// TestFilter checks that only points inside every region are kept, including boxes across the antimeridian.
+func TestFilter(t *testing.T) {
+	points := []Point{
+		{Name: "paris", Lat: 48.8566, Lon: 2.3522},
+		{Name: "versailles", Lat: 48.8049, Lon: 2.1204},
+		{Name: "london", Lat: 51.5074, Lon: -0.1276},
+		{Name: "fiji", Lat: -17.7134, Lon: 178.065},
+	}
+	names := func(points []Point) []string {
+		var names []string
+		for _, p := range points {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		regions  []Region
+		expected []string
+	}{
+		{nil, []string{"paris", "versailles", "london", "fiji"}},
+		{[]Region{BBox{MinLon: -5, MinLat: 42, MaxLon: 8, MaxLat: 51}}, []string{"paris", "versailles"}},
+		{[]Region{Circle{Lat: 48.8566, Lon: 2.3522, Radius: 10000}}, []string{"paris"}},
+		{[]Region{BBox{MinLon: -5, MinLat: 42, MaxLon: 8, MaxLat: 60}, Circle{Lat: 51.5, Lon: -0.1, Radius: 50000}}, []string{"london"}},
+		{[]Region{BBox{MinLon: 170, MinLat: -30, MaxLon: -170, MaxLat: 0}}, []string{"fiji"}},
+	}
+	for _, tt := range tests {
+		got := names(Filter(points, tt.regions...))
+		if len(got) != len(tt.expected) {
+			t.Errorf("Expected %v inside %+v, got %v", tt.expected, tt.regions, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("Expected %v inside %+v, got %v", tt.expected, tt.regions, got)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package geodata
+
+import (
+	"slices"
+	"time"
+)
+
+// Smooth returns the points in capture order with each position replaced by the median latitude and longitude of
+// the window photos around it, taking out the zig-zag of noisy phone GPS while keeping corners sharper than an
+// average would. An even window is rounded up, and one below 3 leaves the positions as they are. Photos more than
+// gap apart, if gap is set, aren't smoothed together, and near either end of a stretch the window narrows so it
+// stays centered on the photo. The points passed aren't modified.
+func Smooth(points []Point, window int, gap time.Duration) []Point {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	SortByTime(sorted)
+	if window < 3 {
+		return sorted
+	}
+
+	smoothed := make([]Point, len(sorted))
+	copy(smoothed, sorted)
+	start := 0
+	for end := 1; end <= len(sorted); end++ {
+		if end < len(sorted) && (gap == 0 || sorted[end].Time.Sub(sorted[end-1].Time) <= gap) {
+			continue
+		}
+		stretch := sorted[start:end]
+		for i := range stretch {
+			r := min(window/2, i, len(stretch)-1-i)
+			if r == 0 {
+				continue
+			}
+			lats := make([]float64, 0, 2*r+1)
+			lons := make([]float64, 0, 2*r+1)
+			for _, p := range stretch[i-r : i+r+1] {
+				lats = append(lats, p.Lat)
+				lons = append(lons, p.Lon)
+			}
+			smoothed[start+i].Lat, smoothed[start+i].Lon = median(lats), median(lons)
+		}
+		start = end
+	}
+	return smoothed
+}
+
+// median returns the middle value of an odd number of values, reordering them.
+func median(values []float64) float64 {
+	slices.Sort(values)
+	return values[len(values)/2]
+}
@@ -0,0 +1,49 @@
+package geodata
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSmooth checks that a median filter takes out a zig-zag, leaves the ends of each stretch alone and doesn't
+// smooth across gaps.
+func TestSmooth(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int, lat, lon float64) Point {
+		return Point{Lat: lat, Lon: lon, Time: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	// walking east along a street, with the fixes jumping to either side of it, then a photo the next day
+	points := []Point{
+		at(4, 45.0002, 7.04),
+		at(0, 45.0, 7.00),
+		at(1, 45.0003, 7.01),
+		at(2, 44.9997, 7.02),
+		at(3, 45.0003, 7.03),
+		at(24*60, 46.0, 8.0),
+	}
+
+	smoothed := Smooth(points, 3, time.Hour)
+	expected := []Point{
+		at(0, 45.0, 7.00),
+		at(1, 45.0, 7.01),
+		at(2, 45.0003, 7.02),
+		at(3, 45.0002, 7.03),
+		at(4, 45.0002, 7.04),
+		at(24*60, 46.0, 8.0),
+	}
+	for i := range expected {
+		if smoothed[i].Lat != expected[i].Lat || smoothed[i].Lon != expected[i].Lon || !smoothed[i].Time.Equal(expected[i].Time) {
+			t.Errorf("Expected point %d at %v,%v, got %v,%v", i, expected[i].Lat, expected[i].Lon, smoothed[i].Lat, smoothed[i].Lon)
+		}
+	}
+	if points[0].Lat != 45.0002 {
+		t.Error("Expected the points passed to be left unchanged")
+	}
+
+	if unsmoothed := Smooth(points, 1, 0); unsmoothed[2].Lat != 44.9997 {
+		t.Errorf("Expected a window of 1 to keep the positions, got %v", unsmoothed[2].Lat)
+	}
+	if across := Smooth(points, 3, 0); across[4].Lat != 45.0003 || across[4].Lon != 7.04 {
+		t.Errorf("Expected the next day's photo to count without a gap, got %v,%v", across[4].Lat, across[4].Lon)
+	}
+}
@@ -0,0 +1,634 @@
+// Package integration runs the photos2map binary end to end against generated photo libraries.
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// binary is the photos2map binary built by TestMain, empty when the tests are skipped
+var binary string
+
+// testdata holds the sample photos with GPS data the libraries are built from
+var testdata = filepath.Join("..", "..", "internal", "testdata")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	dir, err := os.MkdirTemp("", "photos2map-integration")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "photos2map")
+	// #nosec G204 -- building the module's own main package
+	build := exec.Command("go", "build", "-o", binary, "github.com/toozej/photos2map")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error building photos2map: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// result is the outcome of a photos2map run
+type result struct {
+	stdout string
+	stderr string
+	code   int
+	// out is the run's output directory
+	out string
+}
+
+// exists reports whether the run wrote the named file to its output directory.
+func (r result) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(r.out, name))
+	return err == nil
+}
+
+// read returns the content of a file the run wrote to its output directory.
+func (r result) read(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(r.out, name)) // #nosec G304
+	if err != nil {
+		t.Fatalf("Expected %s to be written: %v\nstdout:\n%s\nstderr:\n%s", name, err, r.stdout, r.stderr)
+	}
+	return string(data)
+}
+
+// run runs photos2map with args in a new working directory with an empty out/ directory, and a cache directory
+// of its own so runs don't affect each other.
+func run(t *testing.T, stdin string, args ...string) result {
+	t.Helper()
+	if binary == "" {
+		t.Skip("integration tests are skipped with -short")
+	}
+	work := t.TempDir()
+	out := filepath.Join(work, "out")
+	if err := os.Mkdir(out, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	// #nosec G204 -- running the binary under test
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = work
+	cmd.Env = append(os.Environ(), "HOME="+work, "XDG_CACHE_HOME="+filepath.Join(work, "cache"), "XDG_CONFIG_HOME="+filepath.Join(work, "config"), "PHOTOS2MAP_NO_NETWORK=")
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+
+	r := result{stdout: stdout.String(), stderr: stderr.String(), out: out}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		r.code = exitErr.ExitCode()
+	case err != nil:
+		t.Fatalf("Error running photos2map: %v", err)
+	}
+	return r
+}
+
+// library generates a photo library in a temporary directory and returns its path. files maps slash-separated
+// paths to their content: "gps" for a photo with GPS data, "nogps" for a JPEG without EXIF data, "corrupt" for a
+// file that isn't an image, and anything else as text.
+func library(t *testing.T, files map[string]string) string {
+	t.Helper()
+	photo, err := os.ReadFile(filepath.Join(testdata, "DSCN0010.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plain bytes.Buffer
+	if err := jpeg.Encode(&plain, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, kind := range files {
+		data := []byte(kind)
+		switch kind {
+		case "gps":
+			data = photo
+		case "nogps":
+			data = plain.Bytes()
+		case "corrupt":
+			data = []byte("not an image")
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestOutputFormats checks that each output format writes its file with a point per photo.
+func TestOutputFormats(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps", "trip/b.jpg": "gps", "notes.txt": "not a photo"})
+
+	tests := []struct {
+		format string
+		file   string
+		marker string
+	}{
+		{"html", "map.html", "echarts"},
+		{"gpx", "output.gpx", "<wpt "},
+		{"strava", "activity.gpx", "<trkpt "},
+		{"geojson", "output.geojson", `"Feature"`},
+		{"json", "output.json", `"points"`},
+		{"shapefile", "output.dbf", "NAME"},
+		{"gpkg", "output.gpkg", "SQLite format 3"},
+		{"fit", "output.fit", ".FIT"},
+		{"pdf", "output.pdf", "%PDF-"},
+		{"umap", "umap.geojson", `"Feature"`},
+		{"felt", "felt.geojson", `"Feature"`},
+		{"mymaps", "mymaps.kml", "<Placemark>"},
+		{"mymaps-csv", "mymaps.csv", "a,"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			r := run(t, "", "--dir", dir, "--output", tt.format)
+			if r.code != 0 {
+				t.Fatalf("Expected exit code 0, got %d\nstderr:\n%s", r.code, r.stderr)
+			}
+			if content := r.read(t, tt.file); !strings.Contains(content, tt.marker) {
+				t.Errorf("Expected %s to contain %q", tt.file, tt.marker)
+			}
+			if !strings.Contains(r.stdout, "2 with GPS data") {
+				t.Errorf("Expected a summary of 2 photos with GPS data, got:\n%s", r.stdout)
+			}
+			if r.exists("skipped.txt") {
+				t.Error("Expected no skipped files report")
+			}
+		})
+	}
+}
+
+// TestMultipleOutputs checks that formats given comma-separated and repeated are all written from one scan.
+func TestMultipleOutputs(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps"})
+
+	r := run(t, "", "--dir", dir, "--output", "gpx,geojson", "-o", "html")
+	if r.code != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nstderr:\n%s", r.code, r.stderr)
+	}
+	for _, file := range []string{"output.gpx", "output.geojson", "map.html"} {
+		if !r.exists(file) {
+			t.Errorf("Expected %s to be written", file)
+		}
+	}
+	if strings.Count(r.stdout, "Scanned") != 1 {
+		t.Errorf("Expected a single scan, got:\n%s", r.stdout)
+	}
+}
+
+// TestConfig checks that a config file sets defaults the command line overrides, hooks.post_run among them, and
+// that --cache-dir moves the cache.
+func TestConfig(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps"})
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte("output: gpx\nnames: folder\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := run(t, "", "--dir", dir, "--config", config); !r.exists("output.gpx") || r.exists("map.html") {
+		t.Errorf("Expected the GPX output set in the config file, got exit code %d\nstderr:\n%s", r.code, r.stderr)
+	}
+	if r := run(t, "", "--dir", dir, "--config", config, "--output", "geojson"); !r.exists("output.geojson") || r.exists("output.gpx") {
+		t.Errorf("Expected --output to override the config file, got exit code %d\nstderr:\n%s", r.code, r.stderr)
+	}
+
+	hooks := filepath.Join(t.TempDir(), "hooks.yaml")
+	if err := os.WriteFile(hooks, []byte("output: gpx\nhooks:\n  pre_run: echo starting\n  post_run: echo \"wrote $PHOTOS2MAP_FILES\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if r := run(t, "", "--dir", dir, "--config", hooks); !strings.Contains(r.stdout, "starting") || !strings.Contains(r.stdout, "wrote out/output.gpx") {
+		t.Errorf("Expected the hooks of the config file to run, got exit code %d\nstdout:\n%s\nstderr:\n%s", r.code, r.stdout, r.stderr)
+	}
+	if r := run(t, "", "--dir", dir, "--config", hooks, "--post-run", "echo overridden"); !strings.Contains(r.stdout, "overridden") || strings.Contains(r.stdout, "wrote") {
+		t.Errorf("Expected --post-run to override the config file, got stdout:\n%s", r.stdout)
+	}
+
+	cacheDir := t.TempDir()
+	if r := run(t, "", "--dir", dir, "--output", "gpx", "--cache-dir", cacheDir); r.code != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nstderr:\n%s", r.code, r.stderr)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 1 {
+		t.Errorf("Expected the cache file in --cache-dir, got %v", entries)
+	}
+}
+
+// TestOutDir checks that --out-dir is created when missing, that --out-name names the output files, and that the
+// --post-run hook is given the paths of the files written.
+func TestOutDir(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps"})
+	outDir := filepath.Join(t.TempDir(), "exports", "2024")
+
+	r := run(t, "", "--dir", dir, "--output", "html,gpx", "--out-dir", outDir, "--out-name", "trip",
+		"--post-run", `echo "$PHOTOS2MAP_FILES"`)
+	if r.code != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nstderr:\n%s", r.code, r.stderr)
+	}
+	for _, name := range []string{"trip.html", "points.js", "trip.gpx"} {
+		path := filepath.Join(outDir, name)
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be written: %v", path, err)
+		}
+		if !strings.Contains(r.stdout, path+"\n") {
+			t.Errorf("Expected %s in PHOTOS2MAP_FILES, got stdout:\n%s", path, r.stdout)
+		}
+	}
+	if r.exists("map.html") || r.exists("output.gpx") {
+		t.Error("Expected nothing in out/ with --out-dir")
+	}
+}
+
+// TestStdout checks that --out - writes the output to stdout, and nothing else, for pipelines.
+func TestStdout(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps"})
+	for _, tt := range []struct {
+		format, prefix string
+	}{
+		{"gpx", "<?xml"},
+		{"geojson", `{`},
+		{"json", `{`},
+		{"mymaps-csv", "Name,"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			r := run(t, "", "--dir", dir, "--output", tt.format, "--out", "-", "--post-run", "echo done")
+			if r.code != 0 {
+				t.Fatalf("Expected exit code 0, got %d\nstderr:\n%s", r.code, r.stderr)
+			}
+			if !strings.HasPrefix(r.stdout, tt.prefix) || strings.Contains(r.stdout, "Scanned") || strings.Contains(r.stdout, "done") {
+				t.Errorf("Expected only the %s output on stdout, got:\n%s", tt.format, r.stdout)
+			}
+			if !strings.Contains(r.stderr, "Scanned 1 files") || !strings.Contains(r.stderr, "done") {
+				t.Errorf("Expected the summary and hook output on stderr, got:\n%s", r.stderr)
+			}
+			if entries, _ := os.ReadDir(r.out); len(entries) != 0 {
+				t.Errorf("Expected no files written, got %v", entries)
+			}
+		})
+	}
+}
+
+// TestSplitMaps checks that the HTML map is split by region above --max-map-points.
+func TestSplitMaps(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps", "b.jpg": "gps", "c.jpg": "gps"})
+
+	r := run(t, "", "--dir", dir, "--max-map-points", "2")
+	if r.code != 0 {
+		t.Fatalf("Expected exit code 0, got %d\nstderr:\n%s", r.code, r.stderr)
+	}
+	if index := r.read(t, "map.html"); !strings.Contains(index, `href="europe-1/map.html"`) || !strings.Contains(index, `href="europe-2/map.html"`) {
+		t.Errorf("Expected an index linking to two maps of Europe, got:\n%s", index)
+	}
+	if !r.exists(filepath.Join("europe-1", "map.html")) || !r.exists(filepath.Join("europe-2", "points.js")) {
+		t.Error("Expected a map with its points in each region's directory")
+	}
+}
+
+// TestMapEngineFlags checks which of the map flags each --map-engine supports, and that it warns about the
+// others rather than silently ignoring them.
+func TestMapEngineFlags(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps"})
+
+	tests := []struct {
+		args []string
+		// engines are the map engines supporting the flag
+		engines []string
+	}{
+		{[]string{"--region", "world"}, []string{"echarts"}},
+		{[]string{"--map-style", "https://example.com/style.json"}, []string{"maplibre"}},
+		{[]string{"--map-lang", "en"}, []string{"maplibre"}},
+		{[]string{"--tiles", "carto"}, []string{"leaflet", "maplibre"}},
+		{[]string{"--tiles-attribution", "Tiles by me"}, []string{"leaflet", "maplibre"}},
+		{[]string{"--path"}, []string{"echarts", "leaflet", "maplibre"}},
+		{[]string{"--stop-radius", "10"}, []string{"echarts"}},
+		{[]string{"--fullscreen"}, []string{"echarts"}},
+		{[]string{"--scale-bar"}, []string{"echarts"}},
+		{[]string{"--measure"}, []string{"echarts"}},
+		{[]string{"--locate"}, []string{"echarts"}},
+		{[]string{"--minimap"}, []string{"echarts"}},
+		{[]string{"--search"}, []string{"echarts"}},
+		{[]string{"--legend", "day"}, []string{"echarts"}},
+		{[]string{"--permalink"}, []string{"echarts"}},
+		{[]string{"--inline"}, []string{"echarts", "leaflet", "maplibre"}},
+		{[]string{"--pwa"}, []string{"echarts"}},
+		{[]string{"--thumbnails"}, []string{"echarts", "leaflet", "maplibre"}},
+		{[]string{"--thin-above", "1"}, []string{"echarts"}},
+		{[]string{"--cluster"}, []string{"echarts"}},
+		{[]string{"--renderer", "webgl"}, []string{"echarts"}},
+		{[]string{"--number-markers"}, []string{"echarts", "leaflet", "maplibre"}},
+		{[]string{"--spread-duplicates"}, []string{"echarts"}},
+		{[]string{"--units", "imperial", "--title", "Trip", "--max-map-points", "1"}, []string{"echarts", "leaflet", "maplibre"}},
+	}
+	for _, tt := range tests {
+		for _, engine := range []string{"echarts", "leaflet", "maplibre"} {
+			t.Run(engine+" "+tt.args[0], func(t *testing.T) {
+				t.Parallel()
+				r := run(t, "", append([]string{"--dir", dir, "--map-engine", engine}, tt.args...)...)
+				if r.code != 0 {
+					t.Fatalf("Expected exit code 0, got %d\nstderr:\n%s", r.code, r.stderr)
+				}
+				warned := strings.Contains(r.stderr, "doesn't support")
+				if supported := slices.Contains(tt.engines, engine); warned == supported {
+					t.Errorf("Expected a warning only if %s isn't supported by %s, got:\n%s", tt.args[0], engine, r.stderr)
+				}
+				if warned && !strings.Contains(r.stderr, "doesn't support "+tt.args[0]+",") {
+					t.Errorf("Expected the warning to name %s, got:\n%s", tt.args[0], r.stderr)
+				}
+			})
+		}
+	}
+}
+
+// TestNoNetworkTiles checks that --no-network allows the maps drawn on tiles from this machine.
+func TestNoNetworkTiles(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps"})
+
+	for _, args := range [][]string{
+		{"--map-engine", "echarts"},
+		{"--map-engine", "leaflet", "--tiles", "http://localhost:8080/{z}/{x}/{y}.png"},
+		{"--map-engine", "maplibre", "--map-style", "file:///srv/tiles/style.json"},
+		{"--map-engine", "maplibre", "--tiles", "tiles/{z}/{x}/{y}.png"},
+	} {
+		if r := run(t, "", append([]string{"--dir", dir, "--no-network"}, args...)...); r.code != 0 || !r.exists("map.html") {
+			t.Errorf("Expected the map with %v, got exit code %d\nstderr:\n%s", args, r.code, r.stderr)
+		}
+	}
+}
+
+// TestFilters checks the flags narrowing down which files are scanned.
+func TestFilters(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps", "b.png": "gps", "sub/c.jpg": "gps", "sub/deeper/d.jpg": "gps"})
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "4 with GPS data"},
+		{[]string{"--ext", "jpg"}, "3 with GPS data"},
+		{[]string{"--ext", "png"}, "1 with GPS data"},
+		{[]string{"--types", "jpg,png"}, "4 with GPS data"},
+		{[]string{"--exclude-ext", "png"}, "3 with GPS data"},
+		{[]string{"--no-recursive"}, "2 with GPS data"},
+		{[]string{"--max-depth", "2"}, "3 with GPS data"},
+		{[]string{"--ext", "heic"}, "No GPS data found"},
+		{[]string{"--near", "43.4674,11.8851,1km"}, "Kept the 4 of 4 photos"},
+		{[]string{"--bbox", "0,0,10,10"}, "Kept the 0 of 4 photos"},
+		// every photo in the library is a copy of the same one
+		{[]string{"--min-distance", "50m"}, "Kept the 1 of 4 photos at least 50m apart"},
+		{[]string{"--dedupe", "content"}, "Left out 3 duplicate photos"},
+		{[]string{"--dedupe", "burst", "--ext", "jpg"}, "Left out 2 duplicate photos"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			r := run(t, "", append([]string{"--dir", dir, "--output", "gpx"}, tt.args...)...)
+			if r.code != 0 || !strings.Contains(r.stdout, tt.expected) {
+				t.Errorf("Expected exit code 0 and %q, got %d:\n%s\nstderr:\n%s", tt.expected, r.code, r.stdout, r.stderr)
+			}
+		})
+	}
+}
+
+// TestSkipMarkers checks that directories marked with .nomedia and photo managers' previews folders are skipped
+// unless asked otherwise.
+func TestSkipMarkers(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps", "private/.nomedia": "", "private/b.jpg": "gps", "@eaDir/c.jpg": "gps"})
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "1 with GPS data"},
+		{[]string{"--skip-markers", ""}, "2 with GPS data"},
+		{[]string{"--scan-previews"}, "2 with GPS data"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			r := run(t, "", append([]string{"--dir", dir, "--output", "gpx"}, tt.args...)...)
+			if r.code != 0 || !strings.Contains(r.stdout, tt.expected) {
+				t.Errorf("Expected exit code 0 and %q, got %d:\n%s\nstderr:\n%s", tt.expected, r.code, r.stdout, r.stderr)
+			}
+		})
+	}
+}
+
+// TestApproximateLocations checks that --precision and --fuzz hide the exact coordinates, the same way every run.
+func TestApproximateLocations(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps"})
+
+	if gpx := run(t, "", "--dir", dir, "--output", "gpx", "--precision", "1").read(t, "output.gpx"); !strings.Contains(gpx, `lat="43.5" lon="11.9"`) {
+		t.Errorf("Expected coordinates rounded to 1 decimal place, got:\n%s", gpx)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "fuzz.key")
+	if err := os.WriteFile(keyFile, []byte("a secret of 32 bytes or so, kept"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fuzzed := run(t, "", "--dir", dir, "--output", "gpx", "--fuzz", "1000", "--fuzz-key-file", keyFile).read(t, "output.gpx")
+	if exact := run(t, "", "--dir", dir, "--output", "gpx").read(t, "output.gpx"); fuzzed == exact {
+		t.Error("Expected the fuzzed coordinates to differ from the exact ones")
+	}
+	if again := run(t, "", "--dir", dir, "--output", "gpx", "--fuzz", "1000", "--fuzz-key-file", keyFile).read(t, "output.gpx"); again != fuzzed {
+		t.Error("Expected the same fuzzed coordinates with the same key")
+	}
+	if err := os.WriteFile(keyFile, []byte("7"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if r := run(t, "", "--dir", dir, "--output", "gpx", "--fuzz", "1000", "--fuzz-key-file", keyFile); r.code != 1 {
+		t.Errorf("Expected a key too short to guess to fail the run, got exit code %d", r.code)
+	}
+
+	// without --fuzz-key-file, the offsets come from a key kept in the config directory for the next runs
+	r := run(t, "", "--dir", dir, "--output", "gpx", "--fuzz", "1000")
+	if key, err := os.ReadFile(filepath.Join(filepath.Dir(r.out), "config", "photos2map", "fuzz.key")); err != nil || len(key) != 32 {
+		t.Errorf("Expected a fuzz key in the config directory, got %d bytes, %v\nstderr:\n%s", len(key), err, r.stderr)
+	}
+}
+
+// TestDuplicateNames checks that --names tells apart photos sharing a file name in different folders, and that
+// --name-template builds on the names it gives.
+func TestDuplicateNames(t *testing.T) {
+	dir := library(t, map[string]string{"rome/IMG_0001.jpg": "gps", "paris/IMG_0001.jpg": "gps"})
+
+	gpx := run(t, "", "--dir", dir, "--output", "gpx", "--names", "folder").read(t, "output.gpx")
+	for _, name := range []string{"<name>rome/IMG_0001</name>", "<name>paris/IMG_0001</name>"} {
+		if !strings.Contains(gpx, name) {
+			t.Errorf("Expected %s in:\n%s", name, gpx)
+		}
+	}
+	if r := run(t, "", "--dir", dir, "--names", "random"); r.code == 0 {
+		t.Error("Expected an unknown --names strategy to fail")
+	}
+
+	gpx = run(t, "", "--dir", dir, "--output", "gpx", "--names", "folder", "--name-template", "{{.Date}} {{.Name}}").read(t, "output.gpx")
+	for _, name := range []string{"<name>2008-10-22 rome/IMG_0001</name>", "<name>2008-10-22 paris/IMG_0001</name>"} {
+		if !strings.Contains(gpx, name) {
+			t.Errorf("Expected %s with --name-template in:\n%s", name, gpx)
+		}
+	}
+}
+
+// TestBackupManifest checks that --backup-manifest marks the photos missing from the backup, by path and by
+// checksum.
+func TestBackupManifest(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps", "trip/b.jpg": "gps"})
+	manifest := filepath.Join(t.TempDir(), "backup.txt")
+	if err := os.WriteFile(manifest, []byte("/mnt/backup/photos/a.jpg\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := run(t, "", "--dir", dir, "--output", "json", "--backup-manifest", manifest)
+	if !strings.Contains(r.stdout, "1 of 2 photos aren't in the backup.") {
+		t.Errorf("Expected the number of photos missing from the backup, got:\n%s", r.stdout)
+	}
+	if json := r.read(t, "output.json"); strings.Count(json, `"not_backed_up": true`) != 1 {
+		t.Errorf("Expected one photo marked as not backed up, got:\n%s", json)
+	}
+
+	photo, err := os.ReadFile(filepath.Join(dir, "a.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(photo)
+	if err := os.WriteFile(manifest, []byte(hex.EncodeToString(sum[:])+"  elsewhere/renamed.jpg\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if r := run(t, "", "--dir", dir, "--backup-manifest", manifest); !strings.Contains(r.stdout, "0 of 2 photos aren't in the backup.") {
+		t.Errorf("Expected the photos to be found in the backup by checksum, got:\n%s", r.stdout)
+	}
+}
+
+// TestSources checks the inputs other than a directory.
+func TestSources(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps", "b.jpg": "gps"})
+
+	t.Run("files from stdin", func(t *testing.T) {
+		r := run(t, filepath.Join(dir, "a.jpg")+"\n", "--files", "-", "--output", "gpx")
+		if r.code != 0 || !strings.Contains(r.stdout, "1 with GPS data") {
+			t.Errorf("Expected the listed photo only, got %d:\n%s", r.code, r.stdout)
+		}
+	})
+
+	t.Run("zip archive", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "photos.zip")
+		f, err := os.Create(archive) // #nosec G304
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw := zip.NewWriter(f)
+		for _, name := range []string{"a.jpg", "b.jpg"} {
+			data, err := os.ReadFile(filepath.Join(dir, name)) // #nosec G304
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, err := zw.Create("2024/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		r := run(t, "", "--dir", archive, "--output", "gpx")
+		if r.code != 0 || !strings.Contains(r.stdout, "2 with GPS data") {
+			t.Errorf("Expected both photos in the archive, got %d:\n%s\nstderr:\n%s", r.code, r.stdout, r.stderr)
+		}
+	})
+}
+
+// TestErrors checks the exit codes and reports for files that can't be read and for invalid usage.
+func TestErrors(t *testing.T) {
+	dir := library(t, map[string]string{"a.jpg": "gps", "plain.jpg": "nogps", "broken.jpg": "corrupt"})
+
+	r := run(t, "", "--dir", dir, "--output", "gpx")
+	if r.code != 0 {
+		t.Errorf("Expected unreadable files not to fail the run without --strict, got exit code %d", r.code)
+	}
+	if report := r.read(t, "skipped.txt"); !strings.Contains(report, "broken.jpg") || !strings.Contains(report, "plain.jpg") {
+		t.Errorf("Expected both files without GPS data in the skipped files report, got:\n%s", report)
+	}
+	if !r.exists("output.gpx") {
+		t.Error("Expected the GPX file for the readable photo")
+	}
+
+	if r := run(t, "", "--dir", dir, "--output", "gpx", "--strict"); r.code != 1 {
+		t.Errorf("Expected exit code 1 with --strict, got %d", r.code)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown flag", []string{"--no-such-flag"}},
+		{"missing directory", []string{"--dir", filepath.Join(dir, "missing")}},
+		{"network source with --no-network", []string{"--dir", "s3://bucket/photos", "--no-network"}},
+		{"leaflet tiles with --no-network", []string{"--dir", dir, "--map-engine", "leaflet", "--no-network"}},
+		{"maplibre style with --no-network", []string{"--dir", dir, "--map-engine", "maplibre", "--no-network"}},
+		{"remote tiles with --no-network", []string{"--dir", dir, "--map-engine", "maplibre", "--tiles", "carto", "--no-network"}},
+		{"invalid bounding box", []string{"--dir", dir, "--bbox", "1,2,3"}},
+		{"unknown output format", []string{"--dir", dir, "--output", "gpx,gps"}},
+		{"missing config file", []string{"--dir", dir, "--config", filepath.Join(dir, "missing.yaml")}},
+		{"directory in --out-name", []string{"--out-name", "trips/rome"}},
+		{"--out to a file", []string{"--output", "gpx", "--out", "photos.gpx"}},
+		{"several outputs to stdout", []string{"--output", "gpx,json", "--out", "-"}},
+		{"html to stdout", []string{"--out", "-"}},
+		{"unknown map engine", []string{"--map-engine", "google"}},
+		{"unknown region", []string{"--region", "USA"}},
+		{"unknown field in --name-template", []string{"--dir", dir, "--name-template", "{{.Town}}"}},
+		{"unknown tiles", []string{"--map-engine", "leaflet", "--tiles", "google"}},
+		{"both --tiles and --map-style", []string{"--map-engine", "maplibre", "--tiles", "osm", "--map-style", "https://example.com/style.json"}},
+		{"invalid --map-lang", []string{"--map-engine", "maplibre", "--map-lang", "English"}},
+		{"negative --retries", []string{"--retries", "-1"}},
+		{"negative --timeout", []string{"--timeout", "-1s"}},
+		{"missing --ca-cert", []string{"--dir", dir, "--ca-cert", filepath.Join(dir, "missing.pem")}},
+		{"invalid --max-bandwidth", []string{"--max-bandwidth", "fast"}},
+		{"backup legend without a manifest", []string{"--legend", "backup"}},
+		{"missing backup manifest", []string{"--backup-manifest", filepath.Join(dir, "missing.txt")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := run(t, "", tt.args...); r.code != 1 {
+				t.Errorf("Expected exit code 1, got %d:\n%s\nstderr:\n%s", r.code, r.stdout, r.stderr)
+			}
+		})
+	}
+}
+
+// TestFeatures checks that the features command lists what the build supports.
+func TestFeatures(t *testing.T) {
+	r := run(t, "", "features")
+	if r.code != 0 || !strings.Contains(r.stdout, "JPEG and PNG metadata") {
+		t.Errorf("Expected the list of features, got %d:\n%s", r.code, r.stdout)
+	}
+}